@@ -1,20 +1,36 @@
 package main
 
 import (
+	"context"
 	"fmt"
+	"io"
 	"log"
+	"net"
 	"os"
 	"os/signal"
+	"sync"
 	"syscall"
+	"time"
 
 	"github.com/gofiber/fiber/v2"
 	_ "github.com/hoshina-dev/custapi/docs"
 	"github.com/hoshina-dev/custapi/internal/config"
 	"github.com/hoshina-dev/custapi/internal/database"
+	"github.com/hoshina-dev/custapi/internal/graphql"
+	"github.com/hoshina-dev/custapi/internal/grpcapi"
 	"github.com/hoshina-dev/custapi/internal/handlers"
+	"github.com/hoshina-dev/custapi/internal/mail"
+	"github.com/hoshina-dev/custapi/internal/mediacheck"
+	"github.com/hoshina-dev/custapi/internal/oidc"
+	"github.com/hoshina-dev/custapi/internal/outbox"
+	"github.com/hoshina-dev/custapi/internal/password"
+	"github.com/hoshina-dev/custapi/internal/ratelimit"
 	"github.com/hoshina-dev/custapi/internal/repositories"
 	"github.com/hoshina-dev/custapi/internal/routes"
 	"github.com/hoshina-dev/custapi/internal/services"
+	"github.com/hoshina-dev/custapi/internal/storage"
+	"github.com/hoshina-dev/custapi/internal/stream"
+	"github.com/hoshina-dev/custapi/internal/webhooks"
 )
 
 // @title				Customer API
@@ -27,6 +43,27 @@ import (
 //
 // @tag.name			users
 // @tag.description	Operations related to users
+//
+// @tag.name			audit
+// @tag.description	Audit log of changes to users and organizations
+//
+// @tag.name			webhooks
+// @tag.description	Webhook subscriptions for user and organization events
+//
+// @tag.name			events
+// @tag.description	Live stream of user and organization events
+//
+// @tag.name			graphql
+// @tag.description	GraphQL view of users and organizations
+//
+// @tag.name			auth
+// @tag.description	Logins, single sign-on, sessions and password resets
+//
+// @tag.name			media
+// @tag.description	Checks of stored avatar and organization image URLs
+//
+// @tag.name			api-keys
+// @tag.description	Organization scoped API keys for service access
 func main() {
 	// Load configuration
 	cfg := config.Load()
@@ -34,8 +71,13 @@ func main() {
 	// Initialize database
 	db := database.ConnectDB(cfg.DataSourceName)
 
-	// Initialize Fiber app
+	// Initialize Fiber app, accepting bodies large enough for image uploads
+	bodyLimit := max(fiber.DefaultBodyLimit, int(cfg.Media.MaxUploadSize)+64<<10)
 	app := fiber.New(fiber.Config{
+		BodyLimit:               bodyLimit,
+		ProxyHeader:             cfg.ProxyHeader,
+		EnableTrustedProxyCheck: len(cfg.TrustedProxies) > 0,
+		TrustedProxies:          cfg.TrustedProxies,
 		ErrorHandler: func(c *fiber.Ctx, err error) error {
 			code := fiber.StatusInternalServerError
 			if e, ok := err.(*fiber.Error); ok {
@@ -48,19 +90,125 @@ func main() {
 	})
 
 	// Initialize repositories
+	txManager := repositories.NewTxManager(db)
 	userRepo := repositories.NewUserRepository(db)
+	userHistoryRepo := repositories.NewUserHistoryRepository(db)
 	orgRepo := repositories.NewOrganizationRepository(db)
+	orgHistoryRepo := repositories.NewOrganizationHistoryRepository(db)
+	auditRepo := repositories.NewAuditRepository(db)
+	webhookRepo := repositories.NewWebhookRepository(db)
+	outboxRepo := repositories.NewOutboxRepository(db)
+	mediaCheckRepo := repositories.NewMediaCheckRepository(db)
+	sessionRepo := repositories.NewSessionRepository(db)
+	passwordResetRepo := repositories.NewPasswordResetRepository(db)
+	emailVerificationRepo := repositories.NewEmailVerificationRepository(db)
+	recoveryCodeRepo := repositories.NewRecoveryCodeRepository(db)
+	loginChallengeRepo := repositories.NewLoginChallengeRepository(db)
+	apiKeyRepo := repositories.NewAPIKeyRepository(db)
+	ssoProviderRepo := repositories.NewSSOProviderRepository(db)
+	externalIdentityRepo := repositories.NewExternalIdentityRepository(db)
+	ssoLoginStateRepo := repositories.NewSSOLoginStateRepository(db)
+	rateLimitRepo := repositories.NewRateLimitRepository(db)
 
 	// Initialize services
-	userService := services.NewUserService(userRepo, orgRepo)
-	orgService := services.NewOrganizationService(orgRepo)
+	webhookService := services.NewWebhookService(webhookRepo)
+	apiKeyService := services.NewAPIKeyService(apiKeyRepo, orgRepo)
+	auditService := services.NewAuditService(auditRepo, services.NewOutboxPublisher(outboxRepo))
+	passwordPolicy, err := password.NewPolicy(password.Config(cfg.Password))
+	if err != nil {
+		log.Fatalf("Failed to configure password policy: %v", err)
+	}
+	passwordHasher, err := password.NewHasher(password.HashConfig(cfg.PasswordHash))
+	if err != nil {
+		log.Fatalf("Failed to configure password hashing: %v", err)
+	}
+	mailer, err := mail.New(mail.Config(cfg.Mail))
+	if err != nil {
+		log.Fatalf("Failed to configure mailer: %v", err)
+	}
+	authService := services.NewAuthService(txManager, userRepo, userHistoryRepo, sessionRepo, passwordResetRepo, emailVerificationRepo,
+		recoveryCodeRepo, loginChallengeRepo, auditService, passwordPolicy, passwordHasher, mailer,
+		services.AuthConfig(cfg.Auth))
+	userService := services.NewUserService(txManager, userRepo, userHistoryRepo, sessionRepo, orgRepo, auditService, passwordPolicy, passwordHasher, authService)
+	orgService := services.NewOrganizationService(txManager, orgRepo, orgHistoryRepo, userRepo, userHistoryRepo, auditService)
+	ssoService := services.NewSSOService(txManager, ssoProviderRepo, externalIdentityRepo, ssoLoginStateRepo, userRepo, orgRepo,
+		auditService, authService, oidc.NewClient(nil), services.SSOConfig(cfg.SSO))
+	store, err := newStorage(cfg)
+	if err != nil {
+		log.Fatalf("Failed to configure media storage: %v", err)
+	}
+	mediaService := services.NewMediaService(store, mediaCheckRepo, userService, orgService, services.MediaConfig{
+		MaxUploadSize:  cfg.Media.MaxUploadSize,
+		ThumbnailSizes: cfg.Media.ThumbnailSizes,
+	})
+
+	// Initialize rate limiting
+	var rateLimitStore ratelimit.Store
+	switch cfg.RateLimit.Store {
+	case "memory":
+		rateLimitStore = ratelimit.NewMemoryStore()
+	case "postgres":
+		rateLimitStore = ratelimit.NewPostgresStore(rateLimitRepo)
+	default:
+		log.Fatalf("Unknown rate limit store %q", cfg.RateLimit.Store)
+	}
+	rateLimits := routes.RateLimits{
+		Store:  rateLimitStore,
+		IP:     ratelimit.Limit(cfg.RateLimit.IP),
+		API:    ratelimit.Limit(cfg.RateLimit.API),
+		Search: ratelimit.Limit(cfg.RateLimit.Search),
+		Auth:   ratelimit.Limit(cfg.RateLimit.Auth),
+		Login:  ratelimit.Limit(cfg.RateLimit.Login),
+	}
 
 	// Initialize handlers
 	userHandler := handlers.NewUserHandler(userService)
 	orgHandler := handlers.NewOrgHandler(orgService)
+	auditHandler := handlers.NewAuditHandler(auditService)
+	webhookHandler := handlers.NewWebhookHandler(webhookService)
+	apiKeyHandler := handlers.NewAPIKeyHandler(apiKeyService)
+	ssoHandler := handlers.NewSSOHandler(ssoService)
+	hub := stream.NewHub(outboxRepo, cfg.EventReplayBuffer)
+	eventHandler := handlers.NewEventHandler(hub)
+	graphqlSchema, err := graphql.NewSchema(userService, orgService, rateLimits.Store, rateLimits.Search)
+	if err != nil {
+		log.Fatalf("Failed to build GraphQL schema: %v", err)
+	}
+	graphqlHandler := handlers.NewGraphQLHandler(graphqlSchema)
+	mediaHandler := handlers.NewMediaHandler(mediaService, cfg.Media.MaxUploadSize)
+	authHandler := handlers.NewAuthHandler(authService)
 
 	// Setup routes
-	routes.SetupRoutes(app, userHandler, orgHandler)
+	routes.SetupRoutes(app, userHandler, orgHandler, auditHandler, webhookHandler, eventHandler, graphqlHandler, mediaHandler, authHandler,
+		apiKeyHandler, ssoHandler, userService, authService, apiKeyService, rateLimits)
+	if cfg.Media.Storage == "local" {
+		app.Static("/media", cfg.Media.LocalDir)
+	}
+
+	// Start the background workers: the outbox relay, webhook delivery, event stream hub
+	// and, unless disabled, the media checker
+	sinks, err := newSinks(cfg.Outbox, webhookService)
+	if err != nil {
+		log.Fatalf("Failed to configure outbox sinks: %v", err)
+	}
+	relay := outbox.NewRelay(txManager, outboxRepo, sinks, outbox.Config{
+		PollInterval: cfg.Outbox.PollInterval,
+		BatchSize:    cfg.Outbox.BatchSize,
+		Lease:        cfg.Outbox.ClaimLease,
+		Retention:    cfg.Outbox.Retention,
+		MaxAttempts:  cfg.Outbox.MaxAttempts,
+	})
+	worker := webhooks.NewWorker(webhookRepo, nil, webhooks.Config(cfg.Webhooks))
+
+	workerCtx, stopWorkers := context.WithCancel(context.Background())
+	var workers sync.WaitGroup
+	workers.Go(func() { relay.Run(workerCtx) })
+	workers.Go(func() { worker.Run(workerCtx) })
+	workers.Go(func() { hub.Run(workerCtx, cfg.DataSourceName) })
+	if cfg.MediaCheck.PollInterval > 0 {
+		checker := mediacheck.NewChecker(mediaCheckRepo, userService, orgService, nil, mediacheck.Config(cfg.MediaCheck))
+		workers.Go(func() { checker.Run(workerCtx) })
+	}
 
 	// Start server in a goroutine
 	go func() {
@@ -71,15 +219,87 @@ func main() {
 		}
 	}()
 
+	// Serve the gRPC API alongside the REST one
+	grpcServer := grpcapi.NewServer(userService, orgService, authService, grpcapi.Config{
+		Reflection:     cfg.GRPCReflection,
+		RateLimitStore: rateLimitStore,
+		IPRateLimit:    rateLimits.IP,
+		RateLimit:      rateLimits.API,
+	})
+	go func() {
+		addr := fmt.Sprintf(":%d", cfg.GRPCPort)
+		lis, err := net.Listen("tcp", addr)
+		if err != nil {
+			log.Fatalf("Failed to listen for gRPC: %v", err)
+		}
+		log.Printf("Starting gRPC server on %s", addr)
+		if err := grpcServer.Serve(lis); err != nil {
+			log.Fatalf("gRPC server error: %v", err)
+		}
+	}()
+
 	// Wait for interrupt signal for graceful shutdown
 	sigChan := make(chan os.Signal, 1)
 	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
 	<-sigChan
 
 	log.Println("Shutting down server...")
+	// Stopping the hub first ends the open event streams, which would otherwise hold the shutdown
+	stopWorkers()
 	if err := app.Shutdown(); err != nil {
 		log.Fatalf("Failed to shutdown gracefully: %v", err)
 	}
+	grpcServer.GracefulStop()
+	workers.Wait()
+	for _, sink := range sinks {
+		if closer, ok := sink.(io.Closer); ok {
+			closer.Close()
+		}
+	}
 
 	log.Println("Server stopped")
 }
+
+// newSinks creates the outbox sinks listed in the configuration
+func newSinks(cfg config.OutboxConfig, webhookService services.WebhookService) ([]outbox.Sink, error) {
+	sinks := make([]outbox.Sink, 0, len(cfg.Sinks))
+	for _, name := range cfg.Sinks {
+		switch name {
+		case "webhook":
+			sinks = append(sinks, webhookService)
+		case "stdout":
+			sinks = append(sinks, outbox.NewWriterSink(os.Stdout))
+		case "nats":
+			sink, err := outbox.NewNATSSink(cfg.NATSURL, cfg.NATSSubjectPrefix, 5*time.Second)
+			if err != nil {
+				return nil, err
+			}
+			sinks = append(sinks, sink)
+		default:
+			return nil, fmt.Errorf("unknown outbox sink %q", name)
+		}
+	}
+	return sinks, nil
+}
+
+// newStorage creates the media storage selected in the configuration
+func newStorage(cfg *config.Config) (storage.Storage, error) {
+	switch cfg.Media.Storage {
+	case "local":
+		publicURL := cfg.Media.PublicURL
+		if publicURL == "" {
+			publicURL = fmt.Sprintf("http://localhost:%d/media", cfg.Port)
+		}
+		return storage.NewLocal(cfg.Media.LocalDir, publicURL), nil
+	case "s3":
+		return storage.NewS3(storage.S3Config{
+			Endpoint:  cfg.Media.S3Endpoint,
+			Region:    cfg.Media.S3Region,
+			Bucket:    cfg.Media.S3Bucket,
+			AccessKey: cfg.Media.S3AccessKey,
+			SecretKey: cfg.Media.S3SecretKey,
+			PublicURL: cfg.Media.PublicURL,
+		}, nil), nil
+	}
+	return nil, fmt.Errorf("unknown media storage %q", cfg.Media.Storage)
+}
@@ -0,0 +1,36 @@
+package auth
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestActorAccess(t *testing.T) {
+	userID, keyID := uuid.New(), uuid.New()
+	orgID, otherOrgID := uuid.New(), uuid.New()
+
+	tests := []struct {
+		name           string
+		actor          Actor
+		wantPermission bool
+		wantInScope    bool
+	}{
+		{"anonymous", Actor{}, false, false},
+		{"user", Actor{UserID: &userID}, true, true},
+		{"API key with the permission", Actor{APIKeyID: &keyID, OrganizationScope: &orgID, Permissions: []string{PermissionUsersRead}}, true, true},
+		{"API key without the permission", Actor{APIKeyID: &keyID, OrganizationScope: &orgID, Permissions: []string{PermissionUsersWrite}}, false, true},
+		{"API key of another organization", Actor{APIKeyID: &keyID, OrganizationScope: &otherOrgID, Permissions: []string{PermissionUsersRead}}, true, false},
+		{"API key without an organization", Actor{APIKeyID: &keyID, Permissions: []string{PermissionUsersRead}}, true, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.actor.HasPermission(PermissionUsersRead); got != tt.wantPermission {
+				t.Errorf("HasPermission() = %v, want %v", got, tt.wantPermission)
+			}
+			if got := tt.actor.InOrganizationScope(orgID); got != tt.wantInScope {
+				t.Errorf("InOrganizationScope() = %v, want %v", got, tt.wantInScope)
+			}
+		})
+	}
+}
@@ -0,0 +1,38 @@
+package auth
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"strings"
+)
+
+// APIKeyPrefix starts every API key, which tells them apart from session tokens
+const APIKeyPrefix = "custapi_"
+
+// apiKeyVisibleLength is how many characters of a key are stored in clear as its
+// prefix, so that a key can be recognized in listings
+const apiKeyVisibleLength = len(APIKeyPrefix) + 8
+
+// Permissions an API key can be granted
+const (
+	PermissionUsersRead          = "users:read"
+	PermissionUsersWrite         = "users:write"
+	PermissionOrganizationsRead  = "organizations:read"
+	PermissionOrganizationsWrite = "organizations:write"
+)
+
+// NewAPIKey returns a random API key, the prefix of it shown in listings and the hash
+// under which it is stored
+func NewAPIKey() (key, prefix, hash string, err error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", "", "", err
+	}
+	key = APIKeyPrefix + base64.RawURLEncoding.EncodeToString(b)
+	return key, key[:apiKeyVisibleLength], HashToken(key), nil
+}
+
+// IsAPIKey reports whether a bearer token is an API key rather than a session token
+func IsAPIKey(token string) bool {
+	return strings.HasPrefix(token, APIKeyPrefix)
+}
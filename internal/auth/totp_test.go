@@ -0,0 +1,85 @@
+package auth
+
+import (
+	"encoding/base32"
+	"strings"
+	"testing"
+	"time"
+)
+
+// rfc6238Secret is the SHA-1 key of the RFC 6238 test vectors, "12345678901234567890"
+var rfc6238Secret = base32NoPadding.EncodeToString([]byte("12345678901234567890"))
+
+func TestValidateTOTPVectors(t *testing.T) {
+	// The RFC 6238 appendix B vectors for SHA-1, truncated to the six digits we use
+	tests := []struct {
+		unix int64
+		code string
+	}{
+		{59, "287082"},
+		{1111111109, "081804"},
+		{1111111111, "050471"},
+		{1234567890, "005924"},
+		{2000000000, "279037"},
+		{20000000000, "353130"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.code, func(t *testing.T) {
+			step, ok := ValidateTOTP(rfc6238Secret, tt.code, time.Unix(tt.unix, 0))
+			if !ok || step != tt.unix/30 {
+				t.Errorf("ValidateTOTP(%d) = %d, %v, want %d, true", tt.unix, step, ok, tt.unix/30)
+			}
+		})
+	}
+}
+
+func TestValidateTOTP(t *testing.T) {
+	at := time.Unix(1111111111, 0)
+	const code = "050471"
+
+	tests := []struct {
+		name     string
+		secret   string
+		code     string
+		now      time.Time
+		wantStep int64
+		wantOK   bool
+	}{
+		{"current step", rfc6238Secret, code, at, 37037037, true},
+		{"one step later", rfc6238Secret, code, at.Add(totpPeriod), 37037037, true},
+		{"one step earlier", rfc6238Secret, code, at.Add(-totpPeriod), 37037037, true},
+		{"two steps later", rfc6238Secret, code, at.Add(2 * totpPeriod), 0, false},
+		{"two steps earlier", rfc6238Secret, code, at.Add(-2 * totpPeriod), 0, false},
+		{"lower case secret", strings.ToLower(rfc6238Secret), code, at, 37037037, true},
+		{"wrong code", rfc6238Secret, "050472", at, 0, false},
+		{"too short", rfc6238Secret, "50471", at, 0, false},
+		{"too long", rfc6238Secret, "0050471", at, 0, false},
+		{"invalid secret", "not base32!", code, at, 0, false},
+		{"other secret", base32.StdEncoding.EncodeToString([]byte("another secret key!!")), code, at, 0, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			step, ok := ValidateTOTP(tt.secret, tt.code, tt.now)
+			if step != tt.wantStep || ok != tt.wantOK {
+				t.Errorf("ValidateTOTP() = %d, %v, want %d, %v", step, ok, tt.wantStep, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestNewTOTPSecret(t *testing.T) {
+	secret, err := NewTOTPSecret()
+	if err != nil {
+		t.Fatalf("NewTOTPSecret() error = %v", err)
+	}
+	key, err := base32NoPadding.DecodeString(secret)
+	if err != nil || len(key) != 20 {
+		t.Fatalf("NewTOTPSecret() = %q, want 20 base32 encoded bytes", secret)
+	}
+
+	now := time.Now()
+	code := totpCode(key, now.Unix()/int64(totpPeriod.Seconds()))
+	if _, ok := ValidateTOTP(secret, code, now); !ok {
+		t.Errorf("ValidateTOTP() rejected the current code %s of a new secret", code)
+	}
+}
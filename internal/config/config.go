@@ -4,6 +4,8 @@ import (
 	"fmt"
 	"os"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/gofiber/fiber/v2/log"
 	"github.com/joho/godotenv"
@@ -11,8 +13,160 @@ import (
 
 // Config holds application configuration
 type Config struct {
-	Port           int
+	Port     int
+	GRPCPort int
+	// GRPCReflection enables gRPC server reflection, for tools like grpcurl
+	GRPCReflection bool
 	DataSourceName string
+	Webhooks       WebhookConfig
+	Outbox         OutboxConfig
+	Media          MediaConfig
+	MediaCheck     MediaCheckConfig
+	Password       PasswordConfig
+	PasswordHash   PasswordHashConfig
+	Auth           AuthConfig
+	SSO            SSOConfig
+	Mail           MailConfig
+	RateLimit      RateLimitConfig
+	// ProxyHeader is the header the proxies in front of the API put the client's IP
+	// address in, such as X-Forwarded-For. It is only read from TrustedProxies.
+	ProxyHeader    string
+	TrustedProxies []string
+	// EventReplayBuffer is how many recent events live streams can resume from
+	EventReplayBuffer int
+}
+
+// OutboxConfig holds domain event relay settings
+type OutboxConfig struct {
+	// Sinks lists where events are published: webhook, stdout and/or nats
+	Sinks             []string
+	PollInterval      time.Duration
+	BatchSize         int
+	ClaimLease        time.Duration
+	Retention         time.Duration
+	MaxAttempts       int
+	NATSURL           string
+	NATSSubjectPrefix string
+}
+
+// MediaConfig holds image upload and storage settings
+type MediaConfig struct {
+	MaxUploadSize  int64
+	ThumbnailSizes []int
+	// Storage is where uploads are kept: local or s3
+	Storage  string
+	LocalDir string
+	// PublicURL is the base URL of stored files, by default the API's /media path for
+	// local storage and the bucket's URL for S3
+	PublicURL   string
+	S3Endpoint  string
+	S3Region    string
+	S3Bucket    string
+	S3AccessKey string
+	S3SecretKey string
+}
+
+// MediaCheckConfig holds settings of the background check of stored image URLs
+type MediaCheckConfig struct {
+	// PollInterval is how often due URLs are looked for; zero disables the checker
+	PollInterval time.Duration
+	RecheckAfter time.Duration
+	BatchSize    int
+	Concurrency  int
+	Timeout      time.Duration
+	// StripAfter is how many consecutive 404 or 410 responses remove a URL; zero never does
+	StripAfter int
+}
+
+// PasswordConfig holds the password policy
+type PasswordConfig struct {
+	MinLength int
+	MaxLength int
+	// RequiredClasses lists the character classes passwords must contain: lowercase,
+	// uppercase, digit and/or symbol
+	RequiredClasses    []string
+	RejectPersonalInfo bool
+	// BreachedListPath is an offline copy of the Pwned Passwords list; empty disables the check
+	BreachedListPath string
+}
+
+// PasswordHashConfig holds the algorithm and parameters of new password hashes
+type PasswordHashConfig struct {
+	// Algorithm is bcrypt or argon2id. Passwords hashed otherwise are hashed again
+	// on the next login.
+	Algorithm  string
+	BcryptCost int
+	// Argon2Memory is in KiB
+	Argon2Memory      int
+	Argon2Iterations  int
+	Argon2Parallelism int
+}
+
+// AuthConfig holds session, password reset and email verification settings
+type AuthConfig struct {
+	SessionTTL       time.Duration
+	PasswordResetTTL time.Duration
+	// PasswordResetURL is the frontend page that password reset links point to
+	PasswordResetURL     string
+	EmailVerificationTTL time.Duration
+	// EmailVerificationURL is the frontend page that email verification links point to
+	EmailVerificationURL string
+	// TOTPIssuer names the API in authenticator apps
+	TOTPIssuer        string
+	LoginChallengeTTL time.Duration
+}
+
+// SSOConfig holds single sign-on settings
+type SSOConfig struct {
+	// RedirectURL is the frontend page providers send users back to after logging in
+	RedirectURL string
+	// StateTTL is how long users have to log in at their provider
+	StateTTL time.Duration
+}
+
+// RateLimitConfig holds request rate limits. A limit of zero requests disables it.
+type RateLimitConfig struct {
+	// Store is where buckets are kept: memory, for each replica on its own, or postgres,
+	// shared by all replicas
+	Store string
+	// IP limits each IP address across the API, before requests are authenticated
+	IP RateLimit
+	// API limits each client, identified by API key, user or IP address, across the API
+	API RateLimit
+	// Search limits each client on the search and export endpoints
+	Search RateLimit
+	// Auth limits each IP address on the login, password reset and email verification endpoints
+	Auth RateLimit
+	// Login limits the login attempts on each account, whatever the IP addresses they come from
+	Login RateLimit
+}
+
+// RateLimit allows Requests requests at once, and Requests more per period Per
+type RateLimit struct {
+	Requests int
+	Per      time.Duration
+}
+
+// MailConfig holds outgoing email settings
+type MailConfig struct {
+	// Driver is how emails are sent: smtp, log or file
+	Driver       string
+	From         string
+	FileDir      string
+	SMTPHost     string
+	SMTPPort     int
+	SMTPUsername string
+	SMTPPassword string
+}
+
+// WebhookConfig holds webhook delivery settings
+type WebhookConfig struct {
+	PollInterval time.Duration
+	BatchSize    int
+	Timeout      time.Duration
+	MaxAttempts  int
+	BaseBackoff  time.Duration
+	MaxBackoff   time.Duration
 }
 
 // Load loads configuration from environment variables
@@ -41,7 +195,94 @@ func Load() *Config {
 
 	return &Config{
 		Port:           port,
+		GRPCPort:       getEnvInt("GRPC_PORT", 9090),
+		GRPCReflection: getEnvBool("GRPC_REFLECTION", false),
 		DataSourceName: dsn,
+		Webhooks: WebhookConfig{
+			PollInterval: getEnvDuration("WEBHOOK_POLL_INTERVAL", 5*time.Second),
+			BatchSize:    getEnvInt("WEBHOOK_BATCH_SIZE", 50),
+			Timeout:      getEnvDuration("WEBHOOK_TIMEOUT", 10*time.Second),
+			MaxAttempts:  getEnvInt("WEBHOOK_MAX_ATTEMPTS", 8),
+			BaseBackoff:  getEnvDuration("WEBHOOK_BASE_BACKOFF", 30*time.Second),
+			MaxBackoff:   getEnvDuration("WEBHOOK_MAX_BACKOFF", time.Hour),
+		},
+		Outbox: OutboxConfig{
+			Sinks:             getEnvList("OUTBOX_SINKS", []string{"webhook"}),
+			PollInterval:      getEnvDuration("OUTBOX_POLL_INTERVAL", time.Second),
+			BatchSize:         getEnvInt("OUTBOX_BATCH_SIZE", 100),
+			ClaimLease:        getEnvDuration("OUTBOX_CLAIM_LEASE", time.Minute),
+			Retention:         getEnvDuration("OUTBOX_RETENTION", 7*24*time.Hour),
+			MaxAttempts:       getEnvInt("OUTBOX_MAX_ATTEMPTS", 20),
+			NATSURL:           getEnv("NATS_URL", "nats://localhost:4222"),
+			NATSSubjectPrefix: getEnv("NATS_SUBJECT_PREFIX", "custapi"),
+		},
+		Media: MediaConfig{
+			MaxUploadSize:  int64(getEnvInt("MEDIA_MAX_UPLOAD_SIZE", 5<<20)),
+			ThumbnailSizes: getEnvIntList("MEDIA_THUMBNAIL_SIZES", []int{128, 512}),
+			Storage:        getEnv("MEDIA_STORAGE", "local"),
+			LocalDir:       getEnv("MEDIA_LOCAL_DIR", "./media"),
+			PublicURL:      getEnv("MEDIA_PUBLIC_URL", ""),
+			S3Endpoint:     getEnv("S3_ENDPOINT", "http://localhost:9000"),
+			S3Region:       getEnv("S3_REGION", "us-east-1"),
+			S3Bucket:       getEnv("S3_BUCKET", "custapi"),
+			S3AccessKey:    getEnv("S3_ACCESS_KEY", ""),
+			S3SecretKey:    getEnv("S3_SECRET_KEY", ""),
+		},
+		MediaCheck: MediaCheckConfig{
+			PollInterval: getEnvDuration("MEDIA_CHECK_POLL_INTERVAL", 10*time.Minute),
+			RecheckAfter: getEnvDuration("MEDIA_CHECK_RECHECK_AFTER", 24*time.Hour),
+			BatchSize:    getEnvInt("MEDIA_CHECK_BATCH_SIZE", 200),
+			Concurrency:  getEnvInt("MEDIA_CHECK_CONCURRENCY", 8),
+			Timeout:      getEnvDuration("MEDIA_CHECK_TIMEOUT", 10*time.Second),
+			StripAfter:   getEnvInt("MEDIA_CHECK_STRIP_AFTER", 0),
+		},
+		Password: PasswordConfig{
+			MinLength:          getEnvInt("PASSWORD_MIN_LENGTH", 10),
+			MaxLength:          getEnvInt("PASSWORD_MAX_LENGTH", 72),
+			RequiredClasses:    getEnvList("PASSWORD_REQUIRED_CLASSES", []string{"lowercase", "uppercase", "digit"}),
+			RejectPersonalInfo: getEnvBool("PASSWORD_REJECT_PERSONAL_INFO", true),
+			BreachedListPath:   getEnv("PASSWORD_BREACHED_LIST_PATH", ""),
+		},
+		PasswordHash: PasswordHashConfig{
+			Algorithm:         getEnv("PASSWORD_HASH_ALGORITHM", "bcrypt"),
+			BcryptCost:        getEnvInt("PASSWORD_BCRYPT_COST", 10),
+			Argon2Memory:      getEnvInt("PASSWORD_ARGON2_MEMORY", 64*1024),
+			Argon2Iterations:  getEnvInt("PASSWORD_ARGON2_ITERATIONS", 3),
+			Argon2Parallelism: getEnvInt("PASSWORD_ARGON2_PARALLELISM", 4),
+		},
+		Auth: AuthConfig{
+			SessionTTL:           getEnvDuration("SESSION_TTL", 30*24*time.Hour),
+			PasswordResetTTL:     getEnvDuration("PASSWORD_RESET_TTL", time.Hour),
+			PasswordResetURL:     getEnv("PASSWORD_RESET_URL", "http://localhost:3000/reset-password"),
+			EmailVerificationTTL: getEnvDuration("EMAIL_VERIFICATION_TTL", 48*time.Hour),
+			EmailVerificationURL: getEnv("EMAIL_VERIFICATION_URL", "http://localhost:3000/verify-email"),
+			TOTPIssuer:           getEnv("TOTP_ISSUER", "custapi"),
+			LoginChallengeTTL:    getEnvDuration("LOGIN_CHALLENGE_TTL", 5*time.Minute),
+		},
+		SSO: SSOConfig{
+			RedirectURL: getEnv("SSO_REDIRECT_URL", "http://localhost:3000/sso/callback"),
+			StateTTL:    getEnvDuration("SSO_STATE_TTL", 10*time.Minute),
+		},
+		Mail: MailConfig{
+			Driver:       getEnv("MAIL_DRIVER", "log"),
+			From:         getEnv("MAIL_FROM", "custapi <no-reply@localhost>"),
+			FileDir:      getEnv("MAIL_FILE_DIR", "./mail"),
+			SMTPHost:     getEnv("SMTP_HOST", "localhost"),
+			SMTPPort:     getEnvInt("SMTP_PORT", 587),
+			SMTPUsername: getEnv("SMTP_USERNAME", ""),
+			SMTPPassword: getEnv("SMTP_PASSWORD", ""),
+		},
+		RateLimit: RateLimitConfig{
+			Store:  getEnv("RATE_LIMIT_STORE", "memory"),
+			IP:     getEnvRateLimit("RATE_LIMIT_IP", RateLimit{Requests: 1200, Per: time.Minute}),
+			API:    getEnvRateLimit("RATE_LIMIT_API", RateLimit{Requests: 600, Per: time.Minute}),
+			Search: getEnvRateLimit("RATE_LIMIT_SEARCH", RateLimit{Requests: 60, Per: time.Minute}),
+			Auth:   getEnvRateLimit("RATE_LIMIT_AUTH", RateLimit{Requests: 30, Per: time.Minute}),
+			Login:  getEnvRateLimit("RATE_LIMIT_LOGIN", RateLimit{Requests: 10, Per: 15 * time.Minute}),
+		},
+		ProxyHeader:       getEnv("PROXY_HEADER", ""),
+		TrustedProxies:    getEnvList("TRUSTED_PROXIES", nil),
+		EventReplayBuffer: getEnvInt("EVENT_REPLAY_BUFFER", 1000),
 	}
 }
 
@@ -60,3 +301,67 @@ func getEnvInt(key string, defaultValue int) int {
 	}
 	return defaultValue
 }
+
+func getEnvBool(key string, defaultValue bool) bool {
+	if value := os.Getenv(key); value != "" {
+		if boolVal, err := strconv.ParseBool(value); err == nil {
+			return boolVal
+		}
+	}
+	return defaultValue
+}
+
+func getEnvDuration(key string, defaultValue time.Duration) time.Duration {
+	if value := os.Getenv(key); value != "" {
+		if d, err := time.ParseDuration(value); err == nil {
+			return d
+		}
+	}
+	return defaultValue
+}
+
+func getEnvList(key string, defaultValue []string) []string {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+	var list []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			list = append(list, item)
+		}
+	}
+	return list
+}
+
+func getEnvIntList(key string, defaultValue []int) []int {
+	var list []int
+	for _, item := range getEnvList(key, nil) {
+		intVal, err := strconv.Atoi(item)
+		if err != nil {
+			return defaultValue
+		}
+		list = append(list, intVal)
+	}
+	if list == nil {
+		return defaultValue
+	}
+	return list
+}
+
+// getEnvRateLimit reads a limit written as requests/period, such as 100/1m
+func getEnvRateLimit(key string, defaultValue RateLimit) RateLimit {
+	requests, per, ok := strings.Cut(os.Getenv(key), "/")
+	if !ok {
+		return defaultValue
+	}
+	intVal, err := strconv.Atoi(strings.TrimSpace(requests))
+	if err != nil {
+		return defaultValue
+	}
+	d, err := time.ParseDuration(strings.TrimSpace(per))
+	if err != nil {
+		return defaultValue
+	}
+	return RateLimit{Requests: intVal, Per: d}
+}
@@ -0,0 +1,21 @@
+// Package fieldset carries the sparse fieldsets requested by a client through the
+// request context, so that repositories only read the columns a response needs.
+package fieldset
+
+import "context"
+
+// fieldsKey is the context key of the fieldset of model T
+type fieldsKey[T any] struct{}
+
+// NewContext returns a copy of ctx restricting reads of model T to fields, named by the
+// Go field names shared by the response DTOs and the domain models
+func NewContext[T any](ctx context.Context, fields []string) context.Context {
+	return context.WithValue(ctx, fieldsKey[T]{}, fields)
+}
+
+// FromContext returns the fields of model T carried by ctx, or nil when every field is
+// wanted
+func FromContext[T any](ctx context.Context) []string {
+	fields, _ := ctx.Value(fieldsKey[T]{}).([]string)
+	return fields
+}
@@ -0,0 +1,212 @@
+// Package graphql serves a GraphQL view of users and organizations, executed with
+// github.com/graphql-go/graphql. Relations are loaded in batches, and requests are
+// limited in how deeply they may nest fields.
+package graphql
+
+import (
+	"context"
+	"fmt"
+
+	gql "github.com/graphql-go/graphql"
+	"github.com/graphql-go/graphql/gqlerrors"
+	"github.com/graphql-go/graphql/language/ast"
+	"github.com/graphql-go/graphql/language/lexer"
+	"github.com/graphql-go/graphql/language/parser"
+	"github.com/graphql-go/graphql/language/source"
+	"github.com/hoshina-dev/custapi/internal/auth"
+)
+
+// MaxDepth is how deeply fields may be nested in an operation, counting the fields
+// selected through fragments. It bounds the work a single request can cause.
+const MaxDepth = 10
+
+// maxNesting is how deeply selection sets and values may be nested in a document,
+// checked before it is parsed so that the parser's recursion is bounded too
+const maxNesting = 100
+
+// fragmentRules check that the fragments a document spreads exist and do not spread
+// themselves
+var fragmentRules = []gql.ValidationRuleFn{
+	gql.KnownFragmentNamesRule,
+	gql.NoFragmentCyclesRule,
+	gql.UniqueFragmentNamesRule,
+}
+
+// Schema is the GraphQL schema of the API
+type Schema struct {
+	schema gql.Schema
+	// withRequest prepares the context of each request, with its loaders
+	withRequest func(ctx context.Context) context.Context
+}
+
+// Request is a GraphQL request as sent over HTTP
+type Request struct {
+	Query         string         `json:"query"`
+	OperationName string         `json:"operationName"`
+	Variables     map[string]any `json:"variables"`
+}
+
+// Response is the result of executing a request
+type Response struct {
+	Data   any     `json:"data"`
+	Errors []Error `json:"errors,omitempty"`
+}
+
+// Error is a request or field error
+type Error struct {
+	Message string `json:"message"`
+	Path    []any  `json:"path,omitempty"`
+}
+
+// Execute parses, validates and runs a request against the schema
+func (s *Schema) Execute(ctx context.Context, req Request) Response {
+	if err := checkNesting(req.Query); err != nil {
+		return errorResponse(err)
+	}
+	doc, err := parser.Parse(parser.ParseParams{Source: source.NewSource(&source.Source{
+		Body: []byte(req.Query),
+		Name: "GraphQL request",
+	})})
+	if err != nil {
+		return errorResponse(err)
+	}
+	// The other rules recurse through fragment spreads without checking for cycles, so
+	// fragments are checked first, and the depth before the costlier rules run
+	if result := gql.ValidateDocument(&s.schema, doc, fragmentRules); !result.IsValid {
+		return Response{Errors: toErrors(result.Errors)}
+	}
+	if err := checkDepth(doc); err != nil {
+		return errorResponse(err)
+	}
+	if result := gql.ValidateDocument(&s.schema, doc, gql.SpecifiedRules); !result.IsValid {
+		return Response{Errors: toErrors(result.Errors)}
+	}
+
+	// Like the REST API, only users who have verified their email address may change data
+	if op := operation(doc, req.OperationName); op != nil && op.Operation == ast.OperationTypeMutation {
+		actor := auth.FromContext(ctx)
+		if actor.UserID == nil && actor.APIKeyID == nil {
+			return Response{Errors: []Error{{Message: "authentication required"}}}
+		}
+		if actor.UserID != nil && !actor.EmailVerified {
+			return Response{Errors: []Error{{Message: "email address not verified"}}}
+		}
+	}
+
+	result := gql.Execute(gql.ExecuteParams{
+		Schema:        s.schema,
+		AST:           doc,
+		OperationName: req.OperationName,
+		Args:          req.Variables,
+		Context:       s.withRequest(ctx),
+	})
+	return Response{Data: result.Data, Errors: toErrors(result.Errors)}
+}
+
+// operation returns the operation of doc a request named name runs, or nil if there
+// is no such operation, which execution reports
+func operation(doc *ast.Document, name string) *ast.OperationDefinition {
+	var found *ast.OperationDefinition
+	for _, def := range doc.Definitions {
+		op, ok := def.(*ast.OperationDefinition)
+		if !ok {
+			continue
+		}
+		if name == "" {
+			if found != nil {
+				return nil
+			}
+			found = op
+		} else if op.Name != nil && op.Name.Value == name {
+			return op
+		}
+	}
+	return found
+}
+
+// checkNesting checks that selection sets, arguments and values are nested at most
+// maxNesting levels deep in the document src, without parsing it
+func checkNesting(src string) error {
+	next := lexer.Lex(source.NewSource(&source.Source{Body: []byte(src)}))
+	nesting := 0
+	for {
+		token, err := next(0)
+		if err != nil {
+			// Left for the parser to report
+			return nil
+		}
+		switch token.Kind {
+		case lexer.EOF:
+			return nil
+		case lexer.BRACE_L, lexer.BRACKET_L, lexer.PAREN_L:
+			if nesting++; nesting > maxNesting {
+				return fmt.Errorf("document is nested more than %d levels deep", maxNesting)
+			}
+		case lexer.BRACE_R, lexer.BRACKET_R, lexer.PAREN_R:
+			nesting--
+		}
+	}
+}
+
+// checkDepth checks that no operation of doc nests fields deeper than MaxDepth. The
+// fragments of the document must have passed fragmentRules.
+func checkDepth(doc *ast.Document) error {
+	fragments := make(map[string]*ast.FragmentDefinition)
+	for _, def := range doc.Definitions {
+		if frag, ok := def.(*ast.FragmentDefinition); ok {
+			fragments[frag.Name.Value] = frag
+		}
+	}
+
+	depths := make(map[string]int, len(fragments))
+	for _, def := range doc.Definitions {
+		if op, ok := def.(*ast.OperationDefinition); ok {
+			if d := depth(op.SelectionSet, fragments, depths); d > MaxDepth {
+				return fmt.Errorf("operation is nested %d levels deep, more than the maximum of %d", d, MaxDepth)
+			}
+		}
+	}
+	return nil
+}
+
+// depth returns how deeply fields are nested in set. The depth of each fragment is
+// kept in depths, so fragments spread many times are only walked once.
+func depth(set *ast.SelectionSet, fragments map[string]*ast.FragmentDefinition, depths map[string]int) int {
+	if set == nil {
+		return 0
+	}
+	deepest := 0
+	for _, sel := range set.Selections {
+		var d int
+		switch sel := sel.(type) {
+		case *ast.Field:
+			d = 1 + depth(sel.SelectionSet, fragments, depths)
+		case *ast.InlineFragment:
+			d = depth(sel.SelectionSet, fragments, depths)
+		case *ast.FragmentSpread:
+			name := sel.Name.Value
+			var ok bool
+			if d, ok = depths[name]; !ok {
+				d = depth(fragments[name].SelectionSet, fragments, depths)
+				depths[name] = d
+			}
+		}
+		deepest = max(deepest, d)
+	}
+	return deepest
+}
+
+func errorResponse(err error) Response {
+	return Response{Errors: toErrors(gqlerrors.FormatErrors(err))}
+}
+
+func toErrors(formatted []gqlerrors.FormattedError) []Error {
+	if len(formatted) == 0 {
+		return nil
+	}
+	errs := make([]Error, len(formatted))
+	for i, e := range formatted {
+		errs[i] = Error{Message: e.Message, Path: e.Path}
+	}
+	return errs
+}
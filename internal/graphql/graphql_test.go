@@ -0,0 +1,101 @@
+package graphql
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/hoshina-dev/custapi/internal/auth"
+	"github.com/hoshina-dev/custapi/internal/ratelimit"
+)
+
+func TestExecuteLimits(t *testing.T) {
+	// Users and their organization alternate, so each level nests one field deeper
+	nested := func(levels int) string {
+		query, closing := "", ""
+		for i := range levels - 1 {
+			if i%2 == 0 {
+				query += "users { "
+			} else {
+				query += "organization { "
+			}
+			closing += " }"
+		}
+		return "{ " + query + "name" + closing + " }"
+	}
+
+	tests := []struct {
+		name    string
+		query   string
+		wantErr string
+	}{
+		{"at the maximum depth", nested(MaxDepth), ""},
+		{"too deep", nested(MaxDepth + 1), "nested 11 levels deep"},
+		{"too deep through fragments", `{ users { ...U } } fragment U on User { organization { ...O } } fragment O on Organization { ` +
+			strings.TrimPrefix(nested(MaxDepth-1), "{ "), "nested 11 levels deep"},
+		{"deeply nested document", "{ users(search: " + strings.Repeat("[", maxNesting) + strings.Repeat("]", maxNesting) + ") { name } }", "nested more than 100"},
+		{"syntax error", `{ users { name }`, "Syntax Error"},
+		{"unknown field", `{ users { missing } }`, `Cannot query field "missing" on type "User"`},
+		{"fragment cycle", `{ users { ...A } } fragment A on User { name ...A }`, `Cannot spread fragment "A" within itself`},
+		{"fragment cycle through another", `{ users { ...A } } fragment A on User { organization { ...B } } fragment B on Organization { users { ...A } }`, `Cannot spread fragment "A" within itself`},
+		{"undefined fragment", `{ users { ...A } }`, `Unknown fragment "A"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := testSchema(t, newFakeUserService(), &fakeOrgService{}, ratelimit.Limit{}).Execute(verifiedUser(), Request{Query: tt.query})
+			if tt.wantErr == "" {
+				if len(resp.Errors) > 0 {
+					t.Fatalf("Execute() errors = %v", resp.Errors)
+				}
+				return
+			}
+			if resp.Data != nil || len(resp.Errors) == 0 || !strings.Contains(resp.Errors[0].Message, tt.wantErr) {
+				t.Errorf("Execute() = %+v, want error %q", resp, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestExecuteMutationActor(t *testing.T) {
+	userID := uuid.New()
+	tests := []struct {
+		name    string
+		actor   *auth.Actor
+		query   string
+		wantErr string
+	}{
+		{"verified user", &auth.Actor{UserID: &userID, EmailVerified: true}, `mutation { deleteUser(id: "` + userID.String() + `") }`, ""},
+		{"unverified user", &auth.Actor{UserID: &userID}, `mutation { deleteUser(id: "` + userID.String() + `") }`, "email address not verified"},
+		{"unverified user reading", &auth.Actor{UserID: &userID}, `{ users { name } }`, ""},
+		{"anonymous", nil, `mutation { deleteUser(id: "` + userID.String() + `") }`, "authentication required"},
+		{"anonymous reading", nil, `{ users { name } }`, ""},
+		{"operation picked by name", &auth.Actor{UserID: &userID}, `query Q { users { name } } mutation M { deleteUser(id: "` + userID.String() + `") }`, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			userService := newFakeUserService()
+			req := Request{Query: tt.query}
+			if strings.Contains(tt.query, "query Q") {
+				req.OperationName = "Q"
+			}
+			ctx := context.Background()
+			if tt.actor != nil {
+				ctx = auth.NewContext(ctx, tt.actor)
+			}
+			resp := testSchema(t, userService, &fakeOrgService{}, ratelimit.Limit{}).Execute(ctx, req)
+			if tt.wantErr == "" {
+				if len(resp.Errors) > 0 {
+					t.Fatalf("Execute() errors = %v", resp.Errors)
+				}
+				return
+			}
+			if len(resp.Errors) != 1 || resp.Errors[0].Message != tt.wantErr {
+				t.Errorf("Execute() errors = %v, want %q", resp.Errors, tt.wantErr)
+			}
+			if userService.deletedUser != uuid.Nil {
+				t.Error("the mutation ran")
+			}
+		})
+	}
+}
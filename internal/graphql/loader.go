@@ -0,0 +1,63 @@
+package graphql
+
+import (
+	"context"
+)
+
+// loader collects the keys requested while a field is resolved for many objects and
+// fetches them all at once when the first value is needed, then caches the results
+// for the rest of the request. It is not safe for concurrent use; the thunks of a
+// request are called one at a time, once a level of fields has been resolved.
+type loader[K comparable] struct {
+	fetch   func(ctx context.Context, keys []K) (map[K]any, error)
+	pending []K
+	queued  map[K]bool
+	values  map[K]any
+	errs    map[K]error
+}
+
+func newLoader[K comparable](fetch func(ctx context.Context, keys []K) (map[K]any, error)) *loader[K] {
+	return &loader[K]{
+		fetch:  fetch,
+		queued: make(map[K]bool),
+		values: make(map[K]any),
+		errs:   make(map[K]error),
+	}
+}
+
+// load queues key and returns a thunk yielding its value, or nil if it was not found
+func (l *loader[K]) load(ctx context.Context, key K) func() (any, error) {
+	_, loaded := l.values[key]
+	if _, failed := l.errs[key]; !loaded && !failed && !l.queued[key] {
+		l.queued[key] = true
+		l.pending = append(l.pending, key)
+	}
+
+	return func() (any, error) {
+		if l.queued[key] {
+			l.dispatch(ctx)
+		}
+		if err, ok := l.errs[key]; ok {
+			return nil, err
+		}
+		return l.values[key], nil
+	}
+}
+
+// dispatch fetches every pending key in one call
+func (l *loader[K]) dispatch(ctx context.Context) {
+	keys := l.pending
+	l.pending = nil
+	for _, key := range keys {
+		delete(l.queued, key)
+	}
+
+	values, err := l.fetch(ctx, keys)
+	for _, key := range keys {
+		if err != nil {
+			l.errs[key] = err
+			continue
+		}
+		l.values[key] = values[key]
+	}
+}
@@ -0,0 +1,665 @@
+package graphql
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+	gql "github.com/graphql-go/graphql"
+	"github.com/hoshina-dev/custapi/internal/auth"
+	"github.com/hoshina-dev/custapi/internal/models"
+	"github.com/hoshina-dev/custapi/internal/ratelimit"
+	"github.com/hoshina-dev/custapi/internal/services"
+)
+
+// maxPageSize caps the limit argument of list fields
+const maxPageSize = 100
+
+// SDL describes the schema built by NewSchema, for clients that do not introspect it
+const SDL = `scalar Time
+
+enum DeletePolicy {
+  RESTRICT
+  CASCADE
+  REASSIGN
+}
+
+type User {
+  id: ID!
+  email: String!
+  name: String!
+  organizationId: ID!
+  organization: Organization
+  isAdmin: Boolean!
+  phoneNumber: String
+  socialMedia: String
+  description: String
+  avatarUrl: String
+  researchCategories: [String!]!
+  createdAt: Time!
+  updatedAt: Time!
+}
+
+type Organization {
+  id: ID!
+  name: String!
+  lat: Float!
+  lng: Float!
+  address: String
+  description: String
+  imageUrls: [String!]!
+  users(limit: Int = 20, offset: Int = 0): [User!]!
+  createdAt: Time!
+  updatedAt: Time!
+}
+
+input CreateUserInput {
+  email: String!
+  name: String!
+  organizationId: ID!
+  password: String!
+  phoneNumber: String
+  socialMedia: String
+  description: String
+  avatarUrl: String
+  researchCategories: [String!]
+  isAdmin: Boolean
+}
+
+input UpdateUserInput {
+  email: String
+  name: String
+  organizationId: ID
+  password: String
+  phoneNumber: String
+  socialMedia: String
+  description: String
+  avatarUrl: String
+  researchCategories: [String!]
+  isAdmin: Boolean
+}
+
+input CreateOrganizationInput {
+  name: String!
+  lat: Float!
+  lng: Float!
+  address: String
+  description: String
+  imageUrls: [String!]
+}
+
+input UpdateOrganizationInput {
+  name: String
+  lat: Float
+  lng: Float
+  address: String
+  description: String
+  imageUrls: [String!]
+}
+
+type Query {
+  user(id: ID!): User
+  users(search: String, organizationId: ID, limit: Int = 20, offset: Int = 0): [User!]!
+  organization(id: ID!): Organization
+  organizations(search: String, limit: Int = 20, offset: Int = 0): [Organization!]!
+}
+
+type Mutation {
+  createUser(input: CreateUserInput!): User
+  updateUser(id: ID!, input: UpdateUserInput!): User
+  deleteUser(id: ID!): Boolean
+  createOrganization(input: CreateOrganizationInput!): Organization
+  updateOrganization(id: ID!, input: UpdateOrganizationInput!): Organization
+  "Deletes an organization and returns the number of users deleted or reassigned with it"
+  deleteOrganization(id: ID!, policy: DeletePolicy = RESTRICT, reassignTo: ID): Int
+}
+`
+
+// resolver binds the schema to the services
+type resolver struct {
+	userService services.UserService
+	orgService  services.OrganizationService
+	validate    *validator.Validate
+	// limits and searchLimit limit the searches of each client, in the buckets of the
+	// search endpoints of the REST API
+	limits      ratelimit.Store
+	searchLimit ratelimit.Limit
+}
+
+// loadersKey is the context key of the per-request loaders
+type loadersKey struct{}
+
+// loaders batch the relation lookups of one request
+type loaders struct {
+	organizations *loader[uuid.UUID]
+	users         *loader[usersKey]
+}
+
+// usersKey identifies a page of the users of an organization
+type usersKey struct {
+	orgID  uuid.UUID
+	limit  int
+	offset int
+}
+
+// timeType serializes times as the REST API does, in RFC 3339 format
+var timeType = gql.NewScalar(gql.ScalarConfig{
+	Name: "Time",
+	Serialize: func(value any) any {
+		if t, ok := value.(time.Time); ok {
+			return t.Format(time.RFC3339Nano)
+		}
+		return nil
+	},
+})
+
+var deletePolicyType = gql.NewEnum(gql.EnumConfig{
+	Name: "DeletePolicy",
+	Values: gql.EnumValueConfigMap{
+		"RESTRICT": {Value: models.DeletePolicyRestrict},
+		"CASCADE":  {Value: models.DeletePolicyCascade},
+		"REASSIGN": {Value: models.DeletePolicyReassign},
+	},
+})
+
+// NewSchema creates the GraphQL schema over the user and organization services, which
+// is the schema SDL describes. Searches take from the search limit of each client in
+// limits.
+func NewSchema(userService services.UserService, orgService services.OrganizationService,
+	limits ratelimit.Store, searchLimit ratelimit.Limit) (*Schema, error) {
+	r := &resolver{
+		userService: userService,
+		orgService:  orgService,
+		validate:    validator.New(),
+		limits:      limits,
+		searchLimit: searchLimit,
+	}
+
+	var user, org *gql.Object
+	user = gql.NewObject(gql.ObjectConfig{Name: "User", Fields: gql.FieldsThunk(func() gql.Fields {
+		return gql.Fields{
+			"id":                 userField(gql.NewNonNull(gql.ID), func(u *models.UserResponse) any { return u.ID }),
+			"email":              userField(gql.NewNonNull(gql.String), func(u *models.UserResponse) any { return u.Email }),
+			"name":               userField(gql.NewNonNull(gql.String), func(u *models.UserResponse) any { return u.Name }),
+			"organizationId":     userField(gql.NewNonNull(gql.ID), func(u *models.UserResponse) any { return u.OrganizationID }),
+			"isAdmin":            userField(gql.NewNonNull(gql.Boolean), func(u *models.UserResponse) any { return u.IsAdmin }),
+			"phoneNumber":        userField(gql.String, func(u *models.UserResponse) any { return u.PhoneNumber }),
+			"socialMedia":        userField(gql.String, func(u *models.UserResponse) any { return u.SocialMedia }),
+			"description":        userField(gql.String, func(u *models.UserResponse) any { return u.Description }),
+			"avatarUrl":          userField(gql.String, func(u *models.UserResponse) any { return u.AvatarURL }),
+			"researchCategories": userField(stringList, func(u *models.UserResponse) any { return nonNilList(u.ResearchCategories) }),
+			"createdAt":          userField(gql.NewNonNull(timeType), func(u *models.UserResponse) any { return u.CreatedAt }),
+			"updatedAt":          userField(gql.NewNonNull(timeType), func(u *models.UserResponse) any { return u.UpdatedAt }),
+			"organization": {
+				Type: org,
+				Resolve: func(p gql.ResolveParams) (any, error) {
+					return loadersFrom(p.Context).organizations.load(p.Context, p.Source.(*models.UserResponse).OrganizationID), nil
+				},
+			},
+		}
+	})})
+
+	org = gql.NewObject(gql.ObjectConfig{Name: "Organization", Fields: gql.FieldsThunk(func() gql.Fields {
+		return gql.Fields{
+			"id":          orgField(gql.NewNonNull(gql.ID), func(o *models.OrganizationResponse) any { return o.ID }),
+			"name":        orgField(gql.NewNonNull(gql.String), func(o *models.OrganizationResponse) any { return o.Name }),
+			"lat":         orgField(gql.NewNonNull(gql.Float), func(o *models.OrganizationResponse) any { return o.Latitude }),
+			"lng":         orgField(gql.NewNonNull(gql.Float), func(o *models.OrganizationResponse) any { return o.Longitude }),
+			"address":     orgField(gql.String, func(o *models.OrganizationResponse) any { return o.Address }),
+			"description": orgField(gql.String, func(o *models.OrganizationResponse) any { return o.Description }),
+			"imageUrls":   orgField(stringList, func(o *models.OrganizationResponse) any { return nonNilList(o.ImageUrls) }),
+			"createdAt":   orgField(gql.NewNonNull(timeType), func(o *models.OrganizationResponse) any { return o.CreatedAt }),
+			"updatedAt":   orgField(gql.NewNonNull(timeType), func(o *models.OrganizationResponse) any { return o.UpdatedAt }),
+			"users": {
+				Type: listOf(user),
+				Args: pageArgConfig(),
+				Resolve: func(p gql.ResolveParams) (any, error) {
+					limit, offset, err := pageArgs(p.Args)
+					if err != nil {
+						return nil, err
+					}
+					key := usersKey{orgID: p.Source.(*models.OrganizationResponse).ID, limit: limit, offset: offset}
+					return loadersFrom(p.Context).users.load(p.Context, key), nil
+				},
+			},
+		}
+	})})
+
+	idArgs := gql.FieldConfigArgument{"id": {Type: gql.NewNonNull(gql.ID)}}
+	inputArgs := func(input *gql.InputObject) gql.FieldConfigArgument {
+		return gql.FieldConfigArgument{"input": {Type: gql.NewNonNull(input)}}
+	}
+	updateArgs := func(input *gql.InputObject) gql.FieldConfigArgument {
+		return gql.FieldConfigArgument{"id": {Type: gql.NewNonNull(gql.ID)}, "input": {Type: gql.NewNonNull(input)}}
+	}
+
+	usersArgs := pageArgConfig()
+	usersArgs["search"] = &gql.ArgumentConfig{Type: gql.String}
+	usersArgs["organizationId"] = &gql.ArgumentConfig{Type: gql.ID}
+	orgsArgs := pageArgConfig()
+	orgsArgs["search"] = &gql.ArgumentConfig{Type: gql.String}
+
+	query := gql.NewObject(gql.ObjectConfig{Name: "Query", Fields: gql.Fields{
+		"user":          {Type: user, Args: idArgs, Resolve: r.user},
+		"users":         {Type: listOf(user), Args: usersArgs, Resolve: r.users},
+		"organization":  {Type: org, Args: idArgs, Resolve: r.organization},
+		"organizations": {Type: listOf(org), Args: orgsArgs, Resolve: r.organizations},
+	}})
+
+	mutation := gql.NewObject(gql.ObjectConfig{Name: "Mutation", Fields: gql.Fields{
+		"createUser":         {Type: user, Args: inputArgs(createUserInput), Resolve: r.createUser},
+		"updateUser":         {Type: user, Args: updateArgs(updateUserInput), Resolve: r.updateUser},
+		"deleteUser":         {Type: gql.Boolean, Args: idArgs, Resolve: r.deleteUser},
+		"createOrganization": {Type: org, Args: inputArgs(createOrganizationInput), Resolve: r.createOrganization},
+		"updateOrganization": {Type: org, Args: updateArgs(updateOrganizationInput), Resolve: r.updateOrganization},
+		"deleteOrganization": {
+			Type:        gql.Int,
+			Description: "Deletes an organization and returns the number of users deleted or reassigned with it",
+			Args: gql.FieldConfigArgument{
+				"id":         {Type: gql.NewNonNull(gql.ID)},
+				"policy":     {Type: deletePolicyType, DefaultValue: models.DeletePolicyRestrict},
+				"reassignTo": {Type: gql.ID},
+			},
+			Resolve: r.deleteOrganization,
+		},
+	}})
+
+	schema, err := gql.NewSchema(gql.SchemaConfig{Query: query, Mutation: mutation})
+	if err != nil {
+		return nil, err
+	}
+	return &Schema{schema: schema, withRequest: r.withLoaders}, nil
+}
+
+var stringList = gql.NewNonNull(gql.NewList(gql.NewNonNull(gql.String)))
+
+var createUserInput = gql.NewInputObject(gql.InputObjectConfig{Name: "CreateUserInput", Fields: gql.InputObjectConfigFieldMap{
+	"email":              {Type: gql.NewNonNull(gql.String)},
+	"name":               {Type: gql.NewNonNull(gql.String)},
+	"organizationId":     {Type: gql.NewNonNull(gql.ID)},
+	"password":           {Type: gql.NewNonNull(gql.String)},
+	"phoneNumber":        {Type: gql.String},
+	"socialMedia":        {Type: gql.String},
+	"description":        {Type: gql.String},
+	"avatarUrl":          {Type: gql.String},
+	"researchCategories": {Type: gql.NewList(gql.NewNonNull(gql.String))},
+	"isAdmin":            {Type: gql.Boolean},
+}})
+
+var updateUserInput = gql.NewInputObject(gql.InputObjectConfig{Name: "UpdateUserInput", Fields: gql.InputObjectConfigFieldMap{
+	"email":              {Type: gql.String},
+	"name":               {Type: gql.String},
+	"organizationId":     {Type: gql.ID},
+	"password":           {Type: gql.String},
+	"phoneNumber":        {Type: gql.String},
+	"socialMedia":        {Type: gql.String},
+	"description":        {Type: gql.String},
+	"avatarUrl":          {Type: gql.String},
+	"researchCategories": {Type: gql.NewList(gql.NewNonNull(gql.String))},
+	"isAdmin":            {Type: gql.Boolean},
+}})
+
+var createOrganizationInput = gql.NewInputObject(gql.InputObjectConfig{Name: "CreateOrganizationInput", Fields: gql.InputObjectConfigFieldMap{
+	"name":        {Type: gql.NewNonNull(gql.String)},
+	"lat":         {Type: gql.NewNonNull(gql.Float)},
+	"lng":         {Type: gql.NewNonNull(gql.Float)},
+	"address":     {Type: gql.String},
+	"description": {Type: gql.String},
+	"imageUrls":   {Type: gql.NewList(gql.NewNonNull(gql.String))},
+}})
+
+var updateOrganizationInput = gql.NewInputObject(gql.InputObjectConfig{Name: "UpdateOrganizationInput", Fields: gql.InputObjectConfigFieldMap{
+	"name":        {Type: gql.String},
+	"lat":         {Type: gql.Float},
+	"lng":         {Type: gql.Float},
+	"address":     {Type: gql.String},
+	"description": {Type: gql.String},
+	"imageUrls":   {Type: gql.NewList(gql.NewNonNull(gql.String))},
+}})
+
+// withLoaders attaches fresh loaders to the context of a request
+func (r *resolver) withLoaders(ctx context.Context) context.Context {
+	return context.WithValue(ctx, loadersKey{}, &loaders{
+		organizations: newLoader(r.loadOrganizations),
+		users:         newLoader(r.loadUsers),
+	})
+}
+
+func loadersFrom(ctx context.Context) *loaders {
+	return ctx.Value(loadersKey{}).(*loaders)
+}
+
+// loadOrganizations fetches the organizations of many users with one FindByIDs query
+func (r *resolver) loadOrganizations(ctx context.Context, ids []uuid.UUID) (map[uuid.UUID]any, error) {
+	orgs, _, err := r.orgService.GetByIDs(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	values := make(map[uuid.UUID]any, len(orgs))
+	for i := range orgs {
+		response := orgs[i].ToResponse()
+		values[orgs[i].ID] = &response
+	}
+	return values, nil
+}
+
+// loadUsers fetches the users of many organizations with one query per page size
+func (r *resolver) loadUsers(ctx context.Context, keys []usersKey) (map[usersKey]any, error) {
+	type page struct{ limit, offset int }
+	orgIDs := make(map[page][]uuid.UUID)
+	for _, key := range keys {
+		p := page{key.limit, key.offset}
+		orgIDs[p] = append(orgIDs[p], key.orgID)
+	}
+
+	values := make(map[usersKey]any, len(keys))
+	for p, ids := range orgIDs {
+		users, err := r.userService.ListUsersByOrganizations(ctx, ids, p.limit, p.offset)
+		if err != nil {
+			return nil, err
+		}
+		for _, id := range ids {
+			values[usersKey{id, p.limit, p.offset}] = []any{}
+		}
+		for i := range users {
+			key := usersKey{users[i].OrganizationID, p.limit, p.offset}
+			response := users[i].ToResponse()
+			values[key] = append(values[key].([]any), &response)
+		}
+	}
+	return values, nil
+}
+
+func (r *resolver) user(p gql.ResolveParams) (any, error) {
+	id, err := idArg(p.Args, "id")
+	if err != nil {
+		return nil, err
+	}
+	user, err := r.userService.GetUser(p.Context, id)
+	if err != nil || user == nil {
+		return nil, err
+	}
+	response := user.ToResponse()
+	return &response, nil
+}
+
+func (r *resolver) users(p gql.ResolveParams) (any, error) {
+	limit, offset, err := pageArgs(p.Args)
+	if err != nil {
+		return nil, err
+	}
+	filter := models.UserFilter{Limit: limit, Offset: offset}
+	filter.Query, _ = p.Args["search"].(string)
+	if err := r.limitSearch(p.Context, filter.Query); err != nil {
+		return nil, err
+	}
+	if _, ok := p.Args["organizationId"]; ok {
+		orgID, err := idArg(p.Args, "organizationId")
+		if err != nil {
+			return nil, err
+		}
+		filter.OrganizationID = &orgID
+	}
+
+	users, err := r.userService.FindUsers(p.Context, filter)
+	if err != nil {
+		return nil, err
+	}
+	list := make([]any, len(users))
+	for i := range users {
+		response := users[i].ToResponse()
+		list[i] = &response
+	}
+	return list, nil
+}
+
+func (r *resolver) organization(p gql.ResolveParams) (any, error) {
+	id, err := idArg(p.Args, "id")
+	if err != nil {
+		return nil, err
+	}
+	org, err := r.orgService.GetOrganization(p.Context, id)
+	if err != nil || org == nil {
+		return nil, err
+	}
+	response := org.ToResponse()
+	return &response, nil
+}
+
+func (r *resolver) organizations(p gql.ResolveParams) (any, error) {
+	limit, offset, err := pageArgs(p.Args)
+	if err != nil {
+		return nil, err
+	}
+	filter := models.OrganizationFilter{Limit: limit, Offset: offset}
+	filter.Query, _ = p.Args["search"].(string)
+	if err := r.limitSearch(p.Context, filter.Query); err != nil {
+		return nil, err
+	}
+
+	orgs, err := r.orgService.FindOrganizations(p.Context, filter)
+	if err != nil {
+		return nil, err
+	}
+	list := make([]any, len(orgs))
+	for i := range orgs {
+		response := orgs[i].ToResponse()
+		list[i] = &response
+	}
+	return list, nil
+}
+
+// limitSearch takes a request from the search limit of the client for a field given
+// the search query, from the same bucket as the search endpoints of the REST API.
+// Searches are let through when the store fails, like requests to those endpoints.
+func (r *resolver) limitSearch(ctx context.Context, query string) error {
+	if query == "" || !r.searchLimit.Enabled() {
+		return nil
+	}
+	result, err := r.limits.Take(ctx, "search:"+ratelimit.ClientKey(auth.FromContext(ctx)), r.searchLimit)
+	if err != nil {
+		log.Printf("Rate limiting failed: %v", err)
+		return nil
+	}
+	if !result.Allowed {
+		return fmt.Errorf("too many searches, retry in %d seconds", int(math.Ceil(result.RetryAfter.Seconds())))
+	}
+	return nil
+}
+
+func (r *resolver) createUser(p gql.ResolveParams) (any, error) {
+	req := new(models.CreateUserRequest)
+	if err := r.input(p.Args, req); err != nil {
+		return nil, err
+	}
+	user, err := r.userService.CreateUser(p.Context, req)
+	if err != nil {
+		return nil, err
+	}
+	response := user.ToResponse()
+	return &response, nil
+}
+
+func (r *resolver) updateUser(p gql.ResolveParams) (any, error) {
+	id, err := idArg(p.Args, "id")
+	if err != nil {
+		return nil, err
+	}
+	req := new(models.UpdateUserRequest)
+	if err := r.input(p.Args, req); err != nil {
+		return nil, err
+	}
+	user, err := r.userService.Update(p.Context, id, req)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, errors.New("user not found")
+	}
+	response := user.ToResponse()
+	return &response, nil
+}
+
+func (r *resolver) deleteUser(p gql.ResolveParams) (any, error) {
+	id, err := idArg(p.Args, "id")
+	if err != nil {
+		return nil, err
+	}
+	if err := r.userService.Delete(p.Context, id); err != nil {
+		return nil, err
+	}
+	return true, nil
+}
+
+func (r *resolver) createOrganization(p gql.ResolveParams) (any, error) {
+	req := new(models.CreateOrganizationRequest)
+	if err := r.input(p.Args, req); err != nil {
+		return nil, err
+	}
+	org, err := r.orgService.CreateOrganization(p.Context, req)
+	if err != nil {
+		return nil, err
+	}
+	response := org.ToResponse()
+	return &response, nil
+}
+
+func (r *resolver) updateOrganization(p gql.ResolveParams) (any, error) {
+	id, err := idArg(p.Args, "id")
+	if err != nil {
+		return nil, err
+	}
+	req := new(models.UpdateOrganizationRequest)
+	if err := r.input(p.Args, req); err != nil {
+		return nil, err
+	}
+	org, err := r.orgService.UpdateOrganization(p.Context, id, req)
+	if err != nil {
+		return nil, err
+	}
+	if org == nil {
+		return nil, errors.New("organization not found")
+	}
+	response := org.ToResponse()
+	return &response, nil
+}
+
+func (r *resolver) deleteOrganization(p gql.ResolveParams) (any, error) {
+	id, err := idArg(p.Args, "id")
+	if err != nil {
+		return nil, err
+	}
+	opts := models.OrganizationDeleteOptions{Policy: p.Args["policy"].(models.OrganizationDeletePolicy)}
+	if _, ok := p.Args["reassignTo"]; ok {
+		target, err := idArg(p.Args, "reassignTo")
+		if err != nil {
+			return nil, err
+		}
+		opts.ReassignTo = &target
+	}
+
+	return r.orgService.DeleteOrganization(p.Context, id, opts)
+}
+
+// input decodes and validates the input argument into a request DTO. Input fields are
+// the camel case versions of the DTO's JSON names.
+func (r *resolver) input(args map[string]any, dst any) error {
+	input, ok := args["input"].(map[string]any)
+	if !ok {
+		return errors.New("input is required")
+	}
+	fields := make(map[string]any, len(input))
+	for k, v := range input {
+		fields[snakeCase(k)] = v
+	}
+
+	body, err := json.Marshal(fields)
+	if err != nil {
+		return err
+	}
+	dec := json.NewDecoder(bytes.NewReader(body))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(dst); err != nil {
+		return fmt.Errorf("invalid input: %w", err)
+	}
+	return r.validate.Struct(dst)
+}
+
+func userField(typ gql.Output, get func(*models.UserResponse) any) *gql.Field {
+	return &gql.Field{Type: typ, Resolve: func(p gql.ResolveParams) (any, error) {
+		return get(p.Source.(*models.UserResponse)), nil
+	}}
+}
+
+func orgField(typ gql.Output, get func(*models.OrganizationResponse) any) *gql.Field {
+	return &gql.Field{Type: typ, Resolve: func(p gql.ResolveParams) (any, error) {
+		return get(p.Source.(*models.OrganizationResponse)), nil
+	}}
+}
+
+// listOf is the type of list fields, which never return null lists or items
+func listOf(typ *gql.Object) gql.Output {
+	return gql.NewNonNull(gql.NewList(gql.NewNonNull(typ)))
+}
+
+// nonNilList keeps empty lists from being returned as null
+func nonNilList(list []string) []string {
+	if list == nil {
+		return []string{}
+	}
+	return list
+}
+
+// pageArgConfig declares the limit and offset arguments of list fields
+func pageArgConfig() gql.FieldConfigArgument {
+	return gql.FieldConfigArgument{
+		"limit":  {Type: gql.Int, DefaultValue: 20},
+		"offset": {Type: gql.Int, DefaultValue: 0},
+	}
+}
+
+func idArg(args map[string]any, name string) (uuid.UUID, error) {
+	s, _ := args[name].(string)
+	id, err := uuid.Parse(s)
+	if err != nil {
+		return uuid.Nil, fmt.Errorf("invalid %s", name)
+	}
+	return id, nil
+}
+
+// pageArgs reads the limit and offset arguments
+func pageArgs(args map[string]any) (limit, offset int, err error) {
+	limit, _ = args["limit"].(int)
+	offset, _ = args["offset"].(int)
+	if limit < 1 || limit > maxPageSize {
+		return 0, 0, fmt.Errorf("limit must be between 1 and %d", maxPageSize)
+	}
+	if offset < 0 {
+		return 0, 0, errors.New("offset must be non-negative")
+	}
+	return limit, offset, nil
+}
+
+// snakeCase converts a camel case name like organizationId to organization_id
+func snakeCase(name string) string {
+	var sb strings.Builder
+	for i, r := range name {
+		if unicode.IsUpper(r) {
+			if i > 0 {
+				sb.WriteByte('_')
+			}
+			r = unicode.ToLower(r)
+		}
+		sb.WriteRune(r)
+	}
+	return sb.String()
+}
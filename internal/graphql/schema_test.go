@@ -0,0 +1,320 @@
+package graphql
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	gql "github.com/graphql-go/graphql"
+	"github.com/graphql-go/graphql/language/ast"
+	"github.com/graphql-go/graphql/language/parser"
+	"github.com/graphql-go/graphql/language/printer"
+	"github.com/hoshina-dev/custapi/internal/auth"
+	"github.com/hoshina-dev/custapi/internal/models"
+	"github.com/hoshina-dev/custapi/internal/ratelimit"
+	"github.com/hoshina-dev/custapi/internal/services"
+)
+
+var (
+	orgA = models.Organization{ID: uuid.MustParse("00000000-0000-0000-0000-00000000000a"), Name: "A"}
+	orgB = models.Organization{ID: uuid.MustParse("00000000-0000-0000-0000-00000000000b"), Name: "B"}
+)
+
+// fakeUserService serves three users, two of them in organization A, and counts
+// the batched lookups of the users of organizations
+type fakeUserService struct {
+	services.UserService
+	users       []models.User
+	batchLoads  int
+	deletedUser uuid.UUID
+}
+
+func newFakeUserService() *fakeUserService {
+	return &fakeUserService{users: []models.User{
+		{ID: uuid.New(), Name: "Ann", OrganizationID: orgA.ID},
+		{ID: uuid.New(), Name: "Bob", OrganizationID: orgB.ID},
+		{ID: uuid.New(), Name: "Cat", OrganizationID: orgA.ID},
+	}}
+}
+
+func (s *fakeUserService) FindUsers(ctx context.Context, filter models.UserFilter) ([]models.User, error) {
+	return s.users, nil
+}
+
+func (s *fakeUserService) ListUsersByOrganizations(ctx context.Context, orgIDs []uuid.UUID, limit, offset int) ([]models.User, error) {
+	s.batchLoads++
+	var users []models.User
+	for _, id := range orgIDs {
+		var page []models.User
+		for _, u := range s.users {
+			if u.OrganizationID == id {
+				page = append(page, u)
+			}
+		}
+		users = append(users, page[min(offset, len(page)):min(offset+limit, len(page))]...)
+	}
+	return users, nil
+}
+
+func (s *fakeUserService) Delete(ctx context.Context, id uuid.UUID) error {
+	s.deletedUser = id
+	return nil
+}
+
+// fakeOrgService serves organizations A and B and counts the batched lookups
+type fakeOrgService struct {
+	services.OrganizationService
+	batchLoads int
+	deleted    models.OrganizationDeleteOptions
+}
+
+func (s *fakeOrgService) FindOrganizations(ctx context.Context, filter models.OrganizationFilter) ([]models.Organization, error) {
+	return []models.Organization{orgA, orgB}, nil
+}
+
+func (s *fakeOrgService) GetByIDs(ctx context.Context, ids []uuid.UUID) ([]models.Organization, []uuid.UUID, error) {
+	s.batchLoads++
+	var orgs []models.Organization
+	for _, org := range []models.Organization{orgA, orgB} {
+		if slices.Contains(ids, org.ID) {
+			orgs = append(orgs, org)
+		}
+	}
+	return orgs, nil, nil
+}
+
+func (s *fakeOrgService) DeleteOrganization(ctx context.Context, id uuid.UUID, opts models.OrganizationDeleteOptions) (int64, error) {
+	s.deleted = opts
+	return 2, nil
+}
+
+func testSchema(t *testing.T, userService services.UserService, orgService services.OrganizationService, searchLimit ratelimit.Limit) *Schema {
+	t.Helper()
+	schema, err := NewSchema(userService, orgService, ratelimit.NewMemoryStore(), searchLimit)
+	if err != nil {
+		t.Fatalf("NewSchema() error = %v", err)
+	}
+	return schema
+}
+
+// verifiedUser is the context of a request by a user who verified their email address
+func verifiedUser() context.Context {
+	id := uuid.New()
+	return auth.NewContext(context.Background(), &auth.Actor{UserID: &id, EmailVerified: true})
+}
+
+func TestSchemaResolvers(t *testing.T) {
+	tests := []struct {
+		name         string
+		req          Request
+		want         string
+		wantOrgLoads int
+		wantUserLoad int
+	}{
+		{
+			name:         "organizations of users are loaded in one batch",
+			req:          Request{Query: `{ users { name organization { name } } }`},
+			want:         `{"data":{"users":[{"name":"Ann","organization":{"name":"A"}},{"name":"Bob","organization":{"name":"B"}},{"name":"Cat","organization":{"name":"A"}}]}}`,
+			wantOrgLoads: 1,
+		},
+		{
+			name:         "users of organizations are loaded in one batch",
+			req:          Request{Query: `{ organizations { name users(limit: 1) { name } } }`},
+			want:         `{"data":{"organizations":[{"name":"A","users":[{"name":"Ann"}]},{"name":"B","users":[{"name":"Bob"}]}]}}`,
+			wantUserLoad: 1,
+		},
+		{
+			name: "variables, aliases and fragments",
+			req: Request{
+				Query:     `query($limit: Int) { orgs: organizations(limit: $limit) { ...O } } fragment O on Organization { id }`,
+				Variables: map[string]any{"limit": 1},
+			},
+			want: `{"data":{"orgs":[{"id":"` + orgA.ID.String() + `"},{"id":"` + orgB.ID.String() + `"}]}}`,
+		},
+		{
+			name: "invalid page",
+			req:  Request{Query: `{ users(limit: 1000) { name } }`},
+			want: `{"data":null,"errors":[{"message":"limit must be between 1 and 100","path":["users"]}]}`,
+		},
+		{
+			name: "delete policy defaults to restrict",
+			req:  Request{Query: `mutation { deleteOrganization(id: "` + orgA.ID.String() + `") }`},
+			want: `{"data":{"deleteOrganization":2}}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			userService, orgService := newFakeUserService(), &fakeOrgService{}
+			resp := testSchema(t, userService, orgService, ratelimit.Limit{}).Execute(verifiedUser(), tt.req)
+			got, err := json.Marshal(resp)
+			if err != nil {
+				t.Fatalf("json.Marshal() error = %v", err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("Execute() = %s\nwant %s", got, tt.want)
+			}
+			if orgService.batchLoads != tt.wantOrgLoads || userService.batchLoads != tt.wantUserLoad {
+				t.Errorf("loaded organizations %d times and users %d times, want %d and %d",
+					orgService.batchLoads, userService.batchLoads, tt.wantOrgLoads, tt.wantUserLoad)
+			}
+		})
+	}
+}
+
+func TestSchemaDeleteOrganizationPolicy(t *testing.T) {
+	target := uuid.New()
+	orgService := &fakeOrgService{}
+	query := fmt.Sprintf(`mutation { deleteOrganization(id: %q, policy: REASSIGN, reassignTo: %q) }`, orgA.ID, target)
+	resp := testSchema(t, newFakeUserService(), orgService, ratelimit.Limit{}).Execute(verifiedUser(), Request{Query: query})
+	if len(resp.Errors) > 0 {
+		t.Fatalf("Execute() errors = %v", resp.Errors)
+	}
+	if orgService.deleted.Policy != models.DeletePolicyReassign || orgService.deleted.ReassignTo == nil || *orgService.deleted.ReassignTo != target {
+		t.Errorf("DeleteOrganization() options = %+v, want reassigning to %s", orgService.deleted, target)
+	}
+}
+
+func TestSchemaSearchLimit(t *testing.T) {
+	schema := testSchema(t, newFakeUserService(), &fakeOrgService{}, ratelimit.Limit{Requests: 2, Per: time.Minute})
+	ctx := verifiedUser()
+
+	tests := []struct {
+		name    string
+		query   string
+		wantErr bool
+	}{
+		{"listing is not limited", `{ users { name } organizations { name } }`, false},
+		{"searches take from the limit", `{ users(search: "a") { name } organizations(search: "a") { name } }`, false},
+		{"users search over the limit", `{ users(search: "a") { name } }`, true},
+		{"organizations search over the limit", `{ organizations(search: "a") { name } }`, true},
+		{"listing is still allowed", `{ users { name } }`, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := schema.Execute(ctx, Request{Query: tt.query})
+			limited := len(resp.Errors) == 1 && strings.HasPrefix(resp.Errors[0].Message, "too many searches")
+			if limited != tt.wantErr || !tt.wantErr && len(resp.Errors) > 0 {
+				t.Errorf("Execute() errors = %v, want limited %v", resp.Errors, tt.wantErr)
+			}
+		})
+	}
+
+	// Other clients have buckets of their own
+	if resp := schema.Execute(verifiedUser(), Request{Query: `{ users(search: "a") { name } }`}); len(resp.Errors) > 0 {
+		t.Errorf("Execute() errors = %v for another user", resp.Errors)
+	}
+}
+
+// TestSDL checks that SDL describes the types, fields and arguments of the schema
+func TestSDL(t *testing.T) {
+	doc, err := parser.Parse(parser.ParseParams{Source: SDL})
+	if err != nil {
+		t.Fatalf("parsing SDL: %v", err)
+	}
+	schema := testSchema(t, nil, nil, ratelimit.Limit{})
+
+	for _, def := range doc.Definitions {
+		switch def := def.(type) {
+		case *ast.ScalarDefinition:
+			if _, ok := schema.schema.Type(def.Name.Value).(*gql.Scalar); !ok {
+				t.Errorf("scalar %s is not in the schema", def.Name.Value)
+			}
+		case *ast.EnumDefinition:
+			enum, ok := schema.schema.Type(def.Name.Value).(*gql.Enum)
+			if !ok {
+				t.Errorf("enum %s is not in the schema", def.Name.Value)
+				continue
+			}
+			var want, got []string
+			for _, v := range def.Values {
+				want = append(want, v.Name.Value)
+			}
+			for _, v := range enum.Values() {
+				got = append(got, v.Name)
+			}
+			slices.Sort(want)
+			slices.Sort(got)
+			if !slices.Equal(got, want) {
+				t.Errorf("enum %s has values %v, SDL %v", def.Name.Value, got, want)
+			}
+		case *ast.ObjectDefinition:
+			object, ok := schema.schema.Type(def.Name.Value).(*gql.Object)
+			if !ok {
+				t.Errorf("type %s is not in the schema", def.Name.Value)
+				continue
+			}
+			fields := object.Fields()
+			if len(fields) != len(def.Fields) {
+				t.Errorf("type %s has %d fields, SDL %d", def.Name.Value, len(fields), len(def.Fields))
+			}
+			for _, f := range def.Fields {
+				name := def.Name.Value + "." + f.Name.Value
+				field, ok := fields[f.Name.Value]
+				if !ok {
+					t.Errorf("field %s is not in the schema", name)
+					continue
+				}
+				checkType(t, name, field.Type, f.Type)
+				if len(field.Args) != len(f.Arguments) {
+					t.Errorf("field %s has %d arguments, SDL %d", name, len(field.Args), len(f.Arguments))
+				}
+				for _, a := range f.Arguments {
+					i := slices.IndexFunc(field.Args, func(arg *gql.Argument) bool { return arg.Name() == a.Name.Value })
+					if i < 0 {
+						t.Errorf("argument %s(%s) is not in the schema", name, a.Name.Value)
+						continue
+					}
+					checkType(t, name+"("+a.Name.Value+")", field.Args[i].Type, a.Type)
+					checkDefault(t, name+"("+a.Name.Value+")", field.Args[i], a.DefaultValue)
+				}
+			}
+		case *ast.InputObjectDefinition:
+			input, ok := schema.schema.Type(def.Name.Value).(*gql.InputObject)
+			if !ok {
+				t.Errorf("input %s is not in the schema", def.Name.Value)
+				continue
+			}
+			fields := input.Fields()
+			if len(fields) != len(def.Fields) {
+				t.Errorf("input %s has %d fields, SDL %d", def.Name.Value, len(fields), len(def.Fields))
+			}
+			for _, f := range def.Fields {
+				if field, ok := fields[f.Name.Value]; !ok {
+					t.Errorf("input field %s.%s is not in the schema", def.Name.Value, f.Name.Value)
+				} else {
+					checkType(t, def.Name.Value+"."+f.Name.Value, field.Type, f.Type)
+				}
+			}
+		default:
+			t.Errorf("unexpected definition %T in SDL", def)
+		}
+	}
+}
+
+func checkType(t *testing.T, name string, got gql.Type, want ast.Type) {
+	t.Helper()
+	if got.String() != printer.Print(want) {
+		t.Errorf("%s has type %s, SDL %s", name, got, printer.Print(want))
+	}
+}
+
+func checkDefault(t *testing.T, name string, arg *gql.Argument, want ast.Value) {
+	t.Helper()
+	got := ""
+	if arg.DefaultValue != nil {
+		leaf, _ := arg.Type.(gql.Leaf)
+		got = fmt.Sprint(leaf.Serialize(arg.DefaultValue))
+	}
+	wantDefault := ""
+	if want != nil {
+		wantDefault, _ = printer.Print(want).(string)
+	}
+	if got != wantDefault {
+		t.Errorf("%s defaults to %q, SDL %q", name, got, wantDefault)
+	}
+}
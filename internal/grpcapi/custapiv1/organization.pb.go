@@ -0,0 +1,955 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.6
+// 	protoc        (unknown)
+// source: custapi/v1/organization.proto
+
+package custapiv1
+
+import (
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type DeletePolicy int32
+
+const (
+	DeletePolicy_DELETE_POLICY_UNSPECIFIED DeletePolicy = 0
+	// Refuse to delete an organization that still has users
+	DeletePolicy_DELETE_POLICY_RESTRICT DeletePolicy = 1
+	// Delete the users along with the organization
+	DeletePolicy_DELETE_POLICY_CASCADE DeletePolicy = 2
+	// Move the users to reassign_to
+	DeletePolicy_DELETE_POLICY_REASSIGN DeletePolicy = 3
+)
+
+// Enum value maps for DeletePolicy.
+var (
+	DeletePolicy_name = map[int32]string{
+		0: "DELETE_POLICY_UNSPECIFIED",
+		1: "DELETE_POLICY_RESTRICT",
+		2: "DELETE_POLICY_CASCADE",
+		3: "DELETE_POLICY_REASSIGN",
+	}
+	DeletePolicy_value = map[string]int32{
+		"DELETE_POLICY_UNSPECIFIED": 0,
+		"DELETE_POLICY_RESTRICT":    1,
+		"DELETE_POLICY_CASCADE":     2,
+		"DELETE_POLICY_REASSIGN":    3,
+	}
+)
+
+func (x DeletePolicy) Enum() *DeletePolicy {
+	p := new(DeletePolicy)
+	*p = x
+	return p
+}
+
+func (x DeletePolicy) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (DeletePolicy) Descriptor() protoreflect.EnumDescriptor {
+	return file_custapi_v1_organization_proto_enumTypes[0].Descriptor()
+}
+
+func (DeletePolicy) Type() protoreflect.EnumType {
+	return &file_custapi_v1_organization_proto_enumTypes[0]
+}
+
+func (x DeletePolicy) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use DeletePolicy.Descriptor instead.
+func (DeletePolicy) EnumDescriptor() ([]byte, []int) {
+	return file_custapi_v1_organization_proto_rawDescGZIP(), []int{0}
+}
+
+type Organization struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Name          string                 `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`
+	Lat           float64                `protobuf:"fixed64,3,opt,name=lat,proto3" json:"lat,omitempty"`
+	Lng           float64                `protobuf:"fixed64,4,opt,name=lng,proto3" json:"lng,omitempty"`
+	Address       *string                `protobuf:"bytes,5,opt,name=address,proto3,oneof" json:"address,omitempty"`
+	Description   *string                `protobuf:"bytes,6,opt,name=description,proto3,oneof" json:"description,omitempty"`
+	ImageUrls     []string               `protobuf:"bytes,7,rep,name=image_urls,json=imageUrls,proto3" json:"image_urls,omitempty"`
+	CreatedAt     *timestamppb.Timestamp `protobuf:"bytes,8,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
+	UpdatedAt     *timestamppb.Timestamp `protobuf:"bytes,9,opt,name=updated_at,json=updatedAt,proto3" json:"updated_at,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Organization) Reset() {
+	*x = Organization{}
+	mi := &file_custapi_v1_organization_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Organization) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Organization) ProtoMessage() {}
+
+func (x *Organization) ProtoReflect() protoreflect.Message {
+	mi := &file_custapi_v1_organization_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Organization.ProtoReflect.Descriptor instead.
+func (*Organization) Descriptor() ([]byte, []int) {
+	return file_custapi_v1_organization_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *Organization) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *Organization) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *Organization) GetLat() float64 {
+	if x != nil {
+		return x.Lat
+	}
+	return 0
+}
+
+func (x *Organization) GetLng() float64 {
+	if x != nil {
+		return x.Lng
+	}
+	return 0
+}
+
+func (x *Organization) GetAddress() string {
+	if x != nil && x.Address != nil {
+		return *x.Address
+	}
+	return ""
+}
+
+func (x *Organization) GetDescription() string {
+	if x != nil && x.Description != nil {
+		return *x.Description
+	}
+	return ""
+}
+
+func (x *Organization) GetImageUrls() []string {
+	if x != nil {
+		return x.ImageUrls
+	}
+	return nil
+}
+
+func (x *Organization) GetCreatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.CreatedAt
+	}
+	return nil
+}
+
+func (x *Organization) GetUpdatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.UpdatedAt
+	}
+	return nil
+}
+
+type CreateOrganizationRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Name          string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	Lat           float64                `protobuf:"fixed64,2,opt,name=lat,proto3" json:"lat,omitempty"`
+	Lng           float64                `protobuf:"fixed64,3,opt,name=lng,proto3" json:"lng,omitempty"`
+	Address       *string                `protobuf:"bytes,4,opt,name=address,proto3,oneof" json:"address,omitempty"`
+	Description   *string                `protobuf:"bytes,5,opt,name=description,proto3,oneof" json:"description,omitempty"`
+	ImageUrls     []string               `protobuf:"bytes,6,rep,name=image_urls,json=imageUrls,proto3" json:"image_urls,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CreateOrganizationRequest) Reset() {
+	*x = CreateOrganizationRequest{}
+	mi := &file_custapi_v1_organization_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CreateOrganizationRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CreateOrganizationRequest) ProtoMessage() {}
+
+func (x *CreateOrganizationRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_custapi_v1_organization_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CreateOrganizationRequest.ProtoReflect.Descriptor instead.
+func (*CreateOrganizationRequest) Descriptor() ([]byte, []int) {
+	return file_custapi_v1_organization_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *CreateOrganizationRequest) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *CreateOrganizationRequest) GetLat() float64 {
+	if x != nil {
+		return x.Lat
+	}
+	return 0
+}
+
+func (x *CreateOrganizationRequest) GetLng() float64 {
+	if x != nil {
+		return x.Lng
+	}
+	return 0
+}
+
+func (x *CreateOrganizationRequest) GetAddress() string {
+	if x != nil && x.Address != nil {
+		return *x.Address
+	}
+	return ""
+}
+
+func (x *CreateOrganizationRequest) GetDescription() string {
+	if x != nil && x.Description != nil {
+		return *x.Description
+	}
+	return ""
+}
+
+func (x *CreateOrganizationRequest) GetImageUrls() []string {
+	if x != nil {
+		return x.ImageUrls
+	}
+	return nil
+}
+
+type GetOrganizationRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetOrganizationRequest) Reset() {
+	*x = GetOrganizationRequest{}
+	mi := &file_custapi_v1_organization_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetOrganizationRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetOrganizationRequest) ProtoMessage() {}
+
+func (x *GetOrganizationRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_custapi_v1_organization_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetOrganizationRequest.ProtoReflect.Descriptor instead.
+func (*GetOrganizationRequest) Descriptor() ([]byte, []int) {
+	return file_custapi_v1_organization_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *GetOrganizationRequest) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+type BatchGetOrganizationsRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// At most 100 IDs
+	Ids           []string `protobuf:"bytes,1,rep,name=ids,proto3" json:"ids,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *BatchGetOrganizationsRequest) Reset() {
+	*x = BatchGetOrganizationsRequest{}
+	mi := &file_custapi_v1_organization_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *BatchGetOrganizationsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BatchGetOrganizationsRequest) ProtoMessage() {}
+
+func (x *BatchGetOrganizationsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_custapi_v1_organization_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BatchGetOrganizationsRequest.ProtoReflect.Descriptor instead.
+func (*BatchGetOrganizationsRequest) Descriptor() ([]byte, []int) {
+	return file_custapi_v1_organization_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *BatchGetOrganizationsRequest) GetIds() []string {
+	if x != nil {
+		return x.Ids
+	}
+	return nil
+}
+
+type BatchGetOrganizationsResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// In the order of the requested IDs
+	Organizations []*Organization `protobuf:"bytes,1,rep,name=organizations,proto3" json:"organizations,omitempty"`
+	// Requested IDs that matched no organization
+	MissingIds    []string `protobuf:"bytes,2,rep,name=missing_ids,json=missingIds,proto3" json:"missing_ids,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *BatchGetOrganizationsResponse) Reset() {
+	*x = BatchGetOrganizationsResponse{}
+	mi := &file_custapi_v1_organization_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *BatchGetOrganizationsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BatchGetOrganizationsResponse) ProtoMessage() {}
+
+func (x *BatchGetOrganizationsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_custapi_v1_organization_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BatchGetOrganizationsResponse.ProtoReflect.Descriptor instead.
+func (*BatchGetOrganizationsResponse) Descriptor() ([]byte, []int) {
+	return file_custapi_v1_organization_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *BatchGetOrganizationsResponse) GetOrganizations() []*Organization {
+	if x != nil {
+		return x.Organizations
+	}
+	return nil
+}
+
+func (x *BatchGetOrganizationsResponse) GetMissingIds() []string {
+	if x != nil {
+		return x.MissingIds
+	}
+	return nil
+}
+
+type ListOrganizationsRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Only organizations whose name contains query
+	Query string `protobuf:"bytes,1,opt,name=query,proto3" json:"query,omitempty"`
+	// Page size, 20 when unset and at most 100
+	Limit         int32 `protobuf:"varint,2,opt,name=limit,proto3" json:"limit,omitempty"`
+	Offset        int32 `protobuf:"varint,3,opt,name=offset,proto3" json:"offset,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListOrganizationsRequest) Reset() {
+	*x = ListOrganizationsRequest{}
+	mi := &file_custapi_v1_organization_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListOrganizationsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListOrganizationsRequest) ProtoMessage() {}
+
+func (x *ListOrganizationsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_custapi_v1_organization_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListOrganizationsRequest.ProtoReflect.Descriptor instead.
+func (*ListOrganizationsRequest) Descriptor() ([]byte, []int) {
+	return file_custapi_v1_organization_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *ListOrganizationsRequest) GetQuery() string {
+	if x != nil {
+		return x.Query
+	}
+	return ""
+}
+
+func (x *ListOrganizationsRequest) GetLimit() int32 {
+	if x != nil {
+		return x.Limit
+	}
+	return 0
+}
+
+func (x *ListOrganizationsRequest) GetOffset() int32 {
+	if x != nil {
+		return x.Offset
+	}
+	return 0
+}
+
+type SearchOrganizationsRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Query string                 `protobuf:"bytes,1,opt,name=query,proto3" json:"query,omitempty"`
+	// Maximum number of results, 100 when unset
+	Limit         int32 `protobuf:"varint,2,opt,name=limit,proto3" json:"limit,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SearchOrganizationsRequest) Reset() {
+	*x = SearchOrganizationsRequest{}
+	mi := &file_custapi_v1_organization_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SearchOrganizationsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SearchOrganizationsRequest) ProtoMessage() {}
+
+func (x *SearchOrganizationsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_custapi_v1_organization_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SearchOrganizationsRequest.ProtoReflect.Descriptor instead.
+func (*SearchOrganizationsRequest) Descriptor() ([]byte, []int) {
+	return file_custapi_v1_organization_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *SearchOrganizationsRequest) GetQuery() string {
+	if x != nil {
+		return x.Query
+	}
+	return ""
+}
+
+func (x *SearchOrganizationsRequest) GetLimit() int32 {
+	if x != nil {
+		return x.Limit
+	}
+	return 0
+}
+
+type ListOrganizationsResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Organizations []*Organization        `protobuf:"bytes,1,rep,name=organizations,proto3" json:"organizations,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListOrganizationsResponse) Reset() {
+	*x = ListOrganizationsResponse{}
+	mi := &file_custapi_v1_organization_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListOrganizationsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListOrganizationsResponse) ProtoMessage() {}
+
+func (x *ListOrganizationsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_custapi_v1_organization_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListOrganizationsResponse.ProtoReflect.Descriptor instead.
+func (*ListOrganizationsResponse) Descriptor() ([]byte, []int) {
+	return file_custapi_v1_organization_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *ListOrganizationsResponse) GetOrganizations() []*Organization {
+	if x != nil {
+		return x.Organizations
+	}
+	return nil
+}
+
+// UpdateOrganizationRequest changes the fields that are set. The location is only
+// changed when both lat and lng are set.
+type UpdateOrganizationRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Name          *string                `protobuf:"bytes,2,opt,name=name,proto3,oneof" json:"name,omitempty"`
+	Lat           *float64               `protobuf:"fixed64,3,opt,name=lat,proto3,oneof" json:"lat,omitempty"`
+	Lng           *float64               `protobuf:"fixed64,4,opt,name=lng,proto3,oneof" json:"lng,omitempty"`
+	Address       *string                `protobuf:"bytes,5,opt,name=address,proto3,oneof" json:"address,omitempty"`
+	Description   *string                `protobuf:"bytes,6,opt,name=description,proto3,oneof" json:"description,omitempty"`
+	ImageUrls     []string               `protobuf:"bytes,7,rep,name=image_urls,json=imageUrls,proto3" json:"image_urls,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *UpdateOrganizationRequest) Reset() {
+	*x = UpdateOrganizationRequest{}
+	mi := &file_custapi_v1_organization_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UpdateOrganizationRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UpdateOrganizationRequest) ProtoMessage() {}
+
+func (x *UpdateOrganizationRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_custapi_v1_organization_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UpdateOrganizationRequest.ProtoReflect.Descriptor instead.
+func (*UpdateOrganizationRequest) Descriptor() ([]byte, []int) {
+	return file_custapi_v1_organization_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *UpdateOrganizationRequest) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *UpdateOrganizationRequest) GetName() string {
+	if x != nil && x.Name != nil {
+		return *x.Name
+	}
+	return ""
+}
+
+func (x *UpdateOrganizationRequest) GetLat() float64 {
+	if x != nil && x.Lat != nil {
+		return *x.Lat
+	}
+	return 0
+}
+
+func (x *UpdateOrganizationRequest) GetLng() float64 {
+	if x != nil && x.Lng != nil {
+		return *x.Lng
+	}
+	return 0
+}
+
+func (x *UpdateOrganizationRequest) GetAddress() string {
+	if x != nil && x.Address != nil {
+		return *x.Address
+	}
+	return ""
+}
+
+func (x *UpdateOrganizationRequest) GetDescription() string {
+	if x != nil && x.Description != nil {
+		return *x.Description
+	}
+	return ""
+}
+
+func (x *UpdateOrganizationRequest) GetImageUrls() []string {
+	if x != nil {
+		return x.ImageUrls
+	}
+	return nil
+}
+
+type DeleteOrganizationRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Id    string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	// Restrict when unspecified
+	Policy     DeletePolicy `protobuf:"varint,2,opt,name=policy,proto3,enum=custapi.v1.DeletePolicy" json:"policy,omitempty"`
+	ReassignTo *string      `protobuf:"bytes,3,opt,name=reassign_to,json=reassignTo,proto3,oneof" json:"reassign_to,omitempty"`
+	// Report the affected users without deleting anything
+	DryRun        bool `protobuf:"varint,4,opt,name=dry_run,json=dryRun,proto3" json:"dry_run,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DeleteOrganizationRequest) Reset() {
+	*x = DeleteOrganizationRequest{}
+	mi := &file_custapi_v1_organization_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeleteOrganizationRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeleteOrganizationRequest) ProtoMessage() {}
+
+func (x *DeleteOrganizationRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_custapi_v1_organization_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeleteOrganizationRequest.ProtoReflect.Descriptor instead.
+func (*DeleteOrganizationRequest) Descriptor() ([]byte, []int) {
+	return file_custapi_v1_organization_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *DeleteOrganizationRequest) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *DeleteOrganizationRequest) GetPolicy() DeletePolicy {
+	if x != nil {
+		return x.Policy
+	}
+	return DeletePolicy_DELETE_POLICY_UNSPECIFIED
+}
+
+func (x *DeleteOrganizationRequest) GetReassignTo() string {
+	if x != nil && x.ReassignTo != nil {
+		return *x.ReassignTo
+	}
+	return ""
+}
+
+func (x *DeleteOrganizationRequest) GetDryRun() bool {
+	if x != nil {
+		return x.DryRun
+	}
+	return false
+}
+
+type DeleteOrganizationResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Users deleted or reassigned, or that would be with dry_run
+	AffectedUsers int64 `protobuf:"varint,1,opt,name=affected_users,json=affectedUsers,proto3" json:"affected_users,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DeleteOrganizationResponse) Reset() {
+	*x = DeleteOrganizationResponse{}
+	mi := &file_custapi_v1_organization_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeleteOrganizationResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeleteOrganizationResponse) ProtoMessage() {}
+
+func (x *DeleteOrganizationResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_custapi_v1_organization_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeleteOrganizationResponse.ProtoReflect.Descriptor instead.
+func (*DeleteOrganizationResponse) Descriptor() ([]byte, []int) {
+	return file_custapi_v1_organization_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *DeleteOrganizationResponse) GetAffectedUsers() int64 {
+	if x != nil {
+		return x.AffectedUsers
+	}
+	return 0
+}
+
+var File_custapi_v1_organization_proto protoreflect.FileDescriptor
+
+const file_custapi_v1_organization_proto_rawDesc = "" +
+	"\n" +
+	"\x1dcustapi/v1/organization.proto\x12\n" +
+	"custapi.v1\x1a\x1fgoogle/protobuf/timestamp.proto\"\xcd\x02\n" +
+	"\fOrganization\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x12\n" +
+	"\x04name\x18\x02 \x01(\tR\x04name\x12\x10\n" +
+	"\x03lat\x18\x03 \x01(\x01R\x03lat\x12\x10\n" +
+	"\x03lng\x18\x04 \x01(\x01R\x03lng\x12\x1d\n" +
+	"\aaddress\x18\x05 \x01(\tH\x00R\aaddress\x88\x01\x01\x12%\n" +
+	"\vdescription\x18\x06 \x01(\tH\x01R\vdescription\x88\x01\x01\x12\x1d\n" +
+	"\n" +
+	"image_urls\x18\a \x03(\tR\timageUrls\x129\n" +
+	"\n" +
+	"created_at\x18\b \x01(\v2\x1a.google.protobuf.TimestampR\tcreatedAt\x129\n" +
+	"\n" +
+	"updated_at\x18\t \x01(\v2\x1a.google.protobuf.TimestampR\tupdatedAtB\n" +
+	"\n" +
+	"\b_addressB\x0e\n" +
+	"\f_description\"\xd4\x01\n" +
+	"\x19CreateOrganizationRequest\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x12\x10\n" +
+	"\x03lat\x18\x02 \x01(\x01R\x03lat\x12\x10\n" +
+	"\x03lng\x18\x03 \x01(\x01R\x03lng\x12\x1d\n" +
+	"\aaddress\x18\x04 \x01(\tH\x00R\aaddress\x88\x01\x01\x12%\n" +
+	"\vdescription\x18\x05 \x01(\tH\x01R\vdescription\x88\x01\x01\x12\x1d\n" +
+	"\n" +
+	"image_urls\x18\x06 \x03(\tR\timageUrlsB\n" +
+	"\n" +
+	"\b_addressB\x0e\n" +
+	"\f_description\"(\n" +
+	"\x16GetOrganizationRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\"0\n" +
+	"\x1cBatchGetOrganizationsRequest\x12\x10\n" +
+	"\x03ids\x18\x01 \x03(\tR\x03ids\"\x80\x01\n" +
+	"\x1dBatchGetOrganizationsResponse\x12>\n" +
+	"\rorganizations\x18\x01 \x03(\v2\x18.custapi.v1.OrganizationR\rorganizations\x12\x1f\n" +
+	"\vmissing_ids\x18\x02 \x03(\tR\n" +
+	"missingIds\"^\n" +
+	"\x18ListOrganizationsRequest\x12\x14\n" +
+	"\x05query\x18\x01 \x01(\tR\x05query\x12\x14\n" +
+	"\x05limit\x18\x02 \x01(\x05R\x05limit\x12\x16\n" +
+	"\x06offset\x18\x03 \x01(\x05R\x06offset\"H\n" +
+	"\x1aSearchOrganizationsRequest\x12\x14\n" +
+	"\x05query\x18\x01 \x01(\tR\x05query\x12\x14\n" +
+	"\x05limit\x18\x02 \x01(\x05R\x05limit\"[\n" +
+	"\x19ListOrganizationsResponse\x12>\n" +
+	"\rorganizations\x18\x01 \x03(\v2\x18.custapi.v1.OrganizationR\rorganizations\"\x8c\x02\n" +
+	"\x19UpdateOrganizationRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x17\n" +
+	"\x04name\x18\x02 \x01(\tH\x00R\x04name\x88\x01\x01\x12\x15\n" +
+	"\x03lat\x18\x03 \x01(\x01H\x01R\x03lat\x88\x01\x01\x12\x15\n" +
+	"\x03lng\x18\x04 \x01(\x01H\x02R\x03lng\x88\x01\x01\x12\x1d\n" +
+	"\aaddress\x18\x05 \x01(\tH\x03R\aaddress\x88\x01\x01\x12%\n" +
+	"\vdescription\x18\x06 \x01(\tH\x04R\vdescription\x88\x01\x01\x12\x1d\n" +
+	"\n" +
+	"image_urls\x18\a \x03(\tR\timageUrlsB\a\n" +
+	"\x05_nameB\x06\n" +
+	"\x04_latB\x06\n" +
+	"\x04_lngB\n" +
+	"\n" +
+	"\b_addressB\x0e\n" +
+	"\f_description\"\xac\x01\n" +
+	"\x19DeleteOrganizationRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x120\n" +
+	"\x06policy\x18\x02 \x01(\x0e2\x18.custapi.v1.DeletePolicyR\x06policy\x12$\n" +
+	"\vreassign_to\x18\x03 \x01(\tH\x00R\n" +
+	"reassignTo\x88\x01\x01\x12\x17\n" +
+	"\adry_run\x18\x04 \x01(\bR\x06dryRunB\x0e\n" +
+	"\f_reassign_to\"C\n" +
+	"\x1aDeleteOrganizationResponse\x12%\n" +
+	"\x0eaffected_users\x18\x01 \x01(\x03R\raffectedUsers*\x80\x01\n" +
+	"\fDeletePolicy\x12\x1d\n" +
+	"\x19DELETE_POLICY_UNSPECIFIED\x10\x00\x12\x1a\n" +
+	"\x16DELETE_POLICY_RESTRICT\x10\x01\x12\x19\n" +
+	"\x15DELETE_POLICY_CASCADE\x10\x02\x12\x1a\n" +
+	"\x16DELETE_POLICY_REASSIGN\x10\x032\xaf\x05\n" +
+	"\x13OrganizationService\x12U\n" +
+	"\x12CreateOrganization\x12%.custapi.v1.CreateOrganizationRequest\x1a\x18.custapi.v1.Organization\x12O\n" +
+	"\x0fGetOrganization\x12\".custapi.v1.GetOrganizationRequest\x1a\x18.custapi.v1.Organization\x12l\n" +
+	"\x15BatchGetOrganizations\x12(.custapi.v1.BatchGetOrganizationsRequest\x1a).custapi.v1.BatchGetOrganizationsResponse\x12`\n" +
+	"\x11ListOrganizations\x12$.custapi.v1.ListOrganizationsRequest\x1a%.custapi.v1.ListOrganizationsResponse\x12d\n" +
+	"\x13SearchOrganizations\x12&.custapi.v1.SearchOrganizationsRequest\x1a%.custapi.v1.ListOrganizationsResponse\x12U\n" +
+	"\x12UpdateOrganization\x12%.custapi.v1.UpdateOrganizationRequest\x1a\x18.custapi.v1.Organization\x12c\n" +
+	"\x12DeleteOrganization\x12%.custapi.v1.DeleteOrganizationRequest\x1a&.custapi.v1.DeleteOrganizationResponseBEZCgithub.com/hoshina-dev/custapi/internal/grpcapi/custapiv1;custapiv1b\x06proto3"
+
+var (
+	file_custapi_v1_organization_proto_rawDescOnce sync.Once
+	file_custapi_v1_organization_proto_rawDescData []byte
+)
+
+func file_custapi_v1_organization_proto_rawDescGZIP() []byte {
+	file_custapi_v1_organization_proto_rawDescOnce.Do(func() {
+		file_custapi_v1_organization_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_custapi_v1_organization_proto_rawDesc), len(file_custapi_v1_organization_proto_rawDesc)))
+	})
+	return file_custapi_v1_organization_proto_rawDescData
+}
+
+var file_custapi_v1_organization_proto_enumTypes = make([]protoimpl.EnumInfo, 1)
+var file_custapi_v1_organization_proto_msgTypes = make([]protoimpl.MessageInfo, 11)
+var file_custapi_v1_organization_proto_goTypes = []any{
+	(DeletePolicy)(0),                     // 0: custapi.v1.DeletePolicy
+	(*Organization)(nil),                  // 1: custapi.v1.Organization
+	(*CreateOrganizationRequest)(nil),     // 2: custapi.v1.CreateOrganizationRequest
+	(*GetOrganizationRequest)(nil),        // 3: custapi.v1.GetOrganizationRequest
+	(*BatchGetOrganizationsRequest)(nil),  // 4: custapi.v1.BatchGetOrganizationsRequest
+	(*BatchGetOrganizationsResponse)(nil), // 5: custapi.v1.BatchGetOrganizationsResponse
+	(*ListOrganizationsRequest)(nil),      // 6: custapi.v1.ListOrganizationsRequest
+	(*SearchOrganizationsRequest)(nil),    // 7: custapi.v1.SearchOrganizationsRequest
+	(*ListOrganizationsResponse)(nil),     // 8: custapi.v1.ListOrganizationsResponse
+	(*UpdateOrganizationRequest)(nil),     // 9: custapi.v1.UpdateOrganizationRequest
+	(*DeleteOrganizationRequest)(nil),     // 10: custapi.v1.DeleteOrganizationRequest
+	(*DeleteOrganizationResponse)(nil),    // 11: custapi.v1.DeleteOrganizationResponse
+	(*timestamppb.Timestamp)(nil),         // 12: google.protobuf.Timestamp
+}
+var file_custapi_v1_organization_proto_depIdxs = []int32{
+	12, // 0: custapi.v1.Organization.created_at:type_name -> google.protobuf.Timestamp
+	12, // 1: custapi.v1.Organization.updated_at:type_name -> google.protobuf.Timestamp
+	1,  // 2: custapi.v1.BatchGetOrganizationsResponse.organizations:type_name -> custapi.v1.Organization
+	1,  // 3: custapi.v1.ListOrganizationsResponse.organizations:type_name -> custapi.v1.Organization
+	0,  // 4: custapi.v1.DeleteOrganizationRequest.policy:type_name -> custapi.v1.DeletePolicy
+	2,  // 5: custapi.v1.OrganizationService.CreateOrganization:input_type -> custapi.v1.CreateOrganizationRequest
+	3,  // 6: custapi.v1.OrganizationService.GetOrganization:input_type -> custapi.v1.GetOrganizationRequest
+	4,  // 7: custapi.v1.OrganizationService.BatchGetOrganizations:input_type -> custapi.v1.BatchGetOrganizationsRequest
+	6,  // 8: custapi.v1.OrganizationService.ListOrganizations:input_type -> custapi.v1.ListOrganizationsRequest
+	7,  // 9: custapi.v1.OrganizationService.SearchOrganizations:input_type -> custapi.v1.SearchOrganizationsRequest
+	9,  // 10: custapi.v1.OrganizationService.UpdateOrganization:input_type -> custapi.v1.UpdateOrganizationRequest
+	10, // 11: custapi.v1.OrganizationService.DeleteOrganization:input_type -> custapi.v1.DeleteOrganizationRequest
+	1,  // 12: custapi.v1.OrganizationService.CreateOrganization:output_type -> custapi.v1.Organization
+	1,  // 13: custapi.v1.OrganizationService.GetOrganization:output_type -> custapi.v1.Organization
+	5,  // 14: custapi.v1.OrganizationService.BatchGetOrganizations:output_type -> custapi.v1.BatchGetOrganizationsResponse
+	8,  // 15: custapi.v1.OrganizationService.ListOrganizations:output_type -> custapi.v1.ListOrganizationsResponse
+	8,  // 16: custapi.v1.OrganizationService.SearchOrganizations:output_type -> custapi.v1.ListOrganizationsResponse
+	1,  // 17: custapi.v1.OrganizationService.UpdateOrganization:output_type -> custapi.v1.Organization
+	11, // 18: custapi.v1.OrganizationService.DeleteOrganization:output_type -> custapi.v1.DeleteOrganizationResponse
+	12, // [12:19] is the sub-list for method output_type
+	5,  // [5:12] is the sub-list for method input_type
+	5,  // [5:5] is the sub-list for extension type_name
+	5,  // [5:5] is the sub-list for extension extendee
+	0,  // [0:5] is the sub-list for field type_name
+}
+
+func init() { file_custapi_v1_organization_proto_init() }
+func file_custapi_v1_organization_proto_init() {
+	if File_custapi_v1_organization_proto != nil {
+		return
+	}
+	file_custapi_v1_organization_proto_msgTypes[0].OneofWrappers = []any{}
+	file_custapi_v1_organization_proto_msgTypes[1].OneofWrappers = []any{}
+	file_custapi_v1_organization_proto_msgTypes[8].OneofWrappers = []any{}
+	file_custapi_v1_organization_proto_msgTypes[9].OneofWrappers = []any{}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_custapi_v1_organization_proto_rawDesc), len(file_custapi_v1_organization_proto_rawDesc)),
+			NumEnums:      1,
+			NumMessages:   11,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_custapi_v1_organization_proto_goTypes,
+		DependencyIndexes: file_custapi_v1_organization_proto_depIdxs,
+		EnumInfos:         file_custapi_v1_organization_proto_enumTypes,
+		MessageInfos:      file_custapi_v1_organization_proto_msgTypes,
+	}.Build()
+	File_custapi_v1_organization_proto = out.File
+	file_custapi_v1_organization_proto_goTypes = nil
+	file_custapi_v1_organization_proto_depIdxs = nil
+}
@@ -0,0 +1,207 @@
+package grpcapi
+
+import (
+	"context"
+
+	"github.com/go-playground/validator/v10"
+	pb "github.com/hoshina-dev/custapi/internal/grpcapi/custapiv1"
+	"github.com/hoshina-dev/custapi/internal/models"
+	"github.com/hoshina-dev/custapi/internal/services"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// organizationServer implements the OrganizationService gRPC service on top of
+// services.OrganizationService
+type organizationServer struct {
+	pb.UnimplementedOrganizationServiceServer
+	orgService services.OrganizationService
+	validate   *validator.Validate
+}
+
+// deletePolicies maps the gRPC delete policies to the domain ones
+var deletePolicies = map[pb.DeletePolicy]models.OrganizationDeletePolicy{
+	pb.DeletePolicy_DELETE_POLICY_UNSPECIFIED: models.DeletePolicyRestrict,
+	pb.DeletePolicy_DELETE_POLICY_RESTRICT:    models.DeletePolicyRestrict,
+	pb.DeletePolicy_DELETE_POLICY_CASCADE:     models.DeletePolicyCascade,
+	pb.DeletePolicy_DELETE_POLICY_REASSIGN:    models.DeletePolicyReassign,
+}
+
+// CreateOrganization creates a new organization
+func (s *organizationServer) CreateOrganization(ctx context.Context, in *pb.CreateOrganizationRequest) (*pb.Organization, error) {
+	lat, lng := in.GetLat(), in.GetLng()
+	req := &models.CreateOrganizationRequest{
+		Name:        in.GetName(),
+		Latitude:    &lat,
+		Longitude:   &lng,
+		Address:     in.Address,
+		Description: in.Description,
+		ImageUrls:   in.GetImageUrls(),
+	}
+	if err := s.validate.Struct(req); err != nil {
+		return nil, toStatus(err)
+	}
+
+	org, err := s.orgService.CreateOrganization(ctx, req)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return organizationToProto(org), nil
+}
+
+// GetOrganization retrieves an organization by ID
+func (s *organizationServer) GetOrganization(ctx context.Context, in *pb.GetOrganizationRequest) (*pb.Organization, error) {
+	id, err := parseID(in.GetId(), "organization id")
+	if err != nil {
+		return nil, err
+	}
+
+	org, err := s.orgService.GetOrganization(ctx, id)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	if org == nil {
+		return nil, status.Error(codes.NotFound, "organization not found")
+	}
+	return organizationToProto(org), nil
+}
+
+// BatchGetOrganizations retrieves organizations by ID in the requested order
+func (s *organizationServer) BatchGetOrganizations(ctx context.Context, in *pb.BatchGetOrganizationsRequest) (*pb.BatchGetOrganizationsResponse, error) {
+	ids, err := parseIDs(in.GetIds(), "organization id")
+	if err != nil {
+		return nil, err
+	}
+
+	orgs, missing, err := s.orgService.GetByIDs(ctx, ids)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return &pb.BatchGetOrganizationsResponse{
+		Organizations: organizationsToProto(orgs).Organizations,
+		MissingIds:    idsToProto(missing),
+	}, nil
+}
+
+// ListOrganizations retrieves a page of organizations, optionally filtered by query
+func (s *organizationServer) ListOrganizations(ctx context.Context, in *pb.ListOrganizationsRequest) (*pb.ListOrganizationsResponse, error) {
+	limit, err := pageSize(in.GetLimit())
+	if err != nil {
+		return nil, err
+	}
+	if in.GetOffset() < 0 {
+		return nil, status.Error(codes.InvalidArgument, "offset must be non-negative")
+	}
+
+	orgs, err := s.orgService.FindOrganizations(ctx, models.OrganizationFilter{
+		Query:  in.GetQuery(),
+		Limit:  limit,
+		Offset: int(in.GetOffset()),
+	})
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return organizationsToProto(orgs), nil
+}
+
+// SearchOrganizations searches organizations by name
+func (s *organizationServer) SearchOrganizations(ctx context.Context, in *pb.SearchOrganizationsRequest) (*pb.ListOrganizationsResponse, error) {
+	if in.GetQuery() == "" {
+		return nil, status.Error(codes.InvalidArgument, "query is required")
+	}
+	limit, err := searchLimit(in.GetLimit())
+	if err != nil {
+		return nil, err
+	}
+
+	orgs, err := s.orgService.SearchOrganizations(ctx, in.GetQuery(), limit)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return organizationsToProto(orgs), nil
+}
+
+// UpdateOrganization updates the fields of an organization that are set in the request
+func (s *organizationServer) UpdateOrganization(ctx context.Context, in *pb.UpdateOrganizationRequest) (*pb.Organization, error) {
+	id, err := parseID(in.GetId(), "organization id")
+	if err != nil {
+		return nil, err
+	}
+
+	req := &models.UpdateOrganizationRequest{
+		Name:        in.Name,
+		Latitude:    in.Lat,
+		Longitude:   in.Lng,
+		Address:     in.Address,
+		Description: in.Description,
+		ImageUrls:   in.GetImageUrls(),
+	}
+	if err := s.validate.Struct(req); err != nil {
+		return nil, toStatus(err)
+	}
+
+	org, err := s.orgService.UpdateOrganization(ctx, id, req)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	if org == nil {
+		return nil, status.Error(codes.NotFound, "organization not found")
+	}
+	return organizationToProto(org), nil
+}
+
+// DeleteOrganization soft deletes an organization according to the requested policy
+func (s *organizationServer) DeleteOrganization(ctx context.Context, in *pb.DeleteOrganizationRequest) (*pb.DeleteOrganizationResponse, error) {
+	id, err := parseID(in.GetId(), "organization id")
+	if err != nil {
+		return nil, err
+	}
+
+	policy, ok := deletePolicies[in.GetPolicy()]
+	if !ok {
+		return nil, status.Error(codes.InvalidArgument, "unknown delete policy")
+	}
+	opts := models.OrganizationDeleteOptions{Policy: policy, DryRun: in.GetDryRun()}
+	if in.ReassignTo != nil {
+		if policy != models.DeletePolicyReassign {
+			return nil, status.Error(codes.InvalidArgument, "reassign_to can only be used with policy reassign")
+		}
+		target, err := parseID(in.GetReassignTo(), "reassign_to organization id")
+		if err != nil {
+			return nil, err
+		}
+		opts.ReassignTo = &target
+	}
+
+	affected, err := s.orgService.DeleteOrganization(ctx, id, opts)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return &pb.DeleteOrganizationResponse{AffectedUsers: affected}, nil
+}
+
+// organizationToProto converts an organization to its gRPC message through its REST
+// response DTO, so that both APIs expose the same fields
+func organizationToProto(org *models.Organization) *pb.Organization {
+	resp := org.ToResponse()
+	return &pb.Organization{
+		Id:          resp.ID.String(),
+		Name:        resp.Name,
+		Lat:         resp.Latitude,
+		Lng:         resp.Longitude,
+		Address:     resp.Address,
+		Description: resp.Description,
+		ImageUrls:   resp.ImageUrls,
+		CreatedAt:   timestamppb.New(resp.CreatedAt),
+		UpdatedAt:   timestamppb.New(resp.UpdatedAt),
+	}
+}
+
+func organizationsToProto(orgs []models.Organization) *pb.ListOrganizationsResponse {
+	resp := &pb.ListOrganizationsResponse{Organizations: make([]*pb.Organization, len(orgs))}
+	for i := range orgs {
+		resp.Organizations[i] = organizationToProto(&orgs[i])
+	}
+	return resp
+}
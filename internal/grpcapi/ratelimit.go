@@ -0,0 +1,78 @@
+package grpcapi
+
+import (
+	"context"
+	"log"
+	"math"
+	"net"
+	"strconv"
+
+	"github.com/hoshina-dev/custapi/internal/auth"
+	"github.com/hoshina-dev/custapi/internal/ratelimit"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// rateLimit limits the calls of each client, identified by the key key returns, to
+// limit. Buckets are named like those of the REST API, so a client calling both APIs
+// shares one limit between them. Calls with an empty key are not limited.
+//
+// Denied calls fail with ResourceExhausted and a retry-after header in seconds. Like
+// the REST API, calls are let through when the store fails.
+func rateLimit(store ratelimit.Store, name string, limit ratelimit.Limit, key func(ctx context.Context) string) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		if store == nil || !limit.Enabled() {
+			return handler(ctx, req)
+		}
+		client := key(ctx)
+		if client == "" {
+			return handler(ctx, req)
+		}
+
+		result, err := store.Take(ctx, name+":"+client, limit)
+		if err != nil {
+			log.Printf("Rate limiting failed: %v", err)
+			return handler(ctx, req)
+		}
+		if !result.Allowed {
+			retryAfter := strconv.Itoa(int(math.Ceil(result.RetryAfter.Seconds())))
+			_ = grpc.SetHeader(ctx, metadata.Pairs("retry-after", retryAfter))
+			return nil, status.Error(codes.ResourceExhausted, "too many requests, retry later")
+		}
+		return handler(ctx, req)
+	}
+}
+
+// peerIP returns the IP address of the client of a call, or "" if unknown
+func peerIP(ctx context.Context) string {
+	if p, ok := peer.FromContext(ctx); ok {
+		if host, _, err := net.SplitHostPort(p.Addr.String()); err == nil {
+			return host
+		}
+	}
+	return ""
+}
+
+// ipKey identifies the client of a call for rate limiting by its IP address
+func ipKey(ctx context.Context) string {
+	if ip := peerIP(ctx); ip != "" {
+		return "ip:" + ip
+	}
+	return ""
+}
+
+// clientKey identifies the client of an authenticated call for rate limiting by its
+// user or, for anonymous calls, its IP address, like the REST API does
+func clientKey(ctx context.Context) string {
+	actor := auth.FromContext(ctx)
+	if actor.UserID != nil {
+		return "user:" + actor.UserID.String()
+	}
+	if actor.IP != "" {
+		return "ip:" + actor.IP
+	}
+	return ""
+}
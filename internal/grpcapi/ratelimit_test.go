@@ -0,0 +1,72 @@
+package grpcapi
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hoshina-dev/custapi/internal/auth"
+	"github.com/hoshina-dev/custapi/internal/ratelimit"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// failingStore fails every Take
+type failingStore struct{}
+
+func (failingStore) Take(ctx context.Context, key string, limit ratelimit.Limit) (ratelimit.Result, error) {
+	return ratelimit.Result{}, errors.New("unavailable")
+}
+
+func TestRateLimit(t *testing.T) {
+	limit := ratelimit.Limit{Requests: 2, Per: time.Minute}
+	info := &grpc.UnaryServerInfo{FullMethod: "/custapi.v1.UserService/GetUser"}
+	handler := func(ctx context.Context, req any) (any, error) { return "ok", nil }
+
+	fromIP := func(ip string) context.Context {
+		return peer.NewContext(context.Background(), &peer.Peer{Addr: &net.TCPAddr{IP: net.ParseIP(ip), Port: 50000}})
+	}
+	userID := uuid.New()
+	asUser := auth.NewContext(fromIP("203.0.113.7"), &auth.Actor{UserID: &userID, IP: "203.0.113.7"})
+
+	tests := []struct {
+		name    string
+		store   ratelimit.Store
+		limit   ratelimit.Limit
+		key     func(ctx context.Context) string
+		ctx     context.Context
+		calls   int
+		allowed int
+	}{
+		{"per IP address", ratelimit.NewMemoryStore(), limit, ipKey, fromIP("203.0.113.7"), 3, 2},
+		{"per user", ratelimit.NewMemoryStore(), limit, clientKey, asUser, 3, 2},
+		{"unknown peer", ratelimit.NewMemoryStore(), limit, ipKey, context.Background(), 3, 3},
+		{"disabled", ratelimit.NewMemoryStore(), ratelimit.Limit{}, ipKey, fromIP("203.0.113.7"), 3, 3},
+		{"no store", nil, limit, ipKey, fromIP("203.0.113.7"), 3, 3},
+		{"failing store", failingStore{}, limit, ipKey, fromIP("203.0.113.7"), 3, 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			interceptor := rateLimit(tt.store, "api", tt.limit, tt.key)
+			allowed := 0
+			for range tt.calls {
+				_, err := interceptor(tt.ctx, nil, info, handler)
+				switch status.Code(err) {
+				case codes.OK:
+					allowed++
+				case codes.ResourceExhausted:
+				default:
+					t.Fatalf("call error = %v", err)
+				}
+			}
+			if allowed != tt.allowed {
+				t.Errorf("%d of %d calls allowed, want %d", allowed, tt.calls, tt.allowed)
+			}
+		})
+	}
+}
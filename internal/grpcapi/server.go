@@ -0,0 +1,217 @@
+// Package grpcapi serves the user and organization services over gRPC, next to the
+// REST API. The service definitions live in proto/custapi/v1 and the generated code
+// in custapiv1; regenerate it with `make proto`.
+package grpcapi
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+	"github.com/hoshina-dev/custapi/internal/auth"
+	pb "github.com/hoshina-dev/custapi/internal/grpcapi/custapiv1"
+	"github.com/hoshina-dev/custapi/internal/password"
+	"github.com/hoshina-dev/custapi/internal/ratelimit"
+	"github.com/hoshina-dev/custapi/internal/services"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/reflection"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	// defaultPageSize and maxPageSize bound the page size of list calls
+	defaultPageSize = 20
+	maxPageSize     = 100
+	// defaultSearchLimit matches the default limit of the REST search endpoints
+	defaultSearchLimit = 100
+	// maxBatchSize matches the maximum number of IDs of the REST batch endpoints
+	maxBatchSize = 100
+)
+
+// Config holds gRPC server settings
+type Config struct {
+	// Reflection enables server reflection, which lets tools like grpcurl discover the
+	// services but also tells anyone who can reach the port what they are
+	Reflection bool
+	// RateLimitStore keeps the buckets of clients, shared with the REST API. Nil disables
+	// rate limiting.
+	RateLimitStore ratelimit.Store
+	// IPRateLimit limits each IP address before calls are authenticated, so that
+	// guessing session tokens is limited too
+	IPRateLimit ratelimit.Limit
+	// RateLimit limits each client, identified by user or IP address
+	RateLimit ratelimit.Limit
+}
+
+// NewServer creates a gRPC server exposing the user and organization services
+func NewServer(userService services.UserService, orgService services.OrganizationService, authService services.AuthService, cfg Config) *grpc.Server {
+	server := grpc.NewServer(grpc.ChainUnaryInterceptor(
+		rateLimit(cfg.RateLimitStore, "ip", cfg.IPRateLimit, ipKey),
+		authenticate(authService),
+		rateLimit(cfg.RateLimitStore, "api", cfg.RateLimit, clientKey),
+	))
+
+	validate := validator.New()
+	pb.RegisterUserServiceServer(server, &userServer{userService: userService, validate: validate})
+	pb.RegisterOrganizationServiceServer(server, &organizationServer{orgService: orgService, validate: validate})
+	if cfg.Reflection {
+		reflection.Register(server)
+	}
+
+	return server
+}
+
+// authenticate resolves the caller of each call into an auth.Actor, like the REST
+// Authenticate middleware does. The caller is identified by a session bearer token in
+// the authorization metadata; other calls are anonymous.
+func authenticate(authService services.AuthService) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		actor := &auth.Actor{RequestID: firstMetadata(ctx, "x-request-id")}
+		if actor.RequestID == "" {
+			actor.RequestID = uuid.NewString()
+		}
+		actor.IP = peerIP(ctx)
+
+		token, isBearer := auth.BearerToken(firstMetadata(ctx, "authorization"))
+		if firstMetadata(ctx, "x-api-key") != "" || (isBearer && auth.IsAPIKey(token)) {
+			return nil, status.Error(codes.Unauthenticated, "API keys are only accepted by the REST API")
+		}
+
+		if isBearer {
+			session, err := authService.Authenticate(ctx, token)
+			if err != nil {
+				return nil, status.Error(codes.Internal, err.Error())
+			}
+			if session == nil {
+				return nil, status.Error(codes.Unauthenticated, "invalid or expired session")
+			}
+			actor.UserID = &session.User.ID
+			actor.IsAdmin = session.User.IsAdmin
+			actor.EmailVerified = session.User.EmailVerifiedAt != nil
+			actor.TwoFactorEnabled = session.User.TwoFactorEnabled()
+			actor.TwoFactorVerified = session.TwoFactorVerifiedAt != nil
+		}
+
+		// Like the REST API, only users who have verified their email address may change
+		// data, apart from anonymous callers signing up
+		if !isReadOnly(info.FullMethod) && info.FullMethod != pb.UserService_CreateUser_FullMethodName {
+			if actor.UserID == nil {
+				return nil, status.Error(codes.Unauthenticated, "authentication required")
+			}
+			if !actor.EmailVerified {
+				return nil, status.Error(codes.PermissionDenied, "email address not verified")
+			}
+		}
+
+		return handler(auth.NewContext(ctx, actor), req)
+	}
+}
+
+// isReadOnly reports whether a full gRPC method name is that of a call only reading data
+func isReadOnly(fullMethod string) bool {
+	name := fullMethod[strings.LastIndex(fullMethod, "/")+1:]
+	for _, prefix := range []string{"Get", "BatchGet", "List", "Search"} {
+		if strings.HasPrefix(name, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// firstMetadata returns the first value of an incoming metadata key, or "" if unset
+func firstMetadata(ctx context.Context, key string) string {
+	md, _ := metadata.FromIncomingContext(ctx)
+	if values := md.Get(key); len(values) > 0 {
+		return values[0]
+	}
+	return ""
+}
+
+// toStatus converts a service error to a gRPC status, using the same mapping as the
+// REST handlers
+func toStatus(err error) error {
+	var validationErrors validator.ValidationErrors
+	var policyErr *password.PolicyError
+	switch {
+	case errors.As(err, &validationErrors), errors.As(err, &policyErr):
+		return status.Error(codes.InvalidArgument, err.Error())
+	case errors.Is(err, services.ErrOrganizationHasUsers):
+		return status.Error(codes.FailedPrecondition, err.Error())
+	case errors.Is(err, services.ErrEmailTaken):
+		return status.Error(codes.AlreadyExists, err.Error())
+	case errors.Is(err, services.ErrAuthenticationRequired):
+		return status.Error(codes.Unauthenticated, err.Error())
+	case errors.Is(err, services.ErrAdminRequired), errors.Is(err, services.ErrUserAccessDenied):
+		return status.Error(codes.PermissionDenied, err.Error())
+	}
+
+	switch err.Error() {
+	case "user not found", "organization not found", "target organization not found":
+		return status.Error(codes.NotFound, err.Error())
+	case "reassign target is required", "cannot reassign users to the deleted organization":
+		return status.Error(codes.InvalidArgument, err.Error())
+	}
+	return status.Error(codes.Internal, err.Error())
+}
+
+// parseID parses a UUID field of a request
+func parseID(value, field string) (uuid.UUID, error) {
+	id, err := uuid.Parse(value)
+	if err != nil {
+		return uuid.Nil, status.Errorf(codes.InvalidArgument, "invalid %s", field)
+	}
+	return id, nil
+}
+
+// parseIDs parses the IDs of a batch request
+func parseIDs(values []string, field string) ([]uuid.UUID, error) {
+	if len(values) == 0 || len(values) > maxBatchSize {
+		return nil, status.Errorf(codes.InvalidArgument, "between 1 and %d ids are required", maxBatchSize)
+	}
+	ids := make([]uuid.UUID, len(values))
+	for i, value := range values {
+		id, err := parseID(value, field)
+		if err != nil {
+			return nil, err
+		}
+		ids[i] = id
+	}
+	return ids, nil
+}
+
+// idsToProto formats IDs for a response
+func idsToProto(ids []uuid.UUID) []string {
+	values := make([]string, len(ids))
+	for i, id := range ids {
+		values[i] = id.String()
+	}
+	return values
+}
+
+// pageSize applies the default and maximum page size to a requested limit
+func pageSize(limit int32) (int, error) {
+	switch {
+	case limit < 0:
+		return 0, status.Error(codes.InvalidArgument, "limit must be non-negative")
+	case limit == 0:
+		return defaultPageSize, nil
+	case limit > maxPageSize:
+		return maxPageSize, nil
+	}
+	return int(limit), nil
+}
+
+// searchLimit applies the default search limit to a requested limit
+func searchLimit(limit int32) (int, error) {
+	switch {
+	case limit < 0:
+		return 0, status.Error(codes.InvalidArgument, "limit must be non-negative")
+	case limit == 0:
+		return defaultSearchLimit, nil
+	}
+	return int(limit), nil
+}
@@ -0,0 +1,221 @@
+package grpcapi
+
+import (
+	"context"
+
+	"github.com/go-playground/validator/v10"
+	pb "github.com/hoshina-dev/custapi/internal/grpcapi/custapiv1"
+	"github.com/hoshina-dev/custapi/internal/models"
+	"github.com/hoshina-dev/custapi/internal/services"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// userServer implements the UserService gRPC service on top of services.UserService
+type userServer struct {
+	pb.UnimplementedUserServiceServer
+	userService services.UserService
+	validate    *validator.Validate
+}
+
+// CreateUser creates a new user
+func (s *userServer) CreateUser(ctx context.Context, in *pb.CreateUserRequest) (*pb.User, error) {
+	orgID, err := parseID(in.GetOrganizationId(), "organization_id")
+	if err != nil {
+		return nil, err
+	}
+
+	req := &models.CreateUserRequest{
+		Email:              in.GetEmail(),
+		Name:               in.GetName(),
+		OrganizationID:     orgID,
+		Password:           in.GetPassword(),
+		PhoneNumber:        in.PhoneNumber,
+		SocialMedia:        in.SocialMedia,
+		Description:        in.Description,
+		AvatarURL:          in.AvatarUrl,
+		ResearchCategories: in.GetResearchCategories(),
+		IsAdmin:            in.IsAdmin,
+	}
+	if err := s.validate.Struct(req); err != nil {
+		return nil, toStatus(err)
+	}
+
+	user, err := s.userService.CreateUser(ctx, req)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return userToProto(user), nil
+}
+
+// GetUser retrieves a user by ID
+func (s *userServer) GetUser(ctx context.Context, in *pb.GetUserRequest) (*pb.User, error) {
+	id, err := parseID(in.GetId(), "user id")
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := s.userService.GetUser(ctx, id)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	if user == nil {
+		return nil, status.Error(codes.NotFound, "user not found")
+	}
+	return userToProto(user), nil
+}
+
+// BatchGetUsers retrieves users by ID in the requested order
+func (s *userServer) BatchGetUsers(ctx context.Context, in *pb.BatchGetUsersRequest) (*pb.BatchGetUsersResponse, error) {
+	ids, err := parseIDs(in.GetIds(), "user id")
+	if err != nil {
+		return nil, err
+	}
+
+	users, missing, err := s.userService.GetByIDs(ctx, ids)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return &pb.BatchGetUsersResponse{
+		Users:      usersToProto(users).Users,
+		MissingIds: idsToProto(missing),
+	}, nil
+}
+
+// ListUsers retrieves a page of users, optionally filtered by query and organization
+func (s *userServer) ListUsers(ctx context.Context, in *pb.ListUsersRequest) (*pb.ListUsersResponse, error) {
+	limit, err := pageSize(in.GetLimit())
+	if err != nil {
+		return nil, err
+	}
+	if in.GetOffset() < 0 {
+		return nil, status.Error(codes.InvalidArgument, "offset must be non-negative")
+	}
+
+	filter := models.UserFilter{Query: in.GetQuery(), Limit: limit, Offset: int(in.GetOffset())}
+	if in.OrganizationId != nil {
+		orgID, err := parseID(in.GetOrganizationId(), "organization_id")
+		if err != nil {
+			return nil, err
+		}
+		filter.OrganizationID = &orgID
+	}
+
+	users, err := s.userService.FindUsers(ctx, filter)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return usersToProto(users), nil
+}
+
+// ListUsersByOrganization retrieves all users of an organization
+func (s *userServer) ListUsersByOrganization(ctx context.Context, in *pb.ListUsersByOrganizationRequest) (*pb.ListUsersResponse, error) {
+	orgID, err := parseID(in.GetOrganizationId(), "organization_id")
+	if err != nil {
+		return nil, err
+	}
+
+	users, err := s.userService.ListUsersByOrganization(ctx, orgID)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return usersToProto(users), nil
+}
+
+// SearchUsers searches users by name or email
+func (s *userServer) SearchUsers(ctx context.Context, in *pb.SearchUsersRequest) (*pb.ListUsersResponse, error) {
+	if in.GetQuery() == "" {
+		return nil, status.Error(codes.InvalidArgument, "query is required")
+	}
+	limit, err := searchLimit(in.GetLimit())
+	if err != nil {
+		return nil, err
+	}
+
+	users, err := s.userService.SearchUsers(ctx, in.GetQuery(), limit)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return usersToProto(users), nil
+}
+
+// UpdateUser updates the fields of a user that are set in the request
+func (s *userServer) UpdateUser(ctx context.Context, in *pb.UpdateUserRequest) (*pb.User, error) {
+	id, err := parseID(in.GetId(), "user id")
+	if err != nil {
+		return nil, err
+	}
+
+	req := &models.UpdateUserRequest{
+		Email:              in.Email,
+		Name:               in.Name,
+		Password:           in.Password,
+		PhoneNumber:        in.PhoneNumber,
+		SocialMedia:        in.SocialMedia,
+		Description:        in.Description,
+		AvatarURL:          in.AvatarUrl,
+		ResearchCategories: in.GetResearchCategories(),
+		IsAdmin:            in.IsAdmin,
+	}
+	if in.OrganizationId != nil {
+		orgID, err := parseID(in.GetOrganizationId(), "organization_id")
+		if err != nil {
+			return nil, err
+		}
+		req.OrganizationID = &orgID
+	}
+	if err := s.validate.Struct(req); err != nil {
+		return nil, toStatus(err)
+	}
+
+	user, err := s.userService.Update(ctx, id, req)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	if user == nil {
+		return nil, status.Error(codes.NotFound, "user not found")
+	}
+	return userToProto(user), nil
+}
+
+// DeleteUser soft deletes a user
+func (s *userServer) DeleteUser(ctx context.Context, in *pb.DeleteUserRequest) (*pb.DeleteUserResponse, error) {
+	id, err := parseID(in.GetId(), "user id")
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.userService.Delete(ctx, id); err != nil {
+		return nil, toStatus(err)
+	}
+	return &pb.DeleteUserResponse{}, nil
+}
+
+// userToProto converts a user to its gRPC message through its REST response DTO, so
+// that both APIs expose the same fields
+func userToProto(user *models.User) *pb.User {
+	resp := user.ToResponse()
+	return &pb.User{
+		Id:                 resp.ID.String(),
+		Email:              resp.Email,
+		Name:               resp.Name,
+		OrganizationId:     resp.OrganizationID.String(),
+		IsAdmin:            resp.IsAdmin,
+		PhoneNumber:        resp.PhoneNumber,
+		SocialMedia:        resp.SocialMedia,
+		Description:        resp.Description,
+		AvatarUrl:          resp.AvatarURL,
+		ResearchCategories: resp.ResearchCategories,
+		CreatedAt:          timestamppb.New(resp.CreatedAt),
+		UpdatedAt:          timestamppb.New(resp.UpdatedAt),
+	}
+}
+
+func usersToProto(users []models.User) *pb.ListUsersResponse {
+	resp := &pb.ListUsersResponse{Users: make([]*pb.User, len(users))}
+	for i := range users {
+		resp.Users[i] = userToProto(&users[i])
+	}
+	return resp
+}
@@ -0,0 +1,121 @@
+package handlers
+
+import (
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/hoshina-dev/custapi/internal/models"
+	"github.com/hoshina-dev/custapi/internal/services"
+)
+
+// APIKeyHandler handles API key HTTP requests
+type APIKeyHandler struct {
+	apiKeyService services.APIKeyService
+	validate      *validator.Validate
+}
+
+// NewAPIKeyHandler creates a new API key handler
+func NewAPIKeyHandler(apiKeyService services.APIKeyService) *APIKeyHandler {
+	return &APIKeyHandler{
+		apiKeyService: apiKeyService,
+		validate:      validator.New(),
+	}
+}
+
+// CreateAPIKey godoc
+//
+//	@Summary		Create an API key
+//	@Description	Create an API key giving access to the users and the data of one organization, with the permissions users:read, users:write, organizations:read and/or organizations:write.
+//	@Description	The key is returned once and only its hash is stored. Send it in the X-API-Key header or as "Authorization: Bearer <key>". Admin only.
+//	@Tags			api-keys
+//	@Accept			json
+//	@Produce		json
+//	@Param			api_key	body		models.CreateAPIKeyRequest	true	"API key to create"
+//	@Success		201		{object}	models.APIKeyResponse
+//	@Failure		400		{object}	models.ErrorResponse
+//	@Failure		401		{object}	models.ErrorResponse
+//	@Failure		403		{object}	models.ErrorResponse
+//	@Failure		404		{object}	models.ErrorResponse
+//	@Failure		422		{object}	models.ErrorResponse
+//	@Failure		500		{object}	models.ErrorResponse
+//	@Router			/api-keys [post]
+func (h *APIKeyHandler) CreateAPIKey(c *fiber.Ctx) error {
+	req := new(models.CreateAPIKeyRequest)
+
+	if err := c.BodyParser(req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(models.ErrorResponse{Error: "invalid json payload"})
+	}
+
+	if err := h.validate.Struct(req); err != nil {
+		return c.Status(fiber.StatusUnprocessableEntity).JSON(models.ErrorResponse{Error: err.Error()})
+	}
+
+	apiKey, key, err := h.apiKeyService.CreateAPIKey(c.Context(), req)
+	if err != nil {
+		if err.Error() == "organization not found" {
+			return c.Status(fiber.StatusNotFound).JSON(models.ErrorResponse{Error: err.Error()})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(models.ErrorResponse{Error: "failed to create api key"})
+	}
+
+	response := apiKey.ToResponse()
+	response.Key = key
+	return c.Status(fiber.StatusCreated).JSON(response)
+}
+
+// GetAPIKeys godoc
+//
+//	@Summary		List API keys
+//	@Description	List all API keys that were not deleted, including expired ones. Keys are never returned, only their prefix. Admin only.
+//	@Tags			api-keys
+//	@Accept			json
+//	@Produce		json
+//	@Success		200	{array}		models.APIKeyResponse
+//	@Failure		401	{object}	models.ErrorResponse
+//	@Failure		403	{object}	models.ErrorResponse
+//	@Failure		500	{object}	models.ErrorResponse
+//	@Router			/api-keys [get]
+func (h *APIKeyHandler) GetAPIKeys(c *fiber.Ctx) error {
+	keys, err := h.apiKeyService.ListAPIKeys(c.Context())
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(models.ErrorResponse{Error: err.Error()})
+	}
+
+	response := make([]models.APIKeyResponse, len(keys))
+	for i, k := range keys {
+		response[i] = k.ToResponse()
+	}
+
+	return c.JSON(response)
+}
+
+// DeleteAPIKey godoc
+//
+//	@Summary		Delete an API key
+//	@Description	Revoke an API key. Requests made with it are rejected from then on. Admin only.
+//	@Tags			api-keys
+//	@Accept			json
+//	@Produce		json
+//	@Param			id	path	string	true	"API key ID (UUID)"
+//	@Success		204
+//	@Failure		400	{object}	models.ErrorResponse
+//	@Failure		401	{object}	models.ErrorResponse
+//	@Failure		403	{object}	models.ErrorResponse
+//	@Failure		404	{object}	models.ErrorResponse
+//	@Failure		500	{object}	models.ErrorResponse
+//	@Router			/api-keys/{id} [delete]
+func (h *APIKeyHandler) DeleteAPIKey(c *fiber.Ctx) error {
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(models.ErrorResponse{Error: "invalid api key id"})
+	}
+
+	if err := h.apiKeyService.DeleteAPIKey(c.Context(), id); err != nil {
+		if err.Error() == "api key not found" {
+			return c.Status(fiber.StatusNotFound).JSON(models.ErrorResponse{Error: err.Error()})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(models.ErrorResponse{Error: err.Error()})
+	}
+
+	return c.SendStatus(fiber.StatusNoContent)
+}
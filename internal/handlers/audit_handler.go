@@ -0,0 +1,95 @@
+package handlers
+
+import (
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/hoshina-dev/custapi/internal/models"
+	"github.com/hoshina-dev/custapi/internal/services"
+)
+
+// AuditHandler handles audit log HTTP requests
+type AuditHandler struct {
+	auditService services.AuditService
+}
+
+// NewAuditHandler creates a new audit handler
+func NewAuditHandler(auditService services.AuditService) *AuditHandler {
+	return &AuditHandler{
+		auditService: auditService,
+	}
+}
+
+// GetAuditEvents godoc
+//
+//	@Summary		List audit events
+//	@Description	List recorded creates, updates and deletes, newest first. Admin only.
+//	@Tags			audit
+//	@Accept			json
+//	@Produce		json
+//	@Param			entity		query		string	false	"Entity type"	Enums(user, organization)
+//	@Param			entity_id	query		string	false	"Entity ID (UUID)"
+//	@Param			actor		query		string	false	"ID (UUID) of the user who made the change"
+//	@Param			api_key		query		string	false	"ID (UUID) of the API key the change was made with"
+//	@Param			since		query		string	false	"Only events at or after this RFC 3339 time"
+//	@Param			limit		query		int		false	"Maximum number of results to return (default: 100)"
+//	@Success		200			{array}		models.AuditEventResponse
+//	@Failure		400			{object}	models.ErrorResponse
+//	@Failure		401			{object}	models.ErrorResponse
+//	@Failure		403			{object}	models.ErrorResponse
+//	@Failure		500			{object}	models.ErrorResponse
+//	@Router			/audit [get]
+func (h *AuditHandler) GetAuditEvents(c *fiber.Ctx) error {
+	filter := models.AuditFilter{
+		EntityType: c.Query("entity"),
+		Limit:      c.QueryInt("limit", 100),
+	}
+	if filter.Limit < 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(models.ErrorResponse{Error: "limit must be non-negative"})
+	}
+
+	if raw := c.Query("entity_id"); raw != "" {
+		id, err := uuid.Parse(raw)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(models.ErrorResponse{Error: "invalid entity id"})
+		}
+		filter.EntityID = &id
+	}
+
+	if raw := c.Query("actor"); raw != "" {
+		id, err := uuid.Parse(raw)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(models.ErrorResponse{Error: "invalid actor id"})
+		}
+		filter.ActorID = &id
+	}
+
+	if raw := c.Query("api_key"); raw != "" {
+		id, err := uuid.Parse(raw)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(models.ErrorResponse{Error: "invalid API key id"})
+		}
+		filter.APIKeyID = &id
+	}
+
+	if raw := c.Query("since"); raw != "" {
+		since, err := time.Parse(time.RFC3339, raw)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(models.ErrorResponse{Error: "since must be an RFC 3339 timestamp"})
+		}
+		filter.Since = &since
+	}
+
+	events, err := h.auditService.ListEvents(c.Context(), filter)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(models.ErrorResponse{Error: err.Error()})
+	}
+
+	response := make([]models.AuditEventResponse, len(events))
+	for i, e := range events {
+		response[i] = e.ToResponse()
+	}
+
+	return c.JSON(response)
+}
@@ -0,0 +1,341 @@
+package handlers
+
+import (
+	"errors"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+	"github.com/hoshina-dev/custapi/internal/auth"
+	"github.com/hoshina-dev/custapi/internal/models"
+	"github.com/hoshina-dev/custapi/internal/password"
+	"github.com/hoshina-dev/custapi/internal/services"
+)
+
+// AuthHandler handles login, logout, two-factor authentication, password reset and
+// email verification HTTP requests
+type AuthHandler struct {
+	authService services.AuthService
+	validate    *validator.Validate
+}
+
+// NewAuthHandler creates a new auth handler
+func NewAuthHandler(authService services.AuthService) *AuthHandler {
+	return &AuthHandler{
+		authService: authService,
+		validate:    validator.New(),
+	}
+}
+
+// Login godoc
+//
+//	@Summary		Log in
+//	@Description	Check an email and password and start a session. The returned token authenticates further requests in the Authorization header as "Bearer <token>". Users with two-factor authentication get a 202 with a challenge to complete with POST /auth/login/2fa instead.
+//	@Tags			auth
+//	@Accept			json
+//	@Produce		json
+//	@Param			credentials	body		models.LoginRequest	true	"Email and password"
+//	@Success		200			{object}	models.LoginResponse
+//	@Success		202			{object}	models.TwoFactorChallengeResponse
+//	@Failure		400			{object}	models.ErrorResponse
+//	@Failure		401			{object}	models.ErrorResponse
+//	@Failure		422			{object}	models.ErrorResponse
+//	@Failure		500			{object}	models.ErrorResponse
+//	@Router			/auth/login [post]
+func (h *AuthHandler) Login(c *fiber.Ctx) error {
+	req := new(models.LoginRequest)
+	if err := c.BodyParser(req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(models.ErrorResponse{Error: "invalid json payload"})
+	}
+	if err := h.validate.Struct(req); err != nil {
+		return c.Status(fiber.StatusUnprocessableEntity).JSON(models.ErrorResponse{Error: err.Error()})
+	}
+
+	session, token, err := h.authService.Login(c.Context(), req.Email, req.Password, c.Get(fiber.HeaderUserAgent))
+	if err != nil {
+		var twoFactorErr *services.TwoFactorRequiredError
+		switch {
+		case errors.As(err, &twoFactorErr):
+			return c.Status(fiber.StatusAccepted).JSON(models.TwoFactorChallengeResponse{
+				TwoFactorRequired: true,
+				ChallengeToken:    twoFactorErr.ChallengeToken,
+				ExpiresAt:         twoFactorErr.ExpiresAt,
+			})
+		case errors.Is(err, services.ErrInvalidCredentials):
+			return c.Status(fiber.StatusUnauthorized).JSON(models.ErrorResponse{Error: err.Error()})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(models.ErrorResponse{Error: err.Error()})
+	}
+
+	return c.JSON(models.LoginResponse{
+		Token:     token,
+		ExpiresAt: session.ExpiresAt,
+		User:      session.User.ToResponse(),
+	})
+}
+
+// CompleteLogin godoc
+//
+//	@Summary		Complete a login with a second factor
+//	@Description	Start the session of a login challenged for a second factor, with a code of the authenticator app or an unused recovery code. A challenge allows a few attempts before it has to be started over.
+//	@Tags			auth
+//	@Accept			json
+//	@Produce		json
+//	@Param			request	body		models.TwoFactorLoginRequest	true	"Challenge token and code"
+//	@Success		200		{object}	models.LoginResponse
+//	@Failure		400		{object}	models.ErrorResponse
+//	@Failure		401		{object}	models.ErrorResponse
+//	@Failure		422		{object}	models.ErrorResponse
+//	@Failure		500		{object}	models.ErrorResponse
+//	@Router			/auth/login/2fa [post]
+func (h *AuthHandler) CompleteLogin(c *fiber.Ctx) error {
+	req := new(models.TwoFactorLoginRequest)
+	if err := c.BodyParser(req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(models.ErrorResponse{Error: "invalid json payload"})
+	}
+	if err := h.validate.Struct(req); err != nil {
+		return c.Status(fiber.StatusUnprocessableEntity).JSON(models.ErrorResponse{Error: err.Error()})
+	}
+
+	session, token, err := h.authService.CompleteLogin(c.Context(), req.ChallengeToken, req.Code, c.Get(fiber.HeaderUserAgent))
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidLoginChallenge) || errors.Is(err, services.ErrInvalidTwoFactorCode) {
+			return c.Status(fiber.StatusUnauthorized).JSON(models.ErrorResponse{Error: err.Error()})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(models.ErrorResponse{Error: err.Error()})
+	}
+
+	return c.JSON(models.LoginResponse{
+		Token:     token,
+		ExpiresAt: session.ExpiresAt,
+		User:      session.User.ToResponse(),
+	})
+}
+
+// Logout godoc
+//
+//	@Summary		Log out
+//	@Description	End the session whose token authenticates the request
+//	@Tags			auth
+//	@Accept			json
+//	@Produce		json
+//	@Param			Authorization	header	string	true	"Bearer <token>"
+//	@Success		204
+//	@Failure		401	{object}	models.ErrorResponse
+//	@Failure		500	{object}	models.ErrorResponse
+//	@Router			/auth/logout [post]
+func (h *AuthHandler) Logout(c *fiber.Ctx) error {
+	token, ok := auth.BearerToken(c.Get(fiber.HeaderAuthorization))
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(models.ErrorResponse{Error: "bearer token required"})
+	}
+
+	if err := h.authService.Logout(c.Context(), token); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(models.ErrorResponse{Error: err.Error()})
+	}
+
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// ForgotPassword godoc
+//
+//	@Summary		Ask for a password reset link
+//	@Description	Email a single-use link for setting a new password to the user with the given email. The response is the same whether or not a user has that email.
+//	@Tags			auth
+//	@Accept			json
+//	@Produce		json
+//	@Param			request	body	models.ForgotPasswordRequest	true	"Email of the account"
+//	@Success		202
+//	@Failure		400	{object}	models.ErrorResponse
+//	@Failure		422	{object}	models.ErrorResponse
+//	@Failure		500	{object}	models.ErrorResponse
+//	@Router			/auth/password/forgot [post]
+func (h *AuthHandler) ForgotPassword(c *fiber.Ctx) error {
+	req := new(models.ForgotPasswordRequest)
+	if err := c.BodyParser(req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(models.ErrorResponse{Error: "invalid json payload"})
+	}
+	if err := h.validate.Struct(req); err != nil {
+		return c.Status(fiber.StatusUnprocessableEntity).JSON(models.ErrorResponse{Error: err.Error()})
+	}
+
+	if err := h.authService.ForgotPassword(c.Context(), req.Email); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(models.ErrorResponse{Error: err.Error()})
+	}
+
+	return c.SendStatus(fiber.StatusAccepted)
+}
+
+// ResetPassword godoc
+//
+//	@Summary		Reset a password
+//	@Description	Set a new password with the token of a password reset link. The token can only be used once, and every session of the user is ended.
+//	@Tags			auth
+//	@Accept			json
+//	@Produce		json
+//	@Param			request	body	models.ResetPasswordRequest	true	"Reset token and new password"
+//	@Success		204
+//	@Failure		400	{object}	models.ErrorResponse
+//	@Failure		422	{object}	models.PasswordPolicyErrorResponse
+//	@Failure		500	{object}	models.ErrorResponse
+//	@Router			/auth/password/reset [post]
+func (h *AuthHandler) ResetPassword(c *fiber.Ctx) error {
+	req := new(models.ResetPasswordRequest)
+	if err := c.BodyParser(req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(models.ErrorResponse{Error: "invalid json payload"})
+	}
+	if err := h.validate.Struct(req); err != nil {
+		return c.Status(fiber.StatusUnprocessableEntity).JSON(models.ErrorResponse{Error: err.Error()})
+	}
+
+	err := h.authService.ResetPassword(c.Context(), req.Token, req.Password)
+	if err != nil {
+		var policyErr *password.PolicyError
+		switch {
+		case errors.As(err, &policyErr):
+			return c.Status(fiber.StatusUnprocessableEntity).JSON(passwordPolicyResponse(policyErr))
+		case errors.Is(err, services.ErrInvalidResetToken):
+			return c.Status(fiber.StatusBadRequest).JSON(models.ErrorResponse{Error: err.Error()})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(models.ErrorResponse{Error: err.Error()})
+	}
+
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// VerifyEmail godoc
+//
+//	@Summary		Verify an email address
+//	@Description	Confirm an email address with the token of a verification link. For a pending email change, this is when the email of the user changes.
+//	@Tags			auth
+//	@Accept			json
+//	@Produce		json
+//	@Param			request	body		models.VerifyEmailRequest	true	"Verification token"
+//	@Success		200		{object}	models.UserResponse
+//	@Failure		400		{object}	models.ErrorResponse
+//	@Failure		409		{object}	models.ErrorResponse
+//	@Failure		422		{object}	models.ErrorResponse
+//	@Failure		500		{object}	models.ErrorResponse
+//	@Router			/auth/verify-email [post]
+func (h *AuthHandler) VerifyEmail(c *fiber.Ctx) error {
+	req := new(models.VerifyEmailRequest)
+	if err := c.BodyParser(req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(models.ErrorResponse{Error: "invalid json payload"})
+	}
+	if err := h.validate.Struct(req); err != nil {
+		return c.Status(fiber.StatusUnprocessableEntity).JSON(models.ErrorResponse{Error: err.Error()})
+	}
+
+	user, err := h.authService.VerifyEmail(c.Context(), req.Token)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrInvalidVerificationToken):
+			return c.Status(fiber.StatusBadRequest).JSON(models.ErrorResponse{Error: err.Error()})
+		case errors.Is(err, services.ErrEmailTaken):
+			return c.Status(fiber.StatusConflict).JSON(models.ErrorResponse{Error: err.Error()})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(models.ErrorResponse{Error: err.Error()})
+	}
+
+	return c.JSON(user.ToResponse())
+}
+
+// ResendEmailVerification godoc
+//
+//	@Summary		Resend the email verification link
+//	@Description	Send a new verification link for the pending email change of the authenticated user or, if there is none, for their unverified email address. Links sent before stop working.
+//	@Tags			auth
+//	@Accept			json
+//	@Produce		json
+//	@Success		202
+//	@Failure		401	{object}	models.ErrorResponse
+//	@Failure		409	{object}	models.ErrorResponse
+//	@Failure		500	{object}	models.ErrorResponse
+//	@Router			/auth/verify-email/resend [post]
+func (h *AuthHandler) ResendEmailVerification(c *fiber.Ctx) error {
+	actor := auth.FromContext(c.Context())
+	if actor.UserID == nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(models.ErrorResponse{Error: "authentication required"})
+	}
+
+	if err := h.authService.ResendEmailVerification(c.Context(), *actor.UserID); err != nil {
+		if errors.Is(err, services.ErrEmailAlreadyVerified) {
+			return c.Status(fiber.StatusConflict).JSON(models.ErrorResponse{Error: err.Error()})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(models.ErrorResponse{Error: err.Error()})
+	}
+
+	return c.SendStatus(fiber.StatusAccepted)
+}
+
+// SetupTwoFactor godoc
+//
+//	@Summary		Set up two-factor authentication
+//	@Description	Create a TOTP secret for the authenticated user to enroll in an authenticator app. Logins only need codes once a first code is verified with POST /auth/2fa/verify. Setting up again replaces a secret that was not verified yet.
+//	@Tags			auth
+//	@Accept			json
+//	@Produce		json
+//	@Success		200	{object}	models.TwoFactorSetupResponse
+//	@Failure		401	{object}	models.ErrorResponse
+//	@Failure		409	{object}	models.ErrorResponse
+//	@Failure		500	{object}	models.ErrorResponse
+//	@Router			/auth/2fa/setup [post]
+func (h *AuthHandler) SetupTwoFactor(c *fiber.Ctx) error {
+	actor := auth.FromContext(c.Context())
+	if actor.UserID == nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(models.ErrorResponse{Error: "authentication required"})
+	}
+
+	secret, uri, err := h.authService.SetupTwoFactor(c.Context(), *actor.UserID)
+	if err != nil {
+		if errors.Is(err, services.ErrTwoFactorAlreadyEnabled) {
+			return c.Status(fiber.StatusConflict).JSON(models.ErrorResponse{Error: err.Error()})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(models.ErrorResponse{Error: err.Error()})
+	}
+
+	return c.JSON(models.TwoFactorSetupResponse{Secret: secret, OTPAuthURI: uri})
+}
+
+// VerifyTwoFactor godoc
+//
+//	@Summary		Enable two-factor authentication
+//	@Description	Check a first code of the authenticator app and enable two-factor authentication for the authenticated user. The response lists one-time recovery codes, which are not shown again. Other sessions of the user are ended.
+//	@Tags			auth
+//	@Accept			json
+//	@Produce		json
+//	@Param			request	body		models.TwoFactorVerifyRequest	true	"Code of the authenticator app"
+//	@Success		200		{object}	models.RecoveryCodesResponse
+//	@Failure		400		{object}	models.ErrorResponse
+//	@Failure		401		{object}	models.ErrorResponse
+//	@Failure		409		{object}	models.ErrorResponse
+//	@Failure		422		{object}	models.ErrorResponse
+//	@Failure		500		{object}	models.ErrorResponse
+//	@Router			/auth/2fa/verify [post]
+func (h *AuthHandler) VerifyTwoFactor(c *fiber.Ctx) error {
+	actor := auth.FromContext(c.Context())
+	if actor.UserID == nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(models.ErrorResponse{Error: "authentication required"})
+	}
+	req := new(models.TwoFactorVerifyRequest)
+	if err := c.BodyParser(req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(models.ErrorResponse{Error: "invalid json payload"})
+	}
+	if err := h.validate.Struct(req); err != nil {
+		return c.Status(fiber.StatusUnprocessableEntity).JSON(models.ErrorResponse{Error: err.Error()})
+	}
+
+	// The session making the request stays open; it just proved the second factor
+	sessionToken, _ := auth.BearerToken(c.Get(fiber.HeaderAuthorization))
+	codes, err := h.authService.EnableTwoFactor(c.Context(), *actor.UserID, req.Code, sessionToken)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrInvalidTwoFactorCode):
+			return c.Status(fiber.StatusBadRequest).JSON(models.ErrorResponse{Error: err.Error()})
+		case errors.Is(err, services.ErrTwoFactorAlreadyEnabled), errors.Is(err, services.ErrTwoFactorNotSetUp):
+			return c.Status(fiber.StatusConflict).JSON(models.ErrorResponse{Error: err.Error()})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(models.ErrorResponse{Error: err.Error()})
+	}
+
+	return c.JSON(models.RecoveryCodesResponse{RecoveryCodes: codes})
+}
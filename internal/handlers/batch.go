@@ -0,0 +1,24 @@
+package handlers
+
+import (
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// HeaderMissingIDs lists the requested IDs of a batch lookup that matched nothing,
+// leaving the body a plain array of what was found
+const HeaderMissingIDs = "X-Missing-IDs"
+
+// setMissingIDs sets the HeaderMissingIDs header, unless every ID was found
+func setMissingIDs(c *fiber.Ctx, missing []uuid.UUID) {
+	if len(missing) == 0 {
+		return
+	}
+	ids := make([]string, len(missing))
+	for i, id := range missing {
+		ids[i] = id.String()
+	}
+	c.Set(HeaderMissingIDs, strings.Join(ids, ","))
+}
@@ -0,0 +1,138 @@
+package handlers
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/hoshina-dev/custapi/internal/models"
+	"github.com/hoshina-dev/custapi/internal/stream"
+)
+
+// eventHeartbeat is how often a comment is sent on idle streams to keep proxies from closing them
+const eventHeartbeat = 15 * time.Second
+
+// EventHandler handles live event stream HTTP requests
+type EventHandler struct {
+	hub *stream.Hub
+}
+
+// NewEventHandler creates a new event handler
+func NewEventHandler(hub *stream.Hub) *EventHandler {
+	return &EventHandler{
+		hub: hub,
+	}
+}
+
+// StreamEvents godoc
+//
+//	@Summary		Stream live changes
+//	@Description	Server-Sent Events stream of user.* and organization.* events committed through any replica. Each message has the event type as its name, the event envelope as JSON data and a numeric ID.
+//	@Description	Reconnecting clients send the last ID seen in the Last-Event-ID header (or last_event_id query) to receive the recent events they missed. Admin only.
+//	@Tags			events
+//	@Produce		text/event-stream
+//	@Param			organization_id	query		string	false	"Only events of this organization and its users (UUID)"
+//	@Param			last_event_id	query		int		false	"Resume after this event ID, for clients that cannot set Last-Event-ID"
+//	@Param			Last-Event-ID	header		int		false	"Resume after this event ID"
+//	@Success		200				{string}	string	"text/event-stream"
+//	@Failure		400				{object}	models.ErrorResponse
+//	@Failure		401				{object}	models.ErrorResponse
+//	@Failure		403				{object}	models.ErrorResponse
+//	@Failure		503				{object}	models.ErrorResponse
+//	@Router			/events/stream [get]
+func (h *EventHandler) StreamEvents(c *fiber.Ctx) error {
+	var orgID *uuid.UUID
+	if raw := c.Query("organization_id"); raw != "" {
+		id, err := uuid.Parse(raw)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(models.ErrorResponse{Error: "invalid organization id"})
+		}
+		orgID = &id
+	}
+
+	var lastID int64
+	if raw := c.Get("Last-Event-ID", c.Query("last_event_id")); raw != "" {
+		id, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(models.ErrorResponse{Error: "invalid last event id"})
+		}
+		lastID = id
+	}
+
+	replay, events, cancel, err := h.hub.Subscribe(lastID)
+	if err != nil {
+		c.Set(fiber.HeaderRetryAfter, "5")
+		return c.Status(fiber.StatusServiceUnavailable).JSON(models.ErrorResponse{Error: err.Error()})
+	}
+
+	c.Set(fiber.HeaderContentType, "text/event-stream")
+	c.Set(fiber.HeaderCacheControl, "no-cache")
+	c.Set(fiber.HeaderConnection, "keep-alive")
+	c.Set("X-Accel-Buffering", "no")
+
+	c.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+		defer cancel()
+
+		send := func(event *models.OutboxEvent) error {
+			if orgID != nil && !eventInOrganization(event, *orgID) {
+				return nil
+			}
+			domainEvent := event.ToDomain()
+			data, err := json.Marshal(domainEvent.Payload())
+			if err != nil {
+				return err
+			}
+			_, err = fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", event.Sequence, event.EventType, data)
+			return err
+		}
+
+		// Tell the browser how long to wait before reconnecting
+		fmt.Fprint(w, "retry: 3000\n\n")
+		for i := range replay {
+			if err := send(&replay[i]); err != nil {
+				return
+			}
+		}
+		if err := w.Flush(); err != nil {
+			return
+		}
+
+		heartbeat := time.NewTicker(eventHeartbeat)
+		defer heartbeat.Stop()
+
+		for {
+			select {
+			case event, ok := <-events:
+				if !ok {
+					return
+				}
+				if err := send(&event); err != nil {
+					return
+				}
+			case <-heartbeat.C:
+				fmt.Fprint(w, ": ping\n\n")
+			}
+			// A failed flush means the client went away
+			if err := w.Flush(); err != nil {
+				return
+			}
+		}
+	})
+
+	return nil
+}
+
+// eventInOrganization reports whether event is about the organization orgID or one of its users
+func eventInOrganization(event *models.OutboxEvent, orgID uuid.UUID) bool {
+	switch event.AggregateType {
+	case "organization":
+		return event.AggregateID == orgID
+	case "user":
+		return event.Payload["organization_id"] == orgID.String()
+	}
+	return false
+}
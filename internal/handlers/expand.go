@@ -0,0 +1,47 @@
+package handlers
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+const (
+	// defaultExpandedUsers and maxExpandedUsers bound the users embedded in each
+	// organization with expand=users
+	defaultExpandedUsers = 20
+	maxExpandedUsers     = 100
+)
+
+// parseExpand reads the comma separated relations of the expand query parameter,
+// rejecting any not in allowed
+func parseExpand(c *fiber.Ctx, allowed ...string) (map[string]bool, error) {
+	expand := make(map[string]bool)
+	for relation := range strings.SplitSeq(c.Query("expand"), ",") {
+		relation = strings.TrimSpace(relation)
+		if relation == "" {
+			continue
+		}
+		if !slices.Contains(allowed, relation) {
+			return nil, fmt.Errorf("expand must be one of %s", strings.Join(allowed, ", "))
+		}
+		expand[relation] = true
+	}
+	return expand, nil
+}
+
+// parseUsersPage reads the users_limit and users_offset query parameters paging the
+// users embedded in each organization
+func parseUsersPage(c *fiber.Ctx) (limit, offset int, err error) {
+	limit = c.QueryInt("users_limit", defaultExpandedUsers)
+	offset = c.QueryInt("users_offset", 0)
+	if limit < 1 || limit > maxExpandedUsers {
+		return 0, 0, fmt.Errorf("users_limit must be between 1 and %d", maxExpandedUsers)
+	}
+	if offset < 0 {
+		return 0, 0, fmt.Errorf("users_offset must be non-negative")
+	}
+	return limit, offset, nil
+}
@@ -0,0 +1,142 @@
+package handlers
+
+import (
+	"bufio"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"log"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/hoshina-dev/custapi/internal/models"
+)
+
+// exportFlushEvery is the number of rows buffered before they are flushed to the client
+const exportFlushEvery = 500
+
+// streamExport streams the rows produced by export to the client as CSV or NDJSON
+// download. The rows are response DTOs; for CSV their JSON tags name the columns
+// and list values are joined with ";" so the file can be imported again.
+func streamExport(c *fiber.Ctx, name string, row any, export func(ctx context.Context, write func(any) error) error) error {
+	format := c.Query("format", "csv")
+	switch format {
+	case "csv":
+		c.Set(fiber.HeaderContentType, "text/csv; charset=utf-8")
+	case "ndjson":
+		c.Set(fiber.HeaderContentType, "application/x-ndjson")
+	default:
+		return c.Status(fiber.StatusBadRequest).JSON(models.ErrorResponse{Error: "format must be csv or ndjson"})
+	}
+
+	filename := fmt.Sprintf("%s-%s.%s", name, time.Now().UTC().Format("20060102T150405Z"), format)
+	c.Set(fiber.HeaderContentDisposition, fmt.Sprintf(`attachment; filename="%s"`, filename))
+
+	c.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+		var write func(any) error
+		if format == "csv" {
+			cw := csv.NewWriter(w)
+			if err := cw.Write(csvHeader(row)); err != nil {
+				log.Printf("Export of %s aborted: %v", name, err)
+				return
+			}
+			write = func(v any) error { return cw.Write(csvRecord(v)) }
+			defer cw.Flush()
+		} else {
+			enc := json.NewEncoder(w)
+			write = enc.Encode
+		}
+
+		count := 0
+		err := export(context.Background(), func(v any) error {
+			if err := write(v); err != nil {
+				return err
+			}
+			count++
+			if count%exportFlushEvery == 0 {
+				// A failed flush means the client went away, which stops the cursor
+				return w.Flush()
+			}
+			return nil
+		})
+		if err != nil {
+			log.Printf("Export of %s aborted after %d rows: %v", name, count, err)
+		}
+	})
+
+	return nil
+}
+
+// csvHeader returns the JSON field names of a response DTO
+func csvHeader(v any) []string {
+	t := reflect.TypeOf(v)
+	header := make([]string, 0, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		if name := jsonName(t.Field(i)); name != "" {
+			header = append(header, name)
+		}
+	}
+	return header
+}
+
+// csvRecord formats the fields of a response DTO in csvHeader order
+func csvRecord(v any) []string {
+	rv := reflect.ValueOf(v)
+	t := rv.Type()
+	record := make([]string, 0, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		if jsonName(t.Field(i)) != "" {
+			record = append(record, csvValue(rv.Field(i)))
+		}
+	}
+	return record
+}
+
+func csvValue(v reflect.Value) string {
+	if v.Kind() == reflect.Pointer {
+		if v.IsNil() {
+			return ""
+		}
+		v = v.Elem()
+	}
+
+	switch x := v.Interface().(type) {
+	case time.Time:
+		return x.Format(time.RFC3339)
+	case fmt.Stringer:
+		return x.String()
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		return v.String()
+	case reflect.Bool:
+		return strconv.FormatBool(v.Bool())
+	case reflect.Float64:
+		return strconv.FormatFloat(v.Float(), 'f', -1, 64)
+	case reflect.Int, reflect.Int64:
+		return strconv.FormatInt(v.Int(), 10)
+	case reflect.Slice:
+		items := make([]string, v.Len())
+		for i := range items {
+			items[i] = csvValue(v.Index(i))
+		}
+		return strings.Join(items, ";")
+	default:
+		return fmt.Sprint(v.Interface())
+	}
+}
+
+// jsonName returns the JSON name of a DTO column, or "" for fields that are not
+// columns, such as expanded relations
+func jsonName(f reflect.StructField) string {
+	name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+	if name == "-" || f.Tag.Get("csv") == "-" {
+		return ""
+	}
+	return name
+}
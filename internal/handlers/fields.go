@@ -0,0 +1,88 @@
+package handlers
+
+import (
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// parseFields reads the comma separated fields query parameter, validated against the
+// JSON names of the response DTO row. It returns the Go names of the fields, which the
+// DTOs share with the domain models, or nil when every field is wanted.
+func parseFields(c *fiber.Ctx, row any) ([]string, error) {
+	raw := c.Query("fields")
+	if raw == "" {
+		return nil, nil
+	}
+
+	t := reflect.TypeOf(row)
+	known := make(map[string]string, t.NumField())
+	var names []string
+	for i := 0; i < t.NumField(); i++ {
+		if name := jsonName(t.Field(i)); name != "" {
+			known[name] = t.Field(i).Name
+			names = append(names, name)
+		}
+	}
+
+	var fields []string
+	for name := range strings.SplitSeq(raw, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		field, ok := known[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown field %q, fields must be among %s", name, strings.Join(names, ", "))
+		}
+		if !slices.Contains(fields, field) {
+			fields = append(fields, field)
+		}
+	}
+	if len(fields) == 0 {
+		return nil, fmt.Errorf("fields must name at least one field")
+	}
+	return fields, nil
+}
+
+// sparse trims the response DTO row to fields, keeping any expanded relation. It
+// returns row unchanged when fields is nil.
+func sparse(row any, fields []string) any {
+	if fields == nil {
+		return row
+	}
+
+	v := reflect.ValueOf(row)
+	t := v.Type()
+	trimmed := make(map[string]any, len(fields)+1)
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if name := jsonName(f); name != "" {
+			if slices.Contains(fields, f.Name) {
+				trimmed[name] = v.Field(i).Interface()
+			}
+			continue
+		}
+		// Relations are left out of columns and only set when expanded
+		if name, _, _ := strings.Cut(f.Tag.Get("json"), ","); name != "-" && !v.Field(i).IsZero() {
+			trimmed[name] = v.Field(i).Interface()
+		}
+	}
+	return trimmed
+}
+
+// sparseAll trims every response DTO of rows to fields
+func sparseAll[T any](rows []T, fields []string) any {
+	if fields == nil {
+		return rows
+	}
+
+	trimmed := make([]any, len(rows))
+	for i, row := range rows {
+		trimmed[i] = sparse(row, fields)
+	}
+	return trimmed
+}
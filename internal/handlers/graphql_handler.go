@@ -0,0 +1,58 @@
+package handlers
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/hoshina-dev/custapi/internal/graphql"
+	"github.com/hoshina-dev/custapi/internal/models"
+)
+
+// GraphQLHandler handles GraphQL HTTP requests
+type GraphQLHandler struct {
+	schema *graphql.Schema
+}
+
+// NewGraphQLHandler creates a new GraphQL handler
+func NewGraphQLHandler(schema *graphql.Schema) *GraphQLHandler {
+	return &GraphQLHandler{
+		schema: schema,
+	}
+}
+
+// Query godoc
+//
+//	@Summary		Run a GraphQL query or mutation
+//	@Description	Query users and organizations with their relations in one request, or run the mutations mirroring the REST endpoints. Relations are loaded in batches.
+//	@Description	Errors are reported in the errors array with a 200 status, as usual for GraphQL. Fields may be nested at most 10 levels deep. Searches share the search rate limit of the REST API.
+//	@Description	The schema can be introspected, or fetched with GET /graphql/schema.
+//	@Tags			graphql
+//	@Accept			json
+//	@Produce		json
+//	@Param			request	body		graphql.Request	true	"GraphQL request"
+//	@Success		200		{object}	graphql.Response
+//	@Failure		400		{object}	models.ErrorResponse
+//	@Router			/graphql [post]
+func (h *GraphQLHandler) Query(c *fiber.Ctx) error {
+	req := new(graphql.Request)
+
+	if err := c.BodyParser(req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(models.ErrorResponse{Error: "invalid json payload"})
+	}
+	if req.Query == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(models.ErrorResponse{Error: "query is required"})
+	}
+
+	return c.JSON(h.schema.Execute(c.Context(), *req))
+}
+
+// Schema godoc
+//
+//	@Summary		Get the GraphQL schema
+//	@Description	Get the GraphQL schema in SDL, for code generators and editors that do not introspect it
+//	@Tags			graphql
+//	@Produce		plain
+//	@Success		200	{string}	string	"Schema definition"
+//	@Router			/graphql/schema [get]
+func (h *GraphQLHandler) Schema(c *fiber.Ctx) error {
+	c.Set(fiber.HeaderContentType, fiber.MIMETextPlainCharsetUTF8)
+	return c.SendString(graphql.SDL)
+}
@@ -0,0 +1,129 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/hoshina-dev/custapi/internal/importer"
+	"github.com/hoshina-dev/custapi/internal/models"
+)
+
+// importUpload is a parsed multipart spreadsheet upload
+type importUpload struct {
+	header  []string
+	records []importer.Record
+	dryRun  bool
+	report  bool
+}
+
+// parseImportUpload reads the "file" part of a multipart import request together
+// with the optional "mapping" (JSON object of source column to field name) and
+// "dry_run" fields. A report=csv query asks for the CSV error report instead of JSON.
+func parseImportUpload(c *fiber.Ctx) (*importUpload, error) {
+	fh, err := c.FormFile("file")
+	if err != nil {
+		return nil, errors.New("multipart field 'file' is required")
+	}
+
+	format, err := importer.DetectFormat(fh.Filename)
+	if err != nil {
+		return nil, err
+	}
+
+	var mapping map[string]string
+	if raw := c.FormValue("mapping"); raw != "" {
+		if err := json.Unmarshal([]byte(raw), &mapping); err != nil {
+			return nil, errors.New("mapping must be a JSON object of column names to field names")
+		}
+	}
+
+	f, err := fh.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	header, records, err := importer.Read(f, format, mapping)
+	if err != nil {
+		return nil, err
+	}
+
+	dryRun := c.QueryBool("dry_run", false)
+	if raw := c.FormValue("dry_run"); raw != "" {
+		if dryRun, err = strconv.ParseBool(raw); err != nil {
+			return nil, errors.New("dry_run must be a boolean")
+		}
+	}
+
+	return &importUpload{
+		header:  header,
+		records: records,
+		dryRun:  dryRun,
+		report:  c.Query("report") == "csv",
+	}, nil
+}
+
+// applyImportResults fills the row results for the records that were handed to the service
+func applyImportResults(rows []models.ImportRowResult, indexes []int, results []models.ImportResult) {
+	for j, r := range results {
+		row := &rows[indexes[j]]
+		switch {
+		case r.Err != nil:
+			row.Action = "rejected"
+			row.Error = r.Err.Error()
+			continue
+		case r.Created:
+			row.Action = "created"
+		default:
+			row.Action = "updated"
+		}
+		if r.ID != uuid.Nil {
+			id := r.ID
+			row.ID = &id
+		}
+	}
+}
+
+// sendImportResult responds with the import summary, or with a CSV report of the
+// rejected rows when the upload asked for one
+func sendImportResult(c *fiber.Ctx, upload *importUpload, rows []models.ImportRowResult, name string) error {
+	res := models.ImportResponse{DryRun: upload.dryRun, Rows: rows}
+	for _, row := range rows {
+		switch row.Action {
+		case "created":
+			res.Created++
+		case "updated":
+			res.Updated++
+		default:
+			res.Rejected++
+		}
+	}
+
+	if !upload.report {
+		return c.JSON(res)
+	}
+
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	_ = w.Write(append([]string{"row", "error"}, upload.header...))
+	for i, row := range rows {
+		if row.Action != "rejected" {
+			continue
+		}
+		_ = w.Write(append([]string{strconv.Itoa(row.Row), row.Error}, upload.records[i].Raw...))
+	}
+	w.Flush()
+	if err := w.Error(); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(models.ErrorResponse{Error: err.Error()})
+	}
+
+	c.Set(fiber.HeaderContentDisposition, fmt.Sprintf(`attachment; filename="%s-import-errors.csv"`, name))
+	c.Set(fiber.HeaderContentType, "text/csv; charset=utf-8")
+	return c.Send(buf.Bytes())
+}
@@ -0,0 +1,182 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/hoshina-dev/custapi/internal/media"
+	"github.com/hoshina-dev/custapi/internal/models"
+	"github.com/hoshina-dev/custapi/internal/services"
+)
+
+// MediaHandler handles image upload and broken image HTTP requests
+type MediaHandler struct {
+	mediaService  services.MediaService
+	maxUploadSize int64
+}
+
+// NewMediaHandler creates a new media handler
+func NewMediaHandler(mediaService services.MediaService, maxUploadSize int64) *MediaHandler {
+	return &MediaHandler{
+		mediaService:  mediaService,
+		maxUploadSize: maxUploadSize,
+	}
+}
+
+// UploadAvatar godoc
+//
+//	@Summary		Upload a user's avatar
+//	@Description	Upload a JPEG, PNG or GIF image as the avatar of a user. The image type is detected from the file content. Thumbnails are rendered and the user's avatar_url is set to the stored image. Users can change their own avatar; admins who logged in with a second factor anyone's.
+//	@Tags			users
+//	@Accept			multipart/form-data
+//	@Produce		json
+//	@Param			id		path		string	true	"User ID (UUID)"
+//	@Param			file	formData	file	true	"Image file"
+//	@Success		200		{object}	models.UploadedImageResponse
+//	@Failure		400		{object}	models.ErrorResponse
+//	@Failure		401		{object}	models.ErrorResponse
+//	@Failure		403		{object}	models.ErrorResponse
+//	@Failure		404		{object}	models.ErrorResponse
+//	@Failure		413		{object}	models.ErrorResponse
+//	@Failure		415		{object}	models.ErrorResponse
+//	@Failure		422		{object}	models.ErrorResponse
+//	@Failure		500		{object}	models.ErrorResponse
+//	@Router			/users/{id}/avatar [put]
+func (h *MediaHandler) UploadAvatar(c *fiber.Ctx) error {
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(models.ErrorResponse{Error: "invalid user id"})
+	}
+
+	data, err := h.readUpload(c)
+	if err != nil {
+		return h.uploadError(c, err)
+	}
+
+	img, err := h.mediaService.UploadAvatar(c.Context(), id, data)
+	if err != nil {
+		return h.uploadError(c, err)
+	}
+
+	return c.JSON(img.ToResponse())
+}
+
+// UploadOrganizationImage godoc
+//
+//	@Summary		Upload an organization image
+//	@Description	Upload a JPEG, PNG or GIF image and add it to the image_urls of an organization. The image type is detected from the file content and thumbnails are rendered.
+//	@Tags			organizations
+//	@Accept			multipart/form-data
+//	@Produce		json
+//	@Param			id		path		string	true	"Organization ID (UUID)"
+//	@Param			file	formData	file	true	"Image file"
+//	@Success		201		{object}	models.UploadedImageResponse
+//	@Failure		400		{object}	models.ErrorResponse
+//	@Failure		404		{object}	models.ErrorResponse
+//	@Failure		413		{object}	models.ErrorResponse
+//	@Failure		415		{object}	models.ErrorResponse
+//	@Failure		422		{object}	models.ErrorResponse
+//	@Failure		500		{object}	models.ErrorResponse
+//	@Router			/organizations/{id}/images [post]
+func (h *MediaHandler) UploadOrganizationImage(c *fiber.Ctx) error {
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(models.ErrorResponse{Error: "invalid organization id"})
+	}
+
+	data, err := h.readUpload(c)
+	if err != nil {
+		return h.uploadError(c, err)
+	}
+
+	img, err := h.mediaService.AddOrganizationImage(c.Context(), id, data)
+	if err != nil {
+		return h.uploadError(c, err)
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(img.ToResponse())
+}
+
+// GetBrokenMedia godoc
+//
+//	@Summary		List broken image URLs
+//	@Description	List the avatar and organization image URLs that did not resolve when last checked by the background media checker, most recently checked first. Admin only.
+//	@Tags			media
+//	@Accept			json
+//	@Produce		json
+//	@Param			entity	query		string	false	"Entity type"	Enums(user, organization)
+//	@Param			limit	query		int		false	"Maximum number of results to return (default: 100)"
+//	@Param			offset	query		int		false	"Number of results to skip (default: 0)"
+//	@Success		200		{array}		models.MediaCheckResponse
+//	@Failure		400		{object}	models.ErrorResponse
+//	@Failure		401		{object}	models.ErrorResponse
+//	@Failure		403		{object}	models.ErrorResponse
+//	@Failure		500		{object}	models.ErrorResponse
+//	@Router			/admin/broken-media [get]
+func (h *MediaHandler) GetBrokenMedia(c *fiber.Ctx) error {
+	filter := models.MediaCheckFilter{
+		EntityType: c.Query("entity"),
+		Limit:      c.QueryInt("limit", 100),
+		Offset:     c.QueryInt("offset", 0),
+	}
+	if filter.Limit < 0 || filter.Offset < 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(models.ErrorResponse{Error: "limit and offset must be non-negative"})
+	}
+
+	checks, err := h.mediaService.ListBrokenMedia(c.Context(), filter)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(models.ErrorResponse{Error: err.Error()})
+	}
+
+	response := make([]models.MediaCheckResponse, len(checks))
+	for i := range checks {
+		response[i] = checks[i].ToResponse()
+	}
+
+	return c.JSON(response)
+}
+
+// readUpload reads the "file" part of a multipart image upload, refusing files above
+// the maximum upload size before reading them
+func (h *MediaHandler) readUpload(c *fiber.Ctx) ([]byte, error) {
+	fh, err := c.FormFile("file")
+	if err != nil {
+		return nil, errors.New("multipart field 'file' is required")
+	}
+	if fh.Size > h.maxUploadSize {
+		return nil, services.ErrImageTooLarge
+	}
+
+	f, err := fh.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return io.ReadAll(io.LimitReader(f, h.maxUploadSize+1))
+}
+
+// uploadError maps an upload error to its response
+func (h *MediaHandler) uploadError(c *fiber.Ctx, err error) error {
+	switch {
+	case errors.Is(err, services.ErrImageTooLarge):
+		return c.Status(fiber.StatusRequestEntityTooLarge).JSON(models.ErrorResponse{
+			Error: fmt.Sprintf("image must not be larger than %d bytes", h.maxUploadSize),
+		})
+	case errors.Is(err, media.ErrUnsupportedType):
+		return c.Status(fiber.StatusUnsupportedMediaType).JSON(models.ErrorResponse{Error: err.Error()})
+	case errors.Is(err, media.ErrTooManyPixels):
+		return c.Status(fiber.StatusUnprocessableEntity).JSON(models.ErrorResponse{Error: err.Error()})
+	case errors.Is(err, services.ErrAuthenticationRequired):
+		return c.Status(fiber.StatusUnauthorized).JSON(models.ErrorResponse{Error: err.Error()})
+	case errors.Is(err, services.ErrUserAccessDenied):
+		return c.Status(fiber.StatusForbidden).JSON(models.ErrorResponse{Error: err.Error()})
+	case err.Error() == "multipart field 'file' is required":
+		return c.Status(fiber.StatusBadRequest).JSON(models.ErrorResponse{Error: err.Error()})
+	case err.Error() == "user not found", err.Error() == "organization not found":
+		return c.Status(fiber.StatusNotFound).JSON(models.ErrorResponse{Error: err.Error()})
+	}
+	return c.Status(fiber.StatusInternalServerError).JSON(models.ErrorResponse{Error: err.Error()})
+}
@@ -1,9 +1,15 @@
 package handlers
 
 import (
+	"context"
+	"errors"
+	"time"
+
 	"github.com/go-playground/validator/v10"
 	"github.com/gofiber/fiber/v2"
 	"github.com/google/uuid"
+	"github.com/hoshina-dev/custapi/internal/fieldset"
+	"github.com/hoshina-dev/custapi/internal/importer"
 	"github.com/hoshina-dev/custapi/internal/models"
 	"github.com/hoshina-dev/custapi/internal/services"
 )
@@ -61,34 +67,63 @@ func (h *OrgHandler) CreateOrganization(c *fiber.Ctx) error {
 //	@Tags			organizations
 //	@Accept			json
 //	@Produce		json
-//	@Success		200	{array}		models.OrganizationResponse
-//	@Failure		500	{object}	models.ErrorResponse
+//	@Param			expand			query		string	false	"Embed related resources"	Enums(users)
+//	@Param			fields			query		string	false	"Comma separated fields to return, for example id,name"
+//	@Param			users_limit		query		int		false	"Users embedded per organization with expand=users (default: 20, max: 100)"
+//	@Param			users_offset	query		int		false	"Users skipped per organization with expand=users"
+//	@Success		200				{array}		models.OrganizationResponse
+//	@Failure		400				{object}	models.ErrorResponse
+//	@Failure		500				{object}	models.ErrorResponse
 //	@Router			/organizations [get]
 func (h *OrgHandler) GetOrganizations(c *fiber.Ctx) error {
-	orgs, err := h.orgService.ListOrganizations(c.Context())
+	expand, err := parseExpand(c, "users")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(models.ErrorResponse{Error: err.Error()})
+	}
+	usersLimit, usersOffset, err := parseUsersPage(c)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(models.ErrorResponse{Error: err.Error()})
+	}
+	fields, err := parseFields(c, models.OrganizationResponse{})
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(models.ErrorResponse{Error: err.Error()})
+	}
+
+	orgs, err := h.orgService.ListOrganizations(fieldset.NewContext[models.Organization](c.Context(), fields))
 	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(models.ErrorResponse{Error: err.Error()})
 	}
+	if expand["users"] {
+		if err := h.orgService.LoadUsers(c.Context(), orgs, usersLimit, usersOffset); err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(models.ErrorResponse{Error: err.Error()})
+		}
+	}
 
 	response := make([]models.OrganizationResponse, len(orgs))
 	for i, o := range orgs {
 		response[i] = o.ToResponse()
 	}
 
-	return c.JSON(response)
+	return c.JSON(sparseAll(response, fields))
 }
 
 // GetOrganization godoc
 //
 //	@Summary		Get an organization by ID
-//	@Description	Get a single organization by their ID
+//	@Description	Get a single organization by their ID, or as it was at a point in time with as_of
 //	@Tags			organizations
 //	@Accept			json
 //	@Produce		json
-//	@Param			id	path		string	true	"Organization ID"
-//	@Success		200	{object}	models.OrganizationResponse
-//	@Failure		404	{object}	models.ErrorResponse
-//	@Failure		500	{object}	models.ErrorResponse
+//	@Param			id				path		string	true	"Organization ID"
+//	@Param			as_of			query		string	false	"Return the organization as it was at this RFC 3339 time"
+//	@Param			expand			query		string	false	"Embed related resources"	Enums(users)
+//	@Param			fields			query		string	false	"Comma separated fields to return, for example id,name"
+//	@Param			users_limit		query		int		false	"Users embedded per organization with expand=users (default: 20, max: 100)"
+//	@Param			users_offset	query		int		false	"Users skipped per organization with expand=users"
+//	@Success		200				{object}	models.OrganizationResponse
+//	@Failure		400				{object}	models.ErrorResponse
+//	@Failure		404				{object}	models.ErrorResponse
+//	@Failure		500				{object}	models.ErrorResponse
 //	@Router			/organizations/{id} [get]
 func (h *OrgHandler) GetOrganization(c *fiber.Ctx) error {
 	id, err := uuid.Parse(c.Params("id"))
@@ -96,7 +131,29 @@ func (h *OrgHandler) GetOrganization(c *fiber.Ctx) error {
 		return c.Status(fiber.StatusBadRequest).JSON(models.ErrorResponse{Error: "invalid organization id"})
 	}
 
-	org, err := h.orgService.GetOrganization(c.Context(), id)
+	expand, err := parseExpand(c, "users")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(models.ErrorResponse{Error: err.Error()})
+	}
+	usersLimit, usersOffset, err := parseUsersPage(c)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(models.ErrorResponse{Error: err.Error()})
+	}
+	fields, err := parseFields(c, models.OrganizationResponse{})
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(models.ErrorResponse{Error: err.Error()})
+	}
+
+	var org *models.Organization
+	if raw := c.Query("as_of"); raw != "" {
+		asOf, err := time.Parse(time.RFC3339, raw)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(models.ErrorResponse{Error: "as_of must be an RFC 3339 timestamp"})
+		}
+		org, err = h.orgService.GetOrganizationAsOf(c.Context(), id, asOf)
+	} else {
+		org, err = h.orgService.GetOrganization(fieldset.NewContext[models.Organization](c.Context(), fields), id)
+	}
 	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(models.ErrorResponse{Error: err.Error()})
 	}
@@ -105,7 +162,50 @@ func (h *OrgHandler) GetOrganization(c *fiber.Ctx) error {
 		return c.Status(fiber.StatusNotFound).JSON(models.ErrorResponse{Error: "organization not found"})
 	}
 
-	return c.JSON(org.ToResponse())
+	orgs := []models.Organization{*org}
+	if expand["users"] {
+		if err := h.orgService.LoadUsers(c.Context(), orgs, usersLimit, usersOffset); err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(models.ErrorResponse{Error: err.Error()})
+		}
+	}
+
+	return c.JSON(sparse(orgs[0].ToResponse(), fields))
+}
+
+// GetOrganizationHistory godoc
+//
+//	@Summary		Get the change history of an organization
+//	@Description	Get every known version of an organization, oldest first, with the fields changed in each version. Deleted organizations keep their history.
+//	@Tags			organizations
+//	@Accept			json
+//	@Produce		json
+//	@Param			id	path		string	true	"Organization ID"
+//	@Success		200	{array}		models.OrganizationVersionResponse
+//	@Failure		400	{object}	models.ErrorResponse
+//	@Failure		404	{object}	models.ErrorResponse
+//	@Failure		500	{object}	models.ErrorResponse
+//	@Router			/organizations/{id}/history [get]
+func (h *OrgHandler) GetOrganizationHistory(c *fiber.Ctx) error {
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(models.ErrorResponse{Error: "invalid organization id"})
+	}
+
+	versions, err := h.orgService.GetOrganizationHistory(c.Context(), id)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(models.ErrorResponse{Error: err.Error()})
+	}
+
+	if versions == nil {
+		return c.Status(fiber.StatusNotFound).JSON(models.ErrorResponse{Error: "organization not found"})
+	}
+
+	response := make([]models.OrganizationVersionResponse, len(versions))
+	for i, v := range versions {
+		response[i] = v.ToResponse()
+	}
+
+	return c.JSON(response)
 }
 
 // GetAllCoords godoc
@@ -177,14 +277,20 @@ func (h *OrgHandler) UpdateOrganization(c *fiber.Ctx) error {
 // DeleteOrganization godoc
 //
 //	@Summary		Delete an organization
-//	@Description	Soft delete an organization by ID
+//	@Description	Soft delete an organization by ID. The policy decides what happens to its users: restrict (default) refuses if users exist, cascade soft deletes them, and reassign_to moves them to another organization. With dry_run the affected user count is reported and nothing is changed.
 //	@Tags			organizations
 //	@Accept			json
 //	@Produce		json
-//	@Param			id	path	string	true	"Organization ID (UUID)"
+//	@Param			id			path		string	true	"Organization ID (UUID)"
+//	@Param			policy		query		string	false	"What to do with the organization's users"	Enums(restrict, cascade, reassign)
+//	@Param			reassign_to	query		string	false	"Organization ID (UUID) to move users to, implies policy=reassign"
+//	@Param			dry_run		query		bool	false	"Report the affected users without deleting anything"
+//	@Success		200			{object}	models.DeleteOrganizationResponse
 //	@Success		204
 //	@Failure		400	{object}	models.ErrorResponse
 //	@Failure		404	{object}	models.ErrorResponse
+//	@Failure		409	{object}	models.ErrorResponse
+//	@Failure		422	{object}	models.ErrorResponse
 //	@Failure		500	{object}	models.ErrorResponse
 //	@Router			/organizations/{id} [delete]
 func (h *OrgHandler) DeleteOrganization(c *fiber.Ctx) error {
@@ -193,30 +299,88 @@ func (h *OrgHandler) DeleteOrganization(c *fiber.Ctx) error {
 		return c.Status(fiber.StatusBadRequest).JSON(models.ErrorResponse{Error: "invalid organization id"})
 	}
 
-	if err := h.orgService.DeleteOrganization(c.Context(), id); err != nil {
-		if err.Error() == "organization not found" {
+	opts := models.OrganizationDeleteOptions{
+		Policy: models.OrganizationDeletePolicy(c.Query("policy", string(models.DeletePolicyRestrict))),
+		DryRun: c.QueryBool("dry_run", false),
+	}
+	if reassignTo := c.Query("reassign_to"); reassignTo != "" {
+		targetID, err := uuid.Parse(reassignTo)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(models.ErrorResponse{Error: "invalid reassign_to organization id"})
+		}
+		if c.Query("policy") != "" && opts.Policy != models.DeletePolicyReassign {
+			return c.Status(fiber.StatusBadRequest).JSON(models.ErrorResponse{Error: "reassign_to can only be used with policy reassign"})
+		}
+		opts.Policy = models.DeletePolicyReassign
+		opts.ReassignTo = &targetID
+	}
+
+	switch opts.Policy {
+	case models.DeletePolicyRestrict, models.DeletePolicyCascade, models.DeletePolicyReassign:
+	default:
+		return c.Status(fiber.StatusBadRequest).JSON(models.ErrorResponse{Error: "policy must be one of restrict, cascade or reassign"})
+	}
+
+	affected, err := h.orgService.DeleteOrganization(c.Context(), id, opts)
+	if err != nil {
+		switch {
+		case err.Error() == "organization not found":
 			return c.Status(fiber.StatusNotFound).JSON(models.ErrorResponse{Error: err.Error()})
+		case errors.Is(err, services.ErrOrganizationHasUsers):
+			return c.Status(fiber.StatusConflict).JSON(models.ErrorResponse{Error: err.Error()})
+		case err.Error() == "target organization not found",
+			err.Error() == "reassign target is required",
+			err.Error() == "cannot reassign users to the deleted organization":
+			return c.Status(fiber.StatusUnprocessableEntity).JSON(models.ErrorResponse{Error: err.Error()})
 		}
 		return c.Status(fiber.StatusInternalServerError).JSON(models.ErrorResponse{Error: err.Error()})
 	}
 
-	return c.SendStatus(fiber.StatusNoContent)
+	if !opts.DryRun {
+		return c.SendStatus(fiber.StatusNoContent)
+	}
+
+	return c.JSON(models.DeleteOrganizationResponse{
+		OrganizationID: id,
+		Policy:         string(opts.Policy),
+		ReassignTo:     opts.ReassignTo,
+		AffectedUsers:  affected,
+		DryRun:         true,
+	})
 }
 
 // GetByIDs godoc
 //
 //	@Summary		Get organizations by multiple IDs (batch)
-//	@Description	Get multiple organizations by their UUIDs in a single request
+//	@Description	Get up to 100 organizations by their UUIDs in a single request. Organizations are returned in the order of the requested IDs. The IDs matching no organization are listed in the X-Missing-IDs header.
 //	@Tags			organizations
 //	@Accept			json
 //	@Produce		json
-//	@Param			request	body		models.GetOrganizationsByIDsRequest	true	"List of organization IDs"
-//	@Success		200		{array}		models.OrganizationResponse
-//	@Failure		400		{object}	models.ErrorResponse
-//	@Failure		422		{object}	models.ErrorResponse
-//	@Failure		500		{object}	models.ErrorResponse
+//	@Param			request			body		models.GetOrganizationsByIDsRequest	true	"List of organization IDs"
+//	@Param			expand			query		string								false	"Embed related resources"	Enums(users)
+//	@Param			fields			query		string								false	"Comma separated fields to return, for example id,name"
+//	@Param			users_limit		query		int									false	"Users embedded per organization with expand=users (default: 20, max: 100)"
+//	@Param			users_offset	query		int									false	"Users skipped per organization with expand=users"
+//	@Success		200				{array}		models.OrganizationResponse
+//	@Header			200				{string}	X-Missing-IDs	"Comma separated requested IDs that matched no organization"
+//	@Failure		400				{object}	models.ErrorResponse
+//	@Failure		422				{object}	models.ErrorResponse
+//	@Failure		500				{object}	models.ErrorResponse
 //	@Router			/organizations/batch [post]
 func (h *OrgHandler) GetByIDs(c *fiber.Ctx) error {
+	expand, err := parseExpand(c, "users")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(models.ErrorResponse{Error: err.Error()})
+	}
+	usersLimit, usersOffset, err := parseUsersPage(c)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(models.ErrorResponse{Error: err.Error()})
+	}
+	fields, err := parseFields(c, models.OrganizationResponse{})
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(models.ErrorResponse{Error: err.Error()})
+	}
+
 	req := new(models.GetOrganizationsByIDsRequest)
 	if err := c.BodyParser(req); err != nil {
 		return c.Status(fiber.StatusBadRequest).JSON(models.ErrorResponse{Error: "invalid request body"})
@@ -226,17 +390,23 @@ func (h *OrgHandler) GetByIDs(c *fiber.Ctx) error {
 		return c.Status(fiber.StatusUnprocessableEntity).JSON(models.ErrorResponse{Error: err.Error()})
 	}
 
-	orgs, err := h.orgService.GetByIDs(c.Context(), req.IDs)
+	orgs, missing, err := h.orgService.GetByIDs(fieldset.NewContext[models.Organization](c.Context(), fields), req.IDs)
 	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(models.ErrorResponse{Error: err.Error()})
 	}
+	if expand["users"] {
+		if err := h.orgService.LoadUsers(c.Context(), orgs, usersLimit, usersOffset); err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(models.ErrorResponse{Error: err.Error()})
+		}
+	}
 
 	res := make([]models.OrganizationResponse, len(orgs))
 	for i, org := range orgs {
 		res[i] = org.ToResponse()
 	}
 
-	return c.JSON(res)
+	setMissingIDs(c, missing)
+	return c.JSON(sparseAll(res, fields))
 }
 
 // SearchOrganizations godoc
@@ -246,11 +416,15 @@ func (h *OrgHandler) GetByIDs(c *fiber.Ctx) error {
 //	@Tags			organizations
 //	@Accept			json
 //	@Produce		json
-//	@Param			q		query		string	true	"Search query"
-//	@Param			limit	query		int		false	"Maximum number of results to return (default: 100)"
-//	@Success		200		{array}		models.OrganizationResponse
-//	@Failure		400		{object}	models.ErrorResponse
-//	@Failure		500		{object}	models.ErrorResponse
+//	@Param			q				query		string	true	"Search query"
+//	@Param			limit			query		int		false	"Maximum number of results to return (default: 100)"
+//	@Param			expand			query		string	false	"Embed related resources"	Enums(users)
+//	@Param			fields			query		string	false	"Comma separated fields to return, for example id,name"
+//	@Param			users_limit		query		int		false	"Users embedded per organization with expand=users (default: 20, max: 100)"
+//	@Param			users_offset	query		int		false	"Users skipped per organization with expand=users"
+//	@Success		200				{array}		models.OrganizationResponse
+//	@Failure		400				{object}	models.ErrorResponse
+//	@Failure		500				{object}	models.ErrorResponse
 //	@Router			/organizations/search [get]
 func (h *OrgHandler) SearchOrganizations(c *fiber.Ctx) error {
 	query := c.Query("q")
@@ -264,15 +438,102 @@ func (h *OrgHandler) SearchOrganizations(c *fiber.Ctx) error {
 		return c.Status(fiber.StatusBadRequest).JSON(models.ErrorResponse{Error: "limit must be non-negative"})
 	}
 
-	orgs, err := h.orgService.SearchOrganizations(c.Context(), query, limit)
+	expand, err := parseExpand(c, "users")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(models.ErrorResponse{Error: err.Error()})
+	}
+	usersLimit, usersOffset, err := parseUsersPage(c)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(models.ErrorResponse{Error: err.Error()})
+	}
+	fields, err := parseFields(c, models.OrganizationResponse{})
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(models.ErrorResponse{Error: err.Error()})
+	}
+
+	orgs, err := h.orgService.SearchOrganizations(fieldset.NewContext[models.Organization](c.Context(), fields), query, limit)
 	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(models.ErrorResponse{Error: err.Error()})
 	}
+	if expand["users"] {
+		if err := h.orgService.LoadUsers(c.Context(), orgs, usersLimit, usersOffset); err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(models.ErrorResponse{Error: err.Error()})
+		}
+	}
 
 	response := make([]models.OrganizationResponse, len(orgs))
 	for i, o := range orgs {
 		response[i] = o.ToResponse()
 	}
 
-	return c.JSON(response)
+	return c.JSON(sparseAll(response, fields))
+}
+
+// ImportOrganizations godoc
+//
+//	@Summary		Import organizations from a spreadsheet
+//	@Description	Upsert organizations by name from a CSV or XLSX file of at most 10000 rows. The header row names the columns, which are matched to the JSON fields of CreateOrganizationRequest (list cells are separated by ";"). Each row is validated on its own and rejected rows are reported; report=csv downloads them as a CSV error report.
+//	@Tags			organizations
+//	@Accept			multipart/form-data
+//	@Produce		json
+//	@Produce		text/csv
+//	@Param			file	formData	file	true	"CSV or XLSX file"
+//	@Param			mapping	formData	string	false	"JSON object mapping source column names to field names"
+//	@Param			dry_run	formData	bool	false	"Preview the outcome without writing anything"
+//	@Param			report	query		string	false	"Set to csv to download the rejected rows"	Enums(csv)
+//	@Success		200		{object}	models.ImportResponse
+//	@Failure		400		{object}	models.ErrorResponse
+//	@Failure		500		{object}	models.ErrorResponse
+//	@Router			/organizations/import [post]
+func (h *OrgHandler) ImportOrganizations(c *fiber.Ctx) error {
+	upload, err := parseImportUpload(c)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(models.ErrorResponse{Error: err.Error()})
+	}
+
+	rows := make([]models.ImportRowResult, len(upload.records))
+	var reqs []*models.CreateOrganizationRequest
+	var indexes []int
+	for i, rec := range upload.records {
+		rows[i] = models.ImportRowResult{Row: rec.Line, Action: "rejected"}
+
+		req := new(models.CreateOrganizationRequest)
+		if err := importer.Decode(rec, req); err != nil {
+			rows[i].Error = err.Error()
+			continue
+		}
+		if err := h.validate.Struct(req); err != nil {
+			rows[i].Error = err.Error()
+			continue
+		}
+		reqs = append(reqs, req)
+		indexes = append(indexes, i)
+	}
+
+	results := h.orgService.ImportOrganizations(c.Context(), reqs, upload.dryRun)
+	applyImportResults(rows, indexes, results)
+
+	return sendImportResult(c, upload, rows, "organizations")
+}
+
+// ExportOrganizations godoc
+//
+//	@Summary		Export organizations
+//	@Description	Stream all organizations matching the filters as a CSV or NDJSON download
+//	@Tags			organizations
+//	@Produce		text/csv
+//	@Produce		application/x-ndjson
+//	@Param			format	query		string	false	"Export format (default: csv)"	Enums(csv, ndjson)
+//	@Param			q		query		string	false	"Only organizations whose name contains this text"
+//	@Success		200		{array}		models.OrganizationResponse
+//	@Failure		400		{object}	models.ErrorResponse
+//	@Router			/organizations/export [get]
+func (h *OrgHandler) ExportOrganizations(c *fiber.Ctx) error {
+	filter := models.OrganizationFilter{Query: c.Query("q")}
+
+	return streamExport(c, "organizations", models.OrganizationResponse{}, func(ctx context.Context, write func(any) error) error {
+		return h.orgService.ExportOrganizations(ctx, filter, func(o *models.Organization) error {
+			return write(o.ToResponse())
+		})
+	})
 }
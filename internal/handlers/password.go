@@ -0,0 +1,18 @@
+package handlers
+
+import (
+	"github.com/hoshina-dev/custapi/internal/models"
+	"github.com/hoshina-dev/custapi/internal/password"
+)
+
+// passwordPolicyResponse lists the password policy rules a password failed
+func passwordPolicyResponse(err *password.PolicyError) models.PasswordPolicyErrorResponse {
+	violations := make([]models.PasswordViolationResponse, len(err.Violations))
+	for i, v := range err.Violations {
+		violations[i] = models.PasswordViolationResponse{Rule: v.Rule, Message: v.Message}
+	}
+	return models.PasswordPolicyErrorResponse{
+		Error:      "password does not meet the password policy",
+		Violations: violations,
+	}
+}
@@ -0,0 +1,233 @@
+package handlers
+
+import (
+	"errors"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/hoshina-dev/custapi/internal/models"
+	"github.com/hoshina-dev/custapi/internal/services"
+)
+
+// SSOHandler handles single sign-on login and configuration HTTP requests
+type SSOHandler struct {
+	ssoService services.SSOService
+	validate   *validator.Validate
+}
+
+// NewSSOHandler creates a new single sign-on handler
+func NewSSOHandler(ssoService services.SSOService) *SSOHandler {
+	return &SSOHandler{
+		ssoService: ssoService,
+		validate:   validator.New(),
+	}
+}
+
+// StartLogin godoc
+//
+//	@Summary		Start a single sign-on login
+//	@Description	Start an OpenID Connect login (authorization code flow with PKCE) with the provider of an organization, or the one handling the domain of an email address. Send the user to the returned URL; the provider sends them back to the configured SSO_REDIRECT_URL with the state and code to post to POST /auth/sso/callback.
+//	@Tags			auth
+//	@Accept			json
+//	@Produce		json
+//	@Param			request	body		models.SSOStartRequest	true	"Email address or organization"
+//	@Success		200		{object}	models.SSOStartResponse
+//	@Failure		400		{object}	models.ErrorResponse
+//	@Failure		404		{object}	models.ErrorResponse
+//	@Failure		422		{object}	models.ErrorResponse
+//	@Failure		500		{object}	models.ErrorResponse
+//	@Failure		502		{object}	models.ErrorResponse
+//	@Router			/auth/sso/start [post]
+func (h *SSOHandler) StartLogin(c *fiber.Ctx) error {
+	req := new(models.SSOStartRequest)
+	if err := c.BodyParser(req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(models.ErrorResponse{Error: "invalid json payload"})
+	}
+	if err := h.validate.Struct(req); err != nil {
+		return c.Status(fiber.StatusUnprocessableEntity).JSON(models.ErrorResponse{Error: err.Error()})
+	}
+
+	authURL, expiresAt, err := h.ssoService.StartLogin(c.Context(), req.Email, req.OrganizationID)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrSSONotConfigured):
+			return c.Status(fiber.StatusNotFound).JSON(models.ErrorResponse{Error: err.Error()})
+		case errors.Is(err, services.ErrSSOProviderUnavailable):
+			return c.Status(fiber.StatusBadGateway).JSON(models.ErrorResponse{Error: err.Error()})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(models.ErrorResponse{Error: err.Error()})
+	}
+
+	return c.JSON(models.SSOStartResponse{AuthorizationURL: authURL, ExpiresAt: expiresAt})
+}
+
+// CompleteLogin godoc
+//
+//	@Summary		Complete a single sign-on login
+//	@Description	Exchange the code the provider sent the user back with for a session. On the first login, the identity is linked to the user of the provider's organization with the verified email address it gives, or a user without password is created in that organization.
+//	@Description	Users with two-factor authentication get a 202 with a challenge to complete with POST /auth/login/2fa instead.
+//	@Tags			auth
+//	@Accept			json
+//	@Produce		json
+//	@Param			request	body		models.SSOCallbackRequest	true	"State and code from the redirect"
+//	@Success		200		{object}	models.LoginResponse
+//	@Success		202		{object}	models.TwoFactorChallengeResponse
+//	@Failure		400		{object}	models.ErrorResponse
+//	@Failure		401		{object}	models.ErrorResponse
+//	@Failure		403		{object}	models.ErrorResponse
+//	@Failure		409		{object}	models.ErrorResponse
+//	@Failure		422		{object}	models.ErrorResponse
+//	@Failure		500		{object}	models.ErrorResponse
+//	@Failure		502		{object}	models.ErrorResponse
+//	@Router			/auth/sso/callback [post]
+func (h *SSOHandler) CompleteLogin(c *fiber.Ctx) error {
+	req := new(models.SSOCallbackRequest)
+	if err := c.BodyParser(req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(models.ErrorResponse{Error: "invalid json payload"})
+	}
+	if err := h.validate.Struct(req); err != nil {
+		return c.Status(fiber.StatusUnprocessableEntity).JSON(models.ErrorResponse{Error: err.Error()})
+	}
+
+	session, token, err := h.ssoService.CompleteLogin(c.Context(), req.State, req.Code, c.Get(fiber.HeaderUserAgent))
+	if err != nil {
+		var twoFactorErr *services.TwoFactorRequiredError
+		switch {
+		case errors.As(err, &twoFactorErr):
+			return c.Status(fiber.StatusAccepted).JSON(models.TwoFactorChallengeResponse{
+				TwoFactorRequired: true,
+				ChallengeToken:    twoFactorErr.ChallengeToken,
+				ExpiresAt:         twoFactorErr.ExpiresAt,
+			})
+		case errors.Is(err, services.ErrInvalidSSOState):
+			return c.Status(fiber.StatusBadRequest).JSON(models.ErrorResponse{Error: err.Error()})
+		case errors.Is(err, services.ErrSSOLoginFailed):
+			return c.Status(fiber.StatusUnauthorized).JSON(models.ErrorResponse{Error: err.Error()})
+		case errors.Is(err, services.ErrSSOEmailNotAllowed), errors.Is(err, services.ErrSSOAccountDeleted):
+			return c.Status(fiber.StatusForbidden).JSON(models.ErrorResponse{Error: err.Error()})
+		case errors.Is(err, services.ErrSSOAccountConflict):
+			return c.Status(fiber.StatusConflict).JSON(models.ErrorResponse{Error: err.Error()})
+		case errors.Is(err, services.ErrSSOProviderUnavailable):
+			return c.Status(fiber.StatusBadGateway).JSON(models.ErrorResponse{Error: err.Error()})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(models.ErrorResponse{Error: err.Error()})
+	}
+
+	return c.JSON(models.LoginResponse{
+		Token:     token,
+		ExpiresAt: session.ExpiresAt,
+		User:      session.User.ToResponse(),
+	})
+}
+
+// GetProvider godoc
+//
+//	@Summary		Get the single sign-on provider of an organization
+//	@Description	Get the OpenID Connect provider the users of an organization log in with. The client secret is never returned. Admin only.
+//	@Tags			organizations
+//	@Accept			json
+//	@Produce		json
+//	@Param			id	path		string	true	"Organization ID (UUID)"
+//	@Success		200	{object}	models.SSOProviderResponse
+//	@Failure		400	{object}	models.ErrorResponse
+//	@Failure		401	{object}	models.ErrorResponse
+//	@Failure		403	{object}	models.ErrorResponse
+//	@Failure		404	{object}	models.ErrorResponse
+//	@Failure		500	{object}	models.ErrorResponse
+//	@Router			/organizations/{id}/sso [get]
+func (h *SSOHandler) GetProvider(c *fiber.Ctx) error {
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(models.ErrorResponse{Error: "invalid organization id"})
+	}
+
+	provider, err := h.ssoService.GetProvider(c.Context(), id)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(models.ErrorResponse{Error: err.Error()})
+	}
+	if provider == nil {
+		return c.Status(fiber.StatusNotFound).JSON(models.ErrorResponse{Error: "sso provider not found"})
+	}
+
+	return c.JSON(provider.ToResponse())
+}
+
+// SaveProvider godoc
+//
+//	@Summary		Configure the single sign-on provider of an organization
+//	@Description	Set the OpenID Connect provider the users of an organization log in with, replacing the one it had. The issuer's OpenID configuration is fetched to check it. Register the configured SSO_REDIRECT_URL as redirect URI with the provider. Admin only.
+//	@Tags			organizations
+//	@Accept			json
+//	@Produce		json
+//	@Param			id			path		string						true	"Organization ID (UUID)"
+//	@Param			provider	body		models.SSOProviderRequest	true	"Provider configuration"
+//	@Success		200			{object}	models.SSOProviderResponse
+//	@Failure		400			{object}	models.ErrorResponse
+//	@Failure		401			{object}	models.ErrorResponse
+//	@Failure		403			{object}	models.ErrorResponse
+//	@Failure		404			{object}	models.ErrorResponse
+//	@Failure		409			{object}	models.ErrorResponse
+//	@Failure		422			{object}	models.ErrorResponse
+//	@Failure		500			{object}	models.ErrorResponse
+//	@Router			/organizations/{id}/sso [put]
+func (h *SSOHandler) SaveProvider(c *fiber.Ctx) error {
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(models.ErrorResponse{Error: "invalid organization id"})
+	}
+
+	req := new(models.SSOProviderRequest)
+	if err := c.BodyParser(req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(models.ErrorResponse{Error: "invalid json payload"})
+	}
+	if err := h.validate.Struct(req); err != nil {
+		return c.Status(fiber.StatusUnprocessableEntity).JSON(models.ErrorResponse{Error: err.Error()})
+	}
+
+	provider, err := h.ssoService.SaveProvider(c.Context(), id, req)
+	if err != nil {
+		switch {
+		case err.Error() == "organization not found":
+			return c.Status(fiber.StatusNotFound).JSON(models.ErrorResponse{Error: err.Error()})
+		case errors.Is(err, services.ErrSSODomainTaken):
+			return c.Status(fiber.StatusConflict).JSON(models.ErrorResponse{Error: err.Error()})
+		case errors.Is(err, services.ErrInvalidSSOIssuer):
+			return c.Status(fiber.StatusUnprocessableEntity).JSON(models.ErrorResponse{Error: err.Error()})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(models.ErrorResponse{Error: err.Error()})
+	}
+
+	return c.JSON(provider.ToResponse())
+}
+
+// DeleteProvider godoc
+//
+//	@Summary		Remove the single sign-on provider of an organization
+//	@Description	Stop users of an organization from logging in with its OpenID Connect provider. Identities already linked are kept, for when a provider is configured again. Admin only.
+//	@Tags			organizations
+//	@Accept			json
+//	@Produce		json
+//	@Param			id	path	string	true	"Organization ID (UUID)"
+//	@Success		204
+//	@Failure		400	{object}	models.ErrorResponse
+//	@Failure		401	{object}	models.ErrorResponse
+//	@Failure		403	{object}	models.ErrorResponse
+//	@Failure		404	{object}	models.ErrorResponse
+//	@Failure		500	{object}	models.ErrorResponse
+//	@Router			/organizations/{id}/sso [delete]
+func (h *SSOHandler) DeleteProvider(c *fiber.Ctx) error {
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(models.ErrorResponse{Error: "invalid organization id"})
+	}
+
+	if err := h.ssoService.DeleteProvider(c.Context(), id); err != nil {
+		if err.Error() == "sso provider not found" {
+			return c.Status(fiber.StatusNotFound).JSON(models.ErrorResponse{Error: err.Error()})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(models.ErrorResponse{Error: err.Error()})
+	}
+
+	return c.SendStatus(fiber.StatusNoContent)
+}
@@ -1,10 +1,18 @@
 package handlers
 
 import (
+	"context"
+	"errors"
+	"time"
+
 	"github.com/go-playground/validator/v10"
 	"github.com/gofiber/fiber/v2"
 	"github.com/google/uuid"
+	"github.com/hoshina-dev/custapi/internal/auth"
+	"github.com/hoshina-dev/custapi/internal/fieldset"
+	"github.com/hoshina-dev/custapi/internal/importer"
 	"github.com/hoshina-dev/custapi/internal/models"
+	"github.com/hoshina-dev/custapi/internal/password"
 	"github.com/hoshina-dev/custapi/internal/services"
 )
 
@@ -25,15 +33,16 @@ func NewUserHandler(userService services.UserService) *UserHandler {
 // CreateUser godoc
 //
 //	@Summary		Create a new user
-//	@Description	Create a new user with email, name, organization, and optional details
+//	@Description	Create a new user with email, name, organization, and optional details. Only admins who logged in with a second factor can grant admin access with is_admin.
 //	@Tags			users
 //	@Accept			json
 //	@Produce		json
 //	@Param			user	body		models.CreateUserRequest	true	"User to create"
 //	@Success		201		{object}	models.UserResponse
 //	@Failure		400		{object}	models.ErrorResponse
+//	@Failure		403		{object}	models.ErrorResponse
 //	@Failure		404		{object}	models.ErrorResponse
-//	@Failure		422		{object}	models.ErrorResponse
+//	@Failure		422		{object}	models.PasswordPolicyErrorResponse
 //	@Failure		500		{object}	models.ErrorResponse
 //	@Router			/users [post]
 func (h *UserHandler) CreateUser(c *fiber.Ctx) error {
@@ -47,8 +56,19 @@ func (h *UserHandler) CreateUser(c *fiber.Ctx) error {
 		return c.Status(fiber.StatusUnprocessableEntity).JSON(models.ErrorResponse{Error: err.Error()})
 	}
 
+	if status, message := apiKeyScopeViolation(auth.FromContext(c.Context()), &req.OrganizationID, req.IsAdmin); status != 0 {
+		return c.Status(status).JSON(models.ErrorResponse{Error: message})
+	}
+
 	user, err := h.userService.CreateUser(c.Context(), req)
 	if err != nil {
+		var policyErr *password.PolicyError
+		if errors.As(err, &policyErr) {
+			return c.Status(fiber.StatusUnprocessableEntity).JSON(passwordPolicyResponse(policyErr))
+		}
+		if errors.Is(err, services.ErrAdminRequired) {
+			return c.Status(fiber.StatusForbidden).JSON(models.ErrorResponse{Error: err.Error()})
+		}
 		if err.Error() == "organization not found" {
 			return c.Status(fiber.StatusNotFound).JSON(models.ErrorResponse{Error: err.Error()})
 		}
@@ -65,34 +85,55 @@ func (h *UserHandler) CreateUser(c *fiber.Ctx) error {
 //	@Tags			users
 //	@Accept			json
 //	@Produce		json
-//	@Success		200	{array}		models.UserResponse
-//	@Failure		500	{object}	models.ErrorResponse
+//	@Param			expand	query		string	false	"Embed related resources"	Enums(organization)
+//	@Param			fields	query		string	false	"Comma separated fields to return, for example id,name"
+//	@Success		200		{array}		models.UserResponse
+//	@Failure		400		{object}	models.ErrorResponse
+//	@Failure		500		{object}	models.ErrorResponse
 //	@Router			/users [get]
 func (h *UserHandler) GetUsers(c *fiber.Ctx) error {
-	users, err := h.userService.ListUsers(c.Context())
+	expand, err := parseExpand(c, "organization")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(models.ErrorResponse{Error: err.Error()})
+	}
+	fields, err := parseFields(c, models.UserResponse{})
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(models.ErrorResponse{Error: err.Error()})
+	}
+
+	users, err := h.userService.ListUsers(fieldset.NewContext[models.User](c.Context(), fields))
 	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(models.ErrorResponse{Error: err.Error()})
 	}
+	if expand["organization"] {
+		if err := h.userService.LoadOrganizations(c.Context(), users); err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(models.ErrorResponse{Error: err.Error()})
+		}
+	}
 
 	response := make([]models.UserResponse, len(users))
 	for i, u := range users {
 		response[i] = u.ToResponse()
 	}
 
-	return c.JSON(response)
+	return c.JSON(sparseAll(response, fields))
 }
 
 // GetUser godoc
 //
 //	@Summary		Get a user by ID
-//	@Description	Get a single user by their ID
+//	@Description	Get a single user by their ID, or as it was at a point in time with as_of
 //	@Tags			users
 //	@Accept			json
 //	@Produce		json
-//	@Param			id	path		string	true	"User ID"
-//	@Success		200	{object}	models.UserResponse
-//	@Failure		404	{object}	models.ErrorResponse
-//	@Failure		500	{object}	models.ErrorResponse
+//	@Param			id		path		string	true	"User ID"
+//	@Param			expand	query		string	false	"Embed related resources"	Enums(organization)
+//	@Param			fields	query		string	false	"Comma separated fields to return, for example id,name"
+//	@Param			as_of	query		string	false	"Return the user as it was at this RFC 3339 time"
+//	@Success		200		{object}	models.UserResponse
+//	@Failure		400		{object}	models.ErrorResponse
+//	@Failure		404		{object}	models.ErrorResponse
+//	@Failure		500		{object}	models.ErrorResponse
 //	@Router			/users/{id} [get]
 func (h *UserHandler) GetUser(c *fiber.Ctx) error {
 	id, err := uuid.Parse(c.Params("id"))
@@ -100,7 +141,25 @@ func (h *UserHandler) GetUser(c *fiber.Ctx) error {
 		return c.Status(fiber.StatusBadRequest).JSON(models.ErrorResponse{Error: "invalid user id"})
 	}
 
-	user, err := h.userService.GetUser(c.Context(), id)
+	expand, err := parseExpand(c, "organization")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(models.ErrorResponse{Error: err.Error()})
+	}
+	fields, err := parseFields(c, models.UserResponse{})
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(models.ErrorResponse{Error: err.Error()})
+	}
+
+	var user *models.User
+	if raw := c.Query("as_of"); raw != "" {
+		asOf, err := time.Parse(time.RFC3339, raw)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(models.ErrorResponse{Error: "as_of must be an RFC 3339 timestamp"})
+		}
+		user, err = h.userService.GetUserAsOf(c.Context(), id, asOf)
+	} else {
+		user, err = h.userService.GetUser(fieldset.NewContext[models.User](c.Context(), fields), id)
+	}
 	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(models.ErrorResponse{Error: err.Error()})
 	}
@@ -109,7 +168,50 @@ func (h *UserHandler) GetUser(c *fiber.Ctx) error {
 		return c.Status(fiber.StatusNotFound).JSON(models.ErrorResponse{Error: "user not found"})
 	}
 
-	return c.JSON(user.ToResponse())
+	users := []models.User{*user}
+	if expand["organization"] {
+		if err := h.userService.LoadOrganizations(c.Context(), users); err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(models.ErrorResponse{Error: err.Error()})
+		}
+	}
+
+	return c.JSON(sparse(users[0].ToResponse(), fields))
+}
+
+// GetUserHistory godoc
+//
+//	@Summary		Get the change history of a user
+//	@Description	Get every known version of a user, oldest first, with the fields changed in each version. Deleted users keep their history. Passwords and second factors are not part of it.
+//	@Tags			users
+//	@Accept			json
+//	@Produce		json
+//	@Param			id	path		string	true	"User ID"
+//	@Success		200	{array}		models.UserVersionResponse
+//	@Failure		400	{object}	models.ErrorResponse
+//	@Failure		404	{object}	models.ErrorResponse
+//	@Failure		500	{object}	models.ErrorResponse
+//	@Router			/users/{id}/history [get]
+func (h *UserHandler) GetUserHistory(c *fiber.Ctx) error {
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(models.ErrorResponse{Error: "invalid user id"})
+	}
+
+	versions, err := h.userService.GetUserHistory(c.Context(), id)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(models.ErrorResponse{Error: err.Error()})
+	}
+
+	if versions == nil {
+		return c.Status(fiber.StatusNotFound).JSON(models.ErrorResponse{Error: "user not found"})
+	}
+
+	response := make([]models.UserVersionResponse, len(versions))
+	for i, v := range versions {
+		response[i] = v.ToResponse()
+	}
+
+	return c.JSON(response)
 }
 
 // GetUsersByOrganization godoc
@@ -120,7 +222,10 @@ func (h *UserHandler) GetUser(c *fiber.Ctx) error {
 //	@Accept			json
 //	@Produce		json
 //	@Param			org_id	path		string	true	"Organization ID"
+//	@Param			expand	query		string	false	"Embed related resources"	Enums(organization)
+//	@Param			fields	query		string	false	"Comma separated fields to return, for example id,name"
 //	@Success		200		{array}		models.UserResponse
+//	@Failure		400		{object}	models.ErrorResponse
 //	@Failure		404		{object}	models.ErrorResponse
 //	@Failure		500		{object}	models.ErrorResponse
 //	@Router			/users/organization/{org_id} [get]
@@ -130,26 +235,40 @@ func (h *UserHandler) GetUsersByOrganization(c *fiber.Ctx) error {
 		return c.Status(fiber.StatusBadRequest).JSON(models.ErrorResponse{Error: "invalid organization id"})
 	}
 
-	users, err := h.userService.ListUsersByOrganization(c.Context(), orgID)
+	expand, err := parseExpand(c, "organization")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(models.ErrorResponse{Error: err.Error()})
+	}
+	fields, err := parseFields(c, models.UserResponse{})
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(models.ErrorResponse{Error: err.Error()})
+	}
+
+	users, err := h.userService.ListUsersByOrganization(fieldset.NewContext[models.User](c.Context(), fields), orgID)
 	if err != nil {
 		if err.Error() == "organization not found" {
 			return c.Status(fiber.StatusNotFound).JSON(models.ErrorResponse{Error: err.Error()})
 		}
 		return c.Status(fiber.StatusInternalServerError).JSON(models.ErrorResponse{Error: err.Error()})
 	}
+	if expand["organization"] {
+		if err := h.userService.LoadOrganizations(c.Context(), users); err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(models.ErrorResponse{Error: err.Error()})
+		}
+	}
 
 	response := make([]models.UserResponse, len(users))
 	for i, u := range users {
 		response[i] = u.ToResponse()
 	}
 
-	return c.JSON(response)
+	return c.JSON(sparseAll(response, fields))
 }
 
 // UpdateUser godoc
 //
 //	@Summary		Update a user
-//	@Description	Update an existing user by ID (partial updates supported)
+//	@Description	Update an existing user by ID (partial updates supported). Users can update themselves; admins who logged in with a second factor can update anyone. A new email is kept as pending_email until confirmed through the verification link sent to it. A new password ends every session of the user. Only admins who logged in with a second factor can change is_admin.
 //	@Tags			users
 //	@Accept			json
 //	@Produce		json
@@ -157,8 +276,11 @@ func (h *UserHandler) GetUsersByOrganization(c *fiber.Ctx) error {
 //	@Param			user	body		models.UpdateUserRequest	true	"Fields to update"
 //	@Success		200		{object}	models.UserResponse
 //	@Failure		400		{object}	models.ErrorResponse
+//	@Failure		401		{object}	models.ErrorResponse
+//	@Failure		403		{object}	models.ErrorResponse
 //	@Failure		404		{object}	models.ErrorResponse
-//	@Failure		422		{object}	models.ErrorResponse
+//	@Failure		409		{object}	models.ErrorResponse
+//	@Failure		422		{object}	models.PasswordPolicyErrorResponse
 //	@Failure		500		{object}	models.ErrorResponse
 //	@Router			/users/{id} [patch]
 func (h *UserHandler) UpdateUser(c *fiber.Ctx) error {
@@ -176,8 +298,28 @@ func (h *UserHandler) UpdateUser(c *fiber.Ctx) error {
 		return c.Status(fiber.StatusUnprocessableEntity).JSON(models.ErrorResponse{Error: err.Error()})
 	}
 
+	if status, message := apiKeyScopeViolation(auth.FromContext(c.Context()), req.OrganizationID, req.IsAdmin); status != 0 {
+		return c.Status(status).JSON(models.ErrorResponse{Error: message})
+	}
+
 	user, err := h.userService.Update(c.Context(), id, req)
 	if err != nil {
+		var policyErr *password.PolicyError
+		if errors.As(err, &policyErr) {
+			return c.Status(fiber.StatusUnprocessableEntity).JSON(passwordPolicyResponse(policyErr))
+		}
+		if errors.Is(err, services.ErrEmailTaken) {
+			return c.Status(fiber.StatusConflict).JSON(models.ErrorResponse{Error: err.Error()})
+		}
+		if errors.Is(err, services.ErrAuthenticationRequired) {
+			return c.Status(fiber.StatusUnauthorized).JSON(models.ErrorResponse{Error: err.Error()})
+		}
+		if errors.Is(err, services.ErrAdminRequired) || errors.Is(err, services.ErrUserAccessDenied) {
+			return c.Status(fiber.StatusForbidden).JSON(models.ErrorResponse{Error: err.Error()})
+		}
+		if err.Error() == "organization not found" {
+			return c.Status(fiber.StatusNotFound).JSON(models.ErrorResponse{Error: err.Error()})
+		}
 		return c.Status(fiber.StatusInternalServerError).JSON(models.ErrorResponse{Error: err.Error()})
 	}
 
@@ -191,13 +333,15 @@ func (h *UserHandler) UpdateUser(c *fiber.Ctx) error {
 // DeleteUser godoc
 //
 //	@Summary		Delete a user
-//	@Description	Soft delete a user by ID
+//	@Description	Soft delete a user by ID. Users can delete themselves; admins who logged in with a second factor can delete anyone.
 //	@Tags			users
 //	@Accept			json
 //	@Produce		json
 //	@Param			id	path	string	true	"User ID (UUID)"
 //	@Success		204
 //	@Failure		400	{object}	models.ErrorResponse
+//	@Failure		401	{object}	models.ErrorResponse
+//	@Failure		403	{object}	models.ErrorResponse
 //	@Failure		404	{object}	models.ErrorResponse
 //	@Failure		500	{object}	models.ErrorResponse
 //	@Router			/users/{id} [delete]
@@ -208,6 +352,12 @@ func (h *UserHandler) DeleteUser(c *fiber.Ctx) error {
 	}
 
 	if err := h.userService.Delete(c.Context(), id); err != nil {
+		if errors.Is(err, services.ErrAuthenticationRequired) {
+			return c.Status(fiber.StatusUnauthorized).JSON(models.ErrorResponse{Error: err.Error()})
+		}
+		if errors.Is(err, services.ErrUserAccessDenied) {
+			return c.Status(fiber.StatusForbidden).JSON(models.ErrorResponse{Error: err.Error()})
+		}
 		if err.Error() == "user not found" {
 			return c.Status(fiber.StatusNotFound).JSON(models.ErrorResponse{Error: err.Error()})
 		}
@@ -226,6 +376,8 @@ func (h *UserHandler) DeleteUser(c *fiber.Ctx) error {
 //	@Produce		json
 //	@Param			q		query		string	true	"Search query"
 //	@Param			limit	query		int		false	"Maximum number of results to return (default: 100)"
+//	@Param			expand	query		string	false	"Embed related resources"	Enums(organization)
+//	@Param			fields	query		string	false	"Comma separated fields to return, for example id,name"
 //	@Success		200		{array}		models.UserResponse
 //	@Failure		400		{object}	models.ErrorResponse
 //	@Failure		500		{object}	models.ErrorResponse
@@ -242,15 +394,310 @@ func (h *UserHandler) SearchUsers(c *fiber.Ctx) error {
 		return c.Status(fiber.StatusBadRequest).JSON(models.ErrorResponse{Error: "limit must be non-negative"})
 	}
 
-	users, err := h.userService.SearchUsers(c.Context(), query, limit)
+	expand, err := parseExpand(c, "organization")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(models.ErrorResponse{Error: err.Error()})
+	}
+	fields, err := parseFields(c, models.UserResponse{})
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(models.ErrorResponse{Error: err.Error()})
+	}
+
+	users, err := h.userService.SearchUsers(fieldset.NewContext[models.User](c.Context(), fields), query, limit)
 	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(models.ErrorResponse{Error: err.Error()})
 	}
+	if expand["organization"] {
+		if err := h.userService.LoadOrganizations(c.Context(), users); err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(models.ErrorResponse{Error: err.Error()})
+		}
+	}
 
 	response := make([]models.UserResponse, len(users))
 	for i, u := range users {
 		response[i] = u.ToResponse()
 	}
 
-	return c.JSON(response)
+	return c.JSON(sparseAll(response, fields))
+}
+
+// GetByIDs godoc
+//
+//	@Summary		Get users by multiple IDs (batch)
+//	@Description	Get up to 100 users by their UUIDs in a single request. Users are returned in the order of the requested IDs. The IDs matching no user are listed in the X-Missing-IDs header.
+//	@Tags			users
+//	@Accept			json
+//	@Produce		json
+//	@Param			request	body		models.GetUsersByIDsRequest	true	"List of user IDs"
+//	@Param			expand	query		string						false	"Embed related resources"	Enums(organization)
+//	@Param			fields	query		string						false	"Comma separated fields to return, for example id,name"
+//	@Success		200		{array}		models.UserResponse
+//	@Header			200		{string}	X-Missing-IDs	"Comma separated requested IDs that matched no user"
+//	@Failure		400		{object}	models.ErrorResponse
+//	@Failure		422		{object}	models.ErrorResponse
+//	@Failure		500		{object}	models.ErrorResponse
+//	@Router			/users/batch [post]
+func (h *UserHandler) GetByIDs(c *fiber.Ctx) error {
+	expand, err := parseExpand(c, "organization")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(models.ErrorResponse{Error: err.Error()})
+	}
+	fields, err := parseFields(c, models.UserResponse{})
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(models.ErrorResponse{Error: err.Error()})
+	}
+
+	req := new(models.GetUsersByIDsRequest)
+	if err := c.BodyParser(req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(models.ErrorResponse{Error: "invalid request body"})
+	}
+
+	if err := h.validate.Struct(req); err != nil {
+		return c.Status(fiber.StatusUnprocessableEntity).JSON(models.ErrorResponse{Error: err.Error()})
+	}
+
+	users, missing, err := h.userService.GetByIDs(fieldset.NewContext[models.User](c.Context(), fields), req.IDs)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(models.ErrorResponse{Error: err.Error()})
+	}
+	if expand["organization"] {
+		if err := h.userService.LoadOrganizations(c.Context(), users); err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(models.ErrorResponse{Error: err.Error()})
+		}
+	}
+
+	response := make([]models.UserResponse, len(users))
+	for i, u := range users {
+		response[i] = u.ToResponse()
+	}
+
+	setMissingIDs(c, missing)
+	return c.JSON(sparseAll(response, fields))
+}
+
+// BulkUsers godoc
+//
+//	@Summary		Create, update and delete users in bulk
+//	@Description	Run up to 500 user operations in one request. Each operation is validated on its own. In atomic mode (default) all operations succeed or none are applied; in best_effort mode each operation succeeds or fails independently. Creates are inserted first with a single batched insert.
+//	@Tags			users
+//	@Accept			json
+//	@Produce		json
+//	@Param			request	body		models.BulkUserRequest	true	"Operations to run"
+//	@Success		200		{object}	models.BulkUserResponse
+//	@Success		207		{object}	models.BulkUserResponse
+//	@Failure		400		{object}	models.ErrorResponse
+//	@Failure		404		{object}	models.BulkUserResponse
+//	@Failure		422		{object}	models.BulkUserResponse
+//	@Failure		500		{object}	models.BulkUserResponse
+//	@Router			/users/bulk [post]
+func (h *UserHandler) BulkUsers(c *fiber.Ctx) error {
+	req := new(models.BulkUserRequest)
+	if err := c.BodyParser(req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(models.ErrorResponse{Error: "invalid json payload"})
+	}
+
+	if err := h.validate.Struct(req); err != nil {
+		return c.Status(fiber.StatusUnprocessableEntity).JSON(models.ErrorResponse{Error: err.Error()})
+	}
+
+	if req.Mode == "" {
+		req.Mode = "atomic"
+	}
+	atomic := req.Mode == "atomic"
+
+	res := models.BulkUserResponse{Mode: req.Mode, Results: make([]models.BulkUserItemResult, len(req.Operations))}
+	var ops []models.BulkUserOperation
+	var indexes []int
+	for i, op := range req.Operations {
+		res.Results[i] = models.BulkUserItemResult{Index: i, Op: op.Op, ID: op.ID}
+		if err := h.validateBulkOperation(&op); err != nil {
+			res.Results[i].Status = fiber.StatusUnprocessableEntity
+			res.Results[i].Error = err.Error()
+			continue
+		}
+		ops = append(ops, op)
+		indexes = append(indexes, i)
+	}
+
+	status := fiber.StatusOK
+	if len(ops) < len(req.Operations) && atomic {
+		for i := range res.Results {
+			if res.Results[i].Status == 0 {
+				res.Results[i].Status = fiber.StatusFailedDependency
+				res.Results[i].Error = services.ErrBulkAborted.Error()
+			}
+		}
+		status = fiber.StatusUnprocessableEntity
+	} else {
+		results, err := h.userService.BulkUsers(c.Context(), ops, atomic)
+		for j, r := range results {
+			item := &res.Results[indexes[j]]
+			item.Status = bulkResultStatus(item.Op, r.Err)
+			if r.Err != nil {
+				item.Error = r.Err.Error()
+				continue
+			}
+			item.ID = &r.ID
+			if r.User != nil {
+				user := r.User.ToResponse()
+				item.User = &user
+			}
+		}
+		if err != nil {
+			status = bulkResultStatus("", err)
+		}
+	}
+
+	for _, item := range res.Results {
+		if item.Error == "" {
+			res.Succeeded++
+		} else {
+			res.Failed++
+		}
+	}
+	if status == fiber.StatusOK && res.Failed > 0 {
+		status = fiber.StatusMultiStatus
+	}
+
+	return c.Status(status).JSON(res)
+}
+
+// validateBulkOperation validates a single bulk operation and the payload it carries
+func (h *UserHandler) validateBulkOperation(op *models.BulkUserOperation) error {
+	if err := h.validate.Struct(op); err != nil {
+		return err
+	}
+
+	switch op.Op {
+	case "create":
+		if op.Create == nil {
+			return errors.New("create payload is required")
+		}
+		return h.validate.Struct(op.Create)
+	case "update":
+		if op.ID == nil {
+			return errors.New("id is required")
+		}
+		if op.Update == nil {
+			return errors.New("update payload is required")
+		}
+		return h.validate.Struct(op.Update)
+	default:
+		if op.ID == nil {
+			return errors.New("id is required")
+		}
+		return nil
+	}
+}
+
+// bulkResultStatus maps the outcome of a bulk operation to an HTTP status code
+func bulkResultStatus(op string, err error) int {
+	switch {
+	case err == nil && op == "create":
+		return fiber.StatusCreated
+	case err == nil && op == "delete":
+		return fiber.StatusNoContent
+	case err == nil:
+		return fiber.StatusOK
+	case errors.Is(err, services.ErrBulkAborted):
+		return fiber.StatusFailedDependency
+	case errors.Is(err, services.ErrAuthenticationRequired):
+		return fiber.StatusUnauthorized
+	case errors.Is(err, services.ErrAdminRequired), errors.Is(err, services.ErrUserAccessDenied):
+		return fiber.StatusForbidden
+	case err.Error() == "user not found", err.Error() == "organization not found":
+		return fiber.StatusNotFound
+	default:
+		return fiber.StatusInternalServerError
+	}
+}
+
+// ImportUsers godoc
+//
+//	@Summary		Import users from a spreadsheet
+//	@Description	Upsert users by email from a CSV or XLSX file of at most 10000 rows. The header row names the columns, which are matched to the JSON fields of CreateUserRequest (list cells are separated by ";"). Rows matching an existing user of the same organization only update its profile; the password, is_admin and organization of existing users are never changed by an import. Each row is validated on its own and rejected rows are reported; report=csv downloads them as a CSV error report.
+//	@Tags			users
+//	@Accept			multipart/form-data
+//	@Produce		json
+//	@Produce		text/csv
+//	@Param			file	formData	file	true	"CSV or XLSX file"
+//	@Param			mapping	formData	string	false	"JSON object mapping source column names to field names"
+//	@Param			dry_run	formData	bool	false	"Preview the outcome without writing anything"
+//	@Param			report	query		string	false	"Set to csv to download the rejected rows"	Enums(csv)
+//	@Success		200		{object}	models.ImportResponse
+//	@Failure		400		{object}	models.ErrorResponse
+//	@Failure		500		{object}	models.ErrorResponse
+//	@Router			/users/import [post]
+func (h *UserHandler) ImportUsers(c *fiber.Ctx) error {
+	upload, err := parseImportUpload(c)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(models.ErrorResponse{Error: err.Error()})
+	}
+
+	rows := make([]models.ImportRowResult, len(upload.records))
+	var reqs []*models.CreateUserRequest
+	var indexes []int
+	for i, rec := range upload.records {
+		rows[i] = models.ImportRowResult{Row: rec.Line, Action: "rejected"}
+
+		req := new(models.CreateUserRequest)
+		if err := importer.Decode(rec, req); err != nil {
+			rows[i].Error = err.Error()
+			continue
+		}
+		if err := h.validate.Struct(req); err != nil {
+			rows[i].Error = err.Error()
+			continue
+		}
+		reqs = append(reqs, req)
+		indexes = append(indexes, i)
+	}
+
+	results := h.userService.ImportUsers(c.Context(), reqs, upload.dryRun)
+	applyImportResults(rows, indexes, results)
+
+	return sendImportResult(c, upload, rows, "users")
+}
+
+// ExportUsers godoc
+//
+//	@Summary		Export users
+//	@Description	Stream all users matching the filters as a CSV or NDJSON download. Password hashes are never included.
+//	@Tags			users
+//	@Produce		text/csv
+//	@Produce		application/x-ndjson
+//	@Param			format			query		string	false	"Export format (default: csv)"	Enums(csv, ndjson)
+//	@Param			q				query		string	false	"Only users whose name or email contains this text"
+//	@Param			organization_id	query		string	false	"Only users of this organization (UUID)"
+//	@Success		200				{array}		models.UserResponse
+//	@Failure		400				{object}	models.ErrorResponse
+//	@Router			/users/export [get]
+func (h *UserHandler) ExportUsers(c *fiber.Ctx) error {
+	filter := models.UserFilter{Query: c.Query("q")}
+	if orgID := c.Query("organization_id"); orgID != "" {
+		id, err := uuid.Parse(orgID)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(models.ErrorResponse{Error: "invalid organization id"})
+		}
+		filter.OrganizationID = &id
+	}
+
+	return streamExport(c, "users", models.UserResponse{}, func(ctx context.Context, write func(any) error) error {
+		return h.userService.ExportUsers(ctx, filter, func(u *models.User) error {
+			return write(u.ToResponse())
+		})
+	})
+}
+
+// apiKeyScopeViolation checks that a user created or updated with an API key stays in
+// the organization of the key and is not made an admin. It returns the status and
+// message to reject the request with, or a zero status if the request is allowed.
+func apiKeyScopeViolation(actor *auth.Actor, orgID *uuid.UUID, isAdmin *bool) (int, string) {
+	if actor.APIKeyID != nil && orgID != nil && !actor.InOrganizationScope(*orgID) {
+		return fiber.StatusNotFound, "organization not found"
+	}
+	if actor.APIKeyID != nil && isAdmin != nil {
+		return fiber.StatusForbidden, "API keys cannot change admin access"
+	}
+	return 0, ""
 }
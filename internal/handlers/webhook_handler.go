@@ -0,0 +1,204 @@
+package handlers
+
+import (
+	"errors"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/hoshina-dev/custapi/internal/models"
+	"github.com/hoshina-dev/custapi/internal/services"
+)
+
+// WebhookHandler handles webhook subscription HTTP requests
+type WebhookHandler struct {
+	webhookService services.WebhookService
+	validate       *validator.Validate
+}
+
+// NewWebhookHandler creates a new webhook handler
+func NewWebhookHandler(webhookService services.WebhookService) *WebhookHandler {
+	return &WebhookHandler{
+		webhookService: webhookService,
+		validate:       validator.New(),
+	}
+}
+
+// CreateWebhook godoc
+//
+//	@Summary		Create a webhook subscription
+//	@Description	Subscribe a URL to user and organization events. Events are filtered by exact type (user.created), by entity (organization.*) or all (*). Event types are user and organization followed by created, updated or deleted; other filters are rejected.
+//	@Description	Deliveries are signed with X-Custapi-Signature: sha256=HMAC-SHA256(secret, X-Custapi-Timestamp + "." + body). A secret is generated and returned once when none is given. Admin only.
+//	@Tags			webhooks
+//	@Accept			json
+//	@Produce		json
+//	@Param			webhook	body		models.CreateWebhookRequest	true	"Webhook to create"
+//	@Success		201		{object}	models.WebhookResponse
+//	@Failure		400		{object}	models.ErrorResponse
+//	@Failure		401		{object}	models.ErrorResponse
+//	@Failure		403		{object}	models.ErrorResponse
+//	@Failure		422		{object}	models.ErrorResponse
+//	@Failure		500		{object}	models.ErrorResponse
+//	@Router			/webhooks [post]
+func (h *WebhookHandler) CreateWebhook(c *fiber.Ctx) error {
+	req := new(models.CreateWebhookRequest)
+
+	if err := c.BodyParser(req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(models.ErrorResponse{Error: "invalid json payload"})
+	}
+
+	if err := h.validate.Struct(req); err != nil {
+		return c.Status(fiber.StatusUnprocessableEntity).JSON(models.ErrorResponse{Error: err.Error()})
+	}
+
+	sub, err := h.webhookService.CreateWebhook(c.Context(), req)
+	if err != nil {
+		if errors.Is(err, services.ErrUnknownEventFilter) {
+			return c.Status(fiber.StatusBadRequest).JSON(models.ErrorResponse{Error: err.Error()})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(models.ErrorResponse{Error: "failed to create webhook"})
+	}
+
+	response := sub.ToResponse()
+	if req.Secret == nil {
+		response.Secret = sub.Secret
+	}
+	return c.Status(fiber.StatusCreated).JSON(response)
+}
+
+// GetWebhooks godoc
+//
+//	@Summary		List webhook subscriptions
+//	@Description	List all webhook subscriptions. Secrets are never returned. Admin only.
+//	@Tags			webhooks
+//	@Accept			json
+//	@Produce		json
+//	@Success		200	{array}		models.WebhookResponse
+//	@Failure		401	{object}	models.ErrorResponse
+//	@Failure		403	{object}	models.ErrorResponse
+//	@Failure		500	{object}	models.ErrorResponse
+//	@Router			/webhooks [get]
+func (h *WebhookHandler) GetWebhooks(c *fiber.Ctx) error {
+	subs, err := h.webhookService.ListWebhooks(c.Context())
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(models.ErrorResponse{Error: err.Error()})
+	}
+
+	response := make([]models.WebhookResponse, len(subs))
+	for i, s := range subs {
+		response[i] = s.ToResponse()
+	}
+
+	return c.JSON(response)
+}
+
+// DeleteWebhook godoc
+//
+//	@Summary		Delete a webhook subscription
+//	@Description	Stop sending events to a webhook. Deliveries already queued are still attempted once. Admin only.
+//	@Tags			webhooks
+//	@Accept			json
+//	@Produce		json
+//	@Param			id	path	string	true	"Webhook ID (UUID)"
+//	@Success		204
+//	@Failure		400	{object}	models.ErrorResponse
+//	@Failure		401	{object}	models.ErrorResponse
+//	@Failure		403	{object}	models.ErrorResponse
+//	@Failure		404	{object}	models.ErrorResponse
+//	@Failure		500	{object}	models.ErrorResponse
+//	@Router			/webhooks/{id} [delete]
+func (h *WebhookHandler) DeleteWebhook(c *fiber.Ctx) error {
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(models.ErrorResponse{Error: "invalid webhook id"})
+	}
+
+	if err := h.webhookService.DeleteWebhook(c.Context(), id); err != nil {
+		if err.Error() == "webhook not found" {
+			return c.Status(fiber.StatusNotFound).JSON(models.ErrorResponse{Error: err.Error()})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(models.ErrorResponse{Error: err.Error()})
+	}
+
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// GetWebhookDeliveries godoc
+//
+//	@Summary		List webhook deliveries
+//	@Description	List the delivery log of a webhook, newest first, with attempts, last error and response status. Admin only.
+//	@Tags			webhooks
+//	@Accept			json
+//	@Produce		json
+//	@Param			id		path		string	true	"Webhook ID (UUID)"
+//	@Param			status	query		string	false	"Delivery status"	Enums(pending, succeeded, dead)
+//	@Param			limit	query		int		false	"Maximum number of results to return (default: 100)"
+//	@Success		200		{array}		models.WebhookDeliveryResponse
+//	@Failure		400		{object}	models.ErrorResponse
+//	@Failure		401		{object}	models.ErrorResponse
+//	@Failure		403		{object}	models.ErrorResponse
+//	@Failure		404		{object}	models.ErrorResponse
+//	@Failure		500		{object}	models.ErrorResponse
+//	@Router			/webhooks/{id}/deliveries [get]
+func (h *WebhookHandler) GetWebhookDeliveries(c *fiber.Ctx) error {
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(models.ErrorResponse{Error: "invalid webhook id"})
+	}
+
+	limit := c.QueryInt("limit", 100)
+	if limit < 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(models.ErrorResponse{Error: "limit must be non-negative"})
+	}
+
+	deliveries, err := h.webhookService.ListDeliveries(c.Context(), id, c.Query("status"), limit)
+	if err != nil {
+		if err.Error() == "webhook not found" {
+			return c.Status(fiber.StatusNotFound).JSON(models.ErrorResponse{Error: err.Error()})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(models.ErrorResponse{Error: err.Error()})
+	}
+
+	response := make([]models.WebhookDeliveryResponse, len(deliveries))
+	for i, d := range deliveries {
+		response[i] = d.ToResponse()
+	}
+
+	return c.JSON(response)
+}
+
+// RetryWebhookDelivery godoc
+//
+//	@Summary		Retry a webhook delivery
+//	@Description	Queue a delivery for immediate delivery again, typically one in the dead state, with a fresh retry budget. Admin only.
+//	@Tags			webhooks
+//	@Accept			json
+//	@Produce		json
+//	@Param			id			path	string	true	"Webhook ID (UUID)"
+//	@Param			delivery_id	path	string	true	"Delivery ID (UUID)"
+//	@Success		202
+//	@Failure		400	{object}	models.ErrorResponse
+//	@Failure		401	{object}	models.ErrorResponse
+//	@Failure		403	{object}	models.ErrorResponse
+//	@Failure		404	{object}	models.ErrorResponse
+//	@Failure		500	{object}	models.ErrorResponse
+//	@Router			/webhooks/{id}/deliveries/{delivery_id}/retry [post]
+func (h *WebhookHandler) RetryWebhookDelivery(c *fiber.Ctx) error {
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(models.ErrorResponse{Error: "invalid webhook id"})
+	}
+	deliveryID, err := uuid.Parse(c.Params("delivery_id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(models.ErrorResponse{Error: "invalid delivery id"})
+	}
+
+	if err := h.webhookService.RetryDelivery(c.Context(), id, deliveryID); err != nil {
+		if err.Error() == "delivery not found" {
+			return c.Status(fiber.StatusNotFound).JSON(models.ErrorResponse{Error: err.Error()})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(models.ErrorResponse{Error: err.Error()})
+	}
+
+	return c.SendStatus(fiber.StatusAccepted)
+}
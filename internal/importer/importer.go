@@ -0,0 +1,151 @@
+package importer
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+)
+
+// Format is a supported spreadsheet file format
+type Format string
+
+const (
+	FormatCSV  Format = "csv"
+	FormatXLSX Format = "xlsx"
+)
+
+// MaxRows and MaxColumns bound the size of imported files. MaxRows counts the data rows,
+// without the header.
+const (
+	MaxRows    = 10000
+	MaxColumns = 256
+)
+
+var (
+	errTooManyRows    = fmt.Errorf("file has more than %d data rows", MaxRows)
+	errTooManyColumns = fmt.Errorf("file has more than %d columns", MaxColumns)
+)
+
+// Record is a single data row of an imported file, keyed by mapped column name
+type Record struct {
+	// Line is the 1-based row number in the source file, the header being row 1
+	Line   int
+	Values map[string]string
+	// Raw holds the cells as they appear in the source file
+	Raw []string
+}
+
+// DetectFormat guesses the file format from a file name
+func DetectFormat(filename string) (Format, error) {
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case ".csv":
+		return FormatCSV, nil
+	case ".xlsx":
+		return FormatXLSX, nil
+	default:
+		return "", fmt.Errorf("unsupported file type %q, expected .csv or .xlsx", filepath.Ext(filename))
+	}
+}
+
+// Read parses a CSV or XLSX file and returns its header and data rows. The mapping
+// renames source columns to field names; unmapped columns keep their normalized name.
+func Read(r io.Reader, format Format, mapping map[string]string) ([]string, []Record, error) {
+	var rows [][]string
+	var err error
+	switch format {
+	case FormatCSV:
+		rows, err = readCSV(r)
+	case FormatXLSX:
+		var data []byte
+		if data, err = io.ReadAll(r); err == nil {
+			rows, err = readXLSX(bytes.NewReader(data), int64(len(data)))
+		}
+	default:
+		err = fmt.Errorf("unsupported format %q", format)
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(rows) == 0 {
+		return nil, nil, errors.New("file has no header row")
+	}
+
+	normalized := make(map[string]string, len(mapping))
+	for from, to := range mapping {
+		normalized[normalize(from)] = normalize(to)
+	}
+
+	header := make([]string, len(rows[0]))
+	for i, name := range rows[0] {
+		header[i] = normalize(name)
+		if to, ok := normalized[header[i]]; ok {
+			header[i] = to
+		}
+	}
+
+	records := make([]Record, 0, len(rows)-1)
+	for i, row := range rows[1:] {
+		if isBlank(row) {
+			continue
+		}
+		rec := Record{Line: i + 2, Values: make(map[string]string, len(header)), Raw: row}
+		for j, name := range header {
+			if name != "" && j < len(row) {
+				rec.Values[name] = strings.TrimSpace(row[j])
+			}
+		}
+		records = append(records, rec)
+	}
+
+	return rows[0], records, nil
+}
+
+func readCSV(r io.Reader) ([][]string, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	var rows [][]string
+	for {
+		row, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid csv: %w", err)
+		}
+		if len(rows) > MaxRows {
+			return nil, errTooManyRows
+		}
+		if len(row) > MaxColumns {
+			return nil, errTooManyColumns
+		}
+		rows = append(rows, row)
+	}
+	if len(rows) > 0 && len(rows[0]) > 0 {
+		// Strip the UTF-8 byte order mark spreadsheet programs like to add
+		rows[0][0] = strings.TrimPrefix(rows[0][0], "\ufeff")
+	}
+	return rows, nil
+}
+
+// normalize turns a column name like "Phone Number" into "phone_number"
+func normalize(name string) string {
+	name = strings.ToLower(strings.TrimSpace(name))
+	return strings.Join(strings.FieldsFunc(name, func(r rune) bool {
+		return r == ' ' || r == '-' || r == '_'
+	}), "_")
+}
+
+func isBlank(row []string) bool {
+	for _, v := range row {
+		if strings.TrimSpace(v) != "" {
+			return false
+		}
+	}
+	return true
+}
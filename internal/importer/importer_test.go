@@ -0,0 +1,225 @@
+package importer
+
+import (
+	"archive/zip"
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// newXLSX builds a minimal workbook whose first sheet has the given sheetData XML
+func newXLSX(t *testing.T, sheetData string, sharedStrings ...string) []byte {
+	t.Helper()
+
+	var shared strings.Builder
+	for _, s := range sharedStrings {
+		fmt.Fprintf(&shared, "<si><t>%s</t></si>", s)
+	}
+	parts := map[string]string{
+		"xl/workbook.xml": `<workbook xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">
+			<sheets><sheet name="Sheet1" sheetId="1" r:id="rId1"/></sheets></workbook>`,
+		"xl/_rels/workbook.xml.rels": `<Relationships>
+			<Relationship Id="rId1" Target="worksheets/sheet1.xml"/></Relationships>`,
+		"xl/sharedStrings.xml":     "<sst>" + shared.String() + "</sst>",
+		"xl/worksheets/sheet1.xml": "<worksheet><sheetData>" + sheetData + "</sheetData></worksheet>",
+	}
+
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for name, content := range parts {
+		w, err := zw.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := w.Write([]byte(content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestReadCSV(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		mapping map[string]string
+		header  []string
+		records []map[string]string
+		lines   []int
+		wantErr string
+	}{
+		{
+			name:    "normalizes and maps the header",
+			input:   "\ufeffFull Name,E-Mail\nAda, ada@example.com\n",
+			mapping: map[string]string{"full name": "name"},
+			header:  []string{"Full Name", "E-Mail"},
+			records: []map[string]string{{"name": "Ada", "e_mail": "ada@example.com"}},
+			lines:   []int{2},
+		},
+		{
+			name:    "skips blank rows but keeps line numbers",
+			input:   "name\nAda\n,\nGrace\n",
+			header:  []string{"name"},
+			records: []map[string]string{{"name": "Ada"}, {"name": "Grace"}},
+			lines:   []int{2, 4},
+		},
+		{
+			name:    "short rows leave the missing columns out",
+			input:   "name,email\nAda\n",
+			header:  []string{"name", "email"},
+			records: []map[string]string{{"name": "Ada"}},
+			lines:   []int{2},
+		},
+		{
+			name:    "empty file",
+			input:   "",
+			wantErr: "file has no header row",
+		},
+		{
+			name:    "malformed quoting",
+			input:   "name\n\"Ada\n",
+			wantErr: "invalid csv",
+		},
+		{
+			name:    "too many rows",
+			input:   "name\n" + strings.Repeat("x\n", MaxRows+1),
+			wantErr: "data rows",
+		},
+		{
+			name:    "too many columns",
+			input:   strings.Repeat("x,", MaxColumns) + "x\n",
+			wantErr: "columns",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			header, records, err := Read(strings.NewReader(tt.input), FormatCSV, tt.mapping)
+			checkRead(t, header, records, err, tt.header, tt.records, tt.lines, tt.wantErr)
+		})
+	}
+}
+
+func TestReadXLSX(t *testing.T) {
+	tests := []struct {
+		name    string
+		sheet   string
+		shared  []string
+		header  []string
+		records []map[string]string
+		lines   []int
+		wantErr string
+	}{
+		{
+			name: "shared, inline and plain cells",
+			sheet: `<row r="1"><c r="A1" t="s"><v>0</v></c><c r="B1" t="s"><v>1</v></c><c r="C1" t="s"><v>2</v></c></row>
+				<row r="2"><c r="A2" t="inlineStr"><is><t>Ada</t></is></c><c r="B2"><v>42</v></c><c r="C2" t="b"><v>1</v></c></row>`,
+			shared:  []string{"name", "age", "is_admin"},
+			header:  []string{"name", "age", "is_admin"},
+			records: []map[string]string{{"name": "Ada", "age": "42", "is_admin": "true"}},
+			lines:   []int{2},
+		},
+		{
+			name: "skipped rows and columns are padded",
+			sheet: `<row r="1"><c r="A1" t="inlineStr"><is><t>name</t></is></c><c r="C1" t="inlineStr"><is><t>email</t></is></c></row>
+				<row r="4"><c r="C4" t="inlineStr"><is><t>ada@example.com</t></is></c></row>`,
+			header:  []string{"name", "", "email"},
+			records: []map[string]string{{"name": "", "email": "ada@example.com"}},
+			lines:   []int{4},
+		},
+		{
+			name:    "lowercase cell reference",
+			sheet:   `<row r="1"><c r="a1"><v>1</v></c></row>`,
+			wantErr: "bad cell reference",
+		},
+		{
+			name:    "cell reference without row number",
+			sheet:   `<row r="1"><c r="AB"><v>1</v></c></row>`,
+			wantErr: "bad cell reference",
+		},
+		{
+			name:    "cell reference with trailing garbage",
+			sheet:   `<row r="1"><c r="A1x"><v>1</v></c></row>`,
+			wantErr: "bad cell reference",
+		},
+		{
+			name:    "column beyond the limit",
+			sheet:   `<row r="1"><c r="XFD1"><v>1</v></c></row>`,
+			wantErr: "columns",
+		},
+		{
+			name:    "row beyond the limit",
+			sheet:   `<row r="100000000"><c r="A100000000"><v>1</v></c></row>`,
+			wantErr: "data rows",
+		},
+		{
+			name:    "shared string out of range",
+			sheet:   `<row r="1"><c r="A1" t="s"><v>3</v></c></row>`,
+			wantErr: "bad shared string index",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data := newXLSX(t, tt.sheet, tt.shared...)
+			header, records, err := Read(bytes.NewReader(data), FormatXLSX, nil)
+			checkRead(t, header, records, err, tt.header, tt.records, tt.lines, tt.wantErr)
+		})
+	}
+}
+
+func TestReadXLSXNotAZip(t *testing.T) {
+	if _, _, err := Read(strings.NewReader("name\nAda\n"), FormatXLSX, nil); err == nil || !strings.Contains(err.Error(), "invalid xlsx") {
+		t.Fatalf("expected an invalid xlsx error, got %v", err)
+	}
+}
+
+func checkRead(t *testing.T, header []string, records []Record, err error, wantHeader []string, wantRecords []map[string]string, wantLines []int, wantErr string) {
+	t.Helper()
+	if wantErr != "" {
+		if err == nil || !strings.Contains(err.Error(), wantErr) {
+			t.Fatalf("expected an error containing %q, got %v", wantErr, err)
+		}
+		return
+	}
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if strings.Join(header, ",") != strings.Join(wantHeader, ",") {
+		t.Errorf("header = %q, want %q", header, wantHeader)
+	}
+	if len(records) != len(wantRecords) {
+		t.Fatalf("got %d records, want %d", len(records), len(wantRecords))
+	}
+	for i, rec := range records {
+		if rec.Line != wantLines[i] {
+			t.Errorf("record %d: line = %d, want %d", i, rec.Line, wantLines[i])
+		}
+		if fmt.Sprint(rec.Values) != fmt.Sprint(wantRecords[i]) {
+			t.Errorf("record %d: values = %v, want %v", i, rec.Values, wantRecords[i])
+		}
+	}
+}
+
+func TestDetectFormat(t *testing.T) {
+	tests := []struct {
+		filename string
+		want     Format
+		wantErr  bool
+	}{
+		{"users.csv", FormatCSV, false},
+		{"Users.XLSX", FormatXLSX, false},
+		{"users.xls", "", true},
+		{"users", "", true},
+	}
+	for _, tt := range tests {
+		got, err := DetectFormat(tt.filename)
+		if got != tt.want || (err != nil) != tt.wantErr {
+			t.Errorf("DetectFormat(%q) = %q, %v", tt.filename, got, err)
+		}
+	}
+}
@@ -0,0 +1,138 @@
+// Package media validates uploaded images and renders their thumbnails using only
+// the standard library decoders, so JPEG, PNG and GIF images are accepted.
+package media
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/draw"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"net/http"
+)
+
+// maxPixels bounds the decoded size of an image, so that a small file cannot expand
+// into an enormous bitmap
+const maxPixels = 40_000_000
+
+var (
+	// ErrUnsupportedType is returned for files that are not JPEG, PNG or GIF images
+	ErrUnsupportedType = errors.New("image must be a JPEG, PNG or GIF file")
+	// ErrTooManyPixels is returned for images whose dimensions exceed maxPixels
+	ErrTooManyPixels = errors.New("image dimensions are too large")
+)
+
+// Image is a decoded upload
+type Image struct {
+	// ContentType is detected from the file content, never taken from the client
+	ContentType string
+	Image       image.Image
+}
+
+// Decode detects the type of data from its content and decodes it, rejecting
+// anything but JPEG, PNG and GIF images. GIFs are decoded to their first frame.
+func Decode(data []byte) (*Image, error) {
+	contentType := http.DetectContentType(data)
+	if Extension(contentType) == "" {
+		return nil, ErrUnsupportedType
+	}
+
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, ErrUnsupportedType
+	}
+	if cfg.Width*cfg.Height > maxPixels {
+		return nil, ErrTooManyPixels
+	}
+
+	var img image.Image
+	switch contentType {
+	case "image/jpeg":
+		img, err = jpeg.Decode(bytes.NewReader(data))
+	case "image/png":
+		img, err = png.Decode(bytes.NewReader(data))
+	case "image/gif":
+		img, err = gif.Decode(bytes.NewReader(data))
+	}
+	if err != nil {
+		return nil, ErrUnsupportedType
+	}
+	return &Image{ContentType: contentType, Image: img}, nil
+}
+
+// Extension returns the file extension of a supported image type, or "" if the
+// type is not supported
+func Extension(contentType string) string {
+	switch contentType {
+	case "image/jpeg":
+		return ".jpg"
+	case "image/png":
+		return ".png"
+	case "image/gif":
+		return ".gif"
+	}
+	return ""
+}
+
+// Thumbnail scales the image down to fit in a size by size square, keeping its
+// aspect ratio, and encodes it. Photos are encoded as JPEG; PNG and GIF images as
+// PNG to keep their transparency. Images already small enough are only re-encoded.
+func (img *Image) Thumbnail(size int) (data []byte, contentType string, err error) {
+	thumb := resize(img.Image, size)
+
+	var buf bytes.Buffer
+	if img.ContentType == "image/jpeg" {
+		contentType = "image/jpeg"
+		err = jpeg.Encode(&buf, thumb, &jpeg.Options{Quality: 85})
+	} else {
+		contentType = "image/png"
+		err = png.Encode(&buf, thumb)
+	}
+	return buf.Bytes(), contentType, err
+}
+
+// resize scales src down to fit in a size by size square by averaging the source
+// pixels covered by each destination pixel
+func resize(src image.Image, size int) image.Image {
+	b := src.Bounds()
+	w, h := b.Dx(), b.Dy()
+	if w <= size && h <= size {
+		return src
+	}
+	dw, dh := size, h*size/w
+	if h > w {
+		dw, dh = w*size/h, size
+	}
+	dw, dh = max(dw, 1), max(dh, 1)
+
+	// Work on premultiplied RGBA so that transparent pixels do not bleed their color
+	rgba := image.NewRGBA(image.Rect(0, 0, w, h))
+	draw.Draw(rgba, rgba.Bounds(), src, b.Min, draw.Src)
+
+	dst := image.NewRGBA(image.Rect(0, 0, dw, dh))
+	for y := range dh {
+		y0, y1 := y*h/dh, max((y+1)*h/dh, y*h/dh+1)
+		for x := range dw {
+			x0, x1 := x*w/dw, max((x+1)*w/dw, x*w/dw+1)
+
+			var sum [4]int
+			for sy := y0; sy < y1; sy++ {
+				row := rgba.Pix[sy*rgba.Stride+x0*4 : sy*rgba.Stride+x1*4]
+				for i := 0; i < len(row); i += 4 {
+					sum[0] += int(row[i])
+					sum[1] += int(row[i+1])
+					sum[2] += int(row[i+2])
+					sum[3] += int(row[i+3])
+				}
+			}
+			n := (y1 - y0) * (x1 - x0)
+			i := y*dst.Stride + x*4
+			for c := range sum {
+				dst.Pix[i+c] = uint8(sum[c] / n)
+			}
+		}
+	}
+	return dst
+}
@@ -0,0 +1,218 @@
+// Package mediacheck periodically checks that the image URLs stored on users and
+// organizations still resolve
+package mediacheck
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/hoshina-dev/custapi/internal/models"
+	"github.com/hoshina-dev/custapi/internal/repositories"
+	"github.com/hoshina-dev/custapi/internal/safehttp"
+	"github.com/hoshina-dev/custapi/internal/services"
+)
+
+// Config controls how often and how fast stored URLs are checked
+type Config struct {
+	PollInterval time.Duration
+	// RecheckAfter is how long the outcome of a check is trusted before the URL is checked again
+	RecheckAfter time.Duration
+	BatchSize    int
+	// Concurrency is the maximum number of requests in flight at once
+	Concurrency int
+	Timeout     time.Duration
+	// StripAfter is the number of consecutive checks finding a URL gone, with 404 Not
+	// Found or 410 Gone, after which it is removed from its user or organization. Zero
+	// keeps dead URLs.
+	StripAfter int
+}
+
+// Checker sends HEAD requests to stored image URLs and records whether they still resolve
+type Checker struct {
+	repo        repositories.MediaCheckRepository
+	userService services.UserService
+	orgService  services.OrganizationService
+	client      *http.Client
+	cfg         Config
+}
+
+// NewChecker creates a media checker. A nil client uses safehttp.NewClient with
+// cfg.Timeout, which only connects to public addresses.
+func NewChecker(repo repositories.MediaCheckRepository, userService services.UserService,
+	orgService services.OrganizationService, client *http.Client, cfg Config) *Checker {
+	if client == nil {
+		client = safehttp.NewClient(cfg.Timeout)
+	}
+	return &Checker{
+		repo:        repo,
+		userService: userService,
+		orgService:  orgService,
+		client:      client,
+		cfg:         cfg,
+	}
+}
+
+// Run checks due URLs until ctx is cancelled
+func (c *Checker) Run(ctx context.Context) {
+	ticker := time.NewTicker(c.cfg.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := c.repo.DeleteStale(ctx); err != nil {
+			log.Printf("mediacheck: removing stale checks: %v", err)
+		}
+
+		// Keep checking while full batches come back
+		for {
+			n, err := c.RunOnce(ctx)
+			if err != nil {
+				log.Printf("mediacheck: %v", err)
+			}
+			if err != nil || n < c.cfg.BatchSize || ctx.Err() != nil {
+				break
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// RunOnce checks one batch of due URLs, records the outcomes, strips the URLs found
+// gone often enough and returns how many URLs were checked
+func (c *Checker) RunOnce(ctx context.Context) (int, error) {
+	checks, err := c.repo.FindDue(ctx, time.Now().Add(-c.cfg.RecheckAfter), c.cfg.BatchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	c.checkAll(ctx, checks)
+	// Requests cut short by the shutdown say nothing about the URLs
+	if ctx.Err() != nil {
+		return 0, ctx.Err()
+	}
+	if err := c.repo.Save(ctx, checks); err != nil {
+		return 0, err
+	}
+
+	if c.cfg.StripAfter > 0 {
+		for i := range checks {
+			if checks[i].Gone() && checks[i].Failures >= c.cfg.StripAfter {
+				c.strip(ctx, &checks[i])
+			}
+		}
+	}
+	return len(checks), nil
+}
+
+// checkAll checks the URLs of checks with at most cfg.Concurrency requests in flight.
+// A URL stored in several places is only requested once.
+func (c *Checker) checkAll(ctx context.Context, checks []models.MediaCheck) {
+	byURL := make(map[string][]*models.MediaCheck)
+	for i := range checks {
+		byURL[checks[i].URL] = append(byURL[checks[i].URL], &checks[i])
+	}
+
+	urls := make(chan string)
+	var wg sync.WaitGroup
+	for range max(1, min(c.cfg.Concurrency, len(byURL))) {
+		wg.Go(func() {
+			for url := range urls {
+				status, err := c.Check(ctx, url)
+				checkedAt := time.Now()
+				for _, check := range byURL[url] {
+					record(check, status, err, checkedAt)
+				}
+			}
+		})
+	}
+	for url := range byURL {
+		urls <- url
+	}
+	close(urls)
+	wg.Wait()
+}
+
+// Check requests url and returns the response status. Servers that do not allow HEAD
+// are sent a GET instead. An error is returned if the request fails or the status is
+// not a success or redirect.
+func (c *Checker) Check(ctx context.Context, url string) (int, error) {
+	status, err := c.request(ctx, http.MethodHead, url)
+	if err == nil && (status == http.StatusMethodNotAllowed || status == http.StatusNotImplemented) {
+		status, err = c.request(ctx, http.MethodGet, url)
+	}
+	if err != nil {
+		return 0, err
+	}
+	if status >= 400 {
+		return status, fmt.Errorf("unexpected status %d", status)
+	}
+	return status, nil
+}
+
+// request sends a single request to url and returns the response status
+func (c *Checker) request(ctx context.Context, method, url string) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, method, url, nil)
+	if err != nil {
+		return 0, err
+	}
+	if req.URL.Scheme != "http" && req.URL.Scheme != "https" {
+		return 0, safehttp.ErrScheme
+	}
+	if method == http.MethodGet {
+		req.Header.Set("Range", "bytes=0-0")
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+	return resp.StatusCode, nil
+}
+
+// record stores the outcome of a request on check
+func record(check *models.MediaCheck, status int, err error, checkedAt time.Time) {
+	check.CheckedAt = checkedAt
+	check.ResponseStatus = nil
+	if status != 0 {
+		check.ResponseStatus = &status
+	}
+
+	if err == nil {
+		check.Status = models.MediaOK
+		check.LastError = nil
+		check.Failures = 0
+		return
+	}
+	msg := err.Error()
+	check.Status = models.MediaBroken
+	check.LastError = &msg
+	check.Failures++
+}
+
+// strip removes a dead URL from the user or organization storing it. The check itself
+// is removed on the next pass, once the URL is no longer referenced.
+func (c *Checker) strip(ctx context.Context, check *models.MediaCheck) {
+	var err error
+	switch check.EntityType {
+	case services.EntityUser:
+		err = c.userService.RemoveAvatar(ctx, check.EntityID, check.URL)
+	case services.EntityOrganization:
+		err = c.orgService.RemoveImage(ctx, check.EntityID, check.URL)
+	}
+	if err != nil {
+		log.Printf("mediacheck: stripping %s from %s %s: %v", check.URL, check.EntityType, check.EntityID, err)
+		return
+	}
+	log.Printf("mediacheck: stripped dead URL %s from %s %s", check.URL, check.EntityType, check.EntityID)
+}
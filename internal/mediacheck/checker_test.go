@@ -0,0 +1,93 @@
+package mediacheck
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hoshina-dev/custapi/internal/safehttp"
+)
+
+// loopbackOnly allows the 127.0.0.1 address httptest servers listen on
+func loopbackOnly(addr netip.Addr) bool {
+	return addr == netip.MustParseAddr("127.0.0.1")
+}
+
+func TestCheck(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/ok":
+			w.WriteHeader(http.StatusOK)
+		case "/no-head":
+			if r.Method == http.MethodHead {
+				w.WriteHeader(http.StatusMethodNotAllowed)
+				return
+			}
+			if r.Header.Get("Range") != "bytes=0-0" {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			w.WriteHeader(http.StatusPartialContent)
+		case "/moved":
+			http.Redirect(w, r, "/ok", http.StatusFound)
+		case "/to-internal":
+			http.Redirect(w, r, "http://127.0.0.2"+r.Host[strings.LastIndex(r.Host, ":"):]+"/ok", http.StatusFound)
+		case "/to-file":
+			http.Redirect(w, r, "file:///etc/passwd", http.StatusFound)
+		case "/loop":
+			http.Redirect(w, r, "/loop", http.StatusFound)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		name       string
+		url        string
+		wantStatus int
+		wantErr    string
+	}{
+		{"ok", server.URL + "/ok", http.StatusOK, ""},
+		{"falls back to get", server.URL + "/no-head", http.StatusPartialContent, ""},
+		{"follows redirects", server.URL + "/moved", http.StatusOK, ""},
+		{"not found", server.URL + "/gone", http.StatusNotFound, "unexpected status 404"},
+		{"redirect to internal address", server.URL + "/to-internal", 0, "connecting to 127.0.0.2 is not allowed"},
+		{"redirect to other scheme", server.URL + "/to-file", 0, safehttp.ErrScheme.Error()},
+		{"redirect loop", server.URL + "/loop", 0, "stopped after 10 redirects"},
+		{"other scheme", "ftp://127.0.0.1/file", 0, safehttp.ErrScheme.Error()},
+	}
+	checker := NewChecker(nil, nil, nil, safehttp.NewClientAllowing(time.Second, loopbackOnly), Config{})
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status, err := checker.Check(context.Background(), tt.url)
+			if status != tt.wantStatus {
+				t.Errorf("Check() status = %d, want %d", status, tt.wantStatus)
+			}
+			if tt.wantErr == "" && err != nil || tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)) {
+				t.Errorf("Check() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestNewCheckerRefusesInternalAddresses(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Error("request reached the internal server")
+	}))
+	defer server.Close()
+
+	checker := NewChecker(nil, nil, nil, nil, Config{Timeout: time.Second})
+	port := server.URL[strings.LastIndex(server.URL, ":"):]
+	for _, url := range []string{server.URL, "http://localhost" + port} {
+		t.Run(url, func(t *testing.T) {
+			if _, err := checker.Check(context.Background(), url); err == nil || !strings.Contains(err.Error(), "is not allowed") {
+				t.Errorf("Check() error = %v, want the address refused", err)
+			}
+		})
+	}
+}
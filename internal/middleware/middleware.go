@@ -1,10 +1,19 @@
 package middleware
 
 import (
+	"fmt"
 	"log"
+	"math"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/hoshina-dev/custapi/internal/auth"
+	"github.com/hoshina-dev/custapi/internal/models"
+	"github.com/hoshina-dev/custapi/internal/ratelimit"
+	"github.com/hoshina-dev/custapi/internal/services"
 )
 
 // Logger middleware logs all HTTP requests
@@ -42,3 +51,241 @@ func ErrorHandler() fiber.Handler {
 		return nil
 	}
 }
+
+// Authenticate resolves the caller of a request into an auth.Actor stored in the
+// request context. The caller is identified by an API key, sent in the X-API-Key
+// header or as a bearer token, or by the bearer token of a session started with
+// POST /auth/login. Other requests are anonymous.
+func Authenticate(authService services.AuthService, apiKeyService services.APIKeyService) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		actor := &auth.Actor{IP: c.IP()}
+		if requestID, ok := c.Locals("requestid").(string); ok {
+			actor.RequestID = requestID
+		}
+
+		token, isBearer := auth.BearerToken(c.Get(fiber.HeaderAuthorization))
+		if key := c.Get("X-API-Key"); key != "" || (isBearer && auth.IsAPIKey(token)) {
+			if key == "" {
+				key = token
+			}
+			apiKey, err := apiKeyService.Authenticate(c.Context(), key)
+			if err != nil {
+				return c.Status(fiber.StatusInternalServerError).JSON(models.ErrorResponse{Error: err.Error()})
+			}
+			if apiKey == nil {
+				return c.Status(fiber.StatusUnauthorized).JSON(models.ErrorResponse{Error: "invalid or expired API key"})
+			}
+			actor.APIKeyID = &apiKey.ID
+			actor.OrganizationScope = &apiKey.OrganizationID
+			actor.Permissions = apiKey.Permissions
+		} else if isBearer {
+			session, err := authService.Authenticate(c.Context(), token)
+			if err != nil {
+				return c.Status(fiber.StatusInternalServerError).JSON(models.ErrorResponse{Error: err.Error()})
+			}
+			if session == nil {
+				return c.Status(fiber.StatusUnauthorized).JSON(models.ErrorResponse{Error: "invalid or expired session"})
+			}
+			actor.UserID = &session.User.ID
+			actor.IsAdmin = session.User.IsAdmin
+			actor.EmailVerified = session.User.EmailVerifiedAt != nil
+			actor.TwoFactorEnabled = session.User.TwoFactorEnabled()
+			actor.TwoFactorVerified = session.TwoFactorVerifiedAt != nil
+		}
+
+		c.Locals(auth.ContextKey, actor)
+		return c.Next()
+	}
+}
+
+// RequireVerifiedEmail only lets API keys and users who have verified their email
+// address change data. Reads are let through; anonymous changes are refused.
+func RequireVerifiedEmail() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		actor := auth.FromContext(c.Context())
+		switch {
+		case isReadOnly(c.Method()):
+		case actor.UserID == nil && actor.APIKeyID == nil:
+			return c.Status(fiber.StatusUnauthorized).JSON(models.ErrorResponse{Error: "authentication required"})
+		case actor.UserID != nil && !actor.EmailVerified:
+			return c.Status(fiber.StatusForbidden).JSON(models.ErrorResponse{Error: "email address not verified"})
+		}
+		return c.Next()
+	}
+}
+
+// isReadOnly reports whether an HTTP method only reads data
+func isReadOnly(method string) bool {
+	return method == fiber.MethodGet || method == fiber.MethodHead || method == fiber.MethodOptions
+}
+
+// RequirePermission only lets requests made with an API key through if the key was
+// granted permission. Requests made by users are not limited; anonymous requests are
+// refused.
+func RequirePermission(permission string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		actor := auth.FromContext(c.Context())
+		if actor.UserID == nil && actor.APIKeyID == nil {
+			return c.Status(fiber.StatusUnauthorized).JSON(models.ErrorResponse{Error: "authentication required"})
+		}
+		if !actor.HasPermission(permission) {
+			return c.Status(fiber.StatusForbidden).JSON(models.ErrorResponse{Error: "API key lacks permission " + permission})
+		}
+		return c.Next()
+	}
+}
+
+// RequireOrganizationScope stops requests made with an API key from reaching an
+// organization other than the key's, identified by the route parameter param. Such
+// requests are answered as if the organization did not exist. Anonymous requests are
+// refused.
+func RequireOrganizationScope(param string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		actor := auth.FromContext(c.Context())
+		if actor.UserID == nil && actor.APIKeyID == nil {
+			return c.Status(fiber.StatusUnauthorized).JSON(models.ErrorResponse{Error: "authentication required"})
+		}
+		// Invalid IDs are left for the handler to reject
+		if id, err := uuid.Parse(c.Params(param)); err == nil && !actor.InOrganizationScope(id) {
+			return c.Status(fiber.StatusNotFound).JSON(models.ErrorResponse{Error: "organization not found"})
+		}
+		return c.Next()
+	}
+}
+
+// RequireUserInScope stops requests made with an API key from reaching a user, identified
+// by the route parameter id, outside the key's organization. Such requests are answered
+// as if the user did not exist. Anonymous requests are refused.
+func RequireUserInScope(userService services.UserService) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		actor := auth.FromContext(c.Context())
+		if actor.UserID == nil && actor.APIKeyID == nil {
+			return c.Status(fiber.StatusUnauthorized).JSON(models.ErrorResponse{Error: "authentication required"})
+		}
+		id, err := uuid.Parse(c.Params("id"))
+		if actor.OrganizationScope == nil || err != nil {
+			return c.Next()
+		}
+
+		user, err := userService.GetUser(c.Context(), id)
+		if err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(models.ErrorResponse{Error: err.Error()})
+		}
+		if user == nil || !actor.InOrganizationScope(user.OrganizationID) {
+			return c.Status(fiber.StatusNotFound).JSON(models.ErrorResponse{Error: "user not found"})
+		}
+		return c.Next()
+	}
+}
+
+// AllowAnonymous lets anonymous requests skip check, which then only applies to
+// requests made by users or with an API key. It marks the routes open to the public.
+func AllowAnonymous(check fiber.Handler) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if actor := auth.FromContext(c.Context()); actor.UserID == nil && actor.APIKeyID == nil {
+			return c.Next()
+		}
+		return check(c)
+	}
+}
+
+// DenyAPIKeys stops requests made with an API key, for routes that span organizations
+// and so cannot be limited to the organization of a key
+func DenyAPIKeys() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if auth.FromContext(c.Context()).APIKeyID != nil {
+			return c.Status(fiber.StatusForbidden).JSON(models.ErrorResponse{Error: "API keys cannot access this endpoint"})
+		}
+		return c.Next()
+	}
+}
+
+// RequireAdmin only lets requests made by admin users through. Admins must have
+// enabled two-factor authentication, with POST /auth/2fa/setup and /auth/2fa/verify,
+// and completed it for the session of the request.
+func RequireAdmin() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		actor := auth.FromContext(c.Context())
+		if actor.UserID == nil && actor.APIKeyID == nil {
+			return c.Status(fiber.StatusUnauthorized).JSON(models.ErrorResponse{Error: "authentication required"})
+		}
+		if !actor.IsAdmin {
+			return c.Status(fiber.StatusForbidden).JSON(models.ErrorResponse{Error: "admin access required"})
+		}
+		if !actor.TwoFactorEnabled {
+			return c.Status(fiber.StatusForbidden).JSON(models.ErrorResponse{Error: "admins must enable two-factor authentication"})
+		}
+		if !actor.IsVerifiedAdmin() {
+			return c.Status(fiber.StatusForbidden).JSON(models.ErrorResponse{Error: "admins must log in with their second factor"})
+		}
+		return c.Next()
+	}
+}
+
+// RateLimit limits the requests of each client, identified by the key key returns, to
+// limit. Buckets are named after the route group they limit, so that a client has
+// one per group. Requests with an empty key are not limited.
+//
+// Responses carry the RateLimit-Limit, RateLimit-Remaining and RateLimit-Reset headers;
+// denied requests get a 429 with Retry-After. Requests are let through when the store
+// fails, as refusing every request would be worse than not limiting them for a while.
+func RateLimit(store ratelimit.Store, name string, limit ratelimit.Limit, key func(c *fiber.Ctx) string) fiber.Handler {
+	if !limit.Enabled() {
+		return func(c *fiber.Ctx) error {
+			return c.Next()
+		}
+	}
+	policy := fmt.Sprintf("%d;w=%d", limit.Requests, int(math.Ceil(limit.Per.Seconds())))
+
+	return func(c *fiber.Ctx) error {
+		client := key(c)
+		if client == "" {
+			return c.Next()
+		}
+
+		result, err := store.Take(c.Context(), name+":"+client, limit)
+		if err != nil {
+			log.Printf("Rate limiting failed: %v", err)
+			return c.Next()
+		}
+
+		c.Set("RateLimit-Policy", policy)
+		c.Set("RateLimit-Limit", strconv.Itoa(result.Limit))
+		c.Set("RateLimit-Remaining", strconv.Itoa(result.Remaining))
+		c.Set("RateLimit-Reset", strconv.Itoa(ceilSeconds(result.Reset)))
+		if !result.Allowed {
+			c.Set(fiber.HeaderRetryAfter, strconv.Itoa(ceilSeconds(result.RetryAfter)))
+			return c.Status(fiber.StatusTooManyRequests).JSON(models.ErrorResponse{Error: "too many requests, retry later"})
+		}
+		return c.Next()
+	}
+}
+
+// ceilSeconds rounds a duration up to whole seconds, as rate limit headers count them
+func ceilSeconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
+
+// ClientKey identifies the client of a request for rate limiting by its API key, its
+// user or, for anonymous requests, its IP address
+func ClientKey(c *fiber.Ctx) string {
+	return ratelimit.ClientKey(auth.FromContext(c.Context()))
+}
+
+// IPKey identifies the client of a request for rate limiting by its IP address
+func IPKey(c *fiber.Ctx) string {
+	return "ip:" + c.IP()
+}
+
+// LoginEmailKey identifies the account a login attempt is for by the email address
+// in the body, so that guessing the password of an account is limited whichever IP
+// addresses the guesses come from. Bodies without one are left for the handler to reject.
+func LoginEmailKey(c *fiber.Ctx) string {
+	var req struct {
+		Email string `json:"email"`
+	}
+	if err := c.BodyParser(&req); err != nil || req.Email == "" {
+		return ""
+	}
+	return "email:" + strings.ToLower(strings.TrimSpace(req.Email))
+}
@@ -1,6 +1,7 @@
 package models
 
 import (
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
@@ -10,13 +11,22 @@ import (
 
 // User represents a user in the system
 type User struct {
-	ID                 uuid.UUID `gorm:"type:uuid;primaryKey;default:uuid_generate_v4()"`
-	Email              string
-	Name               string
-	OrganizationID     uuid.UUID
-	Organization       Organization
-	Password           string
-	IsAdmin            bool
+	ID              uuid.UUID `gorm:"type:uuid;primaryKey;default:uuid_generate_v4()"`
+	Email           string
+	EmailVerifiedAt *time.Time
+	// PendingEmail is the address the user is changing to, until it is verified
+	PendingEmail   *string
+	Name           string
+	OrganizationID uuid.UUID
+	Organization   Organization
+	Password       string
+	IsAdmin        bool
+	// TOTPSecret is the base32 encoded secret of the user's authenticator app, which
+	// only protects logins once TOTPEnabledAt is set
+	TOTPSecret    *string
+	TOTPEnabledAt *time.Time
+	// TOTPLastStep is the time step of the last code accepted, which cannot be used again
+	TOTPLastStep       *int64
 	PhoneNumber        *string
 	SocialMedia        *string
 	Description        *string
@@ -41,3 +51,426 @@ type Organization struct {
 	UpdatedAt   time.Time `gorm:"autoUpdateTime"`
 	DeletedAt   gorm.DeletedAt
 }
+
+// OrganizationDeletePolicy controls what happens to the users of a deleted organization
+type OrganizationDeletePolicy string
+
+const (
+	// DeletePolicyRestrict refuses to delete an organization that still has users
+	DeletePolicyRestrict OrganizationDeletePolicy = "restrict"
+	// DeletePolicyCascade soft deletes the organization's users along with it
+	DeletePolicyCascade OrganizationDeletePolicy = "cascade"
+	// DeletePolicyReassign moves the organization's users to another organization
+	DeletePolicyReassign OrganizationDeletePolicy = "reassign"
+)
+
+// OrganizationDeleteOptions describes how an organization deletion should be carried out
+type OrganizationDeleteOptions struct {
+	Policy     OrganizationDeletePolicy
+	ReassignTo *uuid.UUID
+	DryRun     bool
+}
+
+// BulkUserResult is the outcome of a single operation in a bulk user request
+type BulkUserResult struct {
+	ID   uuid.UUID
+	User *User
+	Err  error
+}
+
+// UploadedImage is an image stored for a user or organization, with its thumbnails
+type UploadedImage struct {
+	URL         string
+	ContentType string
+	Size        int
+	Width       int
+	Height      int
+	Thumbnails  []Thumbnail
+}
+
+// Thumbnail is a scaled down copy of an uploaded image
+type Thumbnail struct {
+	// Size is the largest dimension of the thumbnail in pixels
+	Size int
+	URL  string
+}
+
+// ImportResult is the outcome of upserting a single imported row
+type ImportResult struct {
+	ID      uuid.UUID
+	Created bool
+	Err     error
+}
+
+// UserFilter narrows down user listings and exports
+type UserFilter struct {
+	Query          string
+	OrganizationID *uuid.UUID
+	// Limit and Offset page listings; a zero Limit means no limit
+	Limit  int
+	Offset int
+}
+
+// OrganizationFilter narrows down organization listings and exports
+type OrganizationFilter struct {
+	Query string
+	// Limit and Offset page listings; a zero Limit means no limit
+	Limit  int
+	Offset int
+}
+
+// AuditEvent records a single create, update or delete of an entity
+type AuditEvent struct {
+	ID         uuid.UUID `gorm:"type:uuid;primaryKey;default:uuid_generate_v4()"`
+	ActorID    *uuid.UUID
+	APIKeyID   *uuid.UUID
+	Action     string
+	EntityType string
+	EntityID   uuid.UUID
+	Changes    JSONMap `gorm:"type:jsonb"`
+	RequestID  *string
+	IP         *string
+	CreatedAt  time.Time `gorm:"autoCreateTime"`
+}
+
+// AuditFilter narrows down audit event listings
+type AuditFilter struct {
+	EntityType string
+	EntityID   *uuid.UUID
+	ActorID    *uuid.UUID
+	APIKeyID   *uuid.UUID
+	Since      *time.Time
+	Limit      int
+}
+
+// OrganizationHistory is a past version of an organization, valid from ValidFrom
+// until it was changed or deleted at ValidTo
+type OrganizationHistory struct {
+	HistoryID      int64 `gorm:"primaryKey"`
+	OrganizationID uuid.UUID
+	Operation      string
+	Name           string
+	Latitude       *float64
+	Longitude      *float64
+	Address        *string
+	Description    *string
+	ImageUrls      pq.StringArray `gorm:"type:text[];default:'{}'"`
+	CreatedAt      time.Time      `gorm:"autoCreateTime:false"`
+	ValidFrom      time.Time
+	ValidTo        time.Time `gorm:"default:CURRENT_TIMESTAMP"`
+	ChangedBy      *uuid.UUID
+}
+
+// TableName keeps GORM from pluralizing the history table name
+func (OrganizationHistory) TableName() string {
+	return "organization_history"
+}
+
+// OrganizationVersion is one state in the history of an organization
+type OrganizationVersion struct {
+	Organization Organization
+	ValidFrom    time.Time
+	// ValidTo is nil for the current version
+	ValidTo *time.Time
+	// EndedBy is the operation that replaced this version, empty for the current version
+	EndedBy   string
+	ChangedBy *uuid.UUID
+	// Changes holds the fields that differ from the previous version
+	Changes JSONMap
+}
+
+// UserHistory is a past version of a user, valid from ValidFrom until ValidTo
+type UserHistory struct {
+	HistoryID          int64 `gorm:"primaryKey"`
+	UserID             uuid.UUID
+	Operation          string
+	Email              string
+	Name               string
+	OrganizationID     uuid.UUID
+	IsAdmin            bool
+	PhoneNumber        *string
+	SocialMedia        *string
+	Description        *string
+	AvatarURL          *string
+	ResearchCategories pq.StringArray `gorm:"type:text[];default:'{}'"`
+	CreatedAt          time.Time      `gorm:"autoCreateTime:false"`
+	ValidFrom          time.Time
+	ValidTo            time.Time `gorm:"default:CURRENT_TIMESTAMP"`
+	ChangedBy          *uuid.UUID
+}
+
+// TableName keeps GORM from pluralizing the history table name
+func (UserHistory) TableName() string {
+	return "user_history"
+}
+
+// UserVersion is one state in the history of a user
+type UserVersion struct {
+	User      User
+	ValidFrom time.Time
+	// ValidTo is nil for the current version
+	ValidTo *time.Time
+	// EndedBy is the operation that replaced this version, empty for the current version
+	EndedBy   string
+	ChangedBy *uuid.UUID
+	// Changes holds the fields that differ from the previous version
+	Changes JSONMap
+}
+
+// DomainEvent describes a change to an entity that other systems may react to
+type DomainEvent struct {
+	ID            uuid.UUID
+	Type          string
+	AggregateType string
+	AggregateID   uuid.UUID
+	OccurredAt    time.Time
+	Data          map[string]any
+}
+
+// OutboxEvent is a domain event stored in the same transaction as the change
+// it describes, waiting to be published by the relay
+type OutboxEvent struct {
+	ID            uuid.UUID `gorm:"type:uuid;primaryKey"`
+	Sequence      int64     `gorm:"->"`
+	AggregateType string
+	AggregateID   uuid.UUID
+	EventType     string
+	Payload       JSONMap `gorm:"type:jsonb"`
+	OccurredAt    time.Time
+	Attempts      int
+	LastError     *string
+	PublishedAt   *time.Time
+	// ClaimedUntil is when the claim of the relay publishing the event runs out
+	ClaimedUntil *time.Time
+	// FailedAt is when the relay gave up on the event after too many attempts
+	FailedAt  *time.Time
+	CreatedAt time.Time `gorm:"autoCreateTime"`
+}
+
+// TableName keeps GORM from pluralizing the outbox table name
+func (OutboxEvent) TableName() string {
+	return "outbox"
+}
+
+// Webhook delivery statuses
+const (
+	DeliveryPending   = "pending"
+	DeliverySucceeded = "succeeded"
+	DeliveryDead      = "dead"
+)
+
+// WebhookSubscription is an endpoint that receives the events it subscribed to
+type WebhookSubscription struct {
+	ID        uuid.UUID `gorm:"type:uuid;primaryKey;default:uuid_generate_v4()"`
+	URL       string
+	Secret    string
+	Events    pq.StringArray `gorm:"type:text[];default:'{}'"`
+	Active    bool           `gorm:"default:true"`
+	CreatedAt time.Time      `gorm:"autoCreateTime"`
+	UpdatedAt time.Time      `gorm:"autoUpdateTime"`
+	DeletedAt gorm.DeletedAt
+}
+
+// Matches reports whether the subscription wants events of the given type. A filter
+// entry matches exactly, by entity prefix like "user.*", or everything with "*".
+func (s *WebhookSubscription) Matches(eventType string) bool {
+	for _, filter := range s.Events {
+		if filter == "*" || filter == eventType {
+			return true
+		}
+		if prefix, ok := strings.CutSuffix(filter, ".*"); ok && strings.HasPrefix(eventType, prefix+".") {
+			return true
+		}
+	}
+	return false
+}
+
+// WebhookDelivery is one event queued for delivery to one subscription
+type WebhookDelivery struct {
+	ID             uuid.UUID `gorm:"type:uuid;primaryKey;default:uuid_generate_v4()"`
+	SubscriptionID uuid.UUID
+	Subscription   WebhookSubscription
+	EventID        uuid.UUID
+	EventType      string
+	Payload        JSONMap `gorm:"type:jsonb"`
+	Status         string  `gorm:"default:pending"`
+	Attempts       int
+	NextAttemptAt  time.Time `gorm:"default:CURRENT_TIMESTAMP"`
+	LastError      *string
+	ResponseStatus *int
+	DeliveredAt    *time.Time
+	CreatedAt      time.Time `gorm:"autoCreateTime"`
+	UpdatedAt      time.Time `gorm:"autoUpdateTime"`
+}
+
+// Media check statuses
+const (
+	MediaOK     = "ok"
+	MediaBroken = "broken"
+)
+
+// MediaCheck is the outcome of the last check of an image URL stored on a user or
+// organization
+type MediaCheck struct {
+	ID         uuid.UUID `gorm:"type:uuid;primaryKey;default:uuid_generate_v4()"`
+	EntityType string
+	EntityID   uuid.UUID
+	// Field is the column holding the URL: avatar_url or image_urls
+	Field          string
+	URL            string
+	Status         string
+	ResponseStatus *int
+	LastError      *string
+	// Failures counts the consecutive checks that found the URL broken
+	Failures  int
+	CheckedAt time.Time
+	CreatedAt time.Time `gorm:"autoCreateTime"`
+	UpdatedAt time.Time `gorm:"autoUpdateTime"`
+}
+
+// Gone reports whether the last check found that the URL no longer exists, as opposed
+// to failing for reasons that may be temporary
+func (c *MediaCheck) Gone() bool {
+	return c.ResponseStatus != nil && (*c.ResponseStatus == 404 || *c.ResponseStatus == 410)
+}
+
+// MediaCheckFilter narrows down media check listings
+type MediaCheckFilter struct {
+	EntityType string
+	// Limit and Offset page listings; a zero Limit means no limit
+	Limit  int
+	Offset int
+}
+
+// Session is a login of a user, authenticated by the bearer token it was issued with
+type Session struct {
+	ID        uuid.UUID `gorm:"type:uuid;primaryKey;default:uuid_generate_v4()"`
+	UserID    uuid.UUID
+	User      User
+	TokenHash string
+	IP        *string
+	UserAgent *string
+	// TwoFactorVerifiedAt is when the user completed a second factor for the session,
+	// nil for sessions started with a password or single sign-on alone
+	TwoFactorVerifiedAt *time.Time
+	ExpiresAt           time.Time
+	RevokedAt           *time.Time
+	CreatedAt           time.Time `gorm:"autoCreateTime"`
+	UpdatedAt           time.Time `gorm:"autoUpdateTime"`
+}
+
+// PasswordResetToken lets a user who forgot their password set a new one, once and
+// before it expires
+type PasswordResetToken struct {
+	ID        uuid.UUID `gorm:"type:uuid;primaryKey;default:uuid_generate_v4()"`
+	UserID    uuid.UUID
+	TokenHash string
+	ExpiresAt time.Time
+	UsedAt    *time.Time
+	CreatedAt time.Time `gorm:"autoCreateTime"`
+}
+
+// EmailVerificationToken confirms that a user controls an email address, once and
+// before it expires
+type EmailVerificationToken struct {
+	ID        uuid.UUID `gorm:"type:uuid;primaryKey;default:uuid_generate_v4()"`
+	UserID    uuid.UUID
+	Email     string
+	TokenHash string
+	ExpiresAt time.Time
+	UsedAt    *time.Time
+	CreatedAt time.Time `gorm:"autoCreateTime"`
+}
+
+// TwoFactorEnabled reports whether logins of the user need a second factor
+func (u *User) TwoFactorEnabled() bool {
+	return u.TOTPEnabledAt != nil
+}
+
+// RecoveryCode is a one-time code that stands in for a TOTP code when the user has
+// lost their authenticator
+type RecoveryCode struct {
+	ID        uuid.UUID `gorm:"type:uuid;primaryKey;default:uuid_generate_v4()"`
+	UserID    uuid.UUID
+	CodeHash  string
+	UsedAt    *time.Time
+	CreatedAt time.Time `gorm:"autoCreateTime"`
+}
+
+// LoginChallenge is the second step of a login with two-factor authentication. It is
+// exchanged for a session along with a TOTP or recovery code.
+type LoginChallenge struct {
+	ID        uuid.UUID `gorm:"type:uuid;primaryKey;default:uuid_generate_v4()"`
+	UserID    uuid.UUID
+	TokenHash string
+	Attempts  int
+	ExpiresAt time.Time
+	CreatedAt time.Time `gorm:"autoCreateTime"`
+}
+
+// APIKey lets a service access the data of one organization without logging in, with
+// the permissions it was granted
+type APIKey struct {
+	ID   uuid.UUID `gorm:"type:uuid;primaryKey;default:uuid_generate_v4()"`
+	Name string
+	// Prefix is the start of the key, stored in clear so that keys can be told apart
+	Prefix         string
+	KeyHash        string
+	OrganizationID uuid.UUID
+	Permissions    pq.StringArray `gorm:"type:text[];default:'{}'"`
+	CreatedBy      *uuid.UUID
+	ExpiresAt      *time.Time
+	LastUsedAt     *time.Time
+	CreatedAt      time.Time `gorm:"autoCreateTime"`
+	UpdatedAt      time.Time `gorm:"autoUpdateTime"`
+	DeletedAt      gorm.DeletedAt
+}
+
+// SSOProvider is the OpenID provider the users of an organization log in with
+type SSOProvider struct {
+	ID             uuid.UUID `gorm:"type:uuid;primaryKey;default:uuid_generate_v4()"`
+	OrganizationID uuid.UUID
+	Issuer         string
+	ClientID       string
+	// ClientSecret is empty for public clients, which rely on PKCE alone
+	ClientSecret string
+	// EmailDomains are the lowercase domains of the email addresses that log in with
+	// the provider
+	EmailDomains pq.StringArray `gorm:"type:text[];default:'{}'"`
+	CreatedAt    time.Time      `gorm:"autoCreateTime"`
+	UpdatedAt    time.Time      `gorm:"autoUpdateTime"`
+	DeletedAt    gorm.DeletedAt
+}
+
+// ExternalIdentity links a user to their account at an OpenID provider
+type ExternalIdentity struct {
+	ID          uuid.UUID `gorm:"type:uuid;primaryKey;default:uuid_generate_v4()"`
+	UserID      uuid.UUID
+	Issuer      string
+	Subject     string
+	Email       *string
+	LastLoginAt *time.Time
+	CreatedAt   time.Time `gorm:"autoCreateTime"`
+}
+
+// SSOLoginState is a single sign-on login waiting for the user to come back from
+// their provider with an authorization code
+type SSOLoginState struct {
+	ID           uuid.UUID `gorm:"type:uuid;primaryKey;default:uuid_generate_v4()"`
+	ProviderID   uuid.UUID
+	StateHash    string
+	Nonce        string
+	CodeVerifier string
+	ExpiresAt    time.Time
+	CreatedAt    time.Time `gorm:"autoCreateTime"`
+}
+
+// RateLimitBucket is the token bucket of a rate limited client. Requests take a token
+// and tokens are added back over time, up to the limit.
+type RateLimitBucket struct {
+	Key        string `gorm:"primaryKey"`
+	Tokens     float64
+	RefilledAt time.Time
+	// FullAt is when the bucket has refilled completely and can be forgotten
+	FullAt time.Time
+}
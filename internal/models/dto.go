@@ -8,11 +8,15 @@ import (
 
 // UserResponse is the DTO for user responses
 type UserResponse struct {
-	ID                 uuid.UUID `json:"id" example:"550e8400-e29b-41d4-a716-446655440000"`
-	Email              string    `json:"email" example:"user@example.com"`
+	ID              uuid.UUID  `json:"id" example:"550e8400-e29b-41d4-a716-446655440000"`
+	Email           string     `json:"email" example:"user@example.com"`
+	EmailVerifiedAt *time.Time `json:"email_verified_at,omitempty" example:"2026-01-01T12:05:00.00000+07:00"`
+	// PendingEmail is the address the user is changing to, until it is verified
+	PendingEmail       *string   `json:"pending_email,omitempty" example:"john.doe@example.org"`
 	Name               string    `json:"name" example:"John Doe"`
 	OrganizationID     uuid.UUID `json:"organization_id" example:"550e8400-e29b-41d4-a716-446655440001"`
 	IsAdmin            bool      `json:"is_admin" example:"true"`
+	TwoFactorEnabled   bool      `json:"two_factor_enabled" example:"true"`
 	PhoneNumber        *string   `json:"phone_number,omitempty" example:"+1234567890"`
 	SocialMedia        *string   `json:"social_media,omitempty" example:"@john on Twitter, linkedin.com/in/john"`
 	Description        *string   `json:"description,omitempty" example:"Senior researcher specializing in quantum computing"`
@@ -20,6 +24,8 @@ type UserResponse struct {
 	ResearchCategories []string  `json:"research_categories" example:"QuantumComputing,Qiskit,Cryogenics"`
 	CreatedAt          time.Time `json:"created_at" example:"2026-01-01T12:00:00.00000+07:00"`
 	UpdatedAt          time.Time `json:"updated_at" example:"2026-01-01T12:00:00.00000+07:00"`
+	// Organization is only set with expand=organization
+	Organization *OrganizationResponse `json:"organization,omitempty" csv:"-"`
 } //	@name	UserResponse
 
 // CreateUserRequest is the DTO for user creation
@@ -49,6 +55,38 @@ type UpdateUserRequest struct {
 	IsAdmin            *bool      `json:"is_admin" example:"true"`
 } //	@name	UpdateUserRequest
 
+// BulkUserOperation is a single create, update or delete in a bulk user request
+type BulkUserOperation struct {
+	Op     string             `json:"op" validate:"required,oneof=create update delete" example:"create"`
+	ID     *uuid.UUID         `json:"id,omitempty" example:"550e8400-e29b-41d4-a716-446655440000"`
+	Create *CreateUserRequest `json:"create,omitempty"`
+	Update *UpdateUserRequest `json:"update,omitempty"`
+} //	@name	BulkUserOperation
+
+// BulkUserRequest is the DTO for bulk user operations
+type BulkUserRequest struct {
+	Mode       string              `json:"mode" validate:"omitempty,oneof=atomic best_effort" example:"atomic"`
+	Operations []BulkUserOperation `json:"operations" validate:"required,min=1,max=500"`
+} //	@name	BulkUserRequest
+
+// BulkUserItemResult is the DTO for the outcome of one bulk user operation
+type BulkUserItemResult struct {
+	Index  int           `json:"index" example:"0"`
+	Op     string        `json:"op" example:"create"`
+	Status int           `json:"status" example:"201"`
+	ID     *uuid.UUID    `json:"id,omitempty" example:"550e8400-e29b-41d4-a716-446655440000"`
+	User   *UserResponse `json:"user,omitempty"`
+	Error  string        `json:"error,omitempty" example:"organization not found"`
+} //	@name	BulkUserItemResult
+
+// BulkUserResponse is the DTO for bulk user operation responses
+type BulkUserResponse struct {
+	Mode      string               `json:"mode" example:"atomic"`
+	Succeeded int                  `json:"succeeded" example:"2"`
+	Failed    int                  `json:"failed" example:"0"`
+	Results   []BulkUserItemResult `json:"results"`
+} //	@name	BulkUserResponse
+
 // OrganizationResponse is the DTO for organization responses
 type OrganizationResponse struct {
 	ID          uuid.UUID `json:"id" example:"550e8400-e29b-41d4-a716-446655440001"`
@@ -60,6 +98,8 @@ type OrganizationResponse struct {
 	ImageUrls   []string  `json:"image_urls" example:"https://example.com/example-1.jpg,https://example.com/example-2.jpg"`
 	CreatedAt   time.Time `json:"created_at" example:"2026-01-01T12:00:00.00000+07:00"`
 	UpdatedAt   time.Time `json:"updated_at" example:"2026-01-01T12:00:00.00000+07:00"`
+	// Users is only set with expand=users, with one page of the organization's users
+	Users []UserResponse `json:"users,omitzero" csv:"-"`
 } //	@name	OrganizationResponse
 
 // CreateOrganizationRequest is the DTO for organization creation
@@ -81,6 +121,26 @@ type UpdateOrganizationRequest struct {
 	ImageUrls   []string `json:"image_urls" validate:"omitempty,dive,url" example:"https://example.com/example-1.jpg,https://example.com/example-2.jpg"`
 } //	@name	UpdateOrganizationRequest
 
+// UserVersionResponse is the DTO for one version in a user's history
+type UserVersionResponse struct {
+	User      UserResponse   `json:"user"`
+	ValidFrom time.Time      `json:"valid_from" example:"2026-01-01T12:00:00.00000+07:00"`
+	ValidTo   *time.Time     `json:"valid_to,omitempty" example:"2026-02-01T12:00:00.00000+07:00"`
+	EndedBy   string         `json:"ended_by,omitempty" example:"update" enums:"update,delete"`
+	ChangedBy *uuid.UUID     `json:"changed_by,omitempty" example:"550e8400-e29b-41d4-a716-446655440000"`
+	Changes   map[string]any `json:"changes" swaggertype:"object"`
+} //	@name	UserVersionResponse
+
+// OrganizationVersionResponse is the DTO for one version in an organization's history
+type OrganizationVersionResponse struct {
+	Organization OrganizationResponse `json:"organization"`
+	ValidFrom    time.Time            `json:"valid_from" example:"2026-01-01T12:00:00.00000+07:00"`
+	ValidTo      *time.Time           `json:"valid_to,omitempty" example:"2026-02-01T12:00:00.00000+07:00"`
+	EndedBy      string               `json:"ended_by,omitempty" example:"update" enums:"update,delete"`
+	ChangedBy    *uuid.UUID           `json:"changed_by,omitempty" example:"550e8400-e29b-41d4-a716-446655440000"`
+	Changes      map[string]any       `json:"changes" swaggertype:"object"`
+} //	@name	OrganizationVersionResponse
+
 type OrganizationCoord struct {
 	ID        uuid.UUID `json:"id" example:"550e8400-e29b-41d4-a716-446655440001"`
 	Latitude  float64   `json:"lat" example:"13.7388"`
@@ -88,10 +148,264 @@ type OrganizationCoord struct {
 } //	@name	OrganizationCoord
 
 type GetOrganizationsByIDsRequest struct {
-	IDs []uuid.UUID `json:"ids" validate:"required" example:"550e8400-e29b-41d4-a716-446655440000,550e8400-e29b-41d4-a716-446655440001"`
+	IDs []uuid.UUID `json:"ids" validate:"required,min=1,max=100" example:"550e8400-e29b-41d4-a716-446655440000,550e8400-e29b-41d4-a716-446655440001"`
 } //	@name	GetOrganizationsByIDsRequest
 
+// GetUsersByIDsRequest is the DTO for batch user lookups
+type GetUsersByIDsRequest struct {
+	IDs []uuid.UUID `json:"ids" validate:"required,min=1,max=100" example:"550e8400-e29b-41d4-a716-446655440000,550e8400-e29b-41d4-a716-446655440001"`
+} //	@name	GetUsersByIDsRequest
+
+// DeleteOrganizationResponse is the DTO reporting the effect of an organization deletion
+type DeleteOrganizationResponse struct {
+	OrganizationID uuid.UUID  `json:"organization_id" example:"550e8400-e29b-41d4-a716-446655440001"`
+	Policy         string     `json:"policy" example:"cascade"`
+	ReassignTo     *uuid.UUID `json:"reassign_to,omitempty" example:"550e8400-e29b-41d4-a716-446655440002"`
+	AffectedUsers  int64      `json:"affected_users" example:"42"`
+	DryRun         bool       `json:"dry_run" example:"true"`
+} //	@name	DeleteOrganizationResponse
+
+// ImportRowResult is the DTO for the outcome of one imported row
+type ImportRowResult struct {
+	Row    int        `json:"row" example:"2"`
+	Action string     `json:"action" example:"created" enums:"created,updated,rejected"`
+	ID     *uuid.UUID `json:"id,omitempty" example:"550e8400-e29b-41d4-a716-446655440000"`
+	Error  string     `json:"error,omitempty" example:"email: invalid email"`
+} //	@name	ImportRowResult
+
+// ImportResponse is the DTO for spreadsheet import responses
+type ImportResponse struct {
+	DryRun   bool              `json:"dry_run" example:"false"`
+	Created  int               `json:"created" example:"10"`
+	Updated  int               `json:"updated" example:"2"`
+	Rejected int               `json:"rejected" example:"1"`
+	Rows     []ImportRowResult `json:"rows"`
+} //	@name	ImportResponse
+
+// AuditEventResponse is the DTO for audit event responses
+type AuditEventResponse struct {
+	ID         uuid.UUID      `json:"id" example:"550e8400-e29b-41d4-a716-446655440009"`
+	ActorID    *uuid.UUID     `json:"actor_id,omitempty" example:"550e8400-e29b-41d4-a716-446655440000"`
+	APIKeyID   *uuid.UUID     `json:"api_key_id,omitempty" example:"550e8400-e29b-41d4-a716-446655440020"`
+	Action     string         `json:"action" example:"update" enums:"create,update,delete"`
+	EntityType string         `json:"entity_type" example:"user" enums:"user,organization"`
+	EntityID   uuid.UUID      `json:"entity_id" example:"550e8400-e29b-41d4-a716-446655440003"`
+	Changes    map[string]any `json:"changes" swaggertype:"object"`
+	RequestID  *string        `json:"request_id,omitempty" example:"0b6f3c1e-7f8a-4c55-9d57-4a5b1a8f1c2e"`
+	IP         *string        `json:"ip,omitempty" example:"203.0.113.7"`
+	CreatedAt  time.Time      `json:"created_at" example:"2026-01-01T12:00:00.00000+07:00"`
+} //	@name	AuditEventResponse
+
+// CreateWebhookRequest is the DTO for webhook subscription creation
+type CreateWebhookRequest struct {
+	URL    string   `json:"url" validate:"required,http_url" example:"https://crm.example.com/hooks/custapi"`
+	Secret *string  `json:"secret" validate:"omitempty,min=16" example:"4f9c2b7e1d8a6f3c5e0b9d2a7c4f1e8b"`
+	Events []string `json:"events" validate:"required,min=1,dive,required" example:"user.created,organization.*"`
+} //	@name	CreateWebhookRequest
+
+// WebhookResponse is the DTO for webhook subscription responses. The secret is
+// only returned when it was generated by the server at creation.
+type WebhookResponse struct {
+	ID        uuid.UUID `json:"id" example:"550e8400-e29b-41d4-a716-446655440010"`
+	URL       string    `json:"url" example:"https://crm.example.com/hooks/custapi"`
+	Secret    string    `json:"secret,omitempty" example:"4f9c2b7e1d8a6f3c5e0b9d2a7c4f1e8b"`
+	Events    []string  `json:"events" example:"user.created,organization.*"`
+	Active    bool      `json:"active" example:"true"`
+	CreatedAt time.Time `json:"created_at" example:"2026-01-01T12:00:00.00000+07:00"`
+	UpdatedAt time.Time `json:"updated_at" example:"2026-01-01T12:00:00.00000+07:00"`
+} //	@name	WebhookResponse
+
+// CreateAPIKeyRequest is the DTO for API key creation
+type CreateAPIKeyRequest struct {
+	Name           string     `json:"name" validate:"required,max=255" example:"Nightly CRM sync"`
+	OrganizationID uuid.UUID  `json:"organization_id" validate:"required" example:"550e8400-e29b-41d4-a716-446655440001"`
+	Permissions    []string   `json:"permissions" validate:"required,min=1,dive,oneof=users:read users:write organizations:read organizations:write" example:"users:read,organizations:read"`
+	ExpiresAt      *time.Time `json:"expires_at" example:"2027-01-01T00:00:00.00000+07:00"`
+} //	@name	CreateAPIKeyRequest
+
+// APIKeyResponse is the DTO for API key responses. The key itself is only returned
+// once, at creation.
+type APIKeyResponse struct {
+	ID   uuid.UUID `json:"id" example:"550e8400-e29b-41d4-a716-446655440020"`
+	Name string    `json:"name" example:"Nightly CRM sync"`
+	// Key authenticates requests in the X-API-Key header or as "Bearer <key>"
+	Key            string     `json:"key,omitempty" example:"custapi_q8Zr1mXw4bT7nKc2Lp9sVd3Fh6Jy0AeGu5oRi1WxQ"`
+	Prefix         string     `json:"prefix" example:"custapi_q8Zr1mXw"`
+	OrganizationID uuid.UUID  `json:"organization_id" example:"550e8400-e29b-41d4-a716-446655440001"`
+	Permissions    []string   `json:"permissions" example:"users:read,organizations:read"`
+	CreatedBy      *uuid.UUID `json:"created_by,omitempty" example:"550e8400-e29b-41d4-a716-446655440000"`
+	ExpiresAt      *time.Time `json:"expires_at,omitempty" example:"2027-01-01T00:00:00.00000+07:00"`
+	LastUsedAt     *time.Time `json:"last_used_at,omitempty" example:"2026-01-01T12:00:00.00000+07:00"`
+	CreatedAt      time.Time  `json:"created_at" example:"2026-01-01T12:00:00.00000+07:00"`
+} //	@name	APIKeyResponse
+
+// SSOProviderRequest is the DTO for configuring the single sign-on of an organization
+type SSOProviderRequest struct {
+	// Issuer is the URL the provider publishes its OpenID configuration under
+	Issuer   string `json:"issuer" validate:"required,url,max=2048" example:"https://login.example.edu"`
+	ClientID string `json:"client_id" validate:"required,max=255" example:"custapi"`
+	// ClientSecret is left out for public clients, which rely on PKCE alone
+	ClientSecret *string `json:"client_secret" example:"s3cr3t-from-the-provider"`
+	// EmailDomains route logins by email address to the provider. Users are only
+	// created on first login for addresses of these domains.
+	EmailDomains []string `json:"email_domains" validate:"required,min=1,dive,fqdn" example:"example.edu"`
+} //	@name	SSOProviderRequest
+
+// SSOProviderResponse is the DTO for the single sign-on configuration of an
+// organization. The client secret is never returned.
+type SSOProviderResponse struct {
+	ID              uuid.UUID `json:"id" example:"550e8400-e29b-41d4-a716-446655440030"`
+	OrganizationID  uuid.UUID `json:"organization_id" example:"550e8400-e29b-41d4-a716-446655440001"`
+	Issuer          string    `json:"issuer" example:"https://login.example.edu"`
+	ClientID        string    `json:"client_id" example:"custapi"`
+	HasClientSecret bool      `json:"has_client_secret" example:"true"`
+	EmailDomains    []string  `json:"email_domains" example:"example.edu"`
+	CreatedAt       time.Time `json:"created_at" example:"2026-01-01T12:00:00.00000+07:00"`
+	UpdatedAt       time.Time `json:"updated_at" example:"2026-01-01T12:00:00.00000+07:00"`
+} //	@name	SSOProviderResponse
+
+// WebhookDeliveryResponse is the DTO for webhook delivery log entries
+type WebhookDeliveryResponse struct {
+	ID             uuid.UUID      `json:"id" example:"550e8400-e29b-41d4-a716-446655440011"`
+	SubscriptionID uuid.UUID      `json:"subscription_id" example:"550e8400-e29b-41d4-a716-446655440010"`
+	EventID        uuid.UUID      `json:"event_id" example:"550e8400-e29b-41d4-a716-446655440012"`
+	EventType      string         `json:"event_type" example:"user.created"`
+	Payload        map[string]any `json:"payload" swaggertype:"object"`
+	Status         string         `json:"status" example:"pending" enums:"pending,succeeded,dead"`
+	Attempts       int            `json:"attempts" example:"1"`
+	NextAttemptAt  time.Time      `json:"next_attempt_at" example:"2026-01-01T12:00:30.00000+07:00"`
+	LastError      *string        `json:"last_error,omitempty" example:"unexpected status 503"`
+	ResponseStatus *int           `json:"response_status,omitempty" example:"503"`
+	DeliveredAt    *time.Time     `json:"delivered_at,omitempty" example:"2026-01-01T12:00:00.00000+07:00"`
+	CreatedAt      time.Time      `json:"created_at" example:"2026-01-01T12:00:00.00000+07:00"`
+} //	@name	WebhookDeliveryResponse
+
 // ErrorResponse is the DTO for error responses
 type ErrorResponse struct {
 	Error string `json:"error" example:"error message"`
 } //	@name	ErrorResponse
+
+// PasswordPolicyErrorResponse is the DTO for passwords that fail the password policy
+type PasswordPolicyErrorResponse struct {
+	Error      string                      `json:"error" example:"password does not meet the password policy"`
+	Violations []PasswordViolationResponse `json:"violations"`
+} //	@name	PasswordPolicyErrorResponse
+
+// PasswordViolationResponse is the DTO for a password policy rule a password failed
+type PasswordViolationResponse struct {
+	Rule    string `json:"rule" example:"min_length" enums:"min_length,max_length,lowercase,uppercase,digit,symbol,personal_info,breached"`
+	Message string `json:"message" example:"must be at least 10 characters long"`
+} //	@name	PasswordViolationResponse
+
+// UploadedImageResponse is the DTO for an uploaded image and its thumbnails
+type UploadedImageResponse struct {
+	URL         string              `json:"url" example:"http://localhost:8080/media/avatars/550e8400-e29b-41d4-a716-446655440000/7c9e6679-7425-40de-944b-e07fc1f90ae7.jpg"`
+	ContentType string              `json:"content_type" example:"image/jpeg"`
+	Size        int                 `json:"size" example:"482133"`
+	Width       int                 `json:"width" example:"1920"`
+	Height      int                 `json:"height" example:"1080"`
+	Thumbnails  []ThumbnailResponse `json:"thumbnails"`
+} //	@name	UploadedImageResponse
+
+// ThumbnailResponse is the DTO for a thumbnail of an uploaded image
+type ThumbnailResponse struct {
+	Size int    `json:"size" example:"128"`
+	URL  string `json:"url" example:"http://localhost:8080/media/avatars/550e8400-e29b-41d4-a716-446655440000/7c9e6679-7425-40de-944b-e07fc1f90ae7_128.jpg"`
+} //	@name	ThumbnailResponse
+
+// MediaCheckResponse is the DTO for the last check of a stored image URL
+type MediaCheckResponse struct {
+	ID             uuid.UUID `json:"id" example:"550e8400-e29b-41d4-a716-446655440012"`
+	EntityType     string    `json:"entity_type" example:"organization" enums:"user,organization"`
+	EntityID       uuid.UUID `json:"entity_id" example:"550e8400-e29b-41d4-a716-446655440003"`
+	Field          string    `json:"field" example:"image_urls" enums:"avatar_url,image_urls"`
+	URL            string    `json:"url" example:"https://example.com/images/hq.jpg"`
+	Status         string    `json:"status" example:"broken" enums:"ok,broken"`
+	ResponseStatus *int      `json:"response_status,omitempty" example:"404"`
+	LastError      *string   `json:"last_error,omitempty" example:"unexpected status 404"`
+	Failures       int       `json:"failures" example:"3"`
+	CheckedAt      time.Time `json:"checked_at" example:"2026-01-01T12:00:00.00000+07:00"`
+} //	@name	MediaCheckResponse
+
+// LoginRequest is the DTO for logging in with an email and password
+type LoginRequest struct {
+	Email    string `json:"email" validate:"required,email" example:"user@example.com"`
+	Password string `json:"password" validate:"required" example:"PassWord123!"`
+} //	@name	LoginRequest
+
+// LoginResponse is the DTO for a started session
+type LoginResponse struct {
+	// Token authenticates requests of the session in the Authorization header as "Bearer <token>"
+	Token     string       `json:"token" example:"kq3RZ7wq0bq1cHcXn8yH2v9pBzF4m1Qe7u3XkQmG5rA"`
+	ExpiresAt time.Time    `json:"expires_at" example:"2026-01-31T12:00:00.00000+07:00"`
+	User      UserResponse `json:"user"`
+} //	@name	LoginResponse
+
+// TwoFactorChallengeResponse is the DTO for a login that needs a second factor
+type TwoFactorChallengeResponse struct {
+	TwoFactorRequired bool `json:"two_factor_required" example:"true"`
+	// ChallengeToken is sent to POST /auth/login/2fa along with a code to complete the login
+	ChallengeToken string    `json:"challenge_token" example:"c2v9Rk1mQ3ZxN0pXYnRlUjhaTDRhSzZ5VWoyTmRzRm8"`
+	ExpiresAt      time.Time `json:"expires_at" example:"2026-01-01T12:05:00.00000+07:00"`
+} //	@name	TwoFactorChallengeResponse
+
+// TwoFactorLoginRequest is the DTO for completing a login with a second factor
+type TwoFactorLoginRequest struct {
+	ChallengeToken string `json:"challenge_token" validate:"required" example:"c2v9Rk1mQ3ZxN0pXYnRlUjhaTDRhSzZ5VWoyTmRzRm8"`
+	// Code is a code of the authenticator app or an unused recovery code
+	Code string `json:"code" validate:"required" example:"123456"`
+} //	@name	TwoFactorLoginRequest
+
+// TwoFactorSetupResponse is the DTO for a TOTP secret to enroll in an authenticator app
+type TwoFactorSetupResponse struct {
+	Secret string `json:"secret" example:"JBSWY3DPEHPK3PXPJBSWY3DPEHPK3PXP"`
+	// OTPAuthURI enrolls the secret when shown as a QR code
+	OTPAuthURI string `json:"otpauth_uri" example:"otpauth://totp/custapi:user@example.com?algorithm=SHA1&digits=6&issuer=custapi&period=30&secret=JBSWY3DPEHPK3PXPJBSWY3DPEHPK3PXP"`
+} //	@name	TwoFactorSetupResponse
+
+// TwoFactorVerifyRequest is the DTO for enabling two-factor authentication with a first code
+type TwoFactorVerifyRequest struct {
+	Code string `json:"code" validate:"required,len=6,numeric" example:"123456"`
+} //	@name	TwoFactorVerifyRequest
+
+// RecoveryCodesResponse is the DTO for the recovery codes of a user, which are only shown once
+type RecoveryCodesResponse struct {
+	RecoveryCodes []string `json:"recovery_codes" example:"k7mq-2xht-9pwe-c4rn,3jdu-8fzy-m2qa-w6tb"`
+} //	@name	RecoveryCodesResponse
+
+// SSOStartRequest is the DTO for starting a single sign-on login. The provider is the
+// one of the organization, or the one handling the domain of the email address.
+type SSOStartRequest struct {
+	Email          string     `json:"email" validate:"required_without=OrganizationID,omitempty,email" example:"user@example.edu"`
+	OrganizationID *uuid.UUID `json:"organization_id" example:"550e8400-e29b-41d4-a716-446655440001"`
+} //	@name	SSOStartRequest
+
+// SSOStartResponse is the DTO for a started single sign-on login
+type SSOStartResponse struct {
+	// AuthorizationURL is the login page of the provider to send the user to
+	AuthorizationURL string    `json:"authorization_url" example:"https://login.example.edu/authorize?client_id=custapi&code_challenge=xGueWjVjGVZzMLvOWkCo9FryyRkOXUC940F0AO7t9is&code_challenge_method=S256&response_type=code&scope=openid+email+profile&state=EKW_lZJ0yC8g2UeuSDPkco_KEHG40CLAfX80eJVogJA"`
+	ExpiresAt        time.Time `json:"expires_at" example:"2026-01-01T12:10:00.00000+07:00"`
+} //	@name	SSOStartResponse
+
+// SSOCallbackRequest is the DTO for completing a single sign-on login with the query
+// parameters the provider sent the user back with
+type SSOCallbackRequest struct {
+	State string `json:"state" validate:"required" example:"EKW_lZJ0yC8g2UeuSDPkco_KEHG40CLAfX80eJVogJA"`
+	Code  string `json:"code" validate:"required" example:"QAxF9fefZpWb6SdqRo0TCmRcJqvdzqoP"`
+} //	@name	SSOCallbackRequest
+
+// ForgotPasswordRequest is the DTO for asking for a password reset link
+type ForgotPasswordRequest struct {
+	Email string `json:"email" validate:"required,email" example:"user@example.com"`
+} //	@name	ForgotPasswordRequest
+
+// ResetPasswordRequest is the DTO for setting a new password with a password reset token
+type ResetPasswordRequest struct {
+	Token    string `json:"token" validate:"required" example:"Xo1b0d8mVh3cQ2r5L9tYw7eKp4sZ6nJ1aF3gU8iB0cM"`
+	Password string `json:"password" validate:"required" example:"NewPassWord456!"`
+} //	@name	ResetPasswordRequest
+
+// VerifyEmailRequest is the DTO for confirming an email address with a verification token
+type VerifyEmailRequest struct {
+	Token string `json:"token" validate:"required" example:"Xo1b0d8mVh3cQ2r5L9tYw7eKp4sZ6nJ1aF3gU8iB0cM"`
+} //	@name	VerifyEmailRequest
@@ -1,8 +1,11 @@
 package models
 
 import (
+	"slices"
+	"strings"
+
 	"github.com/google/uuid"
-	"golang.org/x/crypto/bcrypt"
+	"github.com/lib/pq"
 )
 
 func (req *CreateOrganizationRequest) ToDomain() *Organization {
@@ -31,30 +34,38 @@ func (req *UpdateOrganizationRequest) ToDomain(id uuid.UUID) *Organization {
 	return org
 }
 
+// ToResponse converts an organization to its response DTO, embedding its users when
+// they were loaded
 func (org *Organization) ToResponse() OrganizationResponse {
-	return OrganizationResponse{
+	resp := OrganizationResponse{
 		ID:          org.ID,
 		Name:        org.Name,
-		Latitude:    *org.Latitude,
-		Longitude:   *org.Longitude,
 		Address:     org.Address,
 		Description: org.Description,
 		ImageUrls:   org.ImageUrls,
 		CreatedAt:   org.CreatedAt,
 		UpdatedAt:   org.UpdatedAt,
 	}
+	// The location is missing when a sparse fieldset left it out
+	if org.Latitude != nil && org.Longitude != nil {
+		resp.Latitude, resp.Longitude = *org.Latitude, *org.Longitude
+	}
+	if org.Users != nil {
+		resp.Users = make([]UserResponse, len(org.Users))
+		for i := range org.Users {
+			resp.Users[i] = org.Users[i].ToResponse()
+		}
+	}
+	return resp
 }
 
-func (req *CreateUserRequest) ToDomain() (*User, error) {
-	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(req.Password), bcrypt.DefaultCost)
-	if err != nil {
-		return nil, err
-	}
+// ToDomain converts a create request to a user. The password is left for the caller
+// to set to the hash of req.Password.
+func (req *CreateUserRequest) ToDomain() *User {
 	user := &User{
 		Email:              req.Email,
 		Name:               req.Name,
 		OrganizationID:     req.OrganizationID,
-		Password:           string(hashedPassword),
 		PhoneNumber:        req.PhoneNumber,
 		SocialMedia:        req.SocialMedia,
 		Description:        req.Description,
@@ -64,19 +75,14 @@ func (req *CreateUserRequest) ToDomain() (*User, error) {
 	if req.IsAdmin != nil {
 		user.IsAdmin = *req.IsAdmin
 	}
-	return user, nil
+	return user
 }
 
-func (req *UpdateUserRequest) ToDomain(id uuid.UUID) (*User, error) {
+// ToDomain converts an update request to the changes of a user. Like for creation,
+// the hash of a new password is left for the caller to set.
+func (req *UpdateUserRequest) ToDomain(id uuid.UUID) *User {
 	user := &User{ID: id, PhoneNumber: req.PhoneNumber, SocialMedia: req.SocialMedia, Description: req.Description,
 		AvatarURL: req.AvatarURL, ResearchCategories: req.ResearchCategories}
-	if req.Password != nil {
-		hashedPassword, err := bcrypt.GenerateFromPassword([]byte(*req.Password), bcrypt.DefaultCost)
-		if err != nil {
-			return nil, err
-		}
-		user.Password = string(hashedPassword)
-	}
 	if req.Email != nil {
 		user.Email = *req.Email
 	}
@@ -89,16 +95,21 @@ func (req *UpdateUserRequest) ToDomain(id uuid.UUID) (*User, error) {
 	if req.IsAdmin != nil {
 		user.IsAdmin = *req.IsAdmin
 	}
-	return user, nil
+	return user
 }
 
+// ToResponse converts a user to its response DTO, embedding its organization when it
+// was loaded
 func (user *User) ToResponse() UserResponse {
-	return UserResponse{
+	resp := UserResponse{
 		ID:                 user.ID,
 		Email:              user.Email,
+		EmailVerifiedAt:    user.EmailVerifiedAt,
+		PendingEmail:       user.PendingEmail,
 		Name:               user.Name,
 		OrganizationID:     user.OrganizationID,
 		IsAdmin:            user.IsAdmin,
+		TwoFactorEnabled:   user.TwoFactorEnabled(),
 		PhoneNumber:        user.PhoneNumber,
 		SocialMedia:        user.SocialMedia,
 		Description:        user.Description,
@@ -107,4 +118,279 @@ func (user *User) ToResponse() UserResponse {
 		CreatedAt:          user.CreatedAt,
 		UpdatedAt:          user.UpdatedAt,
 	}
+	if user.Organization.ID != uuid.Nil {
+		org := user.Organization.ToResponse()
+		resp.Organization = &org
+	}
+	return resp
+}
+
+func (e *AuditEvent) ToResponse() AuditEventResponse {
+	return AuditEventResponse{
+		ID:         e.ID,
+		ActorID:    e.ActorID,
+		APIKeyID:   e.APIKeyID,
+		Action:     e.Action,
+		EntityType: e.EntityType,
+		EntityID:   e.EntityID,
+		Changes:    e.Changes,
+		RequestID:  e.RequestID,
+		IP:         e.IP,
+		CreatedAt:  e.CreatedAt,
+	}
+}
+
+// NewOrganizationHistory captures the current state of org as a history version
+func NewOrganizationHistory(org *Organization, operation string, changedBy *uuid.UUID) *OrganizationHistory {
+	return &OrganizationHistory{
+		OrganizationID: org.ID,
+		Operation:      operation,
+		Name:           org.Name,
+		Latitude:       org.Latitude,
+		Longitude:      org.Longitude,
+		Address:        org.Address,
+		Description:    org.Description,
+		ImageUrls:      org.ImageUrls,
+		CreatedAt:      org.CreatedAt,
+		ValidFrom:      org.UpdatedAt,
+		ChangedBy:      changedBy,
+	}
+}
+
+// ToDomain rebuilds the organization as it was in this version
+func (h *OrganizationHistory) ToDomain() *Organization {
+	return &Organization{
+		ID:          h.OrganizationID,
+		Name:        h.Name,
+		Latitude:    h.Latitude,
+		Longitude:   h.Longitude,
+		Address:     h.Address,
+		Description: h.Description,
+		ImageUrls:   h.ImageUrls,
+		CreatedAt:   h.CreatedAt,
+		UpdatedAt:   h.ValidFrom,
+	}
+}
+
+func (v *OrganizationVersion) ToResponse() OrganizationVersionResponse {
+	return OrganizationVersionResponse{
+		Organization: v.Organization.ToResponse(),
+		ValidFrom:    v.ValidFrom,
+		ValidTo:      v.ValidTo,
+		EndedBy:      v.EndedBy,
+		ChangedBy:    v.ChangedBy,
+		Changes:      v.Changes,
+	}
+}
+
+// NewUserHistory captures the current state of user as a history version. It is valid
+// from when the user was created until the repository finds a later version it follows.
+func NewUserHistory(user *User, operation string, changedBy *uuid.UUID) *UserHistory {
+	return &UserHistory{
+		UserID:             user.ID,
+		Operation:          operation,
+		Email:              user.Email,
+		Name:               user.Name,
+		OrganizationID:     user.OrganizationID,
+		IsAdmin:            user.IsAdmin,
+		PhoneNumber:        user.PhoneNumber,
+		SocialMedia:        user.SocialMedia,
+		Description:        user.Description,
+		AvatarURL:          user.AvatarURL,
+		ResearchCategories: user.ResearchCategories,
+		CreatedAt:          user.CreatedAt,
+		ValidFrom:          user.CreatedAt,
+		ChangedBy:          changedBy,
+	}
+}
+
+// ToDomain rebuilds the user as it was in this version
+func (h *UserHistory) ToDomain() *User {
+	return &User{
+		ID:                 h.UserID,
+		Email:              h.Email,
+		Name:               h.Name,
+		OrganizationID:     h.OrganizationID,
+		IsAdmin:            h.IsAdmin,
+		PhoneNumber:        h.PhoneNumber,
+		SocialMedia:        h.SocialMedia,
+		Description:        h.Description,
+		AvatarURL:          h.AvatarURL,
+		ResearchCategories: h.ResearchCategories,
+		CreatedAt:          h.CreatedAt,
+		UpdatedAt:          h.ValidFrom,
+	}
+}
+
+func (v *UserVersion) ToResponse() UserVersionResponse {
+	return UserVersionResponse{
+		User:      v.User.ToResponse(),
+		ValidFrom: v.ValidFrom,
+		ValidTo:   v.ValidTo,
+		EndedBy:   v.EndedBy,
+		ChangedBy: v.ChangedBy,
+		Changes:   v.Changes,
+	}
+}
+
+func (req *CreateWebhookRequest) ToDomain(secret string) *WebhookSubscription {
+	return &WebhookSubscription{
+		URL:    req.URL,
+		Secret: secret,
+		Events: req.Events,
+		Active: true,
+	}
+}
+
+func (s *WebhookSubscription) ToResponse() WebhookResponse {
+	return WebhookResponse{
+		ID:        s.ID,
+		URL:       s.URL,
+		Events:    s.Events,
+		Active:    s.Active,
+		CreatedAt: s.CreatedAt,
+		UpdatedAt: s.UpdatedAt,
+	}
+}
+
+func (req *CreateAPIKeyRequest) ToDomain(prefix, keyHash string, createdBy *uuid.UUID) *APIKey {
+	return &APIKey{
+		Name:           req.Name,
+		Prefix:         prefix,
+		KeyHash:        keyHash,
+		OrganizationID: req.OrganizationID,
+		Permissions:    req.Permissions,
+		CreatedBy:      createdBy,
+		ExpiresAt:      req.ExpiresAt,
+	}
+}
+
+func (k *APIKey) ToResponse() APIKeyResponse {
+	return APIKeyResponse{
+		ID:             k.ID,
+		Name:           k.Name,
+		Prefix:         k.Prefix,
+		OrganizationID: k.OrganizationID,
+		Permissions:    k.Permissions,
+		CreatedBy:      k.CreatedBy,
+		ExpiresAt:      k.ExpiresAt,
+		LastUsedAt:     k.LastUsedAt,
+		CreatedAt:      k.CreatedAt,
+	}
+}
+
+// ToDomain converts the request into the provider of an organization. Email domains
+// are stored lowercase, since domains are case-insensitive.
+func (req *SSOProviderRequest) ToDomain(orgID uuid.UUID) *SSOProvider {
+	provider := &SSOProvider{
+		OrganizationID: orgID,
+		Issuer:         req.Issuer,
+		ClientID:       req.ClientID,
+		EmailDomains:   pq.StringArray{},
+	}
+	if req.ClientSecret != nil {
+		provider.ClientSecret = *req.ClientSecret
+	}
+	for _, domain := range req.EmailDomains {
+		domain = strings.ToLower(domain)
+		if !slices.Contains(provider.EmailDomains, domain) {
+			provider.EmailDomains = append(provider.EmailDomains, domain)
+		}
+	}
+	return provider
+}
+
+func (p *SSOProvider) ToResponse() SSOProviderResponse {
+	return SSOProviderResponse{
+		ID:              p.ID,
+		OrganizationID:  p.OrganizationID,
+		Issuer:          p.Issuer,
+		ClientID:        p.ClientID,
+		HasClientSecret: p.ClientSecret != "",
+		EmailDomains:    p.EmailDomains,
+		CreatedAt:       p.CreatedAt,
+		UpdatedAt:       p.UpdatedAt,
+	}
+}
+
+func (d *WebhookDelivery) ToResponse() WebhookDeliveryResponse {
+	return WebhookDeliveryResponse{
+		ID:             d.ID,
+		SubscriptionID: d.SubscriptionID,
+		EventID:        d.EventID,
+		EventType:      d.EventType,
+		Payload:        d.Payload,
+		Status:         d.Status,
+		Attempts:       d.Attempts,
+		NextAttemptAt:  d.NextAttemptAt,
+		LastError:      d.LastError,
+		ResponseStatus: d.ResponseStatus,
+		DeliveredAt:    d.DeliveredAt,
+		CreatedAt:      d.CreatedAt,
+	}
+}
+
+// Payload is the JSON body sent to consumers of the event
+func (e *DomainEvent) Payload() JSONMap {
+	return JSONMap{
+		"id":             e.ID,
+		"type":           e.Type,
+		"aggregate_type": e.AggregateType,
+		"aggregate_id":   e.AggregateID,
+		"occurred_at":    e.OccurredAt,
+		"data":           e.Data,
+	}
+}
+
+// NewOutboxEvent converts a domain event to its outbox row
+func NewOutboxEvent(e *DomainEvent) *OutboxEvent {
+	return &OutboxEvent{
+		ID:            e.ID,
+		AggregateType: e.AggregateType,
+		AggregateID:   e.AggregateID,
+		EventType:     e.Type,
+		Payload:       e.Data,
+		OccurredAt:    e.OccurredAt,
+	}
+}
+
+func (o *OutboxEvent) ToDomain() DomainEvent {
+	return DomainEvent{
+		ID:            o.ID,
+		Type:          o.EventType,
+		AggregateType: o.AggregateType,
+		AggregateID:   o.AggregateID,
+		OccurredAt:    o.OccurredAt,
+		Data:          o.Payload,
+	}
+}
+
+func (img *UploadedImage) ToResponse() UploadedImageResponse {
+	thumbnails := make([]ThumbnailResponse, len(img.Thumbnails))
+	for i, t := range img.Thumbnails {
+		thumbnails[i] = ThumbnailResponse{Size: t.Size, URL: t.URL}
+	}
+	return UploadedImageResponse{
+		URL:         img.URL,
+		ContentType: img.ContentType,
+		Size:        img.Size,
+		Width:       img.Width,
+		Height:      img.Height,
+		Thumbnails:  thumbnails,
+	}
+}
+
+func (c *MediaCheck) ToResponse() MediaCheckResponse {
+	return MediaCheckResponse{
+		ID:             c.ID,
+		EntityType:     c.EntityType,
+		EntityID:       c.EntityID,
+		Field:          c.Field,
+		URL:            c.URL,
+		Status:         c.Status,
+		ResponseStatus: c.ResponseStatus,
+		LastError:      c.LastError,
+		Failures:       c.Failures,
+		CheckedAt:      c.CheckedAt,
+	}
 }
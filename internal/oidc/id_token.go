@@ -0,0 +1,304 @@
+package oidc
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"slices"
+	"strings"
+	"time"
+)
+
+// clockSkew is how far the clocks of a provider and the API may differ when checking
+// when an ID token was issued and expires
+const clockSkew = time.Minute
+
+// keyRefreshInterval limits how often the keys of a provider are fetched again for
+// tokens signed with an unknown key, which happens when providers rotate keys
+const keyRefreshInterval = time.Minute
+
+// ErrInvalidIDToken is returned for ID tokens that are malformed, badly signed or whose
+// claims do not match the login
+var ErrInvalidIDToken = errors.New("invalid ID token")
+
+// Claims are the claims of an ID token that identify the user
+type Claims struct {
+	Issuer string
+	// Subject identifies the user at the issuer and never changes, unlike their email
+	Subject       string
+	Email         string
+	EmailVerified bool
+	Name          string
+}
+
+// idTokenClaims is the JSON payload of an ID token
+type idTokenClaims struct {
+	Issuer        string          `json:"iss"`
+	Subject       string          `json:"sub"`
+	Audience      audience        `json:"aud"`
+	AuthorizedBy  string          `json:"azp"`
+	ExpiresAt     int64           `json:"exp"`
+	IssuedAt      int64           `json:"iat"`
+	Nonce         string          `json:"nonce"`
+	Email         string          `json:"email"`
+	EmailVerified json.RawMessage `json:"email_verified"`
+	Name          string          `json:"name"`
+}
+
+// audience is the aud claim, which is a single string or an array of them
+type audience []string
+
+func (a *audience) UnmarshalJSON(data []byte) error {
+	var single string
+	if err := json.Unmarshal(data, &single); err == nil {
+		*a = audience{single}
+		return nil
+	}
+	return json.Unmarshal(data, (*[]string)(a))
+}
+
+// signingAlgorithms maps the supported JWS algorithms to their hash
+var signingAlgorithms = map[string]crypto.Hash{
+	"RS256": crypto.SHA256,
+	"RS384": crypto.SHA384,
+	"RS512": crypto.SHA512,
+	"ES256": crypto.SHA256,
+	"ES384": crypto.SHA384,
+	"ES512": crypto.SHA512,
+}
+
+// VerifyIDToken checks the signature of an ID token against the keys of the provider
+// and that it was issued by the provider to clientID for the login with the given
+// nonce, and returns the claims identifying the user
+func (c *Client) VerifyIDToken(ctx context.Context, provider *Provider, clientID, rawToken, nonce string) (*Claims, error) {
+	parts := strings.Split(rawToken, ".")
+	if len(parts) != 3 {
+		return nil, fmt.Errorf("%w: not a JWS compact serialization", ErrInvalidIDToken)
+	}
+
+	var header struct {
+		Algorithm string `json:"alg"`
+		KeyID     string `json:"kid"`
+	}
+	if err := decodeSegment(parts[0], &header); err != nil {
+		return nil, fmt.Errorf("%w: malformed header", ErrInvalidIDToken)
+	}
+	hash, ok := signingAlgorithms[header.Algorithm]
+	if !ok {
+		return nil, fmt.Errorf("%w: unsupported algorithm %q", ErrInvalidIDToken, header.Algorithm)
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, fmt.Errorf("%w: malformed signature", ErrInvalidIDToken)
+	}
+
+	key, err := c.signingKey(ctx, provider, header.KeyID, header.Algorithm)
+	if err != nil {
+		return nil, err
+	}
+	h := hash.New()
+	h.Write([]byte(parts[0] + "." + parts[1]))
+	if !verifySignature(key, header.Algorithm, hash, h.Sum(nil), signature) {
+		return nil, fmt.Errorf("%w: bad signature", ErrInvalidIDToken)
+	}
+
+	var claims idTokenClaims
+	if err := decodeSegment(parts[1], &claims); err != nil {
+		return nil, fmt.Errorf("%w: malformed claims", ErrInvalidIDToken)
+	}
+	if err := claims.validate(provider.Issuer, clientID, nonce, c.now()); err != nil {
+		return nil, err
+	}
+
+	return &Claims{
+		Issuer:        claims.Issuer,
+		Subject:       claims.Subject,
+		Email:         claims.Email,
+		EmailVerified: parseBool(claims.EmailVerified),
+		Name:          claims.Name,
+	}, nil
+}
+
+// validate checks the claims of an ID token (OpenID Connect Core 1.0, section 3.1.3.7)
+func (c *idTokenClaims) validate(issuer, clientID, nonce string, now time.Time) error {
+	switch {
+	case c.Issuer != issuer:
+		return fmt.Errorf("%w: issued by %q", ErrInvalidIDToken, c.Issuer)
+	case !slices.Contains(c.Audience, clientID):
+		return fmt.Errorf("%w: not issued to this client", ErrInvalidIDToken)
+	case len(c.Audience) > 1 && c.AuthorizedBy != clientID:
+		return fmt.Errorf("%w: not authorized for this client", ErrInvalidIDToken)
+	case c.Subject == "":
+		return fmt.Errorf("%w: no subject", ErrInvalidIDToken)
+	case now.After(time.Unix(c.ExpiresAt, 0).Add(clockSkew)):
+		return fmt.Errorf("%w: expired", ErrInvalidIDToken)
+	case time.Unix(c.IssuedAt, 0).After(now.Add(clockSkew)):
+		return fmt.Errorf("%w: issued in the future", ErrInvalidIDToken)
+	case subtle.ConstantTimeCompare([]byte(c.Nonce), []byte(nonce)) != 1:
+		return fmt.Errorf("%w: nonce does not match the login", ErrInvalidIDToken)
+	}
+	return nil
+}
+
+// signingKey returns the key of the provider with the given ID, fetching the keys of
+// the provider again if it is unknown. Without an ID, the only key for the algorithm
+// is used.
+func (c *Client) signingKey(ctx context.Context, provider *Provider, keyID, algorithm string) (any, error) {
+	c.mu.Lock()
+	cached, ok := c.keys[provider.JWKSURI]
+	c.mu.Unlock()
+
+	age := c.now().Sub(cached.fetchedAt)
+	if !ok || age >= discoveryTTL || (findKey(cached.keys, keyID, algorithm) == nil && age >= keyRefreshInterval) {
+		keys, err := c.fetchKeys(ctx, provider.JWKSURI)
+		if err != nil {
+			return nil, err
+		}
+		cached = cachedKeys{keys: keys, fetchedAt: c.now()}
+		c.mu.Lock()
+		c.keys[provider.JWKSURI] = cached
+		c.mu.Unlock()
+	}
+
+	if key := findKey(cached.keys, keyID, algorithm); key != nil {
+		return key, nil
+	}
+	return nil, fmt.Errorf("%w: unknown signing key %q", ErrInvalidIDToken, keyID)
+}
+
+// findKey returns the key with the given ID, or without an ID the only key usable
+// with the algorithm
+func findKey(keys map[string]any, keyID, algorithm string) any {
+	if keyID != "" {
+		return keys[keyID]
+	}
+	var found any
+	for _, key := range keys {
+		if _, isRSA := key.(*rsa.PublicKey); isRSA != strings.HasPrefix(algorithm, "RS") {
+			continue
+		}
+		if found != nil {
+			return nil
+		}
+		found = key
+	}
+	return found
+}
+
+// jsonWebKey is a public key of a JWK set (RFC 7517)
+type jsonWebKey struct {
+	KeyType string `json:"kty"`
+	KeyID   string `json:"kid"`
+	Use     string `json:"use"`
+	N       string `json:"n"`
+	E       string `json:"e"`
+	Curve   string `json:"crv"`
+	X       string `json:"x"`
+	Y       string `json:"y"`
+}
+
+// fetchKeys fetches the signing keys of a JWK set by key ID. Keys of other types or
+// uses are left out.
+func (c *Client) fetchKeys(ctx context.Context, jwksURI string) (map[string]any, error) {
+	var set struct {
+		Keys []jsonWebKey `json:"keys"`
+	}
+	if err := c.getJSON(ctx, jwksURI, &set); err != nil {
+		return nil, fmt.Errorf("fetching signing keys: %w", err)
+	}
+
+	keys := make(map[string]any, len(set.Keys))
+	for _, jwk := range set.Keys {
+		if jwk.Use != "" && jwk.Use != "sig" {
+			continue
+		}
+		if key, err := jwk.publicKey(); err == nil {
+			keys[jwk.KeyID] = key
+		}
+	}
+	return keys, nil
+}
+
+// publicKey decodes an RSA or EC public key
+func (k *jsonWebKey) publicKey() (any, error) {
+	switch k.KeyType {
+	case "RSA":
+		n, err := base64.RawURLEncoding.DecodeString(k.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := base64.RawURLEncoding.DecodeString(k.E)
+		if err != nil || len(e) == 0 || len(e) > 4 {
+			return nil, errors.New("invalid RSA exponent")
+		}
+		return &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}, nil
+
+	case "EC":
+		curves := map[string]elliptic.Curve{"P-256": elliptic.P256(), "P-384": elliptic.P384(), "P-521": elliptic.P521()}
+		curve, ok := curves[k.Curve]
+		if !ok {
+			return nil, fmt.Errorf("unsupported curve %q", k.Curve)
+		}
+		x, err := base64.RawURLEncoding.DecodeString(k.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := base64.RawURLEncoding.DecodeString(k.Y)
+		if err != nil {
+			return nil, err
+		}
+		return ecdsa.ParseUncompressedPublicKey(curve, slices.Concat([]byte{4}, x, y))
+	}
+	return nil, fmt.Errorf("unsupported key type %q", k.KeyType)
+}
+
+// verifySignature checks an RS* (PKCS #1 v1.5) or ES* (r || s) signature of digest,
+// made with a key of the type the algorithm calls for
+func verifySignature(key any, algorithm string, hash crypto.Hash, digest, signature []byte) bool {
+	switch key := key.(type) {
+	case *rsa.PublicKey:
+		if !strings.HasPrefix(algorithm, "RS") {
+			return false
+		}
+		return rsa.VerifyPKCS1v15(key, hash, digest, signature) == nil
+	case *ecdsa.PublicKey:
+		if !strings.HasPrefix(algorithm, "ES") {
+			return false
+		}
+		size := (key.Curve.Params().BitSize + 7) / 8
+		if len(signature) != 2*size {
+			return false
+		}
+		r := new(big.Int).SetBytes(signature[:size])
+		s := new(big.Int).SetBytes(signature[size:])
+		return ecdsa.Verify(key, digest, r, s)
+	}
+	return false
+}
+
+// decodeSegment decodes a base64url encoded JSON segment of a JWS into v
+func decodeSegment(segment string, v any) error {
+	data, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
+
+// parseBool reads a boolean claim, which some providers send as the string "true"
+func parseBool(raw json.RawMessage) bool {
+	var b bool
+	if err := json.Unmarshal(raw, &b); err == nil {
+		return b
+	}
+	var s string
+	return json.Unmarshal(raw, &s) == nil && s == "true"
+}
@@ -0,0 +1,357 @@
+package oidc
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+const (
+	testClientID = "custapi"
+	testNonce    = "n-0S6_WzA2Mj"
+)
+
+// mockProvider is an OpenID provider serving its discovery document and JWK set, whose
+// keys can be rotated
+type mockProvider struct {
+	server *httptest.Server
+
+	mu   sync.Mutex
+	keys map[string]crypto.Signer
+	// jwksFetches counts the requests for the JWK set
+	jwksFetches int
+}
+
+func newMockProvider(t *testing.T) *mockProvider {
+	t.Helper()
+	p := &mockProvider{keys: make(map[string]crypto.Signer)}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(Provider{
+			Issuer:                p.server.URL,
+			AuthorizationEndpoint: p.server.URL + "/authorize",
+			TokenEndpoint:         p.server.URL + "/token",
+			JWKSURI:               p.server.URL + "/jwks",
+		})
+	})
+	mux.HandleFunc("/jwks", func(w http.ResponseWriter, r *http.Request) {
+		p.mu.Lock()
+		defer p.mu.Unlock()
+		p.jwksFetches++
+		set := struct {
+			Keys []jsonWebKey `json:"keys"`
+		}{Keys: []jsonWebKey{}}
+		for kid, key := range p.keys {
+			set.Keys = append(set.Keys, publicJWK(kid, key.Public()))
+		}
+		_ = json.NewEncoder(w).Encode(set)
+	})
+	p.server = httptest.NewServer(mux)
+	t.Cleanup(p.server.Close)
+	return p
+}
+
+// setKeys replaces the published keys
+func (p *mockProvider) setKeys(keys map[string]crypto.Signer) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.keys = keys
+}
+
+func (p *mockProvider) fetches() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.jwksFetches
+}
+
+// publicJWK encodes a public key as a JWK
+func publicJWK(kid string, key crypto.PublicKey) jsonWebKey {
+	switch key := key.(type) {
+	case *rsa.PublicKey:
+		return jsonWebKey{KeyType: "RSA", KeyID: kid, Use: "sig",
+			N: b64(key.N.Bytes()), E: b64(big.NewInt(int64(key.E)).Bytes())}
+	case *ecdsa.PublicKey:
+		size := (key.Curve.Params().BitSize + 7) / 8
+		return jsonWebKey{KeyType: "EC", KeyID: kid, Use: "sig", Curve: key.Curve.Params().Name,
+			X: b64(key.X.FillBytes(make([]byte, size))), Y: b64(key.Y.FillBytes(make([]byte, size)))}
+	}
+	panic("unsupported key type")
+}
+
+// signToken creates a JWS of claims. The header names alg and, unless empty, kid.
+func signToken(t *testing.T, key crypto.Signer, alg, kid string, claims map[string]any) string {
+	t.Helper()
+	header := map[string]string{"alg": alg, "typ": "JWT"}
+	if kid != "" {
+		header["kid"] = kid
+	}
+	signingInput := segment(t, header) + "." + segment(t, claims)
+
+	var signature []byte
+	switch {
+	case alg == "none":
+	case strings.HasPrefix(alg, "HS"):
+		// The classic confusion attack: an HMAC keyed with the provider's public key
+		mac := hmac.New(sha256.New, key.Public().(*rsa.PublicKey).N.Bytes())
+		mac.Write([]byte(signingInput))
+		signature = mac.Sum(nil)
+	default:
+		hash := signingAlgorithms[alg]
+		h := hash.New()
+		h.Write([]byte(signingInput))
+		var err error
+		switch key := key.(type) {
+		case *rsa.PrivateKey:
+			signature, err = rsa.SignPKCS1v15(rand.Reader, key, hash, h.Sum(nil))
+		case *ecdsa.PrivateKey:
+			var r, s *big.Int
+			r, s, err = ecdsa.Sign(rand.Reader, key, h.Sum(nil))
+			size := (key.Curve.Params().BitSize + 7) / 8
+			signature = append(r.FillBytes(make([]byte, size)), s.FillBytes(make([]byte, size))...)
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	return signingInput + "." + b64(signature)
+}
+
+func segment(t *testing.T, v any) string {
+	t.Helper()
+	data, err := json.Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return b64(data)
+}
+
+func b64(data []byte) string {
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func TestVerifyIDToken(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	otherKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	mock := newMockProvider(t)
+	mock.setKeys(map[string]crypto.Signer{"rsa": rsaKey, "ec": ecKey})
+	now := time.Unix(1_700_000_000, 0)
+	client := NewClient(mock.server.Client())
+	client.now = func() time.Time { return now }
+	provider, err := client.Discover(context.Background(), mock.server.URL)
+	if err != nil {
+		t.Fatalf("Discover() error = %v", err)
+	}
+
+	// claims returns valid claims with the given changes; nil values remove a claim
+	claims := func(changes map[string]any) map[string]any {
+		c := map[string]any{
+			"iss":            mock.server.URL,
+			"sub":            "248289761001",
+			"aud":            testClientID,
+			"exp":            now.Add(time.Hour).Unix(),
+			"iat":            now.Add(-time.Minute).Unix(),
+			"nonce":          testNonce,
+			"email":          "jane@example.com",
+			"email_verified": true,
+			"name":           "Jane Doe",
+		}
+		for k, v := range changes {
+			if v == nil {
+				delete(c, k)
+			} else {
+				c[k] = v
+			}
+		}
+		return c
+	}
+	valid := signToken(t, rsaKey, "RS256", "rsa", claims(nil))
+	parts := strings.Split(valid, ".")
+
+	tests := []struct {
+		name    string
+		token   string
+		nonce   string
+		wantErr string
+	}{
+		{"RS256", valid, testNonce, ""},
+		{"RS512", signToken(t, rsaKey, "RS512", "rsa", claims(nil)), testNonce, ""},
+		{"ES256", signToken(t, ecKey, "ES256", "ec", claims(nil)), testNonce, ""},
+		{"only key of the algorithm without kid", signToken(t, ecKey, "ES256", "", claims(nil)), testNonce, ""},
+
+		// alg
+		{"alg none", signToken(t, rsaKey, "none", "rsa", claims(nil)), testNonce, `unsupported algorithm "none"`},
+		{"alg HS256 keyed with the public key", signToken(t, rsaKey, "HS256", "rsa", claims(nil)), testNonce, `unsupported algorithm "HS256"`},
+		{"alg of another key type", signToken(t, rsaKey, "RS256", "ec", claims(nil)), testNonce, "bad signature"},
+		{"alg differing from the signature", segment(t, map[string]string{"alg": "RS384", "kid": "rsa"}) + "." + parts[1] + "." + parts[2], testNonce, "bad signature"},
+		{"signed by another key", signToken(t, otherKey, "RS256", "rsa", claims(nil)), testNonce, "bad signature"},
+		{"tampered claims", parts[0] + "." + segment(t, claims(map[string]any{"sub": "admin"})) + "." + parts[2], testNonce, "bad signature"},
+		{"no signature", parts[0] + "." + parts[1] + ".", testNonce, "bad signature"},
+		{"not a JWS", parts[0] + "." + parts[1], testNonce, "not a JWS"},
+
+		// iss and sub
+		{"other issuer", signToken(t, rsaKey, "RS256", "rsa", claims(map[string]any{"iss": "https://evil.example.com"})), testNonce, "issued by"},
+		{"no subject", signToken(t, rsaKey, "RS256", "rsa", claims(map[string]any{"sub": nil})), testNonce, "no subject"},
+
+		// aud and azp
+		{"other audience", signToken(t, rsaKey, "RS256", "rsa", claims(map[string]any{"aud": "other-client"})), testNonce, "not issued to this client"},
+		{"audience array with azp", signToken(t, rsaKey, "RS256", "rsa", claims(map[string]any{"aud": []string{"other-client", testClientID}, "azp": testClientID})), testNonce, ""},
+		{"single audience array", signToken(t, rsaKey, "RS256", "rsa", claims(map[string]any{"aud": []string{testClientID}})), testNonce, ""},
+		{"audience array without azp", signToken(t, rsaKey, "RS256", "rsa", claims(map[string]any{"aud": []string{"other-client", testClientID}})), testNonce, "not authorized for this client"},
+		{"audience array with other azp", signToken(t, rsaKey, "RS256", "rsa", claims(map[string]any{"aud": []string{"other-client", testClientID}, "azp": "other-client"})), testNonce, "not authorized for this client"},
+		{"audience array without the client", signToken(t, rsaKey, "RS256", "rsa", claims(map[string]any{"aud": []string{"a", "b"}, "azp": testClientID})), testNonce, "not issued to this client"},
+
+		// nonce
+		{"other nonce", valid, "another-login", "nonce does not match"},
+		{"no nonce claim", signToken(t, rsaKey, "RS256", "rsa", claims(map[string]any{"nonce": nil})), testNonce, "nonce does not match"},
+
+		// exp and iat
+		{"expired within the clock skew", signToken(t, rsaKey, "RS256", "rsa", claims(map[string]any{"exp": now.Add(-30 * time.Second).Unix()})), testNonce, ""},
+		{"expired", signToken(t, rsaKey, "RS256", "rsa", claims(map[string]any{"exp": now.Add(-2 * time.Minute).Unix()})), testNonce, "expired"},
+		{"no expiry", signToken(t, rsaKey, "RS256", "rsa", claims(map[string]any{"exp": nil})), testNonce, "expired"},
+		{"issued in the future", signToken(t, rsaKey, "RS256", "rsa", claims(map[string]any{"iat": now.Add(2 * time.Minute).Unix()})), testNonce, "issued in the future"},
+
+		// kid
+		{"unknown kid", signToken(t, rsaKey, "RS256", "retired", claims(nil)), testNonce, `unknown signing key "retired"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := client.VerifyIDToken(context.Background(), provider, testClientID, tt.token, tt.nonce)
+			if tt.wantErr != "" {
+				if !errors.Is(err, ErrInvalidIDToken) || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("VerifyIDToken() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("VerifyIDToken() error = %v", err)
+			}
+			want := Claims{Issuer: mock.server.URL, Subject: "248289761001", Email: "jane@example.com", EmailVerified: true, Name: "Jane Doe"}
+			if *got != want {
+				t.Errorf("VerifyIDToken() = %+v, want %+v", *got, want)
+			}
+		})
+	}
+}
+
+func TestVerifyIDTokenEmailVerifiedString(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	mock := newMockProvider(t)
+	mock.setKeys(map[string]crypto.Signer{"ec": key})
+	client := NewClient(mock.server.Client())
+	provider, err := client.Discover(context.Background(), mock.server.URL)
+	if err != nil {
+		t.Fatalf("Discover() error = %v", err)
+	}
+
+	for _, verified := range []any{"true", "false", true, false} {
+		token := signToken(t, key, "ES256", "ec", map[string]any{
+			"iss": mock.server.URL, "sub": "1", "aud": testClientID, "nonce": testNonce,
+			"exp": time.Now().Add(time.Hour).Unix(), "email_verified": verified,
+		})
+		claims, err := client.VerifyIDToken(context.Background(), provider, testClientID, token, testNonce)
+		if err != nil {
+			t.Fatalf("VerifyIDToken() error = %v", err)
+		}
+		if want := verified == "true" || verified == true; claims.EmailVerified != want {
+			t.Errorf("email_verified %#v read as %v, want %v", verified, claims.EmailVerified, want)
+		}
+	}
+}
+
+func TestVerifyIDTokenKeyRotation(t *testing.T) {
+	oldKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	newKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	mock := newMockProvider(t)
+	mock.setKeys(map[string]crypto.Signer{"2024": oldKey})
+	now := time.Unix(1_700_000_000, 0)
+	client := NewClient(mock.server.Client())
+	client.now = func() time.Time { return now }
+	provider, err := client.Discover(context.Background(), mock.server.URL)
+	if err != nil {
+		t.Fatalf("Discover() error = %v", err)
+	}
+
+	token := func(key crypto.Signer, kid string) string {
+		return signToken(t, key, "RS256", kid, map[string]any{
+			"iss": mock.server.URL, "sub": "1", "aud": testClientID, "nonce": testNonce,
+			"exp": now.Add(24 * time.Hour).Unix(), "iat": now.Unix(),
+		})
+	}
+	verify := func(raw string) error {
+		_, err := client.VerifyIDToken(context.Background(), provider, testClientID, raw, testNonce)
+		return err
+	}
+
+	if err := verify(token(oldKey, "2024")); err != nil {
+		t.Fatalf("token of the current key: %v", err)
+	}
+	mock.setKeys(map[string]crypto.Signer{"2024": oldKey, "2025": newKey})
+
+	withdrawn := map[string]crypto.Signer{"2025": newKey}
+	steps := []struct {
+		name    string
+		advance time.Duration
+		// keys, if not nil, are published before the step
+		keys        map[string]crypto.Signer
+		token       string
+		wantErr     bool
+		wantFetches int
+	}{
+		{"cached key", 0, nil, token(oldKey, "2024"), false, 1},
+		{"new key right after a fetch", 10 * time.Second, nil, token(newKey, "2025"), true, 1},
+		{"new key once the keys may be refreshed", keyRefreshInterval, nil, token(newKey, "2025"), false, 2},
+		{"unknown key does not refetch", 10 * time.Second, nil, token(newKey, "2026"), true, 2},
+		{"old key until it is withdrawn", 0, withdrawn, token(oldKey, "2024"), false, 2},
+		{"keys expire from the cache", discoveryTTL, nil, token(newKey, "2025"), false, 3},
+		{"withdrawn key", 0, nil, token(oldKey, "2024"), true, 3},
+	}
+	for _, step := range steps {
+		now = now.Add(step.advance)
+		if step.keys != nil {
+			mock.setKeys(step.keys)
+		}
+		err := verify(step.token)
+		if (err != nil) != step.wantErr {
+			t.Errorf("%s: VerifyIDToken() error = %v, wantErr %v", step.name, err, step.wantErr)
+		}
+		if got := mock.fetches(); got != step.wantFetches {
+			t.Errorf("%s: %d key fetches, want %d", step.name, got, step.wantFetches)
+		}
+	}
+}
@@ -0,0 +1,217 @@
+package oidc
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// discoveryTTL is how long the configuration and keys of a provider are cached
+const discoveryTTL = time.Hour
+
+// Scopes asked for at login: the user's ID, email address and name
+var Scopes = []string{"openid", "email", "profile"}
+
+// Provider is the part of the configuration an OpenID provider publishes at
+// /.well-known/openid-configuration that the authorization code flow needs
+type Provider struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+}
+
+// Client logs users in with OpenID Connect providers using the authorization code
+// flow with PKCE. It caches the configuration and signing keys of providers.
+type Client struct {
+	httpClient *http.Client
+	now        func() time.Time
+
+	mu        sync.Mutex
+	providers map[string]cachedProvider
+	keys      map[string]cachedKeys
+}
+
+type cachedProvider struct {
+	provider  *Provider
+	fetchedAt time.Time
+}
+
+type cachedKeys struct {
+	keys      map[string]any
+	fetchedAt time.Time
+}
+
+// NewClient creates an OpenID Connect client. A nil client uses one with a 10 second timeout.
+func NewClient(httpClient *http.Client) *Client {
+	if httpClient == nil {
+		httpClient = &http.Client{Timeout: 10 * time.Second}
+	}
+	return &Client{
+		httpClient: httpClient,
+		now:        time.Now,
+		providers:  make(map[string]cachedProvider),
+		keys:       make(map[string]cachedKeys),
+	}
+}
+
+// Discover fetches the configuration of the provider identified by issuer
+func (c *Client) Discover(ctx context.Context, issuer string) (*Provider, error) {
+	c.mu.Lock()
+	cached, ok := c.providers[issuer]
+	c.mu.Unlock()
+	if ok && c.now().Sub(cached.fetchedAt) < discoveryTTL {
+		return cached.provider, nil
+	}
+
+	provider := new(Provider)
+	if err := c.getJSON(ctx, strings.TrimSuffix(issuer, "/")+"/.well-known/openid-configuration", provider); err != nil {
+		return nil, fmt.Errorf("discovering %s: %w", issuer, err)
+	}
+	// The issuer is compared with the iss claim of ID tokens, so it must be exactly the
+	// one configured (OpenID Connect Discovery 1.0, section 4.3)
+	if provider.Issuer != issuer {
+		return nil, fmt.Errorf("discovering %s: provider claims to be issuer %q", issuer, provider.Issuer)
+	}
+	if provider.AuthorizationEndpoint == "" || provider.TokenEndpoint == "" || provider.JWKSURI == "" {
+		return nil, fmt.Errorf("discovering %s: incomplete provider configuration", issuer)
+	}
+
+	c.mu.Lock()
+	c.providers[issuer] = cachedProvider{provider: provider, fetchedAt: c.now()}
+	c.mu.Unlock()
+	return provider, nil
+}
+
+// AuthRequest is an authorization request in progress. Its state, nonce and code
+// verifier are kept by the relying party until the user comes back with a code.
+type AuthRequest struct {
+	State        string
+	Nonce        string
+	CodeVerifier string
+}
+
+// NewAuthRequest returns an authorization request with random state, nonce and PKCE code verifier
+func NewAuthRequest() (*AuthRequest, error) {
+	var values [3]string
+	for i := range values {
+		b := make([]byte, 32)
+		if _, err := rand.Read(b); err != nil {
+			return nil, err
+		}
+		values[i] = base64.RawURLEncoding.EncodeToString(b)
+	}
+	return &AuthRequest{State: values[0], Nonce: values[1], CodeVerifier: values[2]}, nil
+}
+
+// CodeChallenge returns the S256 PKCE code challenge of the code verifier (RFC 7636)
+func (r *AuthRequest) CodeChallenge() string {
+	sum := sha256.Sum256([]byte(r.CodeVerifier))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+// AuthCodeURL returns the URL of the provider's login page for an authorization
+// request, which sends the user back to redirectURL with a code and the state.
+// loginHint, if not empty, prefills the user's email address.
+func (p *Provider) AuthCodeURL(clientID, redirectURL, loginHint string, req *AuthRequest) (string, error) {
+	u, err := url.Parse(p.AuthorizationEndpoint)
+	if err != nil {
+		return "", err
+	}
+	query := u.Query()
+	query.Set("response_type", "code")
+	query.Set("client_id", clientID)
+	query.Set("redirect_uri", redirectURL)
+	query.Set("scope", strings.Join(Scopes, " "))
+	query.Set("state", req.State)
+	query.Set("nonce", req.Nonce)
+	query.Set("code_challenge", req.CodeChallenge())
+	query.Set("code_challenge_method", "S256")
+	if loginHint != "" {
+		query.Set("login_hint", loginHint)
+	}
+	u.RawQuery = query.Encode()
+	return u.String(), nil
+}
+
+// Exchange trades an authorization code for the tokens of the user and returns the
+// ID token. Confidential clients authenticate with client_secret_basic; public clients,
+// with an empty secret, only with the code verifier.
+func (c *Client) Exchange(ctx context.Context, provider *Provider, clientID, clientSecret, redirectURL, code, codeVerifier string) (string, error) {
+	form := url.Values{}
+	form.Set("grant_type", "authorization_code")
+	form.Set("code", code)
+	form.Set("redirect_uri", redirectURL)
+	form.Set("code_verifier", codeVerifier)
+	if clientSecret == "" {
+		form.Set("client_id", clientID)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, provider.TokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	if clientSecret != "" {
+		req.SetBasicAuth(url.QueryEscape(clientID), url.QueryEscape(clientSecret))
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	var body struct {
+		IDToken          string `json:"id_token"`
+		Error            string `json:"error"`
+		ErrorDescription string `json:"error_description"`
+	}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(&body); err != nil {
+		return "", fmt.Errorf("token endpoint returned status %d", resp.StatusCode)
+	}
+	if body.Error != "" && body.ErrorDescription != "" {
+		return "", fmt.Errorf("token endpoint returned %s: %s", body.Error, body.ErrorDescription)
+	}
+	if body.Error != "" {
+		return "", fmt.Errorf("token endpoint returned %s", body.Error)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("token endpoint returned status %d", resp.StatusCode)
+	}
+	if body.IDToken == "" {
+		return "", errors.New("token endpoint returned no ID token")
+	}
+	return body.IDToken, nil
+}
+
+// getJSON fetches url and decodes its JSON body into v
+func (c *Client) getJSON(ctx context.Context, url string, v any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s returned status %d", url, resp.StatusCode)
+	}
+	return json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(v)
+}
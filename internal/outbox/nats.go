@@ -0,0 +1,68 @@
+package outbox
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/hoshina-dev/custapi/internal/models"
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+)
+
+// NATSSink publishes every event to a JetStream subject named after its type, such as
+// "custapi.user.created". An event only counts as published once a stream capturing
+// the subject has stored it and acknowledged it. The event ID is sent as the message
+// ID, so the stream drops the duplicates of events published again within its
+// duplicate window.
+type NATSSink struct {
+	conn    *nats.Conn
+	js      jetstream.JetStream
+	prefix  string
+	timeout time.Duration
+}
+
+// NewNATSSink creates a sink for the server at url, like "nats://localhost:4222". The
+// connection is made in the background and kept up for as long as the sink is open.
+func NewNATSSink(url, prefix string, timeout time.Duration) (*NATSSink, error) {
+	conn, err := nats.Connect(url,
+		nats.Name("custapi"),
+		nats.Timeout(timeout),
+		nats.RetryOnFailedConnect(true),
+		nats.MaxReconnects(-1),
+	)
+	if err != nil {
+		return nil, err
+	}
+	js, err := jetstream.New(conn)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return &NATSSink{conn: conn, js: js, prefix: prefix, timeout: timeout}, nil
+}
+
+// Publish publishes events one after the other and waits for the stream to
+// acknowledge each of them
+func (s *NATSSink) Publish(ctx context.Context, events ...models.DomainEvent) error {
+	for i := range events {
+		body, err := json.Marshal(events[i].Payload())
+		if err != nil {
+			return err
+		}
+
+		pubCtx, cancel := context.WithTimeout(ctx, s.timeout)
+		_, err = s.js.Publish(pubCtx, s.prefix+"."+events[i].Type, body, jetstream.WithMsgID(events[i].ID.String()))
+		cancel()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Close closes the connection to the server
+func (s *NATSSink) Close() error {
+	s.conn.Close()
+	return nil
+}
@@ -0,0 +1,164 @@
+// Package outbox publishes the domain events stored in the transactional outbox
+package outbox
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hoshina-dev/custapi/internal/models"
+	"github.com/hoshina-dev/custapi/internal/repositories"
+)
+
+// Sink receives published domain events. A sink may receive the same event more
+// than once and should tolerate duplicates, using the event ID to detect them.
+type Sink interface {
+	Publish(ctx context.Context, events ...models.DomainEvent) error
+}
+
+// Config controls how the relay polls the outbox
+type Config struct {
+	PollInterval time.Duration
+	BatchSize    int
+	// Lease is how long a relay has to publish the batch it claimed before other
+	// relays may claim its events
+	Lease time.Duration
+	// Retention is how long published events are kept before being removed
+	Retention time.Duration
+	// MaxAttempts is how many times an event is tried before the relay gives up on it
+	// and publishes the later events of its aggregate without it. Zero retries forever.
+	MaxAttempts int
+}
+
+// Relay moves events from the outbox to the sinks. Events are published at least
+// once, in the order they were written. When an event fails, the later events of the
+// same aggregate are held back until it succeeds so consumers never see them out of
+// order, or until the relay gives up on it after MaxAttempts. Events given up on are
+// kept in the outbox with their last error.
+type Relay struct {
+	txManager   repositories.TxManager
+	repo        repositories.OutboxRepository
+	sinks       []Sink
+	cfg         Config
+	lastCleanup time.Time
+}
+
+// NewRelay creates an outbox relay publishing to sinks
+func NewRelay(txManager repositories.TxManager, repo repositories.OutboxRepository, sinks []Sink, cfg Config) *Relay {
+	return &Relay{txManager: txManager, repo: repo, sinks: sinks, cfg: cfg}
+}
+
+// Run publishes outbox events until ctx is cancelled
+func (r *Relay) Run(ctx context.Context) {
+	ticker := time.NewTicker(r.cfg.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		// Keep draining while full batches come back
+		for {
+			n, err := r.RunOnce(ctx)
+			if err != nil {
+				log.Printf("outbox: %v", err)
+			}
+			if err != nil || n < r.cfg.BatchSize || ctx.Err() != nil {
+				break
+			}
+		}
+		r.cleanup(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// RunOnce publishes one batch of events and returns how many were published. The
+// batch is claimed in a transaction of its own, so no transaction or lock is held while
+// the sinks are waited on, and the outcome is recorded in a second one.
+func (r *Relay) RunOnce(ctx context.Context) (int, error) {
+	events, err := r.repo.Claim(ctx, r.cfg.BatchSize, r.cfg.Lease)
+	if err != nil || len(events) == 0 {
+		return 0, err
+	}
+
+	// Stop publishing early enough to record the outcome before the claim runs out.
+	// The events left over are published with the next batch.
+	publishCtx, cancel := context.WithTimeout(ctx, r.cfg.Lease*3/4)
+	defer cancel()
+
+	type failure struct {
+		id     uuid.UUID
+		err    string
+		giveUp bool
+	}
+	var published []uuid.UUID
+	var failed []failure
+	blocked := make(map[uuid.UUID]bool)
+	claimed := make([]uuid.UUID, len(events))
+	for i := range events {
+		e := &events[i]
+		claimed[i] = e.ID
+		if blocked[e.AggregateID] || publishCtx.Err() != nil {
+			continue
+		}
+		if err := r.publish(publishCtx, e.ToDomain()); err != nil {
+			blocked[e.AggregateID] = true
+			giveUp := r.cfg.MaxAttempts > 0 && e.Attempts+1 >= r.cfg.MaxAttempts
+			log.Printf("outbox: publishing %s %s (attempt %d): %v", e.EventType, e.ID, e.Attempts+1, err)
+			if giveUp {
+				log.Printf("outbox: giving up on %s %s after %d attempts", e.EventType, e.ID, e.Attempts+1)
+			}
+			failed = append(failed, failure{id: e.ID, err: err.Error(), giveUp: giveUp})
+			continue
+		}
+		published = append(published, e.ID)
+	}
+
+	// The events were published even if ctx was cancelled meanwhile
+	err = r.txManager.WithinTransaction(context.WithoutCancel(ctx), func(ctx context.Context) error {
+		if err := r.repo.MarkPublished(ctx, published); err != nil {
+			return err
+		}
+		for _, f := range failed {
+			if err := r.repo.MarkFailed(ctx, f.id, f.err, f.giveUp); err != nil {
+				return err
+			}
+		}
+		return r.repo.Release(ctx, claimed)
+	})
+	if err != nil {
+		return 0, err
+	}
+	return len(published), nil
+}
+
+// publish hands an event to every sink
+func (r *Relay) publish(ctx context.Context, event models.DomainEvent) error {
+	for _, sink := range r.sinks {
+		if err := sink.Publish(ctx, event); err != nil {
+			return fmt.Errorf("%T: %w", sink, err)
+		}
+	}
+	return nil
+}
+
+// cleanup removes events published longer than the retention ago, at most once an hour
+func (r *Relay) cleanup(ctx context.Context) {
+	if r.cfg.Retention <= 0 || time.Since(r.lastCleanup) < time.Hour {
+		return
+	}
+	r.lastCleanup = time.Now()
+
+	n, err := r.repo.DeletePublishedBefore(ctx, time.Now().Add(-r.cfg.Retention))
+	if err != nil {
+		log.Printf("outbox: cleanup: %v", err)
+		return
+	}
+	if n > 0 {
+		log.Printf("outbox: removed %d published events", n)
+	}
+}
@@ -0,0 +1,200 @@
+package outbox
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hoshina-dev/custapi/internal/models"
+	"github.com/hoshina-dev/custapi/internal/repositories"
+)
+
+// fakeTxManager runs fn directly and tracks whether a transaction is open
+type fakeTxManager struct {
+	open bool
+}
+
+func (m *fakeTxManager) WithinTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	m.open = true
+	defer func() { m.open = false }()
+	return fn(ctx)
+}
+
+// fakeRepository hands out its events as one claim and records their outcome
+type fakeRepository struct {
+	repositories.OutboxRepository
+	tx        *fakeTxManager
+	events    []models.OutboxEvent
+	published []uuid.UUID
+	failed    []uuid.UUID
+	gaveUp    []uuid.UUID
+	released  []uuid.UUID
+	// outsideTx counts outcomes recorded outside of a transaction
+	outsideTx int
+}
+
+func (r *fakeRepository) Claim(ctx context.Context, limit int, lease time.Duration) ([]models.OutboxEvent, error) {
+	return r.events, nil
+}
+
+func (r *fakeRepository) MarkPublished(ctx context.Context, ids []uuid.UUID) error {
+	r.record()
+	r.published = append(r.published, ids...)
+	return nil
+}
+
+func (r *fakeRepository) MarkFailed(ctx context.Context, id uuid.UUID, lastError string, giveUp bool) error {
+	r.record()
+	r.failed = append(r.failed, id)
+	if giveUp {
+		r.gaveUp = append(r.gaveUp, id)
+	}
+	return nil
+}
+
+func (r *fakeRepository) Release(ctx context.Context, ids []uuid.UUID) error {
+	r.record()
+	r.released = append(r.released, ids...)
+	return nil
+}
+
+func (r *fakeRepository) record() {
+	if !r.tx.open {
+		r.outsideTx++
+	}
+}
+
+// fakeSink fails the events listed in fail and remembers the ones published
+type fakeSink struct {
+	tx        *fakeTxManager
+	fail      map[uuid.UUID]bool
+	published []uuid.UUID
+	// inTx counts events published while a transaction was open
+	inTx int
+}
+
+func (s *fakeSink) Publish(ctx context.Context, events ...models.DomainEvent) error {
+	for _, e := range events {
+		if s.tx.open {
+			s.inTx++
+		}
+		if s.fail[e.ID] {
+			return errors.New("unavailable")
+		}
+		s.published = append(s.published, e.ID)
+	}
+	return nil
+}
+
+func TestRelayRunOnce(t *testing.T) {
+	a, b := uuid.New(), uuid.New()
+	events := make([]models.OutboxEvent, 5)
+	for i, aggregate := range []uuid.UUID{a, b, a, b, a} {
+		events[i] = models.OutboxEvent{ID: uuid.New(), AggregateID: aggregate, EventType: "user.updated", Payload: models.JSONMap{}}
+	}
+	ids := func(indexes ...int) []uuid.UUID {
+		out := make([]uuid.UUID, 0, len(indexes))
+		for _, i := range indexes {
+			out = append(out, events[i].ID)
+		}
+		return out
+	}
+
+	tests := []struct {
+		name          string
+		fail          []int
+		wantPublished []uuid.UUID
+		wantFailed    []uuid.UUID
+	}{
+		{"all published in order", nil, ids(0, 1, 2, 3, 4), nil},
+		{"failure holds back the aggregate", []int{2}, ids(0, 1, 3), ids(2)},
+		{"aggregates fail independently", []int{0, 1}, nil, ids(0, 1)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tx := &fakeTxManager{}
+			repo := &fakeRepository{tx: tx, events: events}
+			sink := &fakeSink{tx: tx, fail: make(map[uuid.UUID]bool)}
+			for _, i := range tt.fail {
+				sink.fail[events[i].ID] = true
+			}
+
+			relay := NewRelay(tx, repo, []Sink{sink}, Config{BatchSize: len(events), Lease: time.Minute})
+			n, err := relay.RunOnce(context.Background())
+			if err != nil {
+				t.Fatalf("RunOnce() error = %v", err)
+			}
+
+			if n != len(tt.wantPublished) {
+				t.Errorf("RunOnce() = %d, want %d", n, len(tt.wantPublished))
+			}
+			if !slices.Equal(sink.published, tt.wantPublished) || !slices.Equal(repo.published, tt.wantPublished) {
+				t.Errorf("published %v, marked %v, want %v", sink.published, repo.published, tt.wantPublished)
+			}
+			if !slices.Equal(repo.failed, tt.wantFailed) {
+				t.Errorf("marked failed %v, want %v", repo.failed, tt.wantFailed)
+			}
+			if !slices.Equal(repo.released, ids(0, 1, 2, 3, 4)) {
+				t.Errorf("released %v, want the whole batch", repo.released)
+			}
+			if sink.inTx > 0 {
+				t.Errorf("%d events published inside a transaction", sink.inTx)
+			}
+			if repo.outsideTx > 0 {
+				t.Errorf("%d outcomes recorded outside of a transaction", repo.outsideTx)
+			}
+		})
+	}
+}
+
+func TestRelayRunOnceGivesUp(t *testing.T) {
+	tests := []struct {
+		name        string
+		attempts    int
+		maxAttempts int
+		wantGiveUp  bool
+	}{
+		{"first attempt", 0, 3, false},
+		{"attempts left", 1, 3, false},
+		{"last attempt", 2, 3, true},
+		{"over the cap", 5, 3, true},
+		{"no cap", 100, 0, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			event := models.OutboxEvent{ID: uuid.New(), AggregateID: uuid.New(), EventType: "user.updated", Payload: models.JSONMap{}, Attempts: tt.attempts}
+			tx := &fakeTxManager{}
+			repo := &fakeRepository{tx: tx, events: []models.OutboxEvent{event}}
+			sink := &fakeSink{tx: tx, fail: map[uuid.UUID]bool{event.ID: true}}
+
+			relay := NewRelay(tx, repo, []Sink{sink}, Config{BatchSize: 1, Lease: time.Minute, MaxAttempts: tt.maxAttempts})
+			if _, err := relay.RunOnce(context.Background()); err != nil {
+				t.Fatalf("RunOnce() error = %v", err)
+			}
+
+			if !slices.Equal(repo.failed, []uuid.UUID{event.ID}) {
+				t.Errorf("marked failed %v, want %v", repo.failed, event.ID)
+			}
+			if gaveUp := len(repo.gaveUp) > 0; gaveUp != tt.wantGiveUp {
+				t.Errorf("gave up = %v, want %v", gaveUp, tt.wantGiveUp)
+			}
+		})
+	}
+}
+
+func TestRelayRunOnceNothingClaimed(t *testing.T) {
+	tx := &fakeTxManager{}
+	repo := &fakeRepository{tx: tx}
+	sink := &fakeSink{tx: tx}
+
+	n, err := NewRelay(tx, repo, []Sink{sink}, Config{BatchSize: 10, Lease: time.Minute}).RunOnce(context.Background())
+	if n != 0 || err != nil {
+		t.Fatalf("RunOnce() = %d, %v, want 0, nil", n, err)
+	}
+	if repo.outsideTx+len(repo.released) > 0 {
+		t.Error("RunOnce() recorded outcomes without a claim")
+	}
+}
@@ -0,0 +1,34 @@
+package outbox
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"sync"
+
+	"github.com/hoshina-dev/custapi/internal/models"
+)
+
+// WriterSink writes every event as a JSON line, typically to stdout
+type WriterSink struct {
+	mu  sync.Mutex
+	enc *json.Encoder
+}
+
+// NewWriterSink creates a sink writing events to w
+func NewWriterSink(w io.Writer) *WriterSink {
+	return &WriterSink{enc: json.NewEncoder(w)}
+}
+
+// Publish writes events to the underlying writer
+func (s *WriterSink) Publish(_ context.Context, events ...models.DomainEvent) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i := range events {
+		if err := s.enc.Encode(events[i].Payload()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
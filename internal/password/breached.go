@@ -0,0 +1,134 @@
+package password
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// hashPrefixLen is the length of the SHA-1 prefixes that range files are named after
+const hashPrefixLen = 5
+
+// BreachedList reports whether a password appears in a corpus of breached passwords
+type BreachedList interface {
+	Contains(password string) (bool, error)
+}
+
+// OpenBreachedList opens a breached password list stored offline in the format of the
+// Pwned Passwords k-anonymity API. path is either a directory of range files, named
+// after the first five hex digits of the SHA-1 and listing the remaining digits as
+// "SUFFIX:COUNT" lines, or a single file of "HASH:COUNT" lines sorted by hash.
+func OpenBreachedList(path string) (BreachedList, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if info.IsDir() {
+		return rangeDir(path), nil
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	return &sortedFile{r: f, size: info.Size()}, nil
+}
+
+// hashPassword returns the upper case hex encoded SHA-1 of a password
+func hashPassword(password string) string {
+	sum := sha1.Sum([]byte(password))
+	return strings.ToUpper(hex.EncodeToString(sum[:]))
+}
+
+// lineHash returns the hash or hash suffix at the start of a list line
+func lineHash(line string) string {
+	hash, _, _ := strings.Cut(strings.TrimSpace(line), ":")
+	return strings.ToUpper(hash)
+}
+
+// rangeDir is a directory holding one range file per hash prefix
+type rangeDir string
+
+// Contains looks the password up in the range file of its hash prefix. Only that
+// file is read, so the lookup never touches the full hash.
+func (d rangeDir) Contains(password string) (bool, error) {
+	hash := hashPassword(password)
+	prefix, suffix := hash[:hashPrefixLen], hash[hashPrefixLen:]
+
+	f, err := os.Open(filepath.Join(string(d), prefix+".txt"))
+	if errors.Is(err, fs.ErrNotExist) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		if lineHash(scanner.Text()) == suffix {
+			return true, nil
+		}
+	}
+	return false, scanner.Err()
+}
+
+// sortedFile is a file of full hashes sorted in ascending order, searched by bisecting
+// its byte offsets so that even the full corpus needs only a few dozen reads
+type sortedFile struct {
+	r    io.ReaderAt
+	size int64
+}
+
+// Contains binary searches the file for the password's hash
+func (f *sortedFile) Contains(password string) (bool, error) {
+	hash := hashPassword(password)
+
+	// Find the first offset whose following line holds a hash not below the one we look for
+	lo, hi := int64(0), f.size
+	for lo < hi {
+		mid := lo + (hi-lo)/2
+		line, ok, err := f.lineAt(mid)
+		if err != nil {
+			return false, err
+		}
+		if ok && lineHash(line) < hash {
+			lo = mid + 1
+		} else {
+			hi = mid
+		}
+	}
+
+	line, ok, err := f.lineAt(lo)
+	if err != nil || !ok {
+		return false, err
+	}
+	return lineHash(line) == hash, nil
+}
+
+// lineAt returns the first full line starting at or after offset, or false at the end
+// of the file
+func (f *sortedFile) lineAt(offset int64) (string, bool, error) {
+	start := max(offset-1, 0)
+	r := bufio.NewReader(io.NewSectionReader(f.r, start, f.size-start))
+	if offset > 0 {
+		// Skip the rest of the line the offset falls into
+		if _, err := r.ReadString('\n'); err == io.EOF {
+			return "", false, nil
+		} else if err != nil {
+			return "", false, err
+		}
+	}
+
+	line, err := r.ReadString('\n')
+	if err == io.EOF {
+		return line, line != "", nil
+	}
+	return line, err == nil, err
+}
@@ -0,0 +1,105 @@
+package password
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// breachedPasswords are the passwords the test lists contain
+var breachedPasswords = []string{"password", "123456", "qwerty", "letmein", "dragon", "monkey"}
+
+func TestBreachedList(t *testing.T) {
+	tests := []struct {
+		name string
+		open func(t *testing.T) string
+	}{
+		{"range directory", writeRangeDir},
+		{"sorted file", writeSortedFile(false)},
+		{"sorted file with lower case hashes and CRLF", writeSortedFile(true)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			list, err := OpenBreachedList(tt.open(t))
+			if err != nil {
+				t.Fatalf("OpenBreachedList() error = %v", err)
+			}
+
+			for _, password := range breachedPasswords {
+				if ok, err := list.Contains(password); !ok || err != nil {
+					t.Errorf("Contains(%q) = %v, %v, want true", password, ok, err)
+				}
+			}
+			for _, password := range []string{"", "Password", "correct horse battery staple", "zzzzzzzz"} {
+				if ok, err := list.Contains(password); ok || err != nil {
+					t.Errorf("Contains(%q) = %v, %v, want false", password, ok, err)
+				}
+			}
+		})
+	}
+}
+
+func TestSortedFileSingleLine(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "breached.txt")
+	// The last line has no trailing newline
+	if err := os.WriteFile(path, []byte(hashPassword("password")+":3"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	list, err := OpenBreachedList(path)
+	if err != nil {
+		t.Fatalf("OpenBreachedList() error = %v", err)
+	}
+
+	if ok, err := list.Contains("password"); !ok || err != nil {
+		t.Errorf("Contains(password) = %v, %v, want true", ok, err)
+	}
+	if ok, err := list.Contains("dragon"); ok || err != nil {
+		t.Errorf("Contains(dragon) = %v, %v, want false", ok, err)
+	}
+}
+
+func TestOpenBreachedListMissing(t *testing.T) {
+	if _, err := OpenBreachedList(filepath.Join(t.TempDir(), "missing")); err == nil {
+		t.Error("OpenBreachedList() error = nil, want an error")
+	}
+}
+
+// writeRangeDir writes the breached passwords as range files
+func writeRangeDir(t *testing.T) string {
+	dir := t.TempDir()
+	ranges := make(map[string][]string)
+	for i, password := range breachedPasswords {
+		hash := hashPassword(password)
+		ranges[hash[:hashPrefixLen]] = append(ranges[hash[:hashPrefixLen]], hash[hashPrefixLen:]+":"+strings.Repeat("1", i+1))
+	}
+	for prefix, lines := range ranges {
+		if err := os.WriteFile(filepath.Join(dir, prefix+".txt"), []byte(strings.Join(lines, "\n")+"\n"), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+// writeSortedFile returns a function writing the breached passwords as a sorted
+// file, with lower case hashes and CRLF line endings if windows is set
+func writeSortedFile(windows bool) func(t *testing.T) string {
+	return func(t *testing.T) string {
+		lines := make([]string, len(breachedPasswords))
+		for i, password := range breachedPasswords {
+			lines[i] = hashPassword(password) + ":42"
+		}
+		slices.Sort(lines)
+
+		content := strings.Join(lines, "\n") + "\n"
+		if windows {
+			content = strings.ToLower(strings.ReplaceAll(content, "\n", "\r\n"))
+		}
+		path := filepath.Join(t.TempDir(), "breached.txt")
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+}
@@ -0,0 +1,172 @@
+package password
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// Hashing algorithms
+const (
+	AlgorithmBcrypt   = "bcrypt"
+	AlgorithmArgon2id = "argon2id"
+)
+
+// Lengths in bytes of the salts and keys of Argon2id hashes
+const (
+	argon2SaltLength = 16
+	argon2KeyLength  = 32
+)
+
+// ErrUnknownHash is returned when verifying a hash in a format no algorithm produces
+var ErrUnknownHash = errors.New("unknown password hash format")
+
+// HashConfig selects the algorithm and parameters new password hashes are made with
+type HashConfig struct {
+	// Algorithm is bcrypt or argon2id
+	Algorithm  string
+	BcryptCost int
+	// Argon2Memory is the memory Argon2id uses, in KiB
+	Argon2Memory      int
+	Argon2Iterations  int
+	Argon2Parallelism int
+}
+
+// Hasher hashes passwords with the configured algorithm and parameters. Hashes are
+// self-describing strings: Argon2id hashes are PHC strings and bcrypt hashes keep
+// their own "$2a$" format, which stored hashes predating the hasher use too.
+type Hasher struct {
+	cfg HashConfig
+}
+
+// NewHasher creates a password hasher, checking the parameters of the configured algorithm
+func NewHasher(cfg HashConfig) (*Hasher, error) {
+	switch cfg.Algorithm {
+	case AlgorithmBcrypt:
+		if cfg.BcryptCost < bcrypt.MinCost || cfg.BcryptCost > bcrypt.MaxCost {
+			return nil, fmt.Errorf("bcrypt cost must be between %d and %d", bcrypt.MinCost, bcrypt.MaxCost)
+		}
+	case AlgorithmArgon2id:
+		if cfg.Argon2Memory < 8*cfg.Argon2Parallelism || cfg.Argon2Iterations < 1 ||
+			cfg.Argon2Parallelism < 1 || cfg.Argon2Parallelism > 255 {
+			return nil, errors.New("argon2id needs at least one iteration, a parallelism between 1 and 255 and 8 KiB of memory per lane")
+		}
+	default:
+		return nil, fmt.Errorf("unknown password hashing algorithm %q", cfg.Algorithm)
+	}
+	return &Hasher{cfg: cfg}, nil
+}
+
+// Hash hashes a password with the configured algorithm and parameters
+func (h *Hasher) Hash(password string) (string, error) {
+	if h.cfg.Algorithm == AlgorithmBcrypt {
+		hash, err := bcrypt.GenerateFromPassword([]byte(password), h.cfg.BcryptCost)
+		return string(hash), err
+	}
+
+	params := argon2Params{
+		memory:      uint32(h.cfg.Argon2Memory),
+		iterations:  uint32(h.cfg.Argon2Iterations),
+		parallelism: uint8(h.cfg.Argon2Parallelism),
+		salt:        make([]byte, argon2SaltLength),
+	}
+	if _, err := rand.Read(params.salt); err != nil {
+		return "", err
+	}
+	key := params.key(password, argon2KeyLength)
+	return params.encode(key), nil
+}
+
+// Verify reports whether password matches hash, which can be of any supported
+// algorithm. rehash reports whether a matching hash was made with another algorithm
+// or other parameters than the configured ones, and should be replaced by a new one.
+func (h *Hasher) Verify(password, hash string) (ok, rehash bool, err error) {
+	switch {
+	case strings.HasPrefix(hash, "$2a$"), strings.HasPrefix(hash, "$2b$"), strings.HasPrefix(hash, "$2y$"):
+		err := bcrypt.CompareHashAndPassword([]byte(hash), []byte(password))
+		if errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
+			return false, false, nil
+		}
+		if err != nil {
+			return false, false, err
+		}
+		cost, err := bcrypt.Cost([]byte(hash))
+		if err != nil {
+			return false, false, err
+		}
+		return true, h.cfg.Algorithm != AlgorithmBcrypt || cost != h.cfg.BcryptCost, nil
+
+	case strings.HasPrefix(hash, "$"+AlgorithmArgon2id+"$"):
+		params, key, err := decodeArgon2(hash)
+		if err != nil {
+			return false, false, err
+		}
+		if subtle.ConstantTimeCompare(params.key(password, uint32(len(key))), key) != 1 {
+			return false, false, nil
+		}
+		return true, h.cfg.Algorithm != AlgorithmArgon2id ||
+			params.memory != uint32(h.cfg.Argon2Memory) ||
+			params.iterations != uint32(h.cfg.Argon2Iterations) ||
+			params.parallelism != uint8(h.cfg.Argon2Parallelism) ||
+			len(params.salt) != argon2SaltLength || len(key) != argon2KeyLength, nil
+	}
+	return false, false, ErrUnknownHash
+}
+
+// argon2Params are the parameters and salt of an Argon2id hash
+type argon2Params struct {
+	memory      uint32
+	iterations  uint32
+	parallelism uint8
+	salt        []byte
+}
+
+// key derives a key of length bytes from password
+func (p argon2Params) key(password string, length uint32) []byte {
+	return argon2.IDKey([]byte(password), p.salt, p.iterations, p.memory, p.parallelism, length)
+}
+
+// encode formats the parameters and key as a PHC string:
+// $argon2id$v=19$m=<memory>,t=<iterations>,p=<parallelism>$<salt>$<key>
+func (p argon2Params) encode(key []byte) string {
+	return fmt.Sprintf("$%s$v=%d$m=%d,t=%d,p=%d$%s$%s", AlgorithmArgon2id, argon2.Version,
+		p.memory, p.iterations, p.parallelism,
+		base64.RawStdEncoding.EncodeToString(p.salt), base64.RawStdEncoding.EncodeToString(key))
+}
+
+// decodeArgon2 parses an Argon2id PHC string into its parameters and key
+func decodeArgon2(hash string) (argon2Params, []byte, error) {
+	var params argon2Params
+	parts := strings.Split(hash, "$")
+	if len(parts) != 6 {
+		return params, nil, ErrUnknownHash
+	}
+
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil {
+		return params, nil, ErrUnknownHash
+	}
+	if version != argon2.Version {
+		return params, nil, fmt.Errorf("unsupported argon2 version %d", version)
+	}
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &params.memory, &params.iterations, &params.parallelism); err != nil {
+		return params, nil, ErrUnknownHash
+	}
+
+	salt, err := base64.RawStdEncoding.DecodeString(parts[4])
+	if err != nil {
+		return params, nil, ErrUnknownHash
+	}
+	key, err := base64.RawStdEncoding.DecodeString(parts[5])
+	if err != nil || len(key) == 0 {
+		return params, nil, ErrUnknownHash
+	}
+	params.salt = salt
+	return params, key, nil
+}
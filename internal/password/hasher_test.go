@@ -0,0 +1,130 @@
+package password
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+// Cheap parameters so the tests run quickly
+var (
+	bcryptConfig = HashConfig{Algorithm: AlgorithmBcrypt, BcryptCost: 4}
+	argon2Config = HashConfig{Algorithm: AlgorithmArgon2id, Argon2Memory: 64, Argon2Iterations: 1, Argon2Parallelism: 1}
+)
+
+// Hashes of "password" made elsewhere: a bcrypt hash of cost 4 from before the hasher
+// existed, and the Argon2id vector of the reference implementation's tests
+const (
+	legacyBcryptHash  = "$2a$04$WGv8nxo/lddBvx7YVfGv/.aJzf6VrxBO/rxZJUq4nt19HSK.oWfK."
+	referenceArgon2id = "$argon2id$v=19$m=65536,t=2,p=1$c29tZXNhbHQ$CTFhFdXPJO1aFaMaO6Mm5c8y7cJHAph8ArZWb2GRPPc"
+)
+
+func TestNewHasher(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     HashConfig
+		wantErr bool
+	}{
+		{"bcrypt", bcryptConfig, false},
+		{"argon2id", argon2Config, false},
+		{"bcrypt cost too low", HashConfig{Algorithm: AlgorithmBcrypt, BcryptCost: 3}, true},
+		{"bcrypt cost too high", HashConfig{Algorithm: AlgorithmBcrypt, BcryptCost: 32}, true},
+		{"argon2id without iterations", HashConfig{Algorithm: AlgorithmArgon2id, Argon2Memory: 64, Argon2Parallelism: 1}, true},
+		{"argon2id without parallelism", HashConfig{Algorithm: AlgorithmArgon2id, Argon2Memory: 64, Argon2Iterations: 1}, true},
+		{"argon2id parallelism too high", HashConfig{Algorithm: AlgorithmArgon2id, Argon2Memory: 1 << 20, Argon2Iterations: 1, Argon2Parallelism: 256}, true},
+		{"argon2id too little memory per lane", HashConfig{Algorithm: AlgorithmArgon2id, Argon2Memory: 31, Argon2Iterations: 1, Argon2Parallelism: 4}, true},
+		{"unknown algorithm", HashConfig{Algorithm: "md5"}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := NewHasher(tt.cfg); (err != nil) != tt.wantErr {
+				t.Errorf("NewHasher() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestHasherHash(t *testing.T) {
+	tests := []struct {
+		name       string
+		cfg        HashConfig
+		wantPrefix string
+	}{
+		{"bcrypt", bcryptConfig, "$2a$04$"},
+		{"argon2id", argon2Config, "$argon2id$v=19$m=64,t=1,p=1$"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := mustHasher(t, tt.cfg)
+			hash, err := h.Hash("hunter2")
+			if err != nil {
+				t.Fatalf("Hash() error = %v", err)
+			}
+			if !strings.HasPrefix(hash, tt.wantPrefix) {
+				t.Errorf("Hash() = %q, want prefix %q", hash, tt.wantPrefix)
+			}
+
+			if again, _ := h.Hash("hunter2"); again == hash {
+				t.Error("Hash() returned the same hash twice, want a new salt each time")
+			}
+			if ok, rehash, err := h.Verify("hunter2", hash); !ok || rehash || err != nil {
+				t.Errorf("Verify(own hash) = %v, %v, %v, want true, false, nil", ok, rehash, err)
+			}
+			if ok, _, err := h.Verify("hunter3", hash); ok || err != nil {
+				t.Errorf("Verify(wrong password) = %v, %v, want false, nil", ok, err)
+			}
+		})
+	}
+}
+
+func TestHasherVerify(t *testing.T) {
+	tests := []struct {
+		name       string
+		cfg        HashConfig
+		password   string
+		hash       string
+		wantOK     bool
+		wantRehash bool
+		wantErr    error
+	}{
+		{"legacy bcrypt", bcryptConfig, "password", legacyBcryptHash, true, false, nil},
+		{"legacy bcrypt wrong password", bcryptConfig, "Password", legacyBcryptHash, false, false, nil},
+		{"bcrypt of another cost", HashConfig{Algorithm: AlgorithmBcrypt, BcryptCost: 5}, "password", legacyBcryptHash, true, true, nil},
+		{"bcrypt when argon2id is configured", argon2Config, "password", legacyBcryptHash, true, true, nil},
+		{"bcrypt rehash only after a match", argon2Config, "Password", legacyBcryptHash, false, false, nil},
+		{"$2b$ bcrypt", bcryptConfig, "password", "$2b$" + legacyBcryptHash[4:], true, false, nil},
+		{"reference argon2id", argon2Config, "password", referenceArgon2id, true, true, nil},
+		{"reference argon2id wrong password", argon2Config, "passwore", referenceArgon2id, false, false, nil},
+		{"reference argon2id with matching parameters", HashConfig{Algorithm: AlgorithmArgon2id, Argon2Memory: 65536, Argon2Iterations: 2, Argon2Parallelism: 1},
+			"password", referenceArgon2id, true, true, nil}, // the reference salt is shorter than ours
+		{"argon2id when bcrypt is configured", bcryptConfig, "password", referenceArgon2id, true, true, nil},
+		{"unknown format", bcryptConfig, "password", "5f4dcc3b5aa765d61d8327deb882cf99", false, false, ErrUnknownHash},
+		{"malformed argon2id", argon2Config, "password", "$argon2id$v=19$m=64,t=1,p=1$c29tZXNhbHQ", false, false, ErrUnknownHash},
+		{"argon2id with bad parameters", argon2Config, "password", "$argon2id$v=19$m=x,t=1,p=1$c29tZXNhbHQ$CTFhFdXP", false, false, ErrUnknownHash},
+		{"argon2id with bad base64", argon2Config, "password", "$argon2id$v=19$m=64,t=1,p=1$c29tZXNhbHQ$!!!", false, false, ErrUnknownHash},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ok, rehash, err := mustHasher(t, tt.cfg).Verify(tt.password, tt.hash)
+			if ok != tt.wantOK || rehash != tt.wantRehash || !errors.Is(err, tt.wantErr) {
+				t.Errorf("Verify() = %v, %v, %v, want %v, %v, %v", ok, rehash, err, tt.wantOK, tt.wantRehash, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestHasherVerifyArgon2Version(t *testing.T) {
+	_, _, err := mustHasher(t, argon2Config).Verify("password", strings.Replace(referenceArgon2id, "v=19", "v=16", 1))
+	if err == nil || errors.Is(err, ErrUnknownHash) {
+		t.Errorf("Verify(argon2 v=16) error = %v, want an unsupported version error", err)
+	}
+}
+
+func mustHasher(t *testing.T, cfg HashConfig) *Hasher {
+	t.Helper()
+	h, err := NewHasher(cfg)
+	if err != nil {
+		t.Fatalf("NewHasher() error = %v", err)
+	}
+	return h
+}
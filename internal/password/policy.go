@@ -0,0 +1,157 @@
+// Package password checks new passwords against the password policy and hashes them
+package password
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Rules a password can fail
+const (
+	RuleMinLength    = "min_length"
+	RuleMaxLength    = "max_length"
+	RuleLowercase    = "lowercase"
+	RuleUppercase    = "uppercase"
+	RuleDigit        = "digit"
+	RuleSymbol       = "symbol"
+	RulePersonalInfo = "personal_info"
+	RuleBreached     = "breached"
+)
+
+// Character classes that can be required
+const (
+	ClassLowercase = "lowercase"
+	ClassUppercase = "uppercase"
+	ClassDigit     = "digit"
+	ClassSymbol    = "symbol"
+)
+
+// Config holds the password policy settings
+type Config struct {
+	// MinLength is the minimum number of characters
+	MinLength int
+	// MaxLength is the maximum number of bytes; bcrypt cannot hash more than 72
+	MaxLength int
+	// RequiredClasses lists the character classes every password must contain
+	RequiredClasses []string
+	// RejectPersonalInfo refuses passwords containing the user's email or name
+	RejectPersonalInfo bool
+	// BreachedListPath is a directory of SHA-1 range files or a sorted file of SHA-1
+	// hashes of breached passwords; empty disables the check
+	BreachedListPath string
+}
+
+// Violation is a rule a password failed
+type Violation struct {
+	Rule    string
+	Message string
+}
+
+// PolicyError is returned for passwords that fail the policy
+type PolicyError struct {
+	Violations []Violation
+}
+
+func (e *PolicyError) Error() string {
+	messages := make([]string, len(e.Violations))
+	for i, v := range e.Violations {
+		messages[i] = v.Message
+	}
+	return "password does not meet the password policy: " + strings.Join(messages, "; ")
+}
+
+// Policy checks passwords against the configured rules
+type Policy struct {
+	cfg      Config
+	breached BreachedList
+}
+
+// NewPolicy creates a password policy, opening the breached password list if one is configured
+func NewPolicy(cfg Config) (*Policy, error) {
+	for _, class := range cfg.RequiredClasses {
+		if _, ok := classMatchers[class]; !ok {
+			return nil, fmt.Errorf("unknown password character class %q", class)
+		}
+	}
+
+	p := &Policy{cfg: cfg}
+	if cfg.BreachedListPath != "" {
+		breached, err := OpenBreachedList(cfg.BreachedListPath)
+		if err != nil {
+			return nil, err
+		}
+		p.breached = breached
+	}
+	return p, nil
+}
+
+// classMatchers maps each character class to its rule, message and matcher
+var classMatchers = map[string]struct {
+	rule    string
+	message string
+	match   func(rune) bool
+}{
+	ClassLowercase: {RuleLowercase, "must contain a lowercase letter", unicode.IsLower},
+	ClassUppercase: {RuleUppercase, "must contain an uppercase letter", unicode.IsUpper},
+	ClassDigit:     {RuleDigit, "must contain a digit", unicode.IsDigit},
+	ClassSymbol: {RuleSymbol, "must contain a symbol", func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r) && !unicode.IsSpace(r)
+	}},
+}
+
+// Check checks a password against every rule and returns a *PolicyError listing the
+// ones it fails. email and name are those of the user the password is for.
+func (p *Policy) Check(password, email, name string) error {
+	var violations []Violation
+
+	if n := utf8.RuneCountInString(password); n < p.cfg.MinLength {
+		violations = append(violations, Violation{RuleMinLength, fmt.Sprintf("must be at least %d characters long", p.cfg.MinLength)})
+	}
+	if p.cfg.MaxLength > 0 && len(password) > p.cfg.MaxLength {
+		violations = append(violations, Violation{RuleMaxLength, fmt.Sprintf("must be at most %d bytes long", p.cfg.MaxLength)})
+	}
+	for _, class := range p.cfg.RequiredClasses {
+		m := classMatchers[class]
+		if !strings.ContainsFunc(password, m.match) {
+			violations = append(violations, Violation{m.rule, m.message})
+		}
+	}
+	if p.cfg.RejectPersonalInfo && containsPersonalInfo(password, email, name) {
+		violations = append(violations, Violation{RulePersonalInfo, "must not contain your email address or name"})
+	}
+	if p.breached != nil {
+		breached, err := p.breached.Contains(password)
+		if err != nil {
+			return fmt.Errorf("checking breached passwords: %w", err)
+		}
+		if breached {
+			violations = append(violations, Violation{RuleBreached, "appears in a known data breach"})
+		}
+	}
+
+	if len(violations) > 0 {
+		return &PolicyError{Violations: violations}
+	}
+	return nil
+}
+
+// containsPersonalInfo reports whether password contains, ignoring case, the email,
+// its local part or any word of the name. Parts shorter than three characters are
+// too common to reject.
+func containsPersonalInfo(password, email, name string) bool {
+	password = strings.ToLower(password)
+	parts := strings.Fields(strings.ToLower(name))
+	if email = strings.ToLower(email); email != "" {
+		local, _, _ := strings.Cut(email, "@")
+		parts = append(parts, email, local)
+	}
+
+	for _, part := range parts {
+		if utf8.RuneCountInString(part) >= 3 && strings.Contains(password, part) {
+			return true
+		}
+	}
+	return false
+}
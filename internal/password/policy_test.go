@@ -0,0 +1,110 @@
+package password
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestPolicyCheck(t *testing.T) {
+	strict := Config{
+		MinLength:          8,
+		MaxLength:          72,
+		RequiredClasses:    []string{ClassLowercase, ClassUppercase, ClassDigit, ClassSymbol},
+		RejectPersonalInfo: true,
+	}
+
+	tests := []struct {
+		name      string
+		cfg       Config
+		password  string
+		wantRules []string
+	}{
+		{"meets every rule", strict, "Tr0ub4dor&3", nil},
+		{"too short", strict, "Aa1!", []string{RuleMinLength}},
+		{"length counts characters", Config{MinLength: 4}, "ÄÖÜ", []string{RuleMinLength}},
+		{"multibyte characters are long enough", Config{MinLength: 3}, "ÄÖÜ", nil},
+		{"too long", Config{MaxLength: 72}, string(make([]byte, 73)), []string{RuleMaxLength}},
+		{"max length counts bytes", Config{MaxLength: 4}, "ÄÖÜ", []string{RuleMaxLength}},
+		{"zero max length is unlimited", Config{}, string(make([]byte, 1000)), nil},
+		{"missing classes", strict, "alllowercase", []string{RuleUppercase, RuleDigit, RuleSymbol}},
+		{"unicode classes", Config{RequiredClasses: []string{ClassLowercase, ClassUppercase}}, "éÉ", nil},
+		{"spaces are not symbols", Config{RequiredClasses: []string{ClassSymbol}}, "pass word", []string{RuleSymbol}},
+		{"contains email", strict, "x!JANE.DOE@example.com1", []string{RulePersonalInfo}},
+		{"contains email local part", strict, "Jane.Doe#2024", []string{RulePersonalInfo}},
+		{"contains name", strict, "Smithy#2024a", []string{RulePersonalInfo}},
+		{"short name parts are allowed", strict, "Ab#Jo2024xyz", nil},
+		{"personal info allowed when disabled", Config{}, "jane.doe", nil},
+		{"every violation is reported", strict, "smith", []string{RuleMinLength, RuleUppercase, RuleDigit, RuleSymbol, RulePersonalInfo}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			policy, err := NewPolicy(tt.cfg)
+			if err != nil {
+				t.Fatalf("NewPolicy() error = %v", err)
+			}
+
+			err = policy.Check(tt.password, "jane.doe@example.com", "Jo Smith")
+			if got := violatedRules(t, err); !slices.Equal(got, tt.wantRules) {
+				t.Errorf("Check() violations = %v, want %v", got, tt.wantRules)
+			}
+		})
+	}
+}
+
+func TestPolicyCheckBreached(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "breached.txt")
+	if err := os.WriteFile(path, []byte(hashPassword("correct horse")+":12\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	policy, err := NewPolicy(Config{BreachedListPath: path})
+	if err != nil {
+		t.Fatalf("NewPolicy() error = %v", err)
+	}
+
+	if got := violatedRules(t, policy.Check("correct horse", "", "")); !slices.Equal(got, []string{RuleBreached}) {
+		t.Errorf("Check(breached) violations = %v, want %v", got, []string{RuleBreached})
+	}
+	if err := policy.Check("battery staple", "", ""); err != nil {
+		t.Errorf("Check(not breached) error = %v", err)
+	}
+}
+
+func TestNewPolicy(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     Config
+		wantErr bool
+	}{
+		{"empty", Config{}, false},
+		{"known classes", Config{RequiredClasses: []string{ClassLowercase, ClassUppercase, ClassDigit, ClassSymbol}}, false},
+		{"unknown class", Config{RequiredClasses: []string{"emoji"}}, true},
+		{"missing breached list", Config{BreachedListPath: filepath.Join(t.TempDir(), "missing")}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := NewPolicy(tt.cfg); (err != nil) != tt.wantErr {
+				t.Errorf("NewPolicy() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// violatedRules returns the rules listed by a *PolicyError, or nil for a nil error
+func violatedRules(t *testing.T, err error) []string {
+	t.Helper()
+	if err == nil {
+		return nil
+	}
+	var policyErr *PolicyError
+	if !errors.As(err, &policyErr) {
+		t.Fatalf("error = %v, want a *PolicyError", err)
+	}
+	rules := make([]string, len(policyErr.Violations))
+	for i, v := range policyErr.Violations {
+		rules[i] = v.Rule
+	}
+	return rules
+}
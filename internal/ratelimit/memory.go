@@ -0,0 +1,51 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/hoshina-dev/custapi/internal/models"
+)
+
+// MemoryStore keeps buckets in memory. Each replica of the API has its own, so a client
+// spreading requests over n replicas gets up to n times the limit.
+type MemoryStore struct {
+	now func() time.Time
+
+	mu        sync.Mutex
+	buckets   map[string]*models.RateLimitBucket
+	lastSweep time.Time
+}
+
+// NewMemoryStore creates an in-memory bucket store
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		now:     time.Now,
+		buckets: make(map[string]*models.RateLimitBucket),
+	}
+}
+
+// Take takes a token for a request from the bucket of key, unless it is empty
+func (s *MemoryStore) Take(ctx context.Context, key string, limit Limit) (Result, error) {
+	now := s.now()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if now.Sub(s.lastSweep) >= sweepInterval {
+		for k, bucket := range s.buckets {
+			if !bucket.FullAt.After(now) {
+				delete(s.buckets, k)
+			}
+		}
+		s.lastSweep = now
+	}
+
+	bucket, ok := s.buckets[key]
+	if !ok {
+		bucket = limit.newBucket(key, now)
+		s.buckets[key] = bucket
+	}
+	return limit.take(bucket, now), nil
+}
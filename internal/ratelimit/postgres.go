@@ -0,0 +1,55 @@
+package ratelimit
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/hoshina-dev/custapi/internal/models"
+	"github.com/hoshina-dev/custapi/internal/repositories"
+)
+
+// PostgresStore keeps buckets in the database, so that limits hold across all replicas
+// of the API
+type PostgresStore struct {
+	repo repositories.RateLimitRepository
+	now  func() time.Time
+
+	mu        sync.Mutex
+	lastSweep time.Time
+}
+
+// NewPostgresStore creates a bucket store backed by the rate_limit_buckets table
+func NewPostgresStore(repo repositories.RateLimitRepository) *PostgresStore {
+	return &PostgresStore{repo: repo, now: time.Now}
+}
+
+// Take takes a token for a request from the bucket of key, unless it is empty
+func (s *PostgresStore) Take(ctx context.Context, key string, limit Limit) (Result, error) {
+	now := s.now()
+	s.sweep(ctx, now)
+
+	var result Result
+	err := s.repo.Update(ctx, limit.newBucket(key, now), func(bucket *models.RateLimitBucket) {
+		result = limit.take(bucket, now)
+	})
+	return result, err
+}
+
+// sweep deletes the buckets that have refilled completely, at most once per sweepInterval
+func (s *PostgresStore) sweep(ctx context.Context, now time.Time) {
+	s.mu.Lock()
+	due := now.Sub(s.lastSweep) >= sweepInterval
+	if due {
+		s.lastSweep = now
+	}
+	s.mu.Unlock()
+	if !due {
+		return
+	}
+
+	if _, err := s.repo.DeleteFull(ctx, now); err != nil {
+		log.Printf("Failed to delete full rate limit buckets: %v", err)
+	}
+}
@@ -0,0 +1,94 @@
+// Package ratelimit limits how often clients make requests with token buckets. Each
+// client has a bucket holding up to a limit of tokens, which requests take one of.
+// Tokens are added back at a steady rate, so clients can make bursts of requests up
+// to the limit but no more than the limit per period over time.
+package ratelimit
+
+import (
+	"context"
+	"math"
+	"time"
+
+	"github.com/hoshina-dev/custapi/internal/auth"
+	"github.com/hoshina-dev/custapi/internal/models"
+)
+
+// sweepInterval is how often stores forget the buckets that have refilled completely
+const sweepInterval = time.Minute
+
+// Limit allows Requests requests at once, and Requests more per period Per. A limit of
+// zero requests is no limit.
+type Limit struct {
+	Requests int
+	Per      time.Duration
+}
+
+// Enabled reports whether the limit limits anything
+func (l Limit) Enabled() bool {
+	return l.Requests > 0 && l.Per > 0
+}
+
+// Result is the outcome of taking a token for a request
+type Result struct {
+	Allowed bool
+	Limit   int
+	// Remaining is how many more requests can be made right away
+	Remaining int
+	// RetryAfter is how long until the next request is allowed, for denied requests
+	RetryAfter time.Duration
+	// Reset is how long until the bucket has refilled completely
+	Reset time.Duration
+}
+
+// Store keeps the buckets of clients, identified by key
+type Store interface {
+	// Take takes a token for a request from the bucket of key, unless it is empty
+	Take(ctx context.Context, key string, limit Limit) (Result, error)
+}
+
+// ClientKey identifies the client making a request by its API key, its user or, for
+// anonymous requests, its IP address
+func ClientKey(actor *auth.Actor) string {
+	switch {
+	case actor.APIKeyID != nil:
+		return "key:" + actor.APIKeyID.String()
+	case actor.UserID != nil:
+		return "user:" + actor.UserID.String()
+	}
+	return "ip:" + actor.IP
+}
+
+// newBucket returns a full bucket for key
+func (l Limit) newBucket(key string, now time.Time) *models.RateLimitBucket {
+	return &models.RateLimitBucket{Key: key, Tokens: float64(l.Requests), RefilledAt: now, FullAt: now}
+}
+
+// take adds the tokens due since the bucket was last refilled and takes one for a
+// request if there is one
+func (l Limit) take(bucket *models.RateLimitBucket, now time.Time) Result {
+	capacity := float64(l.Requests)
+	perToken := l.Per.Seconds() / capacity
+
+	// Clocks of replicas may disagree a little; the bucket is never refilled backwards
+	if now.After(bucket.RefilledAt) {
+		bucket.Tokens = min(capacity, bucket.Tokens+now.Sub(bucket.RefilledAt).Seconds()/perToken)
+		bucket.RefilledAt = now
+	}
+
+	result := Result{Limit: l.Requests}
+	if bucket.Tokens >= 1 {
+		bucket.Tokens--
+		result.Allowed = true
+	} else {
+		result.RetryAfter = seconds((1 - bucket.Tokens) * perToken)
+	}
+	result.Remaining = int(bucket.Tokens)
+	result.Reset = seconds((capacity - bucket.Tokens) * perToken)
+	bucket.FullAt = bucket.RefilledAt.Add(result.Reset)
+	return result
+}
+
+// seconds converts a number of seconds into a duration, rounded up to the nanosecond
+func seconds(s float64) time.Duration {
+	return time.Duration(math.Ceil(s * float64(time.Second)))
+}
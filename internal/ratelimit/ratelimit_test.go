@@ -0,0 +1,126 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/hoshina-dev/custapi/internal/models"
+)
+
+func TestLimitTake(t *testing.T) {
+	// One token per second
+	limit := Limit{Requests: 10, Per: 10 * time.Second}
+	now := time.Unix(1_700_000_000, 0)
+
+	tests := []struct {
+		name           string
+		tokens         float64
+		refilledAt     time.Time
+		want           Result
+		wantTokens     float64
+		wantRefilledAt time.Time
+	}{
+		{"full bucket", 10, now, Result{Allowed: true, Limit: 10, Remaining: 9, Reset: time.Second}, 9, now},
+		{"last token", 1, now, Result{Allowed: true, Limit: 10, Remaining: 0, Reset: 10 * time.Second}, 0, now},
+		{"empty bucket", 0, now, Result{Limit: 10, RetryAfter: time.Second, Reset: 10 * time.Second}, 0, now},
+		{"part of a token", 0.5, now, Result{Limit: 10, RetryAfter: 500 * time.Millisecond, Reset: 9500 * time.Millisecond}, 0.5, now},
+		{"refilled since", 0, now.Add(-3 * time.Second), Result{Allowed: true, Limit: 10, Remaining: 2, Reset: 8 * time.Second}, 2, now},
+		{"refilled part of a token", 0.25, now.Add(-500 * time.Millisecond), Result{Limit: 10, RetryAfter: 250 * time.Millisecond, Reset: 9250 * time.Millisecond}, 0.75, now},
+		{"refilled to the limit only", 5, now.Add(-time.Hour), Result{Allowed: true, Limit: 10, Remaining: 9, Reset: time.Second}, 9, now},
+		{"refilled in the future by another clock", 2, now.Add(5 * time.Second), Result{Allowed: true, Limit: 10, Remaining: 1, Reset: 9 * time.Second}, 1, now.Add(5 * time.Second)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bucket := &models.RateLimitBucket{Key: "k", Tokens: tt.tokens, RefilledAt: tt.refilledAt}
+			got := limit.take(bucket, now)
+
+			if got != tt.want {
+				t.Errorf("take() = %+v, want %+v", got, tt.want)
+			}
+			if bucket.Tokens != tt.wantTokens || !bucket.RefilledAt.Equal(tt.wantRefilledAt) {
+				t.Errorf("bucket = %v tokens refilled at %v, want %v at %v", bucket.Tokens, bucket.RefilledAt, tt.wantTokens, tt.wantRefilledAt)
+			}
+			if want := bucket.RefilledAt.Add(got.Reset); !bucket.FullAt.Equal(want) {
+				t.Errorf("bucket full at %v, want %v", bucket.FullAt, want)
+			}
+		})
+	}
+}
+
+func TestLimitTakeBurst(t *testing.T) {
+	limit := Limit{Requests: 3, Per: time.Minute}
+	now := time.Unix(1_700_000_000, 0)
+	bucket := limit.newBucket("k", now)
+
+	for i := range 3 {
+		if got := limit.take(bucket, now); !got.Allowed || got.Remaining != 2-i {
+			t.Fatalf("request %d = %+v, want allowed with %d remaining", i+1, got, 2-i)
+		}
+	}
+	got := limit.take(bucket, now)
+	if got.Allowed || got.RetryAfter != 20*time.Second || got.Reset != time.Minute {
+		t.Errorf("request over the burst = %+v, want denied for 20s", got)
+	}
+
+	// Denied requests take nothing, so the next token comes on time
+	if got := limit.take(bucket, now.Add(20*time.Second)); !got.Allowed {
+		t.Errorf("request after RetryAfter = %+v, want allowed", got)
+	}
+	if got := limit.take(bucket, now.Add(21*time.Second)); got.Allowed {
+		t.Errorf("request before the next token = %+v, want denied", got)
+	}
+}
+
+func TestLimitEnabled(t *testing.T) {
+	tests := []struct {
+		limit Limit
+		want  bool
+	}{
+		{Limit{Requests: 10, Per: time.Minute}, true},
+		{Limit{Requests: 0, Per: time.Minute}, false},
+		{Limit{Requests: 10}, false},
+		{Limit{}, false},
+	}
+	for _, tt := range tests {
+		if got := tt.limit.Enabled(); got != tt.want {
+			t.Errorf("%+v.Enabled() = %v, want %v", tt.limit, got, tt.want)
+		}
+	}
+}
+
+func TestMemoryStore(t *testing.T) {
+	limit := Limit{Requests: 2, Per: time.Minute}
+	now := time.Unix(1_700_000_000, 0)
+	store := NewMemoryStore()
+	store.now = func() time.Time { return now }
+	ctx := context.Background()
+
+	take := func(key string) bool {
+		t.Helper()
+		result, err := store.Take(ctx, key, limit)
+		if err != nil {
+			t.Fatalf("Take(%s) error = %v", key, err)
+		}
+		return result.Allowed
+	}
+
+	if !take("a") || !take("a") || take("a") {
+		t.Error("client a was not limited to a burst of 2")
+	}
+	if !take("b") {
+		t.Error("client b was limited by the requests of client a")
+	}
+
+	// Once refilled, buckets are forgotten on the next sweep
+	now = now.Add(limit.Per + sweepInterval)
+	if !take("c") {
+		t.Error("client c was denied")
+	}
+	if _, ok := store.buckets["a"]; ok {
+		t.Error("the full bucket of client a was kept")
+	}
+	if !take("a") || !take("a") || take("a") {
+		t.Error("client a did not get a full bucket back")
+	}
+}
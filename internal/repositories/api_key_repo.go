@@ -0,0 +1,79 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hoshina-dev/custapi/internal/models"
+	"gorm.io/gorm"
+)
+
+// APIKeyRepository defines API key persistence operations
+type APIKeyRepository interface {
+	Create(ctx context.Context, key *models.APIKey) error
+	FindAll(ctx context.Context) ([]models.APIKey, error)
+	FindActiveByKeyHash(ctx context.Context, keyHash string) (*models.APIKey, error)
+	TouchLastUsed(ctx context.Context, id uuid.UUID, usedAt time.Time, interval time.Duration) error
+	Delete(ctx context.Context, id uuid.UUID) error
+}
+
+// apiKeyRepository is the concrete implementation of APIKeyRepository
+type apiKeyRepository struct {
+	db *gorm.DB
+}
+
+// NewAPIKeyRepository creates a new API key repository
+func NewAPIKeyRepository(db *gorm.DB) APIKeyRepository {
+	return &apiKeyRepository{db: db}
+}
+
+// Create creates a new API key
+func (r *apiKeyRepository) Create(ctx context.Context, key *models.APIKey) error {
+	return dbFromContext(ctx, r.db).Create(key).Error
+}
+
+// FindAll retrieves all API keys that were not deleted, newest first
+func (r *apiKeyRepository) FindAll(ctx context.Context) ([]models.APIKey, error) {
+	var keys []models.APIKey
+	err := dbFromContext(ctx, r.db).Order("created_at DESC").Find(&keys).Error
+	return keys, err
+}
+
+// FindActiveByKeyHash finds the unexpired API key with the given hash, as long as its
+// organization still exists
+func (r *apiKeyRepository) FindActiveByKeyHash(ctx context.Context, keyHash string) (*models.APIKey, error) {
+	var key models.APIKey
+	err := dbFromContext(ctx, r.db).
+		Joins("JOIN organizations ON organizations.id = api_keys.organization_id AND organizations.deleted_at IS NULL").
+		Where("api_keys.key_hash = ? AND (api_keys.expires_at IS NULL OR api_keys.expires_at > ?)", keyHash, time.Now()).
+		First(&key).Error
+	if err == gorm.ErrRecordNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &key, nil
+}
+
+// TouchLastUsed sets when an API key was last used, unless it was already set less
+// than interval before, so that busy keys do not cause a write on every request
+func (r *apiKeyRepository) TouchLastUsed(ctx context.Context, id uuid.UUID, usedAt time.Time, interval time.Duration) error {
+	return dbFromContext(ctx, r.db).Model(&models.APIKey{}).
+		Where("id = ? AND (last_used_at IS NULL OR last_used_at < ?)", id, usedAt.Add(-interval)).
+		Update("last_used_at", usedAt).Error
+}
+
+// Delete soft deletes an API key, which stops it from authenticating requests
+func (r *apiKeyRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	res := dbFromContext(ctx, r.db).Delete(&models.APIKey{}, id)
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return errors.New("api key not found")
+	}
+	return nil
+}
@@ -0,0 +1,57 @@
+package repositories
+
+import (
+	"context"
+
+	"github.com/hoshina-dev/custapi/internal/models"
+	"gorm.io/gorm"
+)
+
+// AuditRepository defines audit event persistence operations
+type AuditRepository interface {
+	Create(ctx context.Context, event *models.AuditEvent) error
+	Find(ctx context.Context, filter models.AuditFilter) ([]models.AuditEvent, error)
+}
+
+// auditRepository is the concrete implementation of AuditRepository
+type auditRepository struct {
+	db *gorm.DB
+}
+
+// NewAuditRepository creates a new audit repository
+func NewAuditRepository(db *gorm.DB) AuditRepository {
+	return &auditRepository{db: db}
+}
+
+// Create records a new audit event
+func (r *auditRepository) Create(ctx context.Context, event *models.AuditEvent) error {
+	return dbFromContext(ctx, r.db).Create(event).Error
+}
+
+// Find retrieves audit events matching filter, newest first
+func (r *auditRepository) Find(ctx context.Context, filter models.AuditFilter) ([]models.AuditEvent, error) {
+	var events []models.AuditEvent
+	db := dbFromContext(ctx, r.db).Order("created_at DESC")
+
+	if filter.EntityType != "" {
+		db = db.Where("entity_type = ?", filter.EntityType)
+	}
+	if filter.EntityID != nil {
+		db = db.Where("entity_id = ?", *filter.EntityID)
+	}
+	if filter.ActorID != nil {
+		db = db.Where("actor_id = ?", *filter.ActorID)
+	}
+	if filter.APIKeyID != nil {
+		db = db.Where("api_key_id = ?", *filter.APIKeyID)
+	}
+	if filter.Since != nil {
+		db = db.Where("created_at >= ?", *filter.Since)
+	}
+	if filter.Limit > 0 {
+		db = db.Limit(filter.Limit)
+	}
+
+	err := db.Find(&events).Error
+	return events, err
+}
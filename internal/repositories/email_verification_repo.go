@@ -0,0 +1,56 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hoshina-dev/custapi/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// EmailVerificationRepository defines email verification token persistence operations
+type EmailVerificationRepository interface {
+	Create(ctx context.Context, token *models.EmailVerificationToken) error
+	Consume(ctx context.Context, tokenHash string) (*models.EmailVerificationToken, error)
+	DeleteUnusedForUser(ctx context.Context, userID uuid.UUID) error
+}
+
+// emailVerificationRepository is the concrete implementation of EmailVerificationRepository
+type emailVerificationRepository struct {
+	db *gorm.DB
+}
+
+// NewEmailVerificationRepository creates a new email verification token repository
+func NewEmailVerificationRepository(db *gorm.DB) EmailVerificationRepository {
+	return &emailVerificationRepository{db: db}
+}
+
+// Create creates a new email verification token
+func (r *emailVerificationRepository) Create(ctx context.Context, token *models.EmailVerificationToken) error {
+	return dbFromContext(ctx, r.db).Create(token).Error
+}
+
+// Consume marks the unused, unexpired token with the given hash as used and returns
+// it, or nil if there is no such token
+func (r *emailVerificationRepository) Consume(ctx context.Context, tokenHash string) (*models.EmailVerificationToken, error) {
+	var tokens []models.EmailVerificationToken
+	now := time.Now()
+	err := dbFromContext(ctx, r.db).Model(&tokens).
+		Clauses(clause.Returning{}).
+		Where("token_hash = ? AND used_at IS NULL AND expires_at > ?", tokenHash, now).
+		Update("used_at", now).Error
+	if err != nil || len(tokens) == 0 {
+		return nil, err
+	}
+	return &tokens[0], nil
+}
+
+// DeleteUnusedForUser deletes the tokens of a user that were not used yet, so that
+// only the most recently sent one remains valid
+func (r *emailVerificationRepository) DeleteUnusedForUser(ctx context.Context, userID uuid.UUID) error {
+	return dbFromContext(ctx, r.db).
+		Where("user_id = ? AND used_at IS NULL", userID).
+		Delete(&models.EmailVerificationToken{}).Error
+}
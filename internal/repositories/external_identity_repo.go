@@ -0,0 +1,53 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hoshina-dev/custapi/internal/models"
+	"gorm.io/gorm"
+)
+
+// ExternalIdentityRepository defines external identity persistence operations
+type ExternalIdentityRepository interface {
+	Create(ctx context.Context, identity *models.ExternalIdentity) error
+	FindByIssuerAndSubject(ctx context.Context, issuer, subject string) (*models.ExternalIdentity, error)
+	RecordLogin(ctx context.Context, id uuid.UUID, email *string) error
+}
+
+// externalIdentityRepository is the concrete implementation of ExternalIdentityRepository
+type externalIdentityRepository struct {
+	db *gorm.DB
+}
+
+// NewExternalIdentityRepository creates a new external identity repository
+func NewExternalIdentityRepository(db *gorm.DB) ExternalIdentityRepository {
+	return &externalIdentityRepository{db: db}
+}
+
+// Create links a new external identity to a user
+func (r *externalIdentityRepository) Create(ctx context.Context, identity *models.ExternalIdentity) error {
+	return dbFromContext(ctx, r.db).Create(identity).Error
+}
+
+// FindByIssuerAndSubject finds the identity a provider gives the subject
+func (r *externalIdentityRepository) FindByIssuerAndSubject(ctx context.Context, issuer, subject string) (*models.ExternalIdentity, error) {
+	var identity models.ExternalIdentity
+	err := dbFromContext(ctx, r.db).Where("issuer = ? AND subject = ?", issuer, subject).First(&identity).Error
+	if err == gorm.ErrRecordNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &identity, nil
+}
+
+// RecordLogin records a login with an identity, along with the email address the
+// provider gave for it then
+func (r *externalIdentityRepository) RecordLogin(ctx context.Context, id uuid.UUID, email *string) error {
+	return dbFromContext(ctx, r.db).Model(&models.ExternalIdentity{}).
+		Where("id = ?", id).
+		Updates(map[string]any{"email": email, "last_login_at": time.Now()}).Error
+}
@@ -0,0 +1,43 @@
+package repositories
+
+import (
+	"context"
+	"slices"
+
+	"github.com/hoshina-dev/custapi/internal/fieldset"
+	"gorm.io/gorm"
+)
+
+var (
+	// userRequiredFields are always read with a sparse fieldset, to identify users and
+	// load their organization
+	userRequiredFields = []string{"ID", "OrganizationID"}
+	// orgRequiredFields are always read with a sparse fieldset, to identify organizations
+	orgRequiredFields = []string{"ID"}
+	// derivedFields maps the response DTO fields computed from a model, rather than
+	// read from a column of their own, to the field they are computed from
+	derivedFields = map[string]string{
+		// UserResponse.TwoFactorEnabled is User.TwoFactorEnabled()
+		"TwoFactorEnabled": "TOTPEnabledAt",
+	}
+)
+
+// selectFields restricts a read of model T to the fields carried by ctx, if any, always
+// loading the required ones
+func selectFields[T any](ctx context.Context, db *gorm.DB, required ...string) *gorm.DB {
+	fields := fieldset.FromContext[T](ctx)
+	if len(fields) == 0 {
+		return db
+	}
+
+	columns := slices.Clone(required)
+	for _, field := range fields {
+		if from, ok := derivedFields[field]; ok {
+			field = from
+		}
+		if !slices.Contains(columns, field) {
+			columns = append(columns, field)
+		}
+	}
+	return db.Select(columns)
+}
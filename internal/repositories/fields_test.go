@@ -0,0 +1,87 @@
+package repositories
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/hoshina-dev/custapi/internal/fieldset"
+	"github.com/hoshina-dev/custapi/internal/models"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+)
+
+// dryRunDB builds statements without a database to run them on
+func dryRunDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	db, err := gorm.Open(postgres.New(postgres.Config{DSN: "host=localhost"}), &gorm.Config{DryRun: true, DisableAutomaticPing: true})
+	if err != nil {
+		t.Fatalf("gorm.Open() error = %v", err)
+	}
+	return db
+}
+
+func TestSelectFields(t *testing.T) {
+	db := dryRunDB(t)
+
+	tests := []struct {
+		name   string
+		fields []string
+		want   string
+	}{
+		{"every field", nil, `SELECT * FROM "users"`},
+		{"column fields", []string{"Name", "Email"}, `SELECT "id","organization_id","name","email" FROM "users"`},
+		{"required field asked for", []string{"ID", "Name"}, `SELECT "id","organization_id","name" FROM "users"`},
+		{"two_factor_enabled", []string{"TwoFactorEnabled"}, `SELECT "id","organization_id","totp_enabled_at" FROM "users"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			if tt.fields != nil {
+				ctx = fieldset.NewContext[models.User](ctx, tt.fields)
+			}
+
+			var users []models.User
+			stmt := selectFields[models.User](ctx, db.WithContext(ctx), userRequiredFields...).Find(&users).Statement
+			if got := stmt.SQL.String(); !strings.HasPrefix(got, tt.want) {
+				t.Errorf("SQL = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestSelectFieldsResponseFields makes sure every field a client can ask for with a
+// sparse fieldset is read from a column of the model
+func TestSelectFieldsResponseFields(t *testing.T) {
+	tests := []struct {
+		model    any
+		response any
+	}{
+		{&models.User{}, models.UserResponse{}},
+		{&models.Organization{}, models.OrganizationResponse{}},
+	}
+	for _, tt := range tests {
+		s, err := schema.Parse(tt.model, &sync.Map{}, schema.NamingStrategy{})
+		if err != nil {
+			t.Fatalf("schema.Parse(%T) error = %v", tt.model, err)
+		}
+
+		rt := reflect.TypeOf(tt.response)
+		for i := 0; i < rt.NumField(); i++ {
+			f := rt.Field(i)
+			if name, _, _ := strings.Cut(f.Tag.Get("json"), ","); name == "" || name == "-" || f.Tag.Get("csv") == "-" {
+				continue
+			}
+			field := f.Name
+			if from, ok := derivedFields[field]; ok {
+				field = from
+			}
+			if sf := s.LookUpField(field); sf == nil || sf.DBName == "" {
+				t.Errorf("%s.%s is not read from a column of %s", rt.Name(), f.Name, s.Name)
+			}
+		}
+	}
+}
@@ -0,0 +1,63 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hoshina-dev/custapi/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// LoginChallengeRepository defines two-factor login challenge persistence operations
+type LoginChallengeRepository interface {
+	Create(ctx context.Context, challenge *models.LoginChallenge) error
+	ClaimAttempt(ctx context.Context, tokenHash string, maxAttempts int) (*models.LoginChallenge, error)
+	Consume(ctx context.Context, id uuid.UUID) (bool, error)
+	DeleteExpired(ctx context.Context) (int64, error)
+}
+
+// loginChallengeRepository is the concrete implementation of LoginChallengeRepository
+type loginChallengeRepository struct {
+	db *gorm.DB
+}
+
+// NewLoginChallengeRepository creates a new login challenge repository
+func NewLoginChallengeRepository(db *gorm.DB) LoginChallengeRepository {
+	return &loginChallengeRepository{db: db}
+}
+
+// Create creates a new login challenge
+func (r *loginChallengeRepository) Create(ctx context.Context, challenge *models.LoginChallenge) error {
+	return dbFromContext(ctx, r.db).Create(challenge).Error
+}
+
+// ClaimAttempt counts an attempt at answering the unexpired challenge with the given
+// token hash and returns it, or nil if there is no such challenge or it has had
+// maxAttempts attempts already. Attempts are counted before the code is checked, so
+// concurrent requests cannot make more guesses than allowed.
+func (r *loginChallengeRepository) ClaimAttempt(ctx context.Context, tokenHash string, maxAttempts int) (*models.LoginChallenge, error) {
+	var challenges []models.LoginChallenge
+	err := dbFromContext(ctx, r.db).Model(&challenges).
+		Clauses(clause.Returning{}).
+		Where("token_hash = ? AND expires_at > ? AND attempts < ?", tokenHash, time.Now(), maxAttempts).
+		Update("attempts", gorm.Expr("attempts + 1")).Error
+	if err != nil || len(challenges) == 0 {
+		return nil, err
+	}
+	return &challenges[0], nil
+}
+
+// Consume deletes an answered challenge and reports whether it still existed, so that
+// a challenge only ever starts one session
+func (r *loginChallengeRepository) Consume(ctx context.Context, id uuid.UUID) (bool, error) {
+	res := dbFromContext(ctx, r.db).Where("id = ?", id).Delete(&models.LoginChallenge{})
+	return res.RowsAffected > 0, res.Error
+}
+
+// DeleteExpired deletes expired challenges and returns how many were deleted
+func (r *loginChallengeRepository) DeleteExpired(ctx context.Context) (int64, error) {
+	res := dbFromContext(ctx, r.db).Where("expires_at <= ?", time.Now()).Delete(&models.LoginChallenge{})
+	return res.RowsAffected, res.Error
+}
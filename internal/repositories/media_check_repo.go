@@ -0,0 +1,101 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"github.com/hoshina-dev/custapi/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// mediaReferencesQuery lists every image URL stored on a live user or organization
+const mediaReferencesQuery = `
+	SELECT 'user' AS entity_type, id AS entity_id, 'avatar_url' AS field, avatar_url AS url
+	FROM users
+	WHERE deleted_at IS NULL AND avatar_url IS NOT NULL AND avatar_url <> ''
+	UNION
+	SELECT 'organization', o.id, 'image_urls', image.url
+	FROM organizations o CROSS JOIN LATERAL unnest(o.image_urls) AS image(url)
+	WHERE o.deleted_at IS NULL AND image.url <> ''`
+
+// MediaCheckRepository defines persistence operations for checks of stored image URLs
+type MediaCheckRepository interface {
+	FindDue(ctx context.Context, checkedBefore time.Time, limit int) ([]models.MediaCheck, error)
+	Save(ctx context.Context, checks []models.MediaCheck) error
+	DeleteStale(ctx context.Context) (int64, error)
+	FindBroken(ctx context.Context, filter models.MediaCheckFilter) ([]models.MediaCheck, error)
+}
+
+// mediaCheckRepository is the concrete implementation of MediaCheckRepository
+type mediaCheckRepository struct {
+	db *gorm.DB
+}
+
+// NewMediaCheckRepository creates a new media check repository
+func NewMediaCheckRepository(db *gorm.DB) MediaCheckRepository {
+	return &mediaCheckRepository{db: db}
+}
+
+// FindDue returns up to limit stored image URLs that were never checked or were last
+// checked before checkedBefore, never checked ones first. Each carries the failure
+// count of its previous check.
+func (r *mediaCheckRepository) FindDue(ctx context.Context, checkedBefore time.Time, limit int) ([]models.MediaCheck, error) {
+	var checks []models.MediaCheck
+	err := dbFromContext(ctx, r.db).Raw(`
+		WITH refs AS (`+mediaReferencesQuery+`)
+		SELECT refs.entity_type, refs.entity_id, refs.field, refs.url, COALESCE(c.failures, 0) AS failures
+		FROM refs
+		LEFT JOIN media_checks c
+			ON c.entity_type = refs.entity_type AND c.entity_id = refs.entity_id AND c.url = refs.url
+		WHERE c.checked_at IS NULL OR c.checked_at < ?
+		ORDER BY c.checked_at NULLS FIRST
+		LIMIT ?`, checkedBefore, limit).
+		Scan(&checks).Error
+	return checks, err
+}
+
+// Save records the outcome of checks, replacing the previous check of the same URL
+func (r *mediaCheckRepository) Save(ctx context.Context, checks []models.MediaCheck) error {
+	if len(checks) == 0 {
+		return nil
+	}
+	return dbFromContext(ctx, r.db).
+		Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "entity_type"}, {Name: "entity_id"}, {Name: "url"}},
+			DoUpdates: clause.AssignmentColumns([]string{"field", "status", "response_status", "last_error", "failures", "checked_at", "updated_at"}),
+		}).
+		Create(&checks).Error
+}
+
+// DeleteStale removes the checks of URLs that are no longer stored on a live user or
+// organization and returns how many were removed
+func (r *mediaCheckRepository) DeleteStale(ctx context.Context) (int64, error) {
+	res := dbFromContext(ctx, r.db).Exec(`
+		WITH refs AS (` + mediaReferencesQuery + `)
+		DELETE FROM media_checks c
+		WHERE NOT EXISTS (
+			SELECT 1 FROM refs
+			WHERE refs.entity_type = c.entity_type AND refs.entity_id = c.entity_id AND refs.url = c.url
+		)`)
+	return res.RowsAffected, res.Error
+}
+
+// FindBroken retrieves the URLs found broken by their last check, most recently checked first
+func (r *mediaCheckRepository) FindBroken(ctx context.Context, filter models.MediaCheckFilter) ([]models.MediaCheck, error) {
+	var checks []models.MediaCheck
+	db := dbFromContext(ctx, r.db).Where("status = ?", models.MediaBroken).Order("checked_at DESC")
+
+	if filter.EntityType != "" {
+		db = db.Where("entity_type = ?", filter.EntityType)
+	}
+	if filter.Limit > 0 {
+		db = db.Limit(filter.Limit)
+	}
+	if filter.Offset > 0 {
+		db = db.Offset(filter.Offset)
+	}
+
+	err := db.Find(&checks).Error
+	return checks, err
+}
@@ -0,0 +1,59 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hoshina-dev/custapi/internal/models"
+	"gorm.io/gorm"
+)
+
+// OrganizationHistoryRepository defines organization history persistence operations
+type OrganizationHistoryRepository interface {
+	Create(ctx context.Context, version *models.OrganizationHistory) error
+	FindByOrganizationID(ctx context.Context, orgID uuid.UUID) ([]models.OrganizationHistory, error)
+	FindAsOf(ctx context.Context, orgID uuid.UUID, asOf time.Time) (*models.OrganizationHistory, error)
+}
+
+// organizationHistoryRepository is the concrete implementation of OrganizationHistoryRepository
+type organizationHistoryRepository struct {
+	db *gorm.DB
+}
+
+// NewOrganizationHistoryRepository creates a new organization history repository
+func NewOrganizationHistoryRepository(db *gorm.DB) OrganizationHistoryRepository {
+	return &organizationHistoryRepository{db: db}
+}
+
+// Create stores a past version of an organization. ValidTo is left to the database
+// so it matches the updated_at the trigger gives the new version.
+func (r *organizationHistoryRepository) Create(ctx context.Context, version *models.OrganizationHistory) error {
+	return dbFromContext(ctx, r.db).Create(version).Error
+}
+
+// FindByOrganizationID retrieves all past versions of an organization, oldest first
+func (r *organizationHistoryRepository) FindByOrganizationID(ctx context.Context, orgID uuid.UUID) ([]models.OrganizationHistory, error) {
+	var versions []models.OrganizationHistory
+	err := dbFromContext(ctx, r.db).
+		Where("organization_id = ?", orgID).
+		Order("valid_from ASC, history_id ASC").
+		Find(&versions).Error
+	return versions, err
+}
+
+// FindAsOf finds the past version of an organization that was valid at asOf
+func (r *organizationHistoryRepository) FindAsOf(ctx context.Context, orgID uuid.UUID, asOf time.Time) (*models.OrganizationHistory, error) {
+	var version models.OrganizationHistory
+	err := dbFromContext(ctx, r.db).
+		Where("organization_id = ? AND valid_from <= ? AND valid_to > ?", orgID, asOf, asOf).
+		Order("valid_from DESC").
+		First(&version).Error
+	if err == gorm.ErrRecordNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &version, nil
+}
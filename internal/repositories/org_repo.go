@@ -14,8 +14,14 @@ import (
 type OrganizationRepository interface {
 	Create(ctx context.Context, org *models.Organization) error
 	FindByID(ctx context.Context, id uuid.UUID) (*models.Organization, error)
+	FindByIDUnscoped(ctx context.Context, id uuid.UUID) (*models.Organization, error)
+	FindByIDForShare(ctx context.Context, id uuid.UUID) (*models.Organization, error)
+	FindByIDForUpdate(ctx context.Context, id uuid.UUID) (*models.Organization, error)
 	FindByIDs(ctx context.Context, ids []uuid.UUID) ([]models.Organization, error)
+	FindByName(ctx context.Context, name string) (*models.Organization, error)
 	FindAll(ctx context.Context) ([]models.Organization, error)
+	Find(ctx context.Context, filter models.OrganizationFilter) ([]models.Organization, error)
+	StreamAll(ctx context.Context, filter models.OrganizationFilter, fn func(*models.Organization) error) error
 	FindAllCoords(ctx context.Context) ([]models.Organization, error)
 	Update(ctx context.Context, org *models.Organization) error
 	Delete(ctx context.Context, id uuid.UUID) error
@@ -34,13 +40,13 @@ func NewOrganizationRepository(db *gorm.DB) OrganizationRepository {
 
 // Create creates a new organization
 func (r *organizationRepository) Create(ctx context.Context, org *models.Organization) error {
-	return r.db.WithContext(ctx).Create(org).Error
+	return dbFromContext(ctx, r.db).Create(org).Error
 }
 
 // FindByID finds an organization by ID
 func (r *organizationRepository) FindByID(ctx context.Context, id uuid.UUID) (*models.Organization, error) {
 	var org models.Organization
-	err := r.db.WithContext(ctx).First(&org, id).Error
+	err := selectFields[models.Organization](ctx, dbFromContext(ctx, r.db), orgRequiredFields...).First(&org, id).Error
 	if err == gorm.ErrRecordNotFound {
 		return nil, nil
 	}
@@ -50,31 +56,111 @@ func (r *organizationRepository) FindByID(ctx context.Context, id uuid.UUID) (*m
 	return &org, nil
 }
 
+// FindByIDUnscoped finds an organization by ID, including soft-deleted ones
+func (r *organizationRepository) FindByIDUnscoped(ctx context.Context, id uuid.UUID) (*models.Organization, error) {
+	var org models.Organization
+	err := dbFromContext(ctx, r.db).Unscoped().First(&org, id).Error
+	if err == gorm.ErrRecordNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &org, nil
+}
+
+// FindByIDForShare finds an organization by ID and locks it with SELECT ... FOR SHARE,
+// preventing it from being updated or deleted until the surrounding transaction ends
+func (r *organizationRepository) FindByIDForShare(ctx context.Context, id uuid.UUID) (*models.Organization, error) {
+	return r.findByIDLocked(ctx, id, clause.LockingStrengthShare)
+}
+
+// FindByIDForUpdate finds an organization by ID and locks it with SELECT ... FOR UPDATE
+func (r *organizationRepository) FindByIDForUpdate(ctx context.Context, id uuid.UUID) (*models.Organization, error) {
+	return r.findByIDLocked(ctx, id, clause.LockingStrengthUpdate)
+}
+
+func (r *organizationRepository) findByIDLocked(ctx context.Context, id uuid.UUID, strength string) (*models.Organization, error) {
+	var org models.Organization
+	err := dbFromContext(ctx, r.db).Clauses(clause.Locking{Strength: strength}).First(&org, id).Error
+	if err == gorm.ErrRecordNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &org, nil
+}
+
+// FindByIDs finds the organizations with the given IDs, in no particular order
 func (r *organizationRepository) FindByIDs(ctx context.Context, ids []uuid.UUID) ([]models.Organization, error) {
 	var orgs []models.Organization
-	err := r.db.WithContext(ctx).Where("id IN ?", ids).Order("created_at DESC").Find(&orgs).Error
+	err := selectFields[models.Organization](ctx, dbFromContext(ctx, r.db), orgRequiredFields...).Where("id IN ?", ids).Find(&orgs).Error
 	return orgs, err
 }
 
+// FindByName finds an organization by name, ignoring case
+func (r *organizationRepository) FindByName(ctx context.Context, name string) (*models.Organization, error) {
+	var org models.Organization
+	err := dbFromContext(ctx, r.db).Where("LOWER(name) = LOWER(?)", name).First(&org).Error
+	if err == gorm.ErrRecordNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &org, nil
+}
+
 // FindAll retrieves all organizations
 func (r *organizationRepository) FindAll(ctx context.Context) ([]models.Organization, error) {
 	var orgs []models.Organization
-	err := r.db.WithContext(ctx).Order("created_at DESC").Find(&orgs).Error
+	err := selectFields[models.Organization](ctx, dbFromContext(ctx, r.db), orgRequiredFields...).Order("created_at DESC").Find(&orgs).Error
+	return orgs, err
+}
+
+// Find retrieves a page of the organizations matching filter, newest first
+func (r *organizationRepository) Find(ctx context.Context, filter models.OrganizationFilter) ([]models.Organization, error) {
+	var orgs []models.Organization
+	err := filterOrganizations(dbFromContext(ctx, r.db), filter).Find(&orgs).Error
 	return orgs, err
 }
 
+// StreamAll reads the organizations matching filter one row at a time from a
+// database cursor and passes each to fn
+func (r *organizationRepository) StreamAll(ctx context.Context, filter models.OrganizationFilter, fn func(*models.Organization) error) error {
+	db := filterOrganizations(dbFromContext(ctx, r.db).Model(&models.Organization{}), filter)
+
+	rows, err := db.Rows()
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var org models.Organization
+		if err := db.ScanRows(rows, &org); err != nil {
+			return err
+		}
+		if err := fn(&org); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
 func (r *organizationRepository) FindAllCoords(ctx context.Context) ([]models.Organization, error) {
 	var orgs []models.Organization
-	err := r.db.WithContext(ctx).Select("id, latitude, longitude").Find(&orgs).Error
+	err := dbFromContext(ctx, r.db).Select("id, latitude, longitude").Find(&orgs).Error
 	return orgs, err
 }
 
 func (r *organizationRepository) Update(ctx context.Context, org *models.Organization) error {
-	return r.db.WithContext(ctx).Model(org).Clauses(clause.Returning{}).Updates(org).Error
+	return dbFromContext(ctx, r.db).Model(org).Clauses(clause.Returning{}).Updates(org).Error
 }
 
 func (r *organizationRepository) Delete(ctx context.Context, id uuid.UUID) error {
-	res := r.db.WithContext(ctx).Delete(&models.Organization{}, id)
+	res := dbFromContext(ctx, r.db).Delete(&models.Organization{}, id)
 	if res.Error != nil {
 		return res.Error
 	}
@@ -88,7 +174,7 @@ func (r *organizationRepository) Delete(ctx context.Context, id uuid.UUID) error
 func (r *organizationRepository) Search(ctx context.Context, query string, limit int) ([]models.Organization, error) {
 	var orgs []models.Organization
 	searchPattern := "%" + query + "%"
-	db := r.db.WithContext(ctx).
+	db := selectFields[models.Organization](ctx, dbFromContext(ctx, r.db), orgRequiredFields...).
 		Where("name ILIKE ?", searchPattern).
 		Order("name ASC")
 
@@ -99,3 +185,18 @@ func (r *organizationRepository) Search(ctx context.Context, query string, limit
 	err := db.Find(&orgs).Error
 	return orgs, err
 }
+
+// filterOrganizations applies the conditions and paging of filter to an organization query
+func filterOrganizations(db *gorm.DB, filter models.OrganizationFilter) *gorm.DB {
+	db = db.Order("created_at DESC")
+	if filter.Query != "" {
+		db = db.Where("name ILIKE ?", "%"+filter.Query+"%")
+	}
+	if filter.Limit > 0 {
+		db = db.Limit(filter.Limit)
+	}
+	if filter.Offset > 0 {
+		db = db.Offset(filter.Offset)
+	}
+	return db
+}
@@ -0,0 +1,137 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hoshina-dev/custapi/internal/models"
+	"gorm.io/gorm"
+)
+
+// outboxLockKey is the advisory lock held by relays while they claim events, so that
+// two replicas never claim batches at the same time
+const outboxLockKey = 0x6375737461706901
+
+// OutboxRepository defines outbox persistence operations
+type OutboxRepository interface {
+	Create(ctx context.Context, events []*models.OutboxEvent) error
+	FindByID(ctx context.Context, id uuid.UUID) (*models.OutboxEvent, error)
+	// Claim claims the oldest events neither published nor given up on for lease,
+	// unless another claim is running, in which case it returns none
+	Claim(ctx context.Context, limit int, lease time.Duration) ([]models.OutboxEvent, error)
+	MarkPublished(ctx context.Context, ids []uuid.UUID) error
+	// MarkFailed records a failed attempt and, if giveUp, that no more attempts will be made
+	MarkFailed(ctx context.Context, id uuid.UUID, lastError string, giveUp bool) error
+	// Release ends the claim on events, whether they were published or not
+	Release(ctx context.Context, ids []uuid.UUID) error
+	DeletePublishedBefore(ctx context.Context, before time.Time) (int64, error)
+}
+
+// outboxRepository is the concrete implementation of OutboxRepository
+type outboxRepository struct {
+	db *gorm.DB
+}
+
+// NewOutboxRepository creates a new outbox repository
+func NewOutboxRepository(db *gorm.DB) OutboxRepository {
+	return &outboxRepository{db: db}
+}
+
+// Create stores events in the outbox
+func (r *outboxRepository) Create(ctx context.Context, events []*models.OutboxEvent) error {
+	if len(events) == 0 {
+		return nil
+	}
+	return dbFromContext(ctx, r.db).Create(&events).Error
+}
+
+// FindByID finds an outbox event by ID
+func (r *outboxRepository) FindByID(ctx context.Context, id uuid.UUID) (*models.OutboxEvent, error) {
+	var event models.OutboxEvent
+	err := dbFromContext(ctx, r.db).First(&event, "id = ?", id).Error
+	if err == gorm.ErrRecordNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &event, nil
+}
+
+// Claim claims a batch of events in a short transaction of its own, which commits
+// before they are published
+func (r *outboxRepository) Claim(ctx context.Context, limit int, lease time.Duration) ([]models.OutboxEvent, error) {
+	var events []models.OutboxEvent
+	err := dbFromContext(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		var locked bool
+		if err := tx.Raw("SELECT pg_try_advisory_xact_lock(?)", outboxLockKey).Scan(&locked).Error; err != nil || !locked {
+			return err
+		}
+
+		// Events stay in order only while a single batch is out at a time
+		var running bool
+		err := tx.Raw("SELECT EXISTS (SELECT 1 FROM outbox WHERE published_at IS NULL AND failed_at IS NULL AND claimed_until > ?)", time.Now()).
+			Scan(&running).Error
+		if err != nil || running {
+			return err
+		}
+
+		err = tx.Where("published_at IS NULL AND failed_at IS NULL").
+			Order("sequence ASC").
+			Limit(limit).
+			Find(&events).Error
+		if err != nil || len(events) == 0 {
+			return err
+		}
+
+		ids := make([]uuid.UUID, len(events))
+		for i := range events {
+			ids[i] = events[i].ID
+		}
+		return tx.Model(&models.OutboxEvent{}).
+			Where("id IN ?", ids).
+			Update("claimed_until", time.Now().Add(lease)).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return events, nil
+}
+
+// MarkPublished records that events were handed to every sink
+func (r *outboxRepository) MarkPublished(ctx context.Context, ids []uuid.UUID) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	return dbFromContext(ctx, r.db).Model(&models.OutboxEvent{}).
+		Where("id IN ?", ids).
+		Updates(map[string]any{"published_at": time.Now(), "last_error": nil}).Error
+}
+
+// MarkFailed records a failed publish attempt of an event
+func (r *outboxRepository) MarkFailed(ctx context.Context, id uuid.UUID, lastError string, giveUp bool) error {
+	updates := map[string]any{"attempts": gorm.Expr("attempts + 1"), "last_error": lastError}
+	if giveUp {
+		updates["failed_at"] = time.Now()
+	}
+	return dbFromContext(ctx, r.db).Model(&models.OutboxEvent{}).
+		Where("id = ?", id).
+		Updates(updates).Error
+}
+
+// Release ends the claim on events
+func (r *outboxRepository) Release(ctx context.Context, ids []uuid.UUID) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	return dbFromContext(ctx, r.db).Model(&models.OutboxEvent{}).
+		Where("id IN ?", ids).
+		Update("claimed_until", nil).Error
+}
+
+// DeletePublishedBefore removes events published before the given time
+func (r *outboxRepository) DeletePublishedBefore(ctx context.Context, before time.Time) (int64, error) {
+	res := dbFromContext(ctx, r.db).Where("published_at < ?", before).Delete(&models.OutboxEvent{})
+	return res.RowsAffected, res.Error
+}
@@ -0,0 +1,57 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hoshina-dev/custapi/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// PasswordResetRepository defines password reset token persistence operations
+type PasswordResetRepository interface {
+	Create(ctx context.Context, token *models.PasswordResetToken) error
+	Consume(ctx context.Context, tokenHash string) (*models.PasswordResetToken, error)
+	DeleteUnusedForUser(ctx context.Context, userID uuid.UUID) error
+}
+
+// passwordResetRepository is the concrete implementation of PasswordResetRepository
+type passwordResetRepository struct {
+	db *gorm.DB
+}
+
+// NewPasswordResetRepository creates a new password reset token repository
+func NewPasswordResetRepository(db *gorm.DB) PasswordResetRepository {
+	return &passwordResetRepository{db: db}
+}
+
+// Create creates a new password reset token
+func (r *passwordResetRepository) Create(ctx context.Context, token *models.PasswordResetToken) error {
+	return dbFromContext(ctx, r.db).Create(token).Error
+}
+
+// Consume marks the unused, unexpired token with the given hash as used and returns
+// it. A single UPDATE claims the token, so it cannot be used twice even by concurrent
+// requests. It returns nil if there is no such token.
+func (r *passwordResetRepository) Consume(ctx context.Context, tokenHash string) (*models.PasswordResetToken, error) {
+	var tokens []models.PasswordResetToken
+	now := time.Now()
+	err := dbFromContext(ctx, r.db).Model(&tokens).
+		Clauses(clause.Returning{}).
+		Where("token_hash = ? AND used_at IS NULL AND expires_at > ?", tokenHash, now).
+		Update("used_at", now).Error
+	if err != nil || len(tokens) == 0 {
+		return nil, err
+	}
+	return &tokens[0], nil
+}
+
+// DeleteUnusedForUser deletes the tokens of a user that were not used yet, so that
+// only the most recently requested one remains valid
+func (r *passwordResetRepository) DeleteUnusedForUser(ctx context.Context, userID uuid.UUID) error {
+	return dbFromContext(ctx, r.db).
+		Where("user_id = ? AND used_at IS NULL", userID).
+		Delete(&models.PasswordResetToken{}).Error
+}
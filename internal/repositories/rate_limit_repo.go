@@ -0,0 +1,55 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"github.com/hoshina-dev/custapi/internal/models"
+	"gorm.io/gorm"
+)
+
+// RateLimitRepository defines rate limit bucket persistence operations
+type RateLimitRepository interface {
+	Update(ctx context.Context, bucket *models.RateLimitBucket, fn func(bucket *models.RateLimitBucket)) error
+	DeleteFull(ctx context.Context, now time.Time) (int64, error)
+}
+
+// rateLimitRepository is the concrete implementation of RateLimitRepository
+type rateLimitRepository struct {
+	db *gorm.DB
+}
+
+// NewRateLimitRepository creates a new rate limit bucket repository
+func NewRateLimitRepository(db *gorm.DB) RateLimitRepository {
+	return &rateLimitRepository{db: db}
+}
+
+// Update loads the bucket with the key of bucket into it, creating the bucket as given
+// if there is none, lets fn change it and saves it. The bucket stays locked in between,
+// so concurrent requests of a client, whichever replica serves them, take turns.
+func (r *rateLimitRepository) Update(ctx context.Context, bucket *models.RateLimitBucket, fn func(bucket *models.RateLimitBucket)) error {
+	return dbFromContext(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		// The no-op update on conflict locks an existing row and returns it as it is
+		err := tx.Raw(`INSERT INTO rate_limit_buckets (key, tokens, refilled_at, full_at) VALUES (?, ?, ?, ?)
+			ON CONFLICT (key) DO UPDATE SET key = EXCLUDED.key
+			RETURNING key, tokens, refilled_at, full_at`,
+			bucket.Key, bucket.Tokens, bucket.RefilledAt, bucket.FullAt).Scan(bucket).Error
+		if err != nil {
+			return err
+		}
+
+		fn(bucket)
+		return tx.Model(bucket).Updates(map[string]any{
+			"tokens":      bucket.Tokens,
+			"refilled_at": bucket.RefilledAt,
+			"full_at":     bucket.FullAt,
+		}).Error
+	})
+}
+
+// DeleteFull deletes the buckets that have refilled completely by now and returns how
+// many were deleted
+func (r *rateLimitRepository) DeleteFull(ctx context.Context, now time.Time) (int64, error) {
+	res := dbFromContext(ctx, r.db).Where("full_at <= ?", now).Delete(&models.RateLimitBucket{})
+	return res.RowsAffected, res.Error
+}
@@ -0,0 +1,82 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hoshina-dev/custapi/internal/models"
+	"gorm.io/gorm"
+)
+
+// SessionRepository defines login session persistence operations
+type SessionRepository interface {
+	Create(ctx context.Context, session *models.Session) error
+	FindActiveByTokenHash(ctx context.Context, tokenHash string) (*models.Session, error)
+	MarkTwoFactorVerified(ctx context.Context, tokenHash string) error
+	Revoke(ctx context.Context, id uuid.UUID) error
+	RevokeAllForUser(ctx context.Context, userID uuid.UUID) (int64, error)
+	RevokeOthersForUser(ctx context.Context, userID uuid.UUID, keepTokenHash string) (int64, error)
+}
+
+// sessionRepository is the concrete implementation of SessionRepository
+type sessionRepository struct {
+	db *gorm.DB
+}
+
+// NewSessionRepository creates a new session repository
+func NewSessionRepository(db *gorm.DB) SessionRepository {
+	return &sessionRepository{db: db}
+}
+
+// Create creates a new session
+func (r *sessionRepository) Create(ctx context.Context, session *models.Session) error {
+	return dbFromContext(ctx, r.db).Omit("User").Create(session).Error
+}
+
+// FindActiveByTokenHash finds the unexpired, unrevoked session with the given token hash
+func (r *sessionRepository) FindActiveByTokenHash(ctx context.Context, tokenHash string) (*models.Session, error) {
+	var session models.Session
+	err := dbFromContext(ctx, r.db).
+		Where("token_hash = ? AND revoked_at IS NULL AND expires_at > ?", tokenHash, time.Now()).
+		First(&session).Error
+	if err == gorm.ErrRecordNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &session, nil
+}
+
+// MarkTwoFactorVerified records that the user completed a second factor for the active
+// session with the given token hash
+func (r *sessionRepository) MarkTwoFactorVerified(ctx context.Context, tokenHash string) error {
+	return dbFromContext(ctx, r.db).Model(&models.Session{}).
+		Where("token_hash = ? AND revoked_at IS NULL AND expires_at > ?", tokenHash, time.Now()).
+		Update("two_factor_verified_at", time.Now()).Error
+}
+
+// Revoke ends a session
+func (r *sessionRepository) Revoke(ctx context.Context, id uuid.UUID) error {
+	return dbFromContext(ctx, r.db).Model(&models.Session{}).
+		Where("id = ? AND revoked_at IS NULL", id).
+		Update("revoked_at", time.Now()).Error
+}
+
+// RevokeAllForUser ends every active session of a user and returns how many were ended
+func (r *sessionRepository) RevokeAllForUser(ctx context.Context, userID uuid.UUID) (int64, error) {
+	res := dbFromContext(ctx, r.db).Model(&models.Session{}).
+		Where("user_id = ? AND revoked_at IS NULL AND expires_at > ?", userID, time.Now()).
+		Update("revoked_at", time.Now())
+	return res.RowsAffected, res.Error
+}
+
+// RevokeOthersForUser ends every active session of a user but the one with the given
+// token hash and returns how many were ended
+func (r *sessionRepository) RevokeOthersForUser(ctx context.Context, userID uuid.UUID, keepTokenHash string) (int64, error) {
+	res := dbFromContext(ctx, r.db).Model(&models.Session{}).
+		Where("user_id = ? AND token_hash <> ? AND revoked_at IS NULL AND expires_at > ?", userID, keepTokenHash, time.Now()).
+		Update("revoked_at", time.Now())
+	return res.RowsAffected, res.Error
+}
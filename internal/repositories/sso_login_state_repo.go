@@ -0,0 +1,53 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"github.com/hoshina-dev/custapi/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// SSOLoginStateRepository defines single sign-on login state persistence operations
+type SSOLoginStateRepository interface {
+	Create(ctx context.Context, state *models.SSOLoginState) error
+	Consume(ctx context.Context, stateHash string) (*models.SSOLoginState, error)
+	DeleteExpired(ctx context.Context) (int64, error)
+}
+
+// ssoLoginStateRepository is the concrete implementation of SSOLoginStateRepository
+type ssoLoginStateRepository struct {
+	db *gorm.DB
+}
+
+// NewSSOLoginStateRepository creates a new single sign-on login state repository
+func NewSSOLoginStateRepository(db *gorm.DB) SSOLoginStateRepository {
+	return &ssoLoginStateRepository{db: db}
+}
+
+// Create creates a new login state
+func (r *ssoLoginStateRepository) Create(ctx context.Context, state *models.SSOLoginState) error {
+	return dbFromContext(ctx, r.db).Create(state).Error
+}
+
+// Consume deletes the unexpired login state with the given hash and returns it, or nil
+// if there is no such state. Deleting it in the same statement makes sure a state
+// only ever completes one login.
+func (r *ssoLoginStateRepository) Consume(ctx context.Context, stateHash string) (*models.SSOLoginState, error) {
+	var states []models.SSOLoginState
+	err := dbFromContext(ctx, r.db).
+		Clauses(clause.Returning{}).
+		Where("state_hash = ? AND expires_at > ?", stateHash, time.Now()).
+		Delete(&states).Error
+	if err != nil || len(states) == 0 {
+		return nil, err
+	}
+	return &states[0], nil
+}
+
+// DeleteExpired deletes expired login states and returns how many were deleted
+func (r *ssoLoginStateRepository) DeleteExpired(ctx context.Context) (int64, error) {
+	res := dbFromContext(ctx, r.db).Where("expires_at <= ?", time.Now()).Delete(&models.SSOLoginState{})
+	return res.RowsAffected, res.Error
+}
@@ -0,0 +1,85 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/hoshina-dev/custapi/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// SSOProviderRepository defines single sign-on provider persistence operations
+type SSOProviderRepository interface {
+	Create(ctx context.Context, provider *models.SSOProvider) error
+	FindByID(ctx context.Context, id uuid.UUID) (*models.SSOProvider, error)
+	FindByOrganizationID(ctx context.Context, orgID uuid.UUID) (*models.SSOProvider, error)
+	FindByEmailDomain(ctx context.Context, domain string) (*models.SSOProvider, error)
+	Update(ctx context.Context, provider *models.SSOProvider) error
+	DeleteByOrganizationID(ctx context.Context, orgID uuid.UUID) error
+}
+
+// ssoProviderRepository is the concrete implementation of SSOProviderRepository
+type ssoProviderRepository struct {
+	db *gorm.DB
+}
+
+// NewSSOProviderRepository creates a new single sign-on provider repository
+func NewSSOProviderRepository(db *gorm.DB) SSOProviderRepository {
+	return &ssoProviderRepository{db: db}
+}
+
+// Create creates a new provider
+func (r *ssoProviderRepository) Create(ctx context.Context, provider *models.SSOProvider) error {
+	return dbFromContext(ctx, r.db).Create(provider).Error
+}
+
+// FindByID finds a provider by ID
+func (r *ssoProviderRepository) FindByID(ctx context.Context, id uuid.UUID) (*models.SSOProvider, error) {
+	return r.first(dbFromContext(ctx, r.db).Where("id = ?", id))
+}
+
+// FindByOrganizationID finds the provider of an organization
+func (r *ssoProviderRepository) FindByOrganizationID(ctx context.Context, orgID uuid.UUID) (*models.SSOProvider, error) {
+	return r.first(dbFromContext(ctx, r.db).Where("organization_id = ?", orgID))
+}
+
+// FindByEmailDomain finds the provider handling the email addresses of a domain, ignoring case
+func (r *ssoProviderRepository) FindByEmailDomain(ctx context.Context, domain string) (*models.SSOProvider, error) {
+	return r.first(dbFromContext(ctx, r.db).Where("email_domains @> ARRAY[?]::text[]", strings.ToLower(domain)))
+}
+
+// first returns the first provider matching db, or nil if none does
+func (r *ssoProviderRepository) first(db *gorm.DB) (*models.SSOProvider, error) {
+	var provider models.SSOProvider
+	err := db.First(&provider).Error
+	if err == gorm.ErrRecordNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &provider, nil
+}
+
+// Update replaces the configuration of a provider, clearing the client secret if it is empty
+func (r *ssoProviderRepository) Update(ctx context.Context, provider *models.SSOProvider) error {
+	return dbFromContext(ctx, r.db).Model(provider).
+		Clauses(clause.Returning{}).
+		Select("issuer", "client_id", "client_secret", "email_domains").
+		Updates(provider).Error
+}
+
+// DeleteByOrganizationID soft deletes the provider of an organization
+func (r *ssoProviderRepository) DeleteByOrganizationID(ctx context.Context, orgID uuid.UUID) error {
+	res := dbFromContext(ctx, r.db).Where("organization_id = ?", orgID).Delete(&models.SSOProvider{})
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return errors.New("sso provider not found")
+	}
+	return nil
+}
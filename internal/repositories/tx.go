@@ -0,0 +1,66 @@
+package repositories
+
+import (
+	"context"
+	"log"
+
+	"gorm.io/gorm"
+)
+
+// txKey is the context key under which the active transaction is stored
+type txKey struct{}
+
+// TxManager runs several repository calls inside a single database transaction
+type TxManager interface {
+	// WithinTransaction calls fn with a context carrying a new transaction. Repositories
+	// called with that context join the transaction. It is committed when fn returns nil
+	// and rolled back when fn returns an error or panics. If ctx already carries a
+	// transaction, fn joins it instead of starting a new one.
+	WithinTransaction(ctx context.Context, fn func(ctx context.Context) error) error
+}
+
+// txManager is the GORM implementation of TxManager
+type txManager struct {
+	db *gorm.DB
+}
+
+// NewTxManager creates a new transaction manager
+func NewTxManager(db *gorm.DB) TxManager {
+	return &txManager{db: db}
+}
+
+// WithinTransaction runs fn inside a transaction carried via the context
+func (m *txManager) WithinTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	if _, ok := ctx.Value(txKey{}).(*gorm.DB); ok {
+		return fn(ctx)
+	}
+
+	tx := m.db.WithContext(ctx).Begin()
+	if tx.Error != nil {
+		return tx.Error
+	}
+
+	defer func() {
+		if p := recover(); p != nil {
+			tx.Rollback()
+			panic(p)
+		}
+	}()
+
+	if err := fn(context.WithValue(ctx, txKey{}, tx)); err != nil {
+		if rbErr := tx.Rollback().Error; rbErr != nil {
+			log.Printf("Failed to roll back transaction: %v", rbErr)
+		}
+		return err
+	}
+
+	return tx.Commit().Error
+}
+
+// dbFromContext returns the transaction carried by ctx, falling back to db
+func dbFromContext(ctx context.Context, db *gorm.DB) *gorm.DB {
+	if tx, ok := ctx.Value(txKey{}).(*gorm.DB); ok {
+		return tx.WithContext(ctx)
+	}
+	return db.WithContext(ctx)
+}
@@ -0,0 +1,72 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hoshina-dev/custapi/internal/models"
+	"gorm.io/gorm"
+)
+
+// UserHistoryRepository defines user history persistence operations
+type UserHistoryRepository interface {
+	Create(ctx context.Context, version *models.UserHistory) error
+	FindByUserID(ctx context.Context, userID uuid.UUID) ([]models.UserHistory, error)
+	FindAsOf(ctx context.Context, userID uuid.UUID, asOf time.Time) (*models.UserHistory, error)
+}
+
+// userHistoryRepository is the concrete implementation of UserHistoryRepository
+type userHistoryRepository struct {
+	db *gorm.DB
+}
+
+// NewUserHistoryRepository creates a new user history repository
+func NewUserHistoryRepository(db *gorm.DB) UserHistoryRepository {
+	return &userHistoryRepository{db: db}
+}
+
+// Create stores a past version of a user. A version starts where the previous one
+// ended, since updated_at also moves on changes the history does not track, like new
+// passwords.
+func (r *userHistoryRepository) Create(ctx context.Context, version *models.UserHistory) error {
+	db := dbFromContext(ctx, r.db)
+	var previousEnd *time.Time
+	err := db.Model(&models.UserHistory{}).
+		Where("user_id = ?", version.UserID).
+		Select("max(valid_to)").
+		Scan(&previousEnd).Error
+	if err != nil {
+		return err
+	}
+	if previousEnd != nil && previousEnd.After(version.ValidFrom) {
+		version.ValidFrom = *previousEnd
+	}
+	return db.Create(version).Error
+}
+
+// FindByUserID retrieves all past versions of a user, oldest first
+func (r *userHistoryRepository) FindByUserID(ctx context.Context, userID uuid.UUID) ([]models.UserHistory, error) {
+	var versions []models.UserHistory
+	err := dbFromContext(ctx, r.db).
+		Where("user_id = ?", userID).
+		Order("valid_from ASC, history_id ASC").
+		Find(&versions).Error
+	return versions, err
+}
+
+// FindAsOf finds the past version of a user that was valid at asOf
+func (r *userHistoryRepository) FindAsOf(ctx context.Context, userID uuid.UUID, asOf time.Time) (*models.UserHistory, error) {
+	var version models.UserHistory
+	err := dbFromContext(ctx, r.db).
+		Where("user_id = ? AND valid_from <= ? AND valid_to > ?", userID, asOf, asOf).
+		Order("valid_from DESC").
+		First(&version).Error
+	if err == gorm.ErrRecordNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &version, nil
+}
@@ -3,6 +3,7 @@ package repositories
 import (
 	"context"
 	"errors"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/hoshina-dev/custapi/internal/models"
@@ -13,11 +14,26 @@ import (
 // UserRepository defines user persistence operations
 type UserRepository interface {
 	Create(ctx context.Context, user *models.User) error
+	CreateBatch(ctx context.Context, users []*models.User) error
 	FindByID(ctx context.Context, id uuid.UUID) (*models.User, error)
+	FindByIDUnscoped(ctx context.Context, id uuid.UUID) (*models.User, error)
+	FindByIDs(ctx context.Context, ids []uuid.UUID) ([]models.User, error)
+	FindByEmail(ctx context.Context, email string) (*models.User, error)
 	FindAll(ctx context.Context) ([]models.User, error)
+	Find(ctx context.Context, filter models.UserFilter) ([]models.User, error)
+	StreamAll(ctx context.Context, filter models.UserFilter, fn func(*models.User) error) error
 	FindByOrganizationID(ctx context.Context, orgID uuid.UUID) ([]models.User, error)
+	FindByOrganizationIDs(ctx context.Context, orgIDs []uuid.UUID, limit, offset int) ([]models.User, error)
+	CountByOrganizationID(ctx context.Context, orgID uuid.UUID) (int64, error)
 	Update(ctx context.Context, user *models.User) error
+	ClearAvatarURL(ctx context.Context, id uuid.UUID, url string) (bool, error)
+	ConfirmEmail(ctx context.Context, id uuid.UUID, email string) error
+	SetTOTPSecret(ctx context.Context, id uuid.UUID, secret string) error
+	EnableTOTP(ctx context.Context, id uuid.UUID) error
+	UseTOTPStep(ctx context.Context, id uuid.UUID, step int64) (bool, error)
 	Delete(ctx context.Context, id uuid.UUID) error
+	DeleteByOrganizationID(ctx context.Context, orgID uuid.UUID) (int64, error)
+	ReassignOrganization(ctx context.Context, fromOrgID uuid.UUID, toOrgID uuid.UUID) (int64, error)
 	Search(ctx context.Context, query string, limit int) ([]models.User, error)
 }
 
@@ -33,13 +49,54 @@ func NewUserRepository(db *gorm.DB) UserRepository {
 
 // Create creates a new user
 func (r *userRepository) Create(ctx context.Context, user *models.User) error {
-	return r.db.WithContext(ctx).Create(user).Error
+	return dbFromContext(ctx, r.db).Create(user).Error
+}
+
+// CreateBatch creates several users with a single batched insert
+func (r *userRepository) CreateBatch(ctx context.Context, users []*models.User) error {
+	if len(users) == 0 {
+		return nil
+	}
+	return dbFromContext(ctx, r.db).Create(&users).Error
 }
 
 // FindByID finds a user by ID
 func (r *userRepository) FindByID(ctx context.Context, id uuid.UUID) (*models.User, error) {
 	var user models.User
-	err := r.db.WithContext(ctx).First(&user, id).Error
+	err := selectFields[models.User](ctx, dbFromContext(ctx, r.db), userRequiredFields...).First(&user, id).Error
+	if err == gorm.ErrRecordNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// FindByIDUnscoped finds a user by ID, including soft-deleted ones
+func (r *userRepository) FindByIDUnscoped(ctx context.Context, id uuid.UUID) (*models.User, error) {
+	var user models.User
+	err := dbFromContext(ctx, r.db).Unscoped().First(&user, id).Error
+	if err == gorm.ErrRecordNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// FindByIDs finds the users with the given IDs, in no particular order
+func (r *userRepository) FindByIDs(ctx context.Context, ids []uuid.UUID) ([]models.User, error) {
+	var users []models.User
+	err := selectFields[models.User](ctx, dbFromContext(ctx, r.db), userRequiredFields...).Where("id IN ?", ids).Find(&users).Error
+	return users, err
+}
+
+// FindByEmail finds a user by email, ignoring case
+func (r *userRepository) FindByEmail(ctx context.Context, email string) (*models.User, error) {
+	var user models.User
+	err := dbFromContext(ctx, r.db).Where("LOWER(email) = LOWER(?)", email).First(&user).Error
 	if err == gorm.ErrRecordNotFound {
 		return nil, nil
 	}
@@ -52,23 +109,128 @@ func (r *userRepository) FindByID(ctx context.Context, id uuid.UUID) (*models.Us
 // FindAll retrieves all users
 func (r *userRepository) FindAll(ctx context.Context) ([]models.User, error) {
 	var users []models.User
-	err := r.db.WithContext(ctx).Order("created_at DESC").Find(&users).Error
+	err := selectFields[models.User](ctx, dbFromContext(ctx, r.db), userRequiredFields...).Order("created_at DESC").Find(&users).Error
 	return users, err
 }
 
+// Find retrieves a page of the users matching filter, newest first
+func (r *userRepository) Find(ctx context.Context, filter models.UserFilter) ([]models.User, error) {
+	var users []models.User
+	err := filterUsers(dbFromContext(ctx, r.db), filter).Find(&users).Error
+	return users, err
+}
+
+// StreamAll reads the users matching filter one row at a time from a database cursor
+// and passes each to fn. The password hash is never read.
+func (r *userRepository) StreamAll(ctx context.Context, filter models.UserFilter, fn func(*models.User) error) error {
+	db := filterUsers(dbFromContext(ctx, r.db).Model(&models.User{}).Omit("password"), filter)
+
+	rows, err := db.Rows()
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var user models.User
+		if err := db.ScanRows(rows, &user); err != nil {
+			return err
+		}
+		if err := fn(&user); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
 // FindByOrganizationID finds all users in an organization
 func (r *userRepository) FindByOrganizationID(ctx context.Context, orgID uuid.UUID) ([]models.User, error) {
 	var users []models.User
-	err := r.db.WithContext(ctx).Where("organization_id = ?", orgID).Order("created_at DESC").Find(&users).Error
+	err := selectFields[models.User](ctx, dbFromContext(ctx, r.db), userRequiredFields...).
+		Where("organization_id = ?", orgID).
+		Order("created_at DESC").
+		Find(&users).Error
 	return users, err
 }
 
+// FindByOrganizationIDs finds the users of several organizations in one query, newest
+// first. limit and offset page the users of each organization separately.
+func (r *userRepository) FindByOrganizationIDs(ctx context.Context, orgIDs []uuid.UUID, limit, offset int) ([]models.User, error) {
+	var users []models.User
+	if len(orgIDs) == 0 {
+		return users, nil
+	}
+
+	db := dbFromContext(ctx, r.db)
+	ranked := db.Model(&models.User{}).
+		Select("users.*, ROW_NUMBER() OVER (PARTITION BY organization_id ORDER BY created_at DESC) AS row_rank").
+		Where("organization_id IN ?", orgIDs)
+	query := db.Table("(?) AS users", ranked).Where("row_rank > ?", offset).Order("organization_id, row_rank")
+	if limit > 0 {
+		query = query.Where("row_rank <= ?", offset+limit)
+	}
+
+	err := query.Find(&users).Error
+	return users, err
+}
+
+// CountByOrganizationID counts the users in an organization
+func (r *userRepository) CountByOrganizationID(ctx context.Context, orgID uuid.UUID) (int64, error) {
+	var count int64
+	err := dbFromContext(ctx, r.db).Model(&models.User{}).Where("organization_id = ?", orgID).Count(&count).Error
+	return count, err
+}
+
 func (r *userRepository) Update(ctx context.Context, user *models.User) error {
-	return r.db.WithContext(ctx).Model(user).Clauses(clause.Returning{}).Updates(user).Error
+	return dbFromContext(ctx, r.db).Model(user).Clauses(clause.Returning{}).Updates(user).Error
+}
+
+// ClearAvatarURL removes the avatar of a user if it is still url and reports whether it did
+func (r *userRepository) ClearAvatarURL(ctx context.Context, id uuid.UUID, url string) (bool, error) {
+	res := dbFromContext(ctx, r.db).Model(&models.User{}).
+		Where("id = ? AND avatar_url = ?", id, url).
+		Update("avatar_url", nil)
+	return res.RowsAffected > 0, res.Error
+}
+
+// ConfirmEmail makes email the verified address of a user, completing the pending
+// change to it if there is one
+func (r *userRepository) ConfirmEmail(ctx context.Context, id uuid.UUID, email string) error {
+	return dbFromContext(ctx, r.db).Model(&models.User{}).
+		Where("id = ?", id).
+		Updates(map[string]any{
+			"email":             email,
+			"pending_email":     gorm.Expr("CASE WHEN LOWER(pending_email) = LOWER(?) THEN NULL ELSE pending_email END", email),
+			"email_verified_at": time.Now(),
+		}).Error
+}
+
+// SetTOTPSecret stores a new TOTP secret for a user, which is not enabled until EnableTOTP
+func (r *userRepository) SetTOTPSecret(ctx context.Context, id uuid.UUID, secret string) error {
+	return dbFromContext(ctx, r.db).Model(&models.User{}).
+		Where("id = ?", id).
+		Updates(map[string]any{"totp_secret": secret, "totp_enabled_at": nil, "totp_last_step": nil}).Error
+}
+
+// EnableTOTP makes logins of a user need a code for their TOTP secret
+func (r *userRepository) EnableTOTP(ctx context.Context, id uuid.UUID) error {
+	return dbFromContext(ctx, r.db).Model(&models.User{}).
+		Where("id = ? AND totp_secret IS NOT NULL", id).
+		Update("totp_enabled_at", time.Now()).Error
+}
+
+// UseTOTPStep records that the TOTP code of a time step was used and reports whether
+// it was the first code used for that step or a later one. A single UPDATE checks and
+// records the step, so concurrent requests cannot use the same code.
+func (r *userRepository) UseTOTPStep(ctx context.Context, id uuid.UUID, step int64) (bool, error) {
+	res := dbFromContext(ctx, r.db).Model(&models.User{}).
+		Where("id = ? AND (totp_last_step IS NULL OR totp_last_step < ?)", id, step).
+		Update("totp_last_step", step)
+	return res.RowsAffected > 0, res.Error
 }
 
 func (r *userRepository) Delete(ctx context.Context, id uuid.UUID) error {
-	res := r.db.WithContext(ctx).Delete(&models.User{}, id)
+	res := dbFromContext(ctx, r.db).Delete(&models.User{}, id)
 	if res.Error != nil {
 		return res.Error
 	}
@@ -78,12 +240,25 @@ func (r *userRepository) Delete(ctx context.Context, id uuid.UUID) error {
 	return nil
 }
 
+// DeleteByOrganizationID soft deletes all users in an organization
+func (r *userRepository) DeleteByOrganizationID(ctx context.Context, orgID uuid.UUID) (int64, error) {
+	res := dbFromContext(ctx, r.db).Where("organization_id = ?", orgID).Delete(&models.User{})
+	return res.RowsAffected, res.Error
+}
+
+// ReassignOrganization moves all users of one organization to another
+func (r *userRepository) ReassignOrganization(ctx context.Context, fromOrgID uuid.UUID, toOrgID uuid.UUID) (int64, error) {
+	res := dbFromContext(ctx, r.db).Model(&models.User{}).
+		Where("organization_id = ?", fromOrgID).
+		Update("organization_id", toOrgID)
+	return res.RowsAffected, res.Error
+}
+
 // Search searches users by name or email using ILIKE
 func (r *userRepository) Search(ctx context.Context, query string, limit int) ([]models.User, error) {
 	var users []models.User
 	searchPattern := "%" + query + "%"
-	db := r.db.WithContext(ctx).
-		Preload("Organization").
+	db := selectFields[models.User](ctx, dbFromContext(ctx, r.db), userRequiredFields...).
 		Where("name ILIKE ? OR email ILIKE ?", searchPattern, searchPattern).
 		Order("name ASC")
 
@@ -94,3 +269,22 @@ func (r *userRepository) Search(ctx context.Context, query string, limit int) ([
 	err := db.Find(&users).Error
 	return users, err
 }
+
+// filterUsers applies the conditions and paging of filter to a user query
+func filterUsers(db *gorm.DB, filter models.UserFilter) *gorm.DB {
+	db = db.Order("created_at DESC")
+	if filter.Query != "" {
+		searchPattern := "%" + filter.Query + "%"
+		db = db.Where("name ILIKE ? OR email ILIKE ?", searchPattern, searchPattern)
+	}
+	if filter.OrganizationID != nil {
+		db = db.Where("organization_id = ?", *filter.OrganizationID)
+	}
+	if filter.Limit > 0 {
+		db = db.Limit(filter.Limit)
+	}
+	if filter.Offset > 0 {
+		db = db.Offset(filter.Offset)
+	}
+	return db
+}
@@ -0,0 +1,173 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hoshina-dev/custapi/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// WebhookRepository defines webhook subscription and delivery persistence operations
+type WebhookRepository interface {
+	CreateSubscription(ctx context.Context, sub *models.WebhookSubscription) error
+	FindSubscriptionByID(ctx context.Context, id uuid.UUID) (*models.WebhookSubscription, error)
+	FindAllSubscriptions(ctx context.Context) ([]models.WebhookSubscription, error)
+	FindActiveSubscriptions(ctx context.Context) ([]models.WebhookSubscription, error)
+	DeleteSubscription(ctx context.Context, id uuid.UUID) error
+	CreateDeliveries(ctx context.Context, deliveries []*models.WebhookDelivery) error
+	ClaimDueDeliveries(ctx context.Context, limit int, lease time.Duration) ([]models.WebhookDelivery, error)
+	UpdateDelivery(ctx context.Context, delivery *models.WebhookDelivery) error
+	FindDeliveries(ctx context.Context, subscriptionID uuid.UUID, status string, limit int) ([]models.WebhookDelivery, error)
+	RetryDelivery(ctx context.Context, subscriptionID, deliveryID uuid.UUID) error
+}
+
+// webhookRepository is the concrete implementation of WebhookRepository
+type webhookRepository struct {
+	db *gorm.DB
+}
+
+// NewWebhookRepository creates a new webhook repository
+func NewWebhookRepository(db *gorm.DB) WebhookRepository {
+	return &webhookRepository{db: db}
+}
+
+// CreateSubscription creates a new webhook subscription
+func (r *webhookRepository) CreateSubscription(ctx context.Context, sub *models.WebhookSubscription) error {
+	return dbFromContext(ctx, r.db).Create(sub).Error
+}
+
+// FindSubscriptionByID finds a webhook subscription by ID
+func (r *webhookRepository) FindSubscriptionByID(ctx context.Context, id uuid.UUID) (*models.WebhookSubscription, error) {
+	var sub models.WebhookSubscription
+	err := dbFromContext(ctx, r.db).First(&sub, id).Error
+	if err == gorm.ErrRecordNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &sub, nil
+}
+
+// FindAllSubscriptions retrieves all webhook subscriptions
+func (r *webhookRepository) FindAllSubscriptions(ctx context.Context) ([]models.WebhookSubscription, error) {
+	var subs []models.WebhookSubscription
+	err := dbFromContext(ctx, r.db).Order("created_at DESC").Find(&subs).Error
+	return subs, err
+}
+
+// FindActiveSubscriptions retrieves the subscriptions that currently receive events
+func (r *webhookRepository) FindActiveSubscriptions(ctx context.Context) ([]models.WebhookSubscription, error) {
+	var subs []models.WebhookSubscription
+	err := dbFromContext(ctx, r.db).Where("active").Find(&subs).Error
+	return subs, err
+}
+
+// DeleteSubscription soft deletes a webhook subscription
+func (r *webhookRepository) DeleteSubscription(ctx context.Context, id uuid.UUID) error {
+	res := dbFromContext(ctx, r.db).Delete(&models.WebhookSubscription{}, id)
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return errors.New("webhook not found")
+	}
+	return nil
+}
+
+// CreateDeliveries queues deliveries with a single batched insert. A delivery of an
+// event already queued for the same subscription is ignored.
+func (r *webhookRepository) CreateDeliveries(ctx context.Context, deliveries []*models.WebhookDelivery) error {
+	if len(deliveries) == 0 {
+		return nil
+	}
+	return dbFromContext(ctx, r.db).Omit("Subscription").
+		Clauses(clause.OnConflict{Columns: []clause.Column{{Name: "subscription_id"}, {Name: "event_id"}}, DoNothing: true}).
+		Create(&deliveries).Error
+}
+
+// ClaimDueDeliveries picks up to limit pending deliveries that are due and pushes their
+// next attempt back by lease, so other workers skip them while they are being sent.
+// SKIP LOCKED lets several replicas claim deliveries concurrently.
+func (r *webhookRepository) ClaimDueDeliveries(ctx context.Context, limit int, lease time.Duration) ([]models.WebhookDelivery, error) {
+	var deliveries []models.WebhookDelivery
+	err := dbFromContext(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		err := tx.Clauses(clause.Locking{Strength: clause.LockingStrengthUpdate, Options: clause.LockingOptionsSkipLocked}).
+			Where("status = ? AND next_attempt_at <= ?", models.DeliveryPending, time.Now()).
+			Order("next_attempt_at ASC").
+			Limit(limit).
+			Find(&deliveries).Error
+		if err != nil || len(deliveries) == 0 {
+			return err
+		}
+
+		ids := make([]uuid.UUID, len(deliveries))
+		for i := range deliveries {
+			ids[i] = deliveries[i].ID
+		}
+		return tx.Model(&models.WebhookDelivery{}).
+			Where("id IN ?", ids).
+			Update("next_attempt_at", time.Now().Add(lease)).Error
+	})
+	if err != nil || len(deliveries) == 0 {
+		return nil, err
+	}
+
+	// Load the subscriptions, including ones deleted since the event was queued
+	subIDs := make([]uuid.UUID, 0, len(deliveries))
+	for _, d := range deliveries {
+		subIDs = append(subIDs, d.SubscriptionID)
+	}
+	var subs []models.WebhookSubscription
+	if err := dbFromContext(ctx, r.db).Unscoped().Where("id IN ?", subIDs).Find(&subs).Error; err != nil {
+		return nil, err
+	}
+	byID := make(map[uuid.UUID]models.WebhookSubscription, len(subs))
+	for _, sub := range subs {
+		byID[sub.ID] = sub
+	}
+	for i := range deliveries {
+		deliveries[i].Subscription = byID[deliveries[i].SubscriptionID]
+	}
+
+	return deliveries, nil
+}
+
+// UpdateDelivery saves the outcome of a delivery attempt
+func (r *webhookRepository) UpdateDelivery(ctx context.Context, delivery *models.WebhookDelivery) error {
+	return dbFromContext(ctx, r.db).Model(delivery).Select(
+		"status", "attempts", "next_attempt_at", "last_error", "response_status", "delivered_at",
+	).Updates(delivery).Error
+}
+
+// FindDeliveries retrieves the deliveries of a subscription, newest first
+func (r *webhookRepository) FindDeliveries(ctx context.Context, subscriptionID uuid.UUID, status string, limit int) ([]models.WebhookDelivery, error) {
+	var deliveries []models.WebhookDelivery
+	db := dbFromContext(ctx, r.db).Where("subscription_id = ?", subscriptionID).Order("created_at DESC")
+	if status != "" {
+		db = db.Where("status = ?", status)
+	}
+	if limit > 0 {
+		db = db.Limit(limit)
+	}
+	err := db.Find(&deliveries).Error
+	return deliveries, err
+}
+
+// RetryDelivery puts a delivery back in the queue for immediate delivery with a fresh retry budget
+func (r *webhookRepository) RetryDelivery(ctx context.Context, subscriptionID, deliveryID uuid.UUID) error {
+	res := dbFromContext(ctx, r.db).Model(&models.WebhookDelivery{}).
+		Where("id = ? AND subscription_id = ?", deliveryID, subscriptionID).
+		Updates(map[string]any{"status": models.DeliveryPending, "attempts": 0, "next_attempt_at": time.Now()})
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return errors.New("delivery not found")
+	}
+	return nil
+}
@@ -5,17 +5,43 @@ import (
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/gofiber/fiber/v2/middleware/cors"
+	"github.com/gofiber/fiber/v2/middleware/requestid"
 	"github.com/gofiber/swagger"
+	"github.com/hoshina-dev/custapi/internal/auth"
 	"github.com/hoshina-dev/custapi/internal/handlers"
 	"github.com/hoshina-dev/custapi/internal/middleware"
+	"github.com/hoshina-dev/custapi/internal/ratelimit"
+	"github.com/hoshina-dev/custapi/internal/services"
 )
 
+// RateLimits are the request rate limits of the route groups and the store keeping
+// the buckets of clients
+type RateLimits struct {
+	Store ratelimit.Store
+	// IP limits each IP address across the API, before requests are authenticated
+	IP ratelimit.Limit
+	// API limits each client across the API
+	API ratelimit.Limit
+	// Search limits each client on the search and export endpoints
+	Search ratelimit.Limit
+	// Auth limits each IP address on the auth endpoints
+	Auth ratelimit.Limit
+	// Login limits the login attempts on each account
+	Login ratelimit.Limit
+}
+
 // SetupRoutes configures all API routes
-func SetupRoutes(app *fiber.App, userHandler *handlers.UserHandler, orgHandler *handlers.OrgHandler) {
+func SetupRoutes(app *fiber.App, userHandler *handlers.UserHandler, orgHandler *handlers.OrgHandler,
+	auditHandler *handlers.AuditHandler, webhookHandler *handlers.WebhookHandler, eventHandler *handlers.EventHandler,
+	graphqlHandler *handlers.GraphQLHandler, mediaHandler *handlers.MediaHandler, authHandler *handlers.AuthHandler,
+	apiKeyHandler *handlers.APIKeyHandler, ssoHandler *handlers.SSOHandler, userService services.UserService,
+	authService services.AuthService, apiKeyService services.APIKeyService, rateLimits RateLimits) {
 	// Middleware
 	app.Use(cors.New(cors.Config{
-		AllowOrigins: "*",
+		AllowOrigins:  "*",
+		ExposeHeaders: handlers.HeaderMissingIDs,
 	}))
+	app.Use(requestid.New())
 	app.Use(middleware.Logger())
 	app.Use(middleware.ErrorHandler())
 
@@ -26,28 +52,101 @@ func SetupRoutes(app *fiber.App, userHandler *handlers.UserHandler, orgHandler *
 	app.Get("/scalar", handlers.ScalarHandler)
 	fmt.Println("📖 Scalar docs available at http://localhost:8080/scalar")
 
-	// API v1
-	v1 := app.Group("/api/v1")
+	// IP address limits run before requests are authenticated, so that guessing tokens
+	// is limited too. The auth routes are also limited per IP address against password
+	// and token guessing, and logins per account against guesses spread over many addresses.
+	app.Use("/api/v1", middleware.RateLimit(rateLimits.Store, "ip", rateLimits.IP, middleware.IPKey))
+	app.Use("/api/v1/auth", middleware.RateLimit(rateLimits.Store, "auth", rateLimits.Auth, middleware.IPKey))
+
+	// API v1, rate limited per API key, user or, for anonymous requests, IP address
+	v1 := app.Group("/api/v1", middleware.Authenticate(authService, apiKeyService),
+		middleware.RateLimit(rateLimits.Store, "api", rateLimits.API, middleware.ClientKey))
 	{
-		// Users routes
-		user := v1.Group("/users")
-		user.Get("/", userHandler.GetUsers)
-		user.Get("/search", userHandler.SearchUsers)
-		user.Get("/:id", userHandler.GetUser)
-		user.Get("/organization/:org_id", userHandler.GetUsersByOrganization)
-		user.Post("/", userHandler.CreateUser)
-		user.Patch("/:id", userHandler.UpdateUser)
-		user.Delete("/:id", userHandler.DeleteUser)
+		// Searches and exports are expensive, so they have a tighter limit of their own
+		search := middleware.RateLimit(rateLimits.Store, "search", rateLimits.Search, middleware.ClientKey)
+
+		// API keys are limited to their organization, so routes spanning organizations deny them
+		usersRead := middleware.RequirePermission(auth.PermissionUsersRead)
+		usersWrite := middleware.RequirePermission(auth.PermissionUsersWrite)
+		userInScope := middleware.RequireUserInScope(userService)
+		orgsRead := middleware.RequirePermission(auth.PermissionOrganizationsRead)
+		orgsWrite := middleware.RequirePermission(auth.PermissionOrganizationsWrite)
+		orgInScope := middleware.RequireOrganizationScope("id")
+
+		// Auth routes
+		authGroup := v1.Group("/auth")
+		authGroup.Post("/login", middleware.RateLimit(rateLimits.Store, "login", rateLimits.Login, middleware.LoginEmailKey), authHandler.Login)
+		authGroup.Post("/login/2fa", authHandler.CompleteLogin)
+		authGroup.Post("/logout", authHandler.Logout)
+		authGroup.Post("/password/forgot", authHandler.ForgotPassword)
+		authGroup.Post("/password/reset", authHandler.ResetPassword)
+		authGroup.Post("/verify-email", authHandler.VerifyEmail)
+		authGroup.Post("/verify-email/resend", authHandler.ResendEmailVerification)
+		authGroup.Post("/2fa/setup", authHandler.SetupTwoFactor)
+		authGroup.Post("/2fa/verify", authHandler.VerifyTwoFactor)
+		authGroup.Post("/sso/start", ssoHandler.StartLogin)
+		authGroup.Post("/sso/callback", ssoHandler.CompleteLogin)
+
+		// Users routes. Signing up is the one change anonymous callers may make, so it is
+		// registered ahead of the group requiring a verified email address.
+		v1.Post("/users", middleware.AllowAnonymous(usersWrite), userHandler.CreateUser)
+		user := v1.Group("/users", middleware.RequireVerifiedEmail())
+		user.Get("/", middleware.DenyAPIKeys(), userHandler.GetUsers)
+		user.Get("/search", middleware.DenyAPIKeys(), search, userHandler.SearchUsers)
+		user.Get("/export", middleware.DenyAPIKeys(), search, userHandler.ExportUsers)
+		user.Get("/:id", usersRead, userInScope, userHandler.GetUser)
+		user.Get("/:id/history", usersRead, userInScope, userHandler.GetUserHistory)
+		user.Get("/organization/:org_id", usersRead, middleware.RequireOrganizationScope("org_id"), userHandler.GetUsersByOrganization)
+		user.Post("/batch", middleware.DenyAPIKeys(), userHandler.GetByIDs)
+		user.Post("/bulk", middleware.DenyAPIKeys(), userHandler.BulkUsers)
+		user.Post("/import", middleware.DenyAPIKeys(), userHandler.ImportUsers)
+		user.Patch("/:id", usersWrite, userInScope, userHandler.UpdateUser)
+		user.Put("/:id/avatar", usersWrite, userInScope, mediaHandler.UploadAvatar)
+		user.Delete("/:id", usersWrite, userInScope, userHandler.DeleteUser)
 
 		// Organizations routes
-		org := v1.Group("/organizations")
-		org.Get("/", orgHandler.GetOrganizations)
-		org.Get("/search", orgHandler.SearchOrganizations)
-		org.Get("/coordinates", orgHandler.GetAllCoords)
-		org.Get("/:id", orgHandler.GetOrganization)
-		org.Post("/", orgHandler.CreateOrganization)
-		org.Post("/batch", orgHandler.GetByIDs)
-		org.Patch("/:id", orgHandler.UpdateOrganization)
-		org.Delete("/:id", orgHandler.DeleteOrganization)
+		org := v1.Group("/organizations", middleware.RequireVerifiedEmail())
+		org.Get("/", middleware.DenyAPIKeys(), orgHandler.GetOrganizations)
+		org.Get("/search", middleware.DenyAPIKeys(), search, orgHandler.SearchOrganizations)
+		org.Get("/export", middleware.DenyAPIKeys(), search, orgHandler.ExportOrganizations)
+		org.Get("/coordinates", middleware.DenyAPIKeys(), orgHandler.GetAllCoords)
+		org.Get("/:id", orgsRead, orgInScope, orgHandler.GetOrganization)
+		org.Get("/:id/history", orgsRead, orgInScope, orgHandler.GetOrganizationHistory)
+		org.Post("/", middleware.DenyAPIKeys(), orgHandler.CreateOrganization)
+		org.Post("/batch", middleware.DenyAPIKeys(), orgHandler.GetByIDs)
+		org.Post("/import", middleware.DenyAPIKeys(), orgHandler.ImportOrganizations)
+		org.Post("/:id/images", orgsWrite, orgInScope, mediaHandler.UploadOrganizationImage)
+		org.Patch("/:id", orgsWrite, orgInScope, orgHandler.UpdateOrganization)
+		org.Delete("/:id", orgsWrite, orgInScope, orgHandler.DeleteOrganization)
+		org.Get("/:id/sso", middleware.RequireAdmin(), ssoHandler.GetProvider)
+		org.Put("/:id/sso", middleware.RequireAdmin(), ssoHandler.SaveProvider)
+		org.Delete("/:id/sso", middleware.RequireAdmin(), ssoHandler.DeleteProvider)
+
+		// Audit log routes
+		v1.Get("/audit", middleware.RequireAdmin(), auditHandler.GetAuditEvents)
+
+		// Webhook routes
+		webhook := v1.Group("/webhooks", middleware.RequireAdmin())
+		webhook.Get("/", webhookHandler.GetWebhooks)
+		webhook.Get("/:id/deliveries", webhookHandler.GetWebhookDeliveries)
+		webhook.Post("/", webhookHandler.CreateWebhook)
+		webhook.Post("/:id/deliveries/:delivery_id/retry", webhookHandler.RetryWebhookDelivery)
+		webhook.Delete("/:id", webhookHandler.DeleteWebhook)
+
+		// API key routes
+		apiKey := v1.Group("/api-keys", middleware.RequireAdmin())
+		apiKey.Get("/", apiKeyHandler.GetAPIKeys)
+		apiKey.Post("/", apiKeyHandler.CreateAPIKey)
+		apiKey.Delete("/:id", apiKeyHandler.DeleteAPIKey)
+
+		// Admin routes
+		v1.Get("/admin/broken-media", middleware.RequireAdmin(), mediaHandler.GetBrokenMedia)
+
+		// Live event stream routes
+		v1.Get("/events/stream", middleware.RequireAdmin(), eventHandler.StreamEvents)
+
+		// GraphQL routes
+		v1.Post("/graphql", middleware.DenyAPIKeys(), graphqlHandler.Query)
+		v1.Get("/graphql/schema", graphqlHandler.Schema)
 	}
 }
@@ -0,0 +1,90 @@
+// Package safehttp makes HTTP requests to URLs given by users without letting them
+// reach the internal network of the server
+package safehttp
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"syscall"
+	"time"
+)
+
+// maxRedirects is how many redirects a request follows, like the default of net/http
+const maxRedirects = 10
+
+// blockedPrefixes are the non-public ranges netip has no predicate for
+var blockedPrefixes = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),     // "this" network
+	netip.MustParsePrefix("100.64.0.0/10"), // carrier-grade NAT
+	netip.MustParsePrefix("192.0.0.0/24"),  // IETF protocol assignments
+	netip.MustParsePrefix("198.18.0.0/15"), // benchmarking
+	netip.MustParsePrefix("240.0.0.0/4"),   // reserved, including broadcast
+	netip.MustParsePrefix("64:ff9b::/96"),  // NAT64, which may reach any IPv4 address
+}
+
+// ErrScheme rejects URLs other than http and https ones
+var ErrScheme = errors.New("only http and https URLs are allowed")
+
+// NewClient creates an HTTP client for URLs that come from users. It only connects to
+// public addresses: loopback, private, link-local (including the 169.254.169.254
+// metadata service) and other reserved addresses are refused. Addresses are checked
+// when connecting, after DNS resolution, so names resolving to internal addresses and
+// redirects to them are refused too.
+func NewClient(timeout time.Duration) *http.Client {
+	return NewClientAllowing(timeout, isPublic)
+}
+
+// NewClientAllowing creates a client like NewClient that only connects to the
+// addresses allowed accepts, for tests to reach local servers
+func NewClientAllowing(timeout time.Duration, allowed func(netip.Addr) bool) *http.Client {
+	dialer := &net.Dialer{
+		Timeout: timeout,
+		Control: func(network, address string, _ syscall.RawConn) error {
+			addrPort, err := netip.ParseAddrPort(address)
+			if err != nil {
+				return err
+			}
+			if addr := addrPort.Addr().Unmap(); !allowed(addr) {
+				return fmt.Errorf("connecting to %s is not allowed", addr)
+			}
+			return nil
+		},
+	}
+
+	return &http.Client{
+		Timeout: timeout,
+		Transport: &http.Transport{
+			// A proxy would make the connections the dialer checks, so none is used
+			Proxy:               nil,
+			DialContext:         dialer.DialContext,
+			TLSHandshakeTimeout: timeout,
+			MaxIdleConnsPerHost: 2,
+			IdleConnTimeout:     90 * time.Second,
+		},
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if len(via) >= maxRedirects {
+				return fmt.Errorf("stopped after %d redirects", maxRedirects)
+			}
+			if req.URL.Scheme != "http" && req.URL.Scheme != "https" {
+				return ErrScheme
+			}
+			return nil
+		},
+	}
+}
+
+// isPublic reports whether addr is a public unicast address
+func isPublic(addr netip.Addr) bool {
+	if !addr.IsValid() || !addr.IsGlobalUnicast() || addr.IsPrivate() {
+		return false
+	}
+	for _, prefix := range blockedPrefixes {
+		if prefix.Contains(addr) {
+			return false
+		}
+	}
+	return true
+}
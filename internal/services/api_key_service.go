@@ -0,0 +1,82 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hoshina-dev/custapi/internal/auth"
+	"github.com/hoshina-dev/custapi/internal/models"
+	"github.com/hoshina-dev/custapi/internal/repositories"
+)
+
+// apiKeyLastUsedInterval is how precisely the last use of API keys is tracked
+const apiKeyLastUsedInterval = time.Minute
+
+// APIKeyService manages API keys and authenticates the requests made with them
+type APIKeyService interface {
+	CreateAPIKey(ctx context.Context, req *models.CreateAPIKeyRequest) (*models.APIKey, string, error)
+	ListAPIKeys(ctx context.Context) ([]models.APIKey, error)
+	DeleteAPIKey(ctx context.Context, id uuid.UUID) error
+	Authenticate(ctx context.Context, key string) (*models.APIKey, error)
+}
+
+// apiKeyService is the concrete implementation of APIKeyService
+type apiKeyService struct {
+	apiKeyRepo repositories.APIKeyRepository
+	orgRepo    repositories.OrganizationRepository
+}
+
+// NewAPIKeyService creates a new API key service
+func NewAPIKeyService(apiKeyRepo repositories.APIKeyRepository, orgRepo repositories.OrganizationRepository) APIKeyService {
+	return &apiKeyService{
+		apiKeyRepo: apiKeyRepo,
+		orgRepo:    orgRepo,
+	}
+}
+
+// CreateAPIKey creates an API key for the organization of req, made by the actor of
+// ctx. It returns the key along with the key itself, which is only stored hashed.
+func (s *apiKeyService) CreateAPIKey(ctx context.Context, req *models.CreateAPIKeyRequest) (*models.APIKey, string, error) {
+	org, err := s.orgRepo.FindByID(ctx, req.OrganizationID)
+	if err != nil {
+		return nil, "", err
+	}
+	if org == nil {
+		return nil, "", errors.New("organization not found")
+	}
+
+	key, prefix, hash, err := auth.NewAPIKey()
+	if err != nil {
+		return nil, "", err
+	}
+	apiKey := req.ToDomain(prefix, hash, auth.FromContext(ctx).UserID)
+	if err := s.apiKeyRepo.Create(ctx, apiKey); err != nil {
+		return nil, "", err
+	}
+	return apiKey, key, nil
+}
+
+// ListAPIKeys retrieves all API keys
+func (s *apiKeyService) ListAPIKeys(ctx context.Context) ([]models.APIKey, error) {
+	return s.apiKeyRepo.FindAll(ctx)
+}
+
+// DeleteAPIKey revokes an API key
+func (s *apiKeyService) DeleteAPIKey(ctx context.Context, id uuid.UUID) error {
+	return s.apiKeyRepo.Delete(ctx, id)
+}
+
+// Authenticate returns the active API key matching key and records that it was used,
+// or nil if no active key matches
+func (s *apiKeyService) Authenticate(ctx context.Context, key string) (*models.APIKey, error) {
+	apiKey, err := s.apiKeyRepo.FindActiveByKeyHash(ctx, auth.HashToken(key))
+	if err != nil || apiKey == nil {
+		return nil, err
+	}
+	if err := s.apiKeyRepo.TouchLastUsed(ctx, apiKey.ID, time.Now(), apiKeyLastUsedInterval); err != nil {
+		return nil, err
+	}
+	return apiKey, nil
+}
@@ -0,0 +1,143 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+
+	"github.com/google/uuid"
+	"github.com/hoshina-dev/custapi/internal/auth"
+	"github.com/hoshina-dev/custapi/internal/models"
+	"github.com/hoshina-dev/custapi/internal/repositories"
+)
+
+// Audit actions and entity types
+const (
+	AuditActionCreate = "create"
+	AuditActionUpdate = "update"
+	AuditActionDelete = "delete"
+
+	EntityUser         = "user"
+	EntityOrganization = "organization"
+)
+
+// redacted replaces secret values in audit diffs
+const redacted = "[REDACTED]"
+
+// AuditService records and lists audit events
+type AuditService interface {
+	// Record stores an audit event for a mutation of an entity and publishes the
+	// matching domain event. before is nil for creates and after is nil for deletes.
+	// It should be called with the context of the transaction making the change so
+	// the events are committed together with it.
+	Record(ctx context.Context, action, entityType string, entityID uuid.UUID, before, after map[string]any) error
+	ListEvents(ctx context.Context, filter models.AuditFilter) ([]models.AuditEvent, error)
+}
+
+// auditService is the concrete implementation of AuditService
+type auditService struct {
+	auditRepo repositories.AuditRepository
+	publisher EventPublisher
+}
+
+// NewAuditService creates a new audit service that publishes domain events to publisher
+func NewAuditService(auditRepo repositories.AuditRepository, publisher EventPublisher) AuditService {
+	return &auditService{
+		auditRepo: auditRepo,
+		publisher: publisher,
+	}
+}
+
+// Record stores an audit event with the user or API key, request ID and IP of the
+// actor taken from ctx
+func (s *auditService) Record(ctx context.Context, action, entityType string, entityID uuid.UUID, before, after map[string]any) error {
+	actor := auth.FromContext(ctx)
+	event := &models.AuditEvent{
+		ActorID:    actor.UserID,
+		APIKeyID:   actor.APIKeyID,
+		Action:     action,
+		EntityType: entityType,
+		EntityID:   entityID,
+		Changes:    diffSnapshots(before, after),
+	}
+	if actor.RequestID != "" {
+		event.RequestID = &actor.RequestID
+	}
+	if actor.IP != "" {
+		event.IP = &actor.IP
+	}
+	if err := s.auditRepo.Create(ctx, event); err != nil {
+		return err
+	}
+	return s.publisher.Publish(ctx, newDomainEvent(action, entityType, entityID, before, after))
+}
+
+// ListEvents retrieves audit events matching filter
+func (s *auditService) ListEvents(ctx context.Context, filter models.AuditFilter) ([]models.AuditEvent, error) {
+	return s.auditRepo.Find(ctx, filter)
+}
+
+// userSnapshot captures the audited fields of a user. The password hash is included
+// only so that changes to it show up in the diff; its value is always redacted.
+func userSnapshot(user *models.User) map[string]any {
+	snapshot := toMap(user.ToResponse())
+	if user.Password != "" {
+		snapshot["password"] = user.Password
+	}
+	return snapshot
+}
+
+// orgSnapshot captures the audited fields of an organization
+func orgSnapshot(org *models.Organization) map[string]any {
+	return toMap(org.ToResponse())
+}
+
+// toMap converts a response DTO to a generic JSON object
+func toMap(v any) map[string]any {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return map[string]any{}
+	}
+	m := make(map[string]any)
+	_ = json.Unmarshal(b, &m)
+	return m
+}
+
+// diffSnapshots returns {"field": {"before": ..., "after": ...}} for every field that changed
+func diffSnapshots(before, after map[string]any) models.JSONMap {
+	changes := models.JSONMap{}
+	for _, key := range unionKeys(before, after) {
+		if key == "updated_at" {
+			continue
+		}
+		oldValue, newValue := before[key], after[key]
+		if reflect.DeepEqual(oldValue, newValue) {
+			continue
+		}
+		if key == "password" {
+			oldValue, newValue = redactValue(oldValue), redactValue(newValue)
+		}
+		changes[key] = map[string]any{"before": oldValue, "after": newValue}
+	}
+	return changes
+}
+
+func unionKeys(a, b map[string]any) []string {
+	keys := make([]string, 0, len(a)+len(b))
+	for k := range a {
+		keys = append(keys, k)
+	}
+	for k := range b {
+		if _, ok := a[k]; !ok {
+			keys = append(keys, k)
+		}
+	}
+	return keys
+}
+
+func redactValue(v any) any {
+	if v == nil {
+		return nil
+	}
+	return redacted
+}
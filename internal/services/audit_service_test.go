@@ -0,0 +1,57 @@
+package services
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/hoshina-dev/custapi/internal/auth"
+	"github.com/hoshina-dev/custapi/internal/models"
+	"github.com/hoshina-dev/custapi/internal/repositories"
+)
+
+// fakeAuditRepository keeps the events it is asked to create
+type fakeAuditRepository struct {
+	repositories.AuditRepository
+	events []*models.AuditEvent
+}
+
+func (r *fakeAuditRepository) Create(ctx context.Context, event *models.AuditEvent) error {
+	r.events = append(r.events, event)
+	return nil
+}
+
+// discardPublisher drops the events it is asked to publish
+type discardPublisher struct{}
+
+func (discardPublisher) Publish(ctx context.Context, events ...models.DomainEvent) error {
+	return nil
+}
+
+func TestAuditServiceRecord(t *testing.T) {
+	userID, keyID := uuid.New(), uuid.New()
+	tests := []struct {
+		name  string
+		actor *auth.Actor
+	}{
+		{"user", &auth.Actor{UserID: &userID, RequestID: "req-1", IP: "203.0.113.7"}},
+		{"API key", &auth.Actor{APIKeyID: &keyID, RequestID: "req-2", IP: "203.0.113.8"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &fakeAuditRepository{}
+			ctx := auth.NewContext(context.Background(), tt.actor)
+			if err := NewAuditService(repo, discardPublisher{}).Record(ctx, "update", "user", uuid.New(), nil, nil); err != nil {
+				t.Fatalf("Record() error = %v", err)
+			}
+
+			event := repo.events[0]
+			if event.ActorID != tt.actor.UserID || event.APIKeyID != tt.actor.APIKeyID {
+				t.Errorf("event actor = %v, API key %v, want %v, %v", event.ActorID, event.APIKeyID, tt.actor.UserID, tt.actor.APIKeyID)
+			}
+			if event.RequestID == nil || *event.RequestID != tt.actor.RequestID || event.IP == nil || *event.IP != tt.actor.IP {
+				t.Errorf("event request = %v from %v, want %s from %s", event.RequestID, event.IP, tt.actor.RequestID, tt.actor.IP)
+			}
+		})
+	}
+}
@@ -0,0 +1,622 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hoshina-dev/custapi/internal/auth"
+	"github.com/hoshina-dev/custapi/internal/mail"
+	"github.com/hoshina-dev/custapi/internal/models"
+	"github.com/hoshina-dev/custapi/internal/password"
+	"github.com/hoshina-dev/custapi/internal/repositories"
+)
+
+var (
+	// ErrInvalidCredentials is returned for logins with an unknown email or a wrong password
+	ErrInvalidCredentials = errors.New("invalid email or password")
+	// ErrInvalidResetToken is returned for password reset tokens that are unknown, used or expired
+	ErrInvalidResetToken = errors.New("invalid or expired password reset token")
+	// ErrInvalidVerificationToken is returned for email verification tokens that are
+	// unknown, used, expired or for an address the user no longer uses
+	ErrInvalidVerificationToken = errors.New("invalid or expired email verification token")
+	// ErrEmailAlreadyVerified is returned when asking to verify an email address that already is
+	ErrEmailAlreadyVerified = errors.New("email address is already verified")
+	// ErrEmailTaken is returned when confirming a change to an address another user has
+	ErrEmailTaken = errors.New("email address is already in use")
+	// ErrInvalidLoginChallenge is returned for login challenges that are unknown, expired
+	// or out of attempts
+	ErrInvalidLoginChallenge = errors.New("invalid or expired login challenge")
+	// ErrInvalidTwoFactorCode is returned for wrong or already used TOTP and recovery codes
+	ErrInvalidTwoFactorCode = errors.New("invalid two-factor authentication code")
+	// ErrTwoFactorAlreadyEnabled is returned when setting up two-factor authentication again
+	ErrTwoFactorAlreadyEnabled = errors.New("two-factor authentication is already enabled")
+	// ErrTwoFactorNotSetUp is returned when enabling two-factor authentication before setting it up
+	ErrTwoFactorNotSetUp = errors.New("two-factor authentication is not set up")
+)
+
+const (
+	// recoveryCodeCount is how many recovery codes a user gets when enabling two-factor authentication
+	recoveryCodeCount = 10
+	// maxLoginChallengeAttempts is how many codes can be tried against a login challenge
+	maxLoginChallengeAttempts = 5
+)
+
+// TwoFactorRequiredError is returned by Login for users with two-factor authentication.
+// The login is completed by passing the challenge token and a code to CompleteLogin.
+type TwoFactorRequiredError struct {
+	ChallengeToken string
+	ExpiresAt      time.Time
+}
+
+func (e *TwoFactorRequiredError) Error() string {
+	return "two-factor authentication required"
+}
+
+// AuthConfig holds session, password reset and email verification settings
+type AuthConfig struct {
+	SessionTTL       time.Duration
+	PasswordResetTTL time.Duration
+	// PasswordResetURL is the page where users set a new password; reset links add
+	// the token to it as the token query parameter
+	PasswordResetURL     string
+	EmailVerificationTTL time.Duration
+	// EmailVerificationURL is the page that confirms email addresses, with the token
+	// added the same way
+	EmailVerificationURL string
+	// TOTPIssuer names the service in authenticator apps
+	TOTPIssuer        string
+	LoginChallengeTTL time.Duration
+}
+
+// AuthService logs users in and out, with a second factor if they enabled one, lets
+// them reset a forgotten password and verifies their email addresses
+type AuthService interface {
+	Login(ctx context.Context, email, password, userAgent string) (*models.Session, string, error)
+	CompleteLogin(ctx context.Context, challengeToken, code, userAgent string) (*models.Session, string, error)
+	LoginUser(ctx context.Context, user *models.User, userAgent string) (*models.Session, string, error)
+	Logout(ctx context.Context, token string) error
+	Authenticate(ctx context.Context, token string) (*models.Session, error)
+	ForgotPassword(ctx context.Context, email string) error
+	ResetPassword(ctx context.Context, token, newPassword string) error
+	SendEmailVerification(ctx context.Context, user *models.User, email string) error
+	ResendEmailVerification(ctx context.Context, userID uuid.UUID) error
+	VerifyEmail(ctx context.Context, token string) (*models.User, error)
+	SetupTwoFactor(ctx context.Context, userID uuid.UUID) (string, string, error)
+	EnableTwoFactor(ctx context.Context, userID uuid.UUID, code, sessionToken string) ([]string, error)
+}
+
+// authService is the concrete implementation of AuthService
+type authService struct {
+	txManager        repositories.TxManager
+	userRepo         repositories.UserRepository
+	historyRepo      repositories.UserHistoryRepository
+	sessionRepo      repositories.SessionRepository
+	resetRepo        repositories.PasswordResetRepository
+	verificationRepo repositories.EmailVerificationRepository
+	recoveryCodeRepo repositories.RecoveryCodeRepository
+	challengeRepo    repositories.LoginChallengeRepository
+	auditService     AuditService
+	passwordPolicy   *password.Policy
+	passwordHasher   *password.Hasher
+	mailer           mail.Mailer
+	cfg              AuthConfig
+	// dummyHash is verified on logins with an unknown email, so that they take as
+	// long as logins with a wrong password and do not reveal which emails exist
+	dummyHash string
+}
+
+// NewAuthService creates a new auth service
+func NewAuthService(txManager repositories.TxManager, userRepo repositories.UserRepository,
+	historyRepo repositories.UserHistoryRepository, sessionRepo repositories.SessionRepository,
+	resetRepo repositories.PasswordResetRepository, verificationRepo repositories.EmailVerificationRepository,
+	recoveryCodeRepo repositories.RecoveryCodeRepository, challengeRepo repositories.LoginChallengeRepository,
+	auditService AuditService, passwordPolicy *password.Policy, passwordHasher *password.Hasher, mailer mail.Mailer,
+	cfg AuthConfig) AuthService {
+	dummyHash, _ := passwordHasher.Hash("dummy password")
+	return &authService{
+		txManager:        txManager,
+		userRepo:         userRepo,
+		historyRepo:      historyRepo,
+		sessionRepo:      sessionRepo,
+		resetRepo:        resetRepo,
+		verificationRepo: verificationRepo,
+		recoveryCodeRepo: recoveryCodeRepo,
+		challengeRepo:    challengeRepo,
+		auditService:     auditService,
+		passwordPolicy:   passwordPolicy,
+		passwordHasher:   passwordHasher,
+		mailer:           mailer,
+		cfg:              cfg,
+		dummyHash:        dummyHash,
+	}
+}
+
+// Login checks a user's credentials and starts a session. It returns the session,
+// with its user, and the bearer token authenticating it, which is only known to the caller.
+// A password hashed with outdated parameters is hashed again with the current ones.
+// For users with two-factor authentication, it returns a *TwoFactorRequiredError instead.
+// Users created by single sign-on have no password until they reset it.
+func (s *authService) Login(ctx context.Context, email, password, userAgent string) (*models.Session, string, error) {
+	user, err := s.userRepo.FindByEmail(ctx, email)
+	if err != nil {
+		return nil, "", err
+	}
+	if user == nil || user.Password == "" {
+		_, _, _ = s.passwordHasher.Verify(password, s.dummyHash)
+		return nil, "", ErrInvalidCredentials
+	}
+	ok, rehash, err := s.passwordHasher.Verify(password, user.Password)
+	if err != nil {
+		return nil, "", err
+	}
+	if !ok {
+		return nil, "", ErrInvalidCredentials
+	}
+	if rehash {
+		s.rehash(ctx, user, password)
+	}
+	return s.LoginUser(ctx, user, userAgent)
+}
+
+// LoginUser starts a session for a user who proved who they are, with their password
+// or through single sign-on. Like Login, it returns a *TwoFactorRequiredError for
+// users with two-factor authentication.
+func (s *authService) LoginUser(ctx context.Context, user *models.User, userAgent string) (*models.Session, string, error) {
+	if user.TwoFactorEnabled() {
+		return nil, "", s.challenge(ctx, user)
+	}
+	return s.startSession(ctx, user, userAgent, false)
+}
+
+// challenge creates a login challenge for the second step of a login and returns the
+// *TwoFactorRequiredError carrying it
+func (s *authService) challenge(ctx context.Context, user *models.User) error {
+	if _, err := s.challengeRepo.DeleteExpired(ctx); err != nil {
+		return err
+	}
+
+	token, hash, err := auth.NewToken()
+	if err != nil {
+		return err
+	}
+	challenge := &models.LoginChallenge{
+		UserID:    user.ID,
+		TokenHash: hash,
+		ExpiresAt: time.Now().Add(s.cfg.LoginChallengeTTL),
+	}
+	if err := s.challengeRepo.Create(ctx, challenge); err != nil {
+		return err
+	}
+	return &TwoFactorRequiredError{ChallengeToken: token, ExpiresAt: challenge.ExpiresAt}
+}
+
+// CompleteLogin starts the session of a login challenged for a second factor, given
+// a TOTP code or an unused recovery code
+func (s *authService) CompleteLogin(ctx context.Context, challengeToken, code, userAgent string) (*models.Session, string, error) {
+	challenge, err := s.challengeRepo.ClaimAttempt(ctx, auth.HashToken(challengeToken), maxLoginChallengeAttempts)
+	if err != nil {
+		return nil, "", err
+	}
+	if challenge == nil {
+		return nil, "", ErrInvalidLoginChallenge
+	}
+	user, err := s.userRepo.FindByID(ctx, challenge.UserID)
+	if err != nil {
+		return nil, "", err
+	}
+	if user == nil || !user.TwoFactorEnabled() {
+		return nil, "", ErrInvalidLoginChallenge
+	}
+
+	ok, err := s.checkSecondFactor(ctx, user, code)
+	if err != nil {
+		return nil, "", err
+	}
+	if !ok {
+		return nil, "", ErrInvalidTwoFactorCode
+	}
+	if consumed, err := s.challengeRepo.Consume(ctx, challenge.ID); err != nil || !consumed {
+		if err == nil {
+			err = ErrInvalidLoginChallenge
+		}
+		return nil, "", err
+	}
+	return s.startSession(ctx, user, userAgent, true)
+}
+
+// checkSecondFactor reports whether code is a current TOTP code of the user that was
+// not used yet or one of their unused recovery codes, which it uses up
+func (s *authService) checkSecondFactor(ctx context.Context, user *models.User, code string) (bool, error) {
+	code = strings.TrimSpace(code)
+	if step, ok := auth.ValidateTOTP(*user.TOTPSecret, code, time.Now()); ok {
+		return s.userRepo.UseTOTPStep(ctx, user.ID, step)
+	}
+
+	used, err := s.recoveryCodeRepo.Consume(ctx, user.ID, auth.HashRecoveryCode(code))
+	if used {
+		log.Printf("User %s logged in with a recovery code", user.ID)
+	}
+	return used, err
+}
+
+// startSession creates a session for a user who logged in and returns it along with
+// its bearer token. twoFactorVerified tells whether the user completed a second factor.
+func (s *authService) startSession(ctx context.Context, user *models.User, userAgent string, twoFactorVerified bool) (*models.Session, string, error) {
+	token, hash, err := auth.NewToken()
+	if err != nil {
+		return nil, "", err
+	}
+	session := &models.Session{
+		UserID:    user.ID,
+		TokenHash: hash,
+		ExpiresAt: time.Now().Add(s.cfg.SessionTTL),
+	}
+	if twoFactorVerified {
+		now := time.Now()
+		session.TwoFactorVerifiedAt = &now
+	}
+	if ip := auth.FromContext(ctx).IP; ip != "" {
+		session.IP = &ip
+	}
+	if userAgent != "" {
+		session.UserAgent = &userAgent
+	}
+	if err := s.sessionRepo.Create(ctx, session); err != nil {
+		return nil, "", err
+	}
+	session.User = *user
+	return session, token, nil
+}
+
+// rehash replaces the password hash of a user by one made with the current algorithm
+// and parameters. The login goes on with the old hash if it fails.
+func (s *authService) rehash(ctx context.Context, user *models.User, password string) {
+	hash, err := s.passwordHasher.Hash(password)
+	if err == nil {
+		err = s.userRepo.Update(ctx, &models.User{ID: user.ID, Password: hash})
+	}
+	if err != nil {
+		log.Printf("Failed to rehash the password of user %s: %v", user.ID, err)
+		return
+	}
+	user.Password = hash
+}
+
+// Logout ends the session authenticated by token. Unknown tokens are ignored.
+func (s *authService) Logout(ctx context.Context, token string) error {
+	session, err := s.sessionRepo.FindActiveByTokenHash(ctx, auth.HashToken(token))
+	if err != nil || session == nil {
+		return err
+	}
+	return s.sessionRepo.Revoke(ctx, session.ID)
+}
+
+// Authenticate returns the active session authenticated by token, with its user, or
+// nil if the token does not belong to one
+func (s *authService) Authenticate(ctx context.Context, token string) (*models.Session, error) {
+	session, err := s.sessionRepo.FindActiveByTokenHash(ctx, auth.HashToken(token))
+	if err != nil || session == nil {
+		return nil, err
+	}
+	user, err := s.userRepo.FindByID(ctx, session.UserID)
+	if err != nil || user == nil {
+		return nil, err
+	}
+	session.User = *user
+	return session, nil
+}
+
+// ForgotPassword emails a password reset link to the user with the given email,
+// replacing any link sent before. Unknown emails are accepted without sending
+// anything, so that the endpoint does not reveal who has an account.
+func (s *authService) ForgotPassword(ctx context.Context, email string) error {
+	user, err := s.userRepo.FindByEmail(ctx, email)
+	if err != nil || user == nil {
+		return err
+	}
+
+	token, hash, err := auth.NewToken()
+	if err != nil {
+		return err
+	}
+	reset := &models.PasswordResetToken{
+		UserID:    user.ID,
+		TokenHash: hash,
+		ExpiresAt: time.Now().Add(s.cfg.PasswordResetTTL),
+	}
+	err = s.txManager.WithinTransaction(ctx, func(ctx context.Context) error {
+		if err := s.resetRepo.DeleteUnusedForUser(ctx, user.ID); err != nil {
+			return err
+		}
+		return s.resetRepo.Create(ctx, reset)
+	})
+	if err != nil {
+		return err
+	}
+
+	link, err := tokenLink(s.cfg.PasswordResetURL, token)
+	if err != nil {
+		return err
+	}
+
+	// A failure is only logged: reporting it would tell the caller the email exists
+	err = s.mailer.Send(ctx, mail.Message{
+		To:      user.Email,
+		Subject: "Reset your password",
+		Body: fmt.Sprintf("Hello %s,\n\nSomeone asked to reset the password of your account. "+
+			"To choose a new password, open the link below within %s:\n\n%s\n\n"+
+			"If you did not ask for this, you can ignore this email.\n", user.Name, s.cfg.PasswordResetTTL, link),
+	})
+	if err != nil {
+		log.Printf("Failed to send password reset email to user %s: %v", user.ID, err)
+	}
+	return nil
+}
+
+// ResetPassword sets a new password with a password reset token. The token can only
+// be used once, and every session of the user is revoked.
+func (s *authService) ResetPassword(ctx context.Context, token, newPassword string) error {
+	return s.txManager.WithinTransaction(ctx, func(ctx context.Context) error {
+		// Consuming the token first keeps concurrent resets with the same token from both succeeding
+		reset, err := s.resetRepo.Consume(ctx, auth.HashToken(token))
+		if err != nil {
+			return err
+		}
+		if reset == nil {
+			return ErrInvalidResetToken
+		}
+		user, err := s.userRepo.FindByID(ctx, reset.UserID)
+		if err != nil {
+			return err
+		}
+		if user == nil {
+			return ErrInvalidResetToken
+		}
+
+		if err := s.passwordPolicy.Check(newPassword, user.Email, user.Name); err != nil {
+			return err
+		}
+		hashedPassword, err := s.passwordHasher.Hash(newPassword)
+		if err != nil {
+			return err
+		}
+
+		updatedUser := &models.User{ID: user.ID, Password: hashedPassword}
+		if err := s.userRepo.Update(ctx, updatedUser); err != nil {
+			return err
+		}
+		if err := s.resetRepo.DeleteUnusedForUser(ctx, user.ID); err != nil {
+			return err
+		}
+		if _, err := s.sessionRepo.RevokeAllForUser(ctx, user.ID); err != nil {
+			return err
+		}
+		return s.auditService.Record(ctx, AuditActionUpdate, EntityUser, user.ID, userSnapshot(user), userSnapshot(updatedUser))
+	})
+}
+
+// SendEmailVerification emails a link confirming that the user controls email, which
+// is either their current address or the one they are changing to. Links sent before
+// stop working.
+func (s *authService) SendEmailVerification(ctx context.Context, user *models.User, email string) error {
+	token, hash, err := auth.NewToken()
+	if err != nil {
+		return err
+	}
+	verification := &models.EmailVerificationToken{
+		UserID:    user.ID,
+		Email:     email,
+		TokenHash: hash,
+		ExpiresAt: time.Now().Add(s.cfg.EmailVerificationTTL),
+	}
+	err = s.txManager.WithinTransaction(ctx, func(ctx context.Context) error {
+		if err := s.verificationRepo.DeleteUnusedForUser(ctx, user.ID); err != nil {
+			return err
+		}
+		return s.verificationRepo.Create(ctx, verification)
+	})
+	if err != nil {
+		return err
+	}
+
+	link, err := tokenLink(s.cfg.EmailVerificationURL, token)
+	if err != nil {
+		return err
+	}
+	return s.mailer.Send(ctx, mail.Message{
+		To:      email,
+		Subject: "Verify your email address",
+		Body: fmt.Sprintf("Hello %s,\n\nPlease confirm that %s is your email address by opening the link below within %s:\n\n%s\n\n"+
+			"If you did not expect this email, you can ignore it.\n", user.Name, email, s.cfg.EmailVerificationTTL, link),
+	})
+}
+
+// ResendEmailVerification sends a new verification link for the pending email change
+// of a user or, if there is none, for their unverified current address
+func (s *authService) ResendEmailVerification(ctx context.Context, userID uuid.UUID) error {
+	user, err := s.userRepo.FindByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if user == nil {
+		return errors.New("user not found")
+	}
+
+	switch {
+	case user.PendingEmail != nil:
+		return s.SendEmailVerification(ctx, user, *user.PendingEmail)
+	case user.EmailVerifiedAt == nil:
+		return s.SendEmailVerification(ctx, user, user.Email)
+	}
+	return ErrEmailAlreadyVerified
+}
+
+// VerifyEmail confirms the address an email verification token was sent to. For a
+// pending email change, this is when the user's email actually changes.
+func (s *authService) VerifyEmail(ctx context.Context, token string) (*models.User, error) {
+	var verifiedUser *models.User
+	err := s.txManager.WithinTransaction(ctx, func(ctx context.Context) error {
+		verification, err := s.verificationRepo.Consume(ctx, auth.HashToken(token))
+		if err != nil {
+			return err
+		}
+		if verification == nil {
+			return ErrInvalidVerificationToken
+		}
+		user, err := s.userRepo.FindByID(ctx, verification.UserID)
+		if err != nil {
+			return err
+		}
+		if user == nil {
+			return ErrInvalidVerificationToken
+		}
+
+		switch {
+		case strings.EqualFold(user.Email, verification.Email):
+		case user.PendingEmail != nil && strings.EqualFold(*user.PendingEmail, verification.Email):
+			existing, err := s.userRepo.FindByEmail(ctx, verification.Email)
+			if err != nil {
+				return err
+			}
+			if existing != nil && existing.ID != user.ID {
+				return ErrEmailTaken
+			}
+		default:
+			// The user has changed their address again since the token was sent
+			return ErrInvalidVerificationToken
+		}
+
+		if err := s.userRepo.ConfirmEmail(ctx, user.ID, verification.Email); err != nil {
+			return err
+		}
+		if err := s.verificationRepo.DeleteUnusedForUser(ctx, user.ID); err != nil {
+			return err
+		}
+		if verifiedUser, err = s.userRepo.FindByID(ctx, user.ID); err != nil {
+			return err
+		}
+		if err := archiveUser(ctx, s.historyRepo, user, verifiedUser, AuditActionUpdate); err != nil {
+			return err
+		}
+		return s.auditService.Record(ctx, AuditActionUpdate, EntityUser, user.ID, userSnapshot(user), userSnapshot(verifiedUser))
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return verifiedUser, nil
+}
+
+// SetupTwoFactor creates a new TOTP secret for a user and returns it along with the
+// otpauth URI enrolling it in an authenticator app. Logins only need codes once the
+// user proves the app works with EnableTwoFactor.
+func (s *authService) SetupTwoFactor(ctx context.Context, userID uuid.UUID) (string, string, error) {
+	user, err := s.userRepo.FindByID(ctx, userID)
+	if err != nil {
+		return "", "", err
+	}
+	if user == nil {
+		return "", "", errors.New("user not found")
+	}
+	if user.TwoFactorEnabled() {
+		return "", "", ErrTwoFactorAlreadyEnabled
+	}
+
+	secret, err := auth.NewTOTPSecret()
+	if err != nil {
+		return "", "", err
+	}
+	if err := s.userRepo.SetTOTPSecret(ctx, user.ID, secret); err != nil {
+		return "", "", err
+	}
+	return secret, auth.TOTPURI(s.cfg.TOTPIssuer, user.Email, secret), nil
+}
+
+// EnableTwoFactor checks a first TOTP code of the secret created by SetupTwoFactor
+// and makes logins of the user need codes from then on. It returns the user's
+// recovery codes, which are only stored hashed. Sessions started without a second
+// factor are ended, except the one authenticated by sessionToken, if any, which
+// counts as verified since the user just entered a code in it.
+func (s *authService) EnableTwoFactor(ctx context.Context, userID uuid.UUID, code, sessionToken string) ([]string, error) {
+	codes := make([]string, recoveryCodeCount)
+	hashes := make([]string, recoveryCodeCount)
+	for i := range codes {
+		var err error
+		if codes[i], hashes[i], err = auth.NewRecoveryCode(); err != nil {
+			return nil, err
+		}
+	}
+
+	err := s.txManager.WithinTransaction(ctx, func(ctx context.Context) error {
+		user, err := s.userRepo.FindByID(ctx, userID)
+		if err != nil {
+			return err
+		}
+		if user == nil {
+			return errors.New("user not found")
+		}
+		if user.TwoFactorEnabled() {
+			return ErrTwoFactorAlreadyEnabled
+		}
+		if user.TOTPSecret == nil {
+			return ErrTwoFactorNotSetUp
+		}
+
+		step, ok := auth.ValidateTOTP(*user.TOTPSecret, strings.TrimSpace(code), time.Now())
+		if !ok {
+			return ErrInvalidTwoFactorCode
+		}
+		if fresh, err := s.userRepo.UseTOTPStep(ctx, user.ID, step); err != nil || !fresh {
+			if err == nil {
+				err = ErrInvalidTwoFactorCode
+			}
+			return err
+		}
+		if err := s.userRepo.EnableTOTP(ctx, user.ID); err != nil {
+			return err
+		}
+		if err := s.recoveryCodeRepo.ReplaceForUser(ctx, user.ID, hashes); err != nil {
+			return err
+		}
+		if sessionToken != "" {
+			if err := s.sessionRepo.MarkTwoFactorVerified(ctx, auth.HashToken(sessionToken)); err != nil {
+				return err
+			}
+			_, err = s.sessionRepo.RevokeOthersForUser(ctx, user.ID, auth.HashToken(sessionToken))
+		} else {
+			_, err = s.sessionRepo.RevokeAllForUser(ctx, user.ID)
+		}
+		if err != nil {
+			return err
+		}
+
+		updatedUser, err := s.userRepo.FindByID(ctx, user.ID)
+		if err != nil {
+			return err
+		}
+		return s.auditService.Record(ctx, AuditActionUpdate, EntityUser, user.ID, userSnapshot(user), userSnapshot(updatedUser))
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return codes, nil
+}
+
+// tokenLink adds token to a frontend page URL as its token query parameter
+func tokenLink(page, token string) (*url.URL, error) {
+	link, err := url.Parse(page)
+	if err != nil {
+		return nil, err
+	}
+	query := link.Query()
+	query.Set("token", token)
+	link.RawQuery = query.Encode()
+	return link, nil
+}
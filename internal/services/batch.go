@@ -0,0 +1,28 @@
+package services
+
+import "github.com/google/uuid"
+
+// inRequestOrder orders the items found for a batch lookup like the requested ids,
+// listing each item once, and returns the ids that matched no item
+func inRequestOrder[T any](ids []uuid.UUID, items []T, idOf func(*T) uuid.UUID) ([]T, []uuid.UUID) {
+	byID := make(map[uuid.UUID]*T, len(items))
+	for i := range items {
+		byID[idOf(&items[i])] = &items[i]
+	}
+
+	ordered := make([]T, 0, len(items))
+	missing := []uuid.UUID{}
+	seen := make(map[uuid.UUID]bool, len(ids))
+	for _, id := range ids {
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+		if item, ok := byID[id]; ok {
+			ordered = append(ordered, *item)
+		} else {
+			missing = append(missing, id)
+		}
+	}
+	return ordered, missing
+}
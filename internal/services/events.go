@@ -0,0 +1,109 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hoshina-dev/custapi/internal/models"
+	"github.com/hoshina-dev/custapi/internal/repositories"
+)
+
+// EventPublisher publishes domain events. Publish is called with the context of the
+// transaction making the change, so implementations that write to the database
+// commit or roll back together with it.
+type EventPublisher interface {
+	Publish(ctx context.Context, events ...models.DomainEvent) error
+}
+
+// eventSuffixes maps audit actions to the suffix of the published event type
+var eventSuffixes = map[string]string{
+	AuditActionCreate: "created",
+	AuditActionUpdate: "updated",
+	AuditActionDelete: "deleted",
+}
+
+// eventEntities are the entity types events are published for
+var eventEntities = []string{EntityUser, EntityOrganization}
+
+// ErrUnknownEventFilter rejects webhook event filters no published event can match
+var ErrUnknownEventFilter = errors.New("unknown event type")
+
+// checkEventFilters makes sure every webhook event filter can match published events
+func checkEventFilters(filters []string) error {
+	for _, filter := range filters {
+		if !knownEventFilter(filter) {
+			return fmt.Errorf("%w %q", ErrUnknownEventFilter, filter)
+		}
+	}
+	return nil
+}
+
+// knownEventFilter reports whether filter is "*", an entity prefix like "user.*" or the
+// type of a published event like "user.created"
+func knownEventFilter(filter string) bool {
+	if filter == "*" {
+		return true
+	}
+	entity, suffix, ok := strings.Cut(filter, ".")
+	if !ok || !slices.Contains(eventEntities, entity) {
+		return false
+	}
+	if suffix == "*" {
+		return true
+	}
+	for _, known := range eventSuffixes {
+		if suffix == known {
+			return true
+		}
+	}
+	return false
+}
+
+// newDomainEvent builds the event for a mutation of an entity, such as "user.created".
+// The data is the state after the change, or before it for deletes, without secrets.
+func newDomainEvent(action, entityType string, entityID uuid.UUID, before, after map[string]any) models.DomainEvent {
+	state := after
+	if state == nil {
+		state = before
+	}
+	data := make(map[string]any, len(state))
+	for k, v := range state {
+		if k != "password" {
+			data[k] = v
+		}
+	}
+
+	return models.DomainEvent{
+		ID:            uuid.New(),
+		Type:          entityType + "." + eventSuffixes[action],
+		AggregateType: entityType,
+		AggregateID:   entityID,
+		OccurredAt:    time.Now(),
+		Data:          data,
+	}
+}
+
+// outboxPublisher is the EventPublisher writing events to the transactional outbox
+type outboxPublisher struct {
+	outboxRepo repositories.OutboxRepository
+}
+
+// NewOutboxPublisher creates a publisher that stores events in the outbox, from
+// where the relay publishes them once the surrounding transaction has committed
+func NewOutboxPublisher(outboxRepo repositories.OutboxRepository) EventPublisher {
+	return &outboxPublisher{outboxRepo: outboxRepo}
+}
+
+// Publish stores events in the outbox within the transaction carried by ctx
+func (p *outboxPublisher) Publish(ctx context.Context, events ...models.DomainEvent) error {
+	rows := make([]*models.OutboxEvent, len(events))
+	for i := range events {
+		rows[i] = models.NewOutboxEvent(&events[i])
+	}
+	return p.outboxRepo.Create(ctx, rows)
+}
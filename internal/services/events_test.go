@@ -0,0 +1,31 @@
+package services
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestCheckEventFilters(t *testing.T) {
+	tests := []struct {
+		name    string
+		filters []string
+		wantErr bool
+	}{
+		{"exact types", []string{"user.created", "organization.deleted"}, false},
+		{"entity prefixes", []string{"user.*", "organization.*"}, false},
+		{"everything", []string{"*"}, false},
+		{"unknown entity", []string{"user.created", "invoice.created"}, true},
+		{"unknown action", []string{"user.archived"}, true},
+		{"audit action instead of event", []string{"user.create"}, true},
+		{"entity without action", []string{"user"}, true},
+		{"other wildcard", []string{"*.created"}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := checkEventFilters(tt.filters)
+			if (err != nil) != tt.wantErr || err != nil && !errors.Is(err, ErrUnknownEventFilter) {
+				t.Errorf("checkEventFilters(%v) error = %v, want error %v", tt.filters, err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,171 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+
+	"github.com/google/uuid"
+	"github.com/hoshina-dev/custapi/internal/media"
+	"github.com/hoshina-dev/custapi/internal/models"
+	"github.com/hoshina-dev/custapi/internal/repositories"
+	"github.com/hoshina-dev/custapi/internal/storage"
+)
+
+// ErrImageTooLarge is returned for uploads above the configured maximum size
+var ErrImageTooLarge = errors.New("image is too large")
+
+// MediaConfig holds the limits of image uploads
+type MediaConfig struct {
+	// MaxUploadSize is the maximum size of an uploaded file in bytes
+	MaxUploadSize int64
+	// ThumbnailSizes are the largest dimensions of the thumbnails rendered for each image
+	ThumbnailSizes []int
+}
+
+// MediaService stores uploaded user avatars and organization images and reports stored
+// image URLs that no longer resolve
+type MediaService interface {
+	UploadAvatar(ctx context.Context, userID uuid.UUID, data []byte) (*models.UploadedImage, error)
+	AddOrganizationImage(ctx context.Context, orgID uuid.UUID, data []byte) (*models.UploadedImage, error)
+	ListBrokenMedia(ctx context.Context, filter models.MediaCheckFilter) ([]models.MediaCheck, error)
+}
+
+// mediaService is the concrete implementation of MediaService
+type mediaService struct {
+	store          storage.Storage
+	mediaCheckRepo repositories.MediaCheckRepository
+	userService    UserService
+	orgService     OrganizationService
+	cfg            MediaConfig
+}
+
+// NewMediaService creates a new media service
+func NewMediaService(store storage.Storage, mediaCheckRepo repositories.MediaCheckRepository,
+	userService UserService, orgService OrganizationService, cfg MediaConfig) MediaService {
+	return &mediaService{
+		store:          store,
+		mediaCheckRepo: mediaCheckRepo,
+		userService:    userService,
+		orgService:     orgService,
+		cfg:            cfg,
+	}
+}
+
+// UploadAvatar stores an image and its thumbnails and makes it the user's avatar
+func (s *mediaService) UploadAvatar(ctx context.Context, userID uuid.UUID, data []byte) (*models.UploadedImage, error) {
+	user, err := s.userService.GetUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, errors.New("user not found")
+	}
+
+	img, keys, err := s.save(ctx, fmt.Sprintf("avatars/%s", userID), data)
+	if err != nil {
+		return nil, err
+	}
+
+	user, err = s.userService.Update(ctx, userID, &models.UpdateUserRequest{AvatarURL: &img.URL})
+	if err == nil && user == nil {
+		err = errors.New("user not found")
+	}
+	if err != nil {
+		s.discard(keys)
+		return nil, err
+	}
+	return img, nil
+}
+
+// AddOrganizationImage stores an image and its thumbnails and adds it to the images
+// of the organization
+func (s *mediaService) AddOrganizationImage(ctx context.Context, orgID uuid.UUID, data []byte) (*models.UploadedImage, error) {
+	org, err := s.orgService.GetOrganization(ctx, orgID)
+	if err != nil {
+		return nil, err
+	}
+	if org == nil {
+		return nil, errors.New("organization not found")
+	}
+
+	img, keys, err := s.save(ctx, fmt.Sprintf("organizations/%s", orgID), data)
+	if err != nil {
+		return nil, err
+	}
+
+	org, err = s.orgService.AddImage(ctx, orgID, img.URL)
+	if err == nil && org == nil {
+		err = errors.New("organization not found")
+	}
+	if err != nil {
+		s.discard(keys)
+		return nil, err
+	}
+	return img, nil
+}
+
+// save validates an upload and stores it with its thumbnails under a new name in
+// dir. It returns the stored image and the keys of all its blobs.
+func (s *mediaService) save(ctx context.Context, dir string, data []byte) (*models.UploadedImage, []string, error) {
+	if int64(len(data)) > s.cfg.MaxUploadSize {
+		return nil, nil, ErrImageTooLarge
+	}
+	decoded, err := media.Decode(data)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	bounds := decoded.Image.Bounds()
+	img := &models.UploadedImage{
+		ContentType: decoded.ContentType,
+		Size:        len(data),
+		Width:       bounds.Dx(),
+		Height:      bounds.Dy(),
+	}
+	name := dir + "/" + uuid.NewString()
+
+	var keys []string
+	put := func(key string, data []byte, contentType string) (string, error) {
+		url, err := s.store.Put(ctx, key, data, contentType)
+		if err != nil {
+			s.discard(keys)
+			return "", err
+		}
+		keys = append(keys, key)
+		return url, nil
+	}
+
+	if img.URL, err = put(name+media.Extension(decoded.ContentType), data, decoded.ContentType); err != nil {
+		return nil, nil, err
+	}
+	for _, size := range s.cfg.ThumbnailSizes {
+		thumb, contentType, err := decoded.Thumbnail(size)
+		if err != nil {
+			s.discard(keys)
+			return nil, nil, err
+		}
+		url, err := put(fmt.Sprintf("%s_%d%s", name, size, media.Extension(contentType)), thumb, contentType)
+		if err != nil {
+			return nil, nil, err
+		}
+		img.Thumbnails = append(img.Thumbnails, models.Thumbnail{Size: size, URL: url})
+	}
+	return img, keys, nil
+}
+
+// discard removes the blobs of an upload that could not be saved. It does not use the
+// request context, which may be the reason the upload failed.
+func (s *mediaService) discard(keys []string) {
+	for _, key := range keys {
+		if err := s.store.Delete(context.Background(), key); err != nil {
+			log.Printf("Failed to delete orphaned blob %s: %v", key, err)
+		}
+	}
+}
+
+// ListBrokenMedia retrieves the stored image URLs that their last check found broken
+func (s *mediaService) ListBrokenMedia(ctx context.Context, filter models.MediaCheckFilter) ([]models.MediaCheck, error) {
+	return s.mediaCheckRepo.FindBroken(ctx, filter)
+}
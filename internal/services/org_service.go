@@ -2,33 +2,60 @@ package services
 
 import (
 	"context"
+	"errors"
+	"slices"
+	"time"
 
 	"github.com/google/uuid"
+	"github.com/hoshina-dev/custapi/internal/auth"
 	"github.com/hoshina-dev/custapi/internal/models"
 	"github.com/hoshina-dev/custapi/internal/repositories"
 )
 
+// ErrOrganizationHasUsers is returned when a restricted deletion hits an organization with users
+var ErrOrganizationHasUsers = errors.New("organization still has users")
+
 // OrganizationService defines organization business logic operations
 type OrganizationService interface {
 	CreateOrganization(ctx context.Context, req *models.CreateOrganizationRequest) (*models.Organization, error)
 	GetOrganization(ctx context.Context, id uuid.UUID) (*models.Organization, error)
-	GetByIDs(ctx context.Context, id []uuid.UUID) ([]models.Organization, error)
+	GetOrganizationAsOf(ctx context.Context, id uuid.UUID, asOf time.Time) (*models.Organization, error)
+	GetOrganizationHistory(ctx context.Context, id uuid.UUID) ([]models.OrganizationVersion, error)
+	GetByIDs(ctx context.Context, ids []uuid.UUID) ([]models.Organization, []uuid.UUID, error)
 	ListOrganizations(ctx context.Context) ([]models.Organization, error)
+	FindOrganizations(ctx context.Context, filter models.OrganizationFilter) ([]models.Organization, error)
+	ExportOrganizations(ctx context.Context, filter models.OrganizationFilter, fn func(*models.Organization) error) error
 	GetAllCoords(ctx context.Context) ([]models.Organization, error)
 	UpdateOrganization(ctx context.Context, id uuid.UUID, req *models.UpdateOrganizationRequest) (*models.Organization, error)
-	DeleteOrganization(ctx context.Context, id uuid.UUID) error
+	AddImage(ctx context.Context, id uuid.UUID, url string) (*models.Organization, error)
+	RemoveImage(ctx context.Context, id uuid.UUID, url string) error
+	DeleteOrganization(ctx context.Context, id uuid.UUID, opts models.OrganizationDeleteOptions) (int64, error)
 	SearchOrganizations(ctx context.Context, query string, limit int) ([]models.Organization, error)
+	LoadUsers(ctx context.Context, orgs []models.Organization, limit, offset int) error
+	ImportOrganizations(ctx context.Context, reqs []*models.CreateOrganizationRequest, dryRun bool) []models.ImportResult
 }
 
 // organizationService is the concrete implementation of OrganizationService
 type organizationService struct {
-	orgRepo repositories.OrganizationRepository
+	txManager       repositories.TxManager
+	orgRepo         repositories.OrganizationRepository
+	historyRepo     repositories.OrganizationHistoryRepository
+	userRepo        repositories.UserRepository
+	userHistoryRepo repositories.UserHistoryRepository
+	auditService    AuditService
 }
 
 // NewOrganizationService creates a new organization service
-func NewOrganizationService(orgRepo repositories.OrganizationRepository) OrganizationService {
+func NewOrganizationService(txManager repositories.TxManager, orgRepo repositories.OrganizationRepository,
+	historyRepo repositories.OrganizationHistoryRepository, userRepo repositories.UserRepository,
+	userHistoryRepo repositories.UserHistoryRepository, auditService AuditService) OrganizationService {
 	return &organizationService{
-		orgRepo: orgRepo,
+		txManager:       txManager,
+		orgRepo:         orgRepo,
+		historyRepo:     historyRepo,
+		userRepo:        userRepo,
+		userHistoryRepo: userHistoryRepo,
+		auditService:    auditService,
 	}
 }
 
@@ -36,7 +63,13 @@ func NewOrganizationService(orgRepo repositories.OrganizationRepository) Organiz
 func (s *organizationService) CreateOrganization(ctx context.Context, req *models.CreateOrganizationRequest) (*models.Organization, error) {
 	org := req.ToDomain()
 
-	if err := s.orgRepo.Create(ctx, org); err != nil {
+	err := s.txManager.WithinTransaction(ctx, func(ctx context.Context) error {
+		if err := s.orgRepo.Create(ctx, org); err != nil {
+			return err
+		}
+		return s.auditService.Record(ctx, AuditActionCreate, EntityOrganization, org.ID, nil, orgSnapshot(org))
+	})
+	if err != nil {
 		return nil, err
 	}
 
@@ -48,8 +81,80 @@ func (s *organizationService) GetOrganization(ctx context.Context, id uuid.UUID)
 	return s.orgRepo.FindByID(ctx, id)
 }
 
-func (s *organizationService) GetByIDs(ctx context.Context, id []uuid.UUID) ([]models.Organization, error) {
-	return s.orgRepo.FindByIDs(ctx, id)
+// GetOrganizationAsOf reconstructs an organization as it was at asOf. It returns
+// nil if the organization did not exist at that time or its state is not known.
+func (s *organizationService) GetOrganizationAsOf(ctx context.Context, id uuid.UUID, asOf time.Time) (*models.Organization, error) {
+	version, err := s.historyRepo.FindAsOf(ctx, id, asOf)
+	if err != nil {
+		return nil, err
+	}
+	if version != nil {
+		return version.ToDomain(), nil
+	}
+
+	org, err := s.orgRepo.FindByIDUnscoped(ctx, id)
+	if err != nil || org == nil {
+		return nil, err
+	}
+	// The current version only applies from its last update until it was deleted
+	if org.UpdatedAt.After(asOf) || (org.DeletedAt.Valid && !org.DeletedAt.Time.After(asOf)) {
+		return nil, nil
+	}
+	return org, nil
+}
+
+// GetOrganizationHistory retrieves every known version of an organization,
+// oldest first, each with the fields changed compared to the previous one
+func (s *organizationService) GetOrganizationHistory(ctx context.Context, id uuid.UUID) ([]models.OrganizationVersion, error) {
+	org, err := s.orgRepo.FindByIDUnscoped(ctx, id)
+	if err != nil || org == nil {
+		return nil, err
+	}
+
+	past, err := s.historyRepo.FindByOrganizationID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	versions := make([]models.OrganizationVersion, 0, len(past)+1)
+	for i := range past {
+		validTo := past[i].ValidTo
+		versions = append(versions, models.OrganizationVersion{
+			Organization: *past[i].ToDomain(),
+			ValidFrom:    past[i].ValidFrom,
+			ValidTo:      &validTo,
+			EndedBy:      past[i].Operation,
+			ChangedBy:    past[i].ChangedBy,
+		})
+	}
+	if !org.DeletedAt.Valid {
+		versions = append(versions, models.OrganizationVersion{Organization: *org, ValidFrom: org.UpdatedAt})
+	}
+
+	var previous map[string]any
+	for i := range versions {
+		current := orgSnapshot(&versions[i].Organization)
+		versions[i].Changes = diffSnapshots(previous, current)
+		previous = current
+	}
+
+	return versions, nil
+}
+
+// archive stores the current state of org in its history before it is changed by operation
+func (s *organizationService) archive(ctx context.Context, org *models.Organization, operation string) error {
+	return s.historyRepo.Create(ctx, models.NewOrganizationHistory(org, operation, auth.FromContext(ctx).UserID))
+}
+
+// GetByIDs retrieves organizations by ID in the requested order, along with the IDs
+// that matched no organization
+func (s *organizationService) GetByIDs(ctx context.Context, ids []uuid.UUID) ([]models.Organization, []uuid.UUID, error) {
+	orgs, err := s.orgRepo.FindByIDs(ctx, ids)
+	if err != nil {
+		return nil, nil, err
+	}
+	orgs, missing := inRequestOrder(ids, orgs, func(org *models.Organization) uuid.UUID { return org.ID })
+	return orgs, missing, nil
 }
 
 // ListOrganizations retrieves all organizations
@@ -57,26 +162,276 @@ func (s *organizationService) ListOrganizations(ctx context.Context) ([]models.O
 	return s.orgRepo.FindAll(ctx)
 }
 
+// FindOrganizations retrieves a page of the organizations matching filter
+func (s *organizationService) FindOrganizations(ctx context.Context, filter models.OrganizationFilter) ([]models.Organization, error) {
+	return s.orgRepo.Find(ctx, filter)
+}
+
+// ExportOrganizations streams the organizations matching filter to fn
+func (s *organizationService) ExportOrganizations(ctx context.Context, filter models.OrganizationFilter, fn func(*models.Organization) error) error {
+	return s.orgRepo.StreamAll(ctx, filter, fn)
+}
+
 func (s *organizationService) GetAllCoords(ctx context.Context) ([]models.Organization, error) {
 	return s.orgRepo.FindAllCoords(ctx)
 }
 
 func (s *organizationService) UpdateOrganization(ctx context.Context, id uuid.UUID, req *models.UpdateOrganizationRequest) (*models.Organization, error) {
-	org, err := s.orgRepo.FindByID(ctx, id)
-	if err != nil || org == nil {
+	var updatedOrg *models.Organization
+	err := s.txManager.WithinTransaction(ctx, func(ctx context.Context) error {
+		org, err := s.orgRepo.FindByIDForUpdate(ctx, id)
+		if err != nil || org == nil {
+			return err
+		}
+
+		if err := s.archive(ctx, org, AuditActionUpdate); err != nil {
+			return err
+		}
+
+		updatedOrg = req.ToDomain(org.ID)
+		if err := s.orgRepo.Update(ctx, updatedOrg); err != nil {
+			return err
+		}
+		return s.auditService.Record(ctx, AuditActionUpdate, EntityOrganization, id, orgSnapshot(org), orgSnapshot(updatedOrg))
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return updatedOrg, nil
+}
+
+// AddImage appends an image URL to an organization. The organization is locked while
+// its images are read and written, so that concurrent uploads all keep their image.
+func (s *organizationService) AddImage(ctx context.Context, id uuid.UUID, url string) (*models.Organization, error) {
+	var updatedOrg *models.Organization
+	err := s.txManager.WithinTransaction(ctx, func(ctx context.Context) error {
+		org, err := s.orgRepo.FindByIDForUpdate(ctx, id)
+		if err != nil || org == nil {
+			return err
+		}
+
+		if err := s.archive(ctx, org, AuditActionUpdate); err != nil {
+			return err
+		}
+
+		updatedOrg = &models.Organization{ID: org.ID, ImageUrls: append(slices.Clone(org.ImageUrls), url)}
+		if err := s.orgRepo.Update(ctx, updatedOrg); err != nil {
+			return err
+		}
+		return s.auditService.Record(ctx, AuditActionUpdate, EntityOrganization, id, orgSnapshot(org), orgSnapshot(updatedOrg))
+	})
+	if err != nil {
 		return nil, err
 	}
 
-	updatedOrg := req.ToDomain(org.ID)
+	return updatedOrg, nil
+}
+
+// RemoveImage removes an image URL from an organization. Nothing is changed if the
+// organization no longer has the image.
+func (s *organizationService) RemoveImage(ctx context.Context, id uuid.UUID, url string) error {
+	return s.txManager.WithinTransaction(ctx, func(ctx context.Context) error {
+		org, err := s.orgRepo.FindByIDForUpdate(ctx, id)
+		if err != nil || org == nil || !slices.Contains(org.ImageUrls, url) {
+			return err
+		}
+
+		if err := s.archive(ctx, org, AuditActionUpdate); err != nil {
+			return err
+		}
 
-	return updatedOrg, s.orgRepo.Update(ctx, updatedOrg)
+		imageUrls := slices.DeleteFunc(slices.Clone(org.ImageUrls), func(u string) bool { return u == url })
+		updatedOrg := &models.Organization{ID: org.ID, ImageUrls: imageUrls}
+		if err := s.orgRepo.Update(ctx, updatedOrg); err != nil {
+			return err
+		}
+		return s.auditService.Record(ctx, AuditActionUpdate, EntityOrganization, id, orgSnapshot(org), orgSnapshot(updatedOrg))
+	})
 }
 
-func (s *organizationService) DeleteOrganization(ctx context.Context, id uuid.UUID) error {
-	return s.orgRepo.Delete(ctx, id)
+// DeleteOrganization soft deletes an organization according to the given policy
+// and returns the number of users affected. In dry-run mode nothing is changed.
+func (s *organizationService) DeleteOrganization(ctx context.Context, id uuid.UUID, opts models.OrganizationDeleteOptions) (int64, error) {
+	if opts.Policy == models.DeletePolicyReassign {
+		if opts.ReassignTo == nil {
+			return 0, errors.New("reassign target is required")
+		}
+		if *opts.ReassignTo == id {
+			return 0, errors.New("cannot reassign users to the deleted organization")
+		}
+	}
+
+	findOrg := s.orgRepo.FindByIDForUpdate
+	if opts.DryRun {
+		findOrg = s.orgRepo.FindByID
+	}
+
+	var affected int64
+	err := s.txManager.WithinTransaction(ctx, func(ctx context.Context) error {
+		org, err := findOrg(ctx, id)
+		if err != nil {
+			return err
+		}
+		if org == nil {
+			return errors.New("organization not found")
+		}
+
+		if opts.Policy == models.DeletePolicyReassign {
+			target, err := s.orgRepo.FindByIDForShare(ctx, *opts.ReassignTo)
+			if err != nil {
+				return err
+			}
+			if target == nil {
+				return errors.New("target organization not found")
+			}
+		}
+
+		affected, err = s.userRepo.CountByOrganizationID(ctx, id)
+		if err != nil {
+			return err
+		}
+		// A dry run reports the users a restricted deletion would be refused for
+		if opts.DryRun {
+			return nil
+		}
+		if opts.Policy == models.DeletePolicyRestrict && affected > 0 {
+			return ErrOrganizationHasUsers
+		}
+
+		var users []models.User
+		if affected > 0 {
+			if users, err = s.userRepo.FindByOrganizationID(ctx, id); err != nil {
+				return err
+			}
+		}
+
+		switch opts.Policy {
+		case models.DeletePolicyCascade:
+			affected, err = s.userRepo.DeleteByOrganizationID(ctx, id)
+		case models.DeletePolicyReassign:
+			affected, err = s.userRepo.ReassignOrganization(ctx, id, *opts.ReassignTo)
+		}
+		if err != nil {
+			return err
+		}
+
+		if err := s.recordMemberChanges(ctx, users, opts); err != nil {
+			return err
+		}
+
+		if err := s.archive(ctx, org, AuditActionDelete); err != nil {
+			return err
+		}
+		if err := s.orgRepo.Delete(ctx, id); err != nil {
+			return err
+		}
+		return s.auditService.Record(ctx, AuditActionDelete, EntityOrganization, id, orgSnapshot(org), nil)
+	})
+	return affected, err
 }
 
 // SearchOrganizations searches organizations by name
 func (s *organizationService) SearchOrganizations(ctx context.Context, query string, limit int) ([]models.Organization, error) {
 	return s.orgRepo.Search(ctx, query, limit)
 }
+
+// LoadUsers sets one page of users, newest first, on each organization, loading them
+// all in one query
+func (s *organizationService) LoadUsers(ctx context.Context, orgs []models.Organization, limit, offset int) error {
+	if len(orgs) == 0 {
+		return nil
+	}
+	orgIDs := make([]uuid.UUID, len(orgs))
+	for i, org := range orgs {
+		orgIDs[i] = org.ID
+	}
+
+	users, err := s.userRepo.FindByOrganizationIDs(ctx, orgIDs, limit, offset)
+	if err != nil {
+		return err
+	}
+	byOrg := make(map[uuid.UUID][]models.User, len(orgs))
+	for _, user := range users {
+		byOrg[user.OrganizationID] = append(byOrg[user.OrganizationID], user)
+	}
+	for i := range orgs {
+		orgs[i].Users = append([]models.User{}, byOrg[orgs[i].ID]...)
+	}
+	return nil
+}
+
+// recordMemberChanges archives and records the audit events of the users deleted or
+// moved along with their organization
+func (s *organizationService) recordMemberChanges(ctx context.Context, users []models.User, opts models.OrganizationDeleteOptions) error {
+	for i := range users {
+		before := userSnapshot(&users[i])
+
+		if opts.Policy != models.DeletePolicyReassign {
+			if err := archiveUser(ctx, s.userHistoryRepo, &users[i], nil, AuditActionDelete); err != nil {
+				return err
+			}
+			if err := s.auditService.Record(ctx, AuditActionDelete, EntityUser, users[i].ID, before, nil); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if err := archiveUser(ctx, s.userHistoryRepo, &users[i], nil, AuditActionUpdate); err != nil {
+			return err
+		}
+		users[i].OrganizationID = *opts.ReassignTo
+		if err := s.auditService.Record(ctx, AuditActionUpdate, EntityUser, users[i].ID, before, userSnapshot(&users[i])); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ImportOrganizations upserts organizations using the name as natural key. Each row
+// is applied in its own transaction so a failing row does not affect the others.
+// In dry-run mode only the outcome is reported and nothing is written.
+func (s *organizationService) ImportOrganizations(ctx context.Context, reqs []*models.CreateOrganizationRequest, dryRun bool) []models.ImportResult {
+	results := make([]models.ImportResult, len(reqs))
+	for i, req := range reqs {
+		results[i] = s.importOrganization(ctx, req, dryRun)
+	}
+	return results
+}
+
+func (s *organizationService) importOrganization(ctx context.Context, req *models.CreateOrganizationRequest, dryRun bool) models.ImportResult {
+	var res models.ImportResult
+	res.Err = s.txManager.WithinTransaction(ctx, func(ctx context.Context) error {
+		existing, err := s.orgRepo.FindByName(ctx, req.Name)
+		if err != nil {
+			return err
+		}
+
+		org := req.ToDomain()
+		if existing == nil {
+			res.Created = true
+			if dryRun {
+				return nil
+			}
+			if err := s.orgRepo.Create(ctx, org); err != nil {
+				return err
+			}
+			res.ID = org.ID
+			return s.auditService.Record(ctx, AuditActionCreate, EntityOrganization, org.ID, nil, orgSnapshot(org))
+		}
+
+		res.ID = existing.ID
+		if dryRun {
+			return nil
+		}
+		if err := s.archive(ctx, existing, AuditActionUpdate); err != nil {
+			return err
+		}
+		org.ID = existing.ID
+		if err := s.orgRepo.Update(ctx, org); err != nil {
+			return err
+		}
+		return s.auditService.Record(ctx, AuditActionUpdate, EntityOrganization, org.ID, orgSnapshot(existing), orgSnapshot(org))
+	})
+	return res
+}
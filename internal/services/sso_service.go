@@ -0,0 +1,343 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hoshina-dev/custapi/internal/auth"
+	"github.com/hoshina-dev/custapi/internal/models"
+	"github.com/hoshina-dev/custapi/internal/oidc"
+	"github.com/hoshina-dev/custapi/internal/repositories"
+)
+
+var (
+	// ErrSSONotConfigured is returned when starting a single sign-on login for an
+	// organization or email domain without a provider
+	ErrSSONotConfigured = errors.New("single sign-on is not configured for this organization or email domain")
+	// ErrSSOProviderUnavailable is returned when the configuration of a provider cannot be fetched
+	ErrSSOProviderUnavailable = errors.New("single sign-on provider is unavailable")
+	// ErrInvalidSSOState is returned for single sign-on logins that are unknown,
+	// already completed or expired
+	ErrInvalidSSOState = errors.New("invalid or expired single sign-on login")
+	// ErrSSOLoginFailed is returned when the provider does not confirm who logged in
+	ErrSSOLoginFailed = errors.New("single sign-on login failed")
+	// ErrSSOEmailNotAllowed is returned when a provider vouches for no verified email
+	// address of the domains of its organization, for a user it has no identity of yet
+	ErrSSOEmailNotAllowed = errors.New("single sign-on provider gave no verified email address of the organization's domains")
+	// ErrSSOAccountConflict is returned when the email address of a new identity
+	// belongs to a user of another organization
+	ErrSSOAccountConflict = errors.New("an account with this email address belongs to another organization")
+	// ErrSSOAccountDeleted is returned for identities of deleted users
+	ErrSSOAccountDeleted = errors.New("the account of this identity was deleted")
+	// ErrSSODomainTaken is returned when configuring an email domain another
+	// organization's provider handles
+	ErrSSODomainTaken = errors.New("email domain is already handled by the single sign-on of another organization")
+	// ErrInvalidSSOIssuer is returned when configuring an issuer whose OpenID
+	// configuration cannot be fetched
+	ErrInvalidSSOIssuer = errors.New("invalid single sign-on issuer")
+)
+
+// SSOConfig holds single sign-on settings
+type SSOConfig struct {
+	// RedirectURL is the page providers send users back to with the state and code
+	// of the login, to be posted to CompleteLogin
+	RedirectURL string
+	StateTTL    time.Duration
+}
+
+// SSOService configures the OpenID Connect providers of organizations and logs users
+// in with them. Users logging in for the first time are linked to the account with
+// their email address or, if there is none, created in the provider's organization.
+type SSOService interface {
+	GetProvider(ctx context.Context, orgID uuid.UUID) (*models.SSOProvider, error)
+	SaveProvider(ctx context.Context, orgID uuid.UUID, req *models.SSOProviderRequest) (*models.SSOProvider, error)
+	DeleteProvider(ctx context.Context, orgID uuid.UUID) error
+	StartLogin(ctx context.Context, email string, orgID *uuid.UUID) (string, time.Time, error)
+	CompleteLogin(ctx context.Context, state, code, userAgent string) (*models.Session, string, error)
+}
+
+// ssoService is the concrete implementation of SSOService
+type ssoService struct {
+	txManager    repositories.TxManager
+	providerRepo repositories.SSOProviderRepository
+	identityRepo repositories.ExternalIdentityRepository
+	stateRepo    repositories.SSOLoginStateRepository
+	userRepo     repositories.UserRepository
+	orgRepo      repositories.OrganizationRepository
+	auditService AuditService
+	authService  AuthService
+	client       *oidc.Client
+	cfg          SSOConfig
+}
+
+// NewSSOService creates a new single sign-on service
+func NewSSOService(txManager repositories.TxManager, providerRepo repositories.SSOProviderRepository,
+	identityRepo repositories.ExternalIdentityRepository, stateRepo repositories.SSOLoginStateRepository,
+	userRepo repositories.UserRepository, orgRepo repositories.OrganizationRepository, auditService AuditService,
+	authService AuthService, client *oidc.Client, cfg SSOConfig) SSOService {
+	return &ssoService{
+		txManager:    txManager,
+		providerRepo: providerRepo,
+		identityRepo: identityRepo,
+		stateRepo:    stateRepo,
+		userRepo:     userRepo,
+		orgRepo:      orgRepo,
+		auditService: auditService,
+		authService:  authService,
+		client:       client,
+		cfg:          cfg,
+	}
+}
+
+// GetProvider retrieves the provider of an organization
+func (s *ssoService) GetProvider(ctx context.Context, orgID uuid.UUID) (*models.SSOProvider, error) {
+	return s.providerRepo.FindByOrganizationID(ctx, orgID)
+}
+
+// SaveProvider configures the provider of an organization, replacing the one it had.
+// The OpenID configuration of the issuer is fetched to check it.
+func (s *ssoService) SaveProvider(ctx context.Context, orgID uuid.UUID, req *models.SSOProviderRequest) (*models.SSOProvider, error) {
+	org, err := s.orgRepo.FindByID(ctx, orgID)
+	if err != nil {
+		return nil, err
+	}
+	if org == nil {
+		return nil, errors.New("organization not found")
+	}
+
+	provider := req.ToDomain(orgID)
+	for _, domain := range provider.EmailDomains {
+		other, err := s.providerRepo.FindByEmailDomain(ctx, domain)
+		if err != nil {
+			return nil, err
+		}
+		if other != nil && other.OrganizationID != orgID {
+			return nil, fmt.Errorf("%w: %s", ErrSSODomainTaken, domain)
+		}
+	}
+	if _, err := s.client.Discover(ctx, provider.Issuer); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidSSOIssuer, err)
+	}
+
+	existing, err := s.providerRepo.FindByOrganizationID(ctx, orgID)
+	if err != nil {
+		return nil, err
+	}
+	if existing == nil {
+		err = s.providerRepo.Create(ctx, provider)
+	} else {
+		provider.ID = existing.ID
+		err = s.providerRepo.Update(ctx, provider)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return provider, nil
+}
+
+// DeleteProvider removes the provider of an organization. Logins started with it can
+// no longer be completed, but identities linked through it are kept.
+func (s *ssoService) DeleteProvider(ctx context.Context, orgID uuid.UUID) error {
+	return s.providerRepo.DeleteByOrganizationID(ctx, orgID)
+}
+
+// StartLogin starts a login with the provider of an organization or, without one,
+// with the provider handling the domain of email. It returns the URL of the provider's
+// login page to send the user to and when the login expires.
+func (s *ssoService) StartLogin(ctx context.Context, email string, orgID *uuid.UUID) (string, time.Time, error) {
+	var provider *models.SSOProvider
+	var err error
+	if orgID != nil {
+		provider, err = s.providerRepo.FindByOrganizationID(ctx, *orgID)
+	} else {
+		provider, err = s.providerRepo.FindByEmailDomain(ctx, emailDomain(email))
+	}
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	if provider == nil {
+		return "", time.Time{}, ErrSSONotConfigured
+	}
+
+	discovered, err := s.client.Discover(ctx, provider.Issuer)
+	if err != nil {
+		return "", time.Time{}, fmt.Errorf("%w: %v", ErrSSOProviderUnavailable, err)
+	}
+	if _, err := s.stateRepo.DeleteExpired(ctx); err != nil {
+		return "", time.Time{}, err
+	}
+
+	authReq, err := oidc.NewAuthRequest()
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	state := &models.SSOLoginState{
+		ProviderID:   provider.ID,
+		StateHash:    auth.HashToken(authReq.State),
+		Nonce:        authReq.Nonce,
+		CodeVerifier: authReq.CodeVerifier,
+		ExpiresAt:    time.Now().Add(s.cfg.StateTTL),
+	}
+	if err := s.stateRepo.Create(ctx, state); err != nil {
+		return "", time.Time{}, err
+	}
+
+	authURL, err := discovered.AuthCodeURL(provider.ClientID, s.cfg.RedirectURL, email, authReq)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	return authURL, state.ExpiresAt, nil
+}
+
+// CompleteLogin exchanges the code a provider sent the user back with for their ID
+// token and logs in the user it identifies, linking or creating their account on
+// their first login. Like Login, it returns a *TwoFactorRequiredError for users with
+// two-factor authentication.
+func (s *ssoService) CompleteLogin(ctx context.Context, state, code, userAgent string) (*models.Session, string, error) {
+	loginState, err := s.stateRepo.Consume(ctx, auth.HashToken(state))
+	if err != nil {
+		return nil, "", err
+	}
+	if loginState == nil {
+		return nil, "", ErrInvalidSSOState
+	}
+	provider, err := s.providerRepo.FindByID(ctx, loginState.ProviderID)
+	if err != nil {
+		return nil, "", err
+	}
+	if provider == nil {
+		return nil, "", ErrInvalidSSOState
+	}
+
+	discovered, err := s.client.Discover(ctx, provider.Issuer)
+	if err != nil {
+		return nil, "", fmt.Errorf("%w: %v", ErrSSOProviderUnavailable, err)
+	}
+	idToken, err := s.client.Exchange(ctx, discovered, provider.ClientID, provider.ClientSecret, s.cfg.RedirectURL, code, loginState.CodeVerifier)
+	if err != nil {
+		return nil, "", fmt.Errorf("%w: %v", ErrSSOLoginFailed, err)
+	}
+	claims, err := s.client.VerifyIDToken(ctx, discovered, provider.ClientID, idToken, loginState.Nonce)
+	if err != nil {
+		return nil, "", fmt.Errorf("%w: %v", ErrSSOLoginFailed, err)
+	}
+
+	user, err := s.userForIdentity(ctx, provider, claims)
+	if err != nil {
+		return nil, "", err
+	}
+	return s.authService.LoginUser(ctx, user, userAgent)
+}
+
+// userForIdentity returns the user an identity is linked to. An identity seen for the
+// first time is linked to the user of the provider's organization with its email
+// address, or to a new user in that organization.
+func (s *ssoService) userForIdentity(ctx context.Context, provider *models.SSOProvider, claims *oidc.Claims) (*models.User, error) {
+	var email *string
+	if claims.Email != "" {
+		email = &claims.Email
+	}
+
+	identity, err := s.identityRepo.FindByIssuerAndSubject(ctx, claims.Issuer, claims.Subject)
+	if err != nil {
+		return nil, err
+	}
+	if identity != nil {
+		user, err := s.userRepo.FindByID(ctx, identity.UserID)
+		if err != nil {
+			return nil, err
+		}
+		if user == nil {
+			return nil, ErrSSOAccountDeleted
+		}
+		if err := s.identityRepo.RecordLogin(ctx, identity.ID, email); err != nil {
+			return nil, err
+		}
+		return user, nil
+	}
+
+	// Only addresses the provider verified and is trusted with may be linked or created
+	if email == nil || !claims.EmailVerified || !slices.Contains(provider.EmailDomains, emailDomain(claims.Email)) {
+		return nil, ErrSSOEmailNotAllowed
+	}
+
+	var user *models.User
+	err = s.txManager.WithinTransaction(ctx, func(ctx context.Context) error {
+		user, err = s.userRepo.FindByEmail(ctx, claims.Email)
+		if err != nil {
+			return err
+		}
+		if user != nil {
+			if user.OrganizationID != provider.OrganizationID {
+				return ErrSSOAccountConflict
+			}
+			if user.EmailVerifiedAt == nil {
+				if err := s.userRepo.ConfirmEmail(ctx, user.ID, user.Email); err != nil {
+					return err
+				}
+			}
+			log.Printf("Linked identity %s of %s to user %s", claims.Subject, claims.Issuer, user.ID)
+		} else {
+			if user, err = s.createUser(ctx, provider, claims); err != nil {
+				return err
+			}
+		}
+
+		now := time.Now()
+		return s.identityRepo.Create(ctx, &models.ExternalIdentity{
+			UserID:      user.ID,
+			Issuer:      claims.Issuer,
+			Subject:     claims.Subject,
+			Email:       email,
+			LastLoginAt: &now,
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.userRepo.FindByID(ctx, user.ID)
+}
+
+// createUser creates the user of an identity seen for the first time in the
+// organization of the provider. The user has no password and a verified email address.
+func (s *ssoService) createUser(ctx context.Context, provider *models.SSOProvider, claims *oidc.Claims) (*models.User, error) {
+	// Keep the organization from being deleted until the user is created
+	org, err := s.orgRepo.FindByIDForShare(ctx, provider.OrganizationID)
+	if err != nil {
+		return nil, err
+	}
+	if org == nil {
+		return nil, ErrSSONotConfigured
+	}
+
+	name := claims.Name
+	if name == "" {
+		name, _, _ = strings.Cut(claims.Email, "@")
+	}
+	now := time.Now()
+	user := &models.User{
+		Email:           claims.Email,
+		EmailVerifiedAt: &now,
+		Name:            name,
+		OrganizationID:  provider.OrganizationID,
+	}
+	if err := s.userRepo.Create(ctx, user); err != nil {
+		return nil, err
+	}
+	if err := s.auditService.Record(ctx, AuditActionCreate, EntityUser, user.ID, nil, userSnapshot(user)); err != nil {
+		return nil, err
+	}
+	log.Printf("Created user %s for identity %s of %s", user.ID, claims.Subject, claims.Issuer)
+	return user, nil
+}
+
+// emailDomain returns the lowercase domain of an email address
+func emailDomain(email string) string {
+	return strings.ToLower(email[strings.LastIndex(email, "@")+1:])
+}
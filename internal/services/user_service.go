@@ -3,9 +3,16 @@ package services
 import (
 	"context"
 	"errors"
+	"log"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
 
 	"github.com/google/uuid"
+	"github.com/hoshina-dev/custapi/internal/auth"
 	"github.com/hoshina-dev/custapi/internal/models"
+	"github.com/hoshina-dev/custapi/internal/password"
 	"github.com/hoshina-dev/custapi/internal/repositories"
 )
 
@@ -13,60 +20,240 @@ import (
 type UserService interface {
 	CreateUser(ctx context.Context, req *models.CreateUserRequest) (*models.User, error)
 	GetUser(ctx context.Context, id uuid.UUID) (*models.User, error)
+	GetUserAsOf(ctx context.Context, id uuid.UUID, asOf time.Time) (*models.User, error)
+	GetUserHistory(ctx context.Context, id uuid.UUID) ([]models.UserVersion, error)
+	GetByIDs(ctx context.Context, ids []uuid.UUID) ([]models.User, []uuid.UUID, error)
 	ListUsers(ctx context.Context) ([]models.User, error)
+	FindUsers(ctx context.Context, filter models.UserFilter) ([]models.User, error)
+	ExportUsers(ctx context.Context, filter models.UserFilter, fn func(*models.User) error) error
 	ListUsersByOrganization(ctx context.Context, orgID uuid.UUID) ([]models.User, error)
+	ListUsersByOrganizations(ctx context.Context, orgIDs []uuid.UUID, limit, offset int) ([]models.User, error)
+	LoadOrganizations(ctx context.Context, users []models.User) error
 	Update(ctx context.Context, id uuid.UUID, req *models.UpdateUserRequest) (*models.User, error)
+	RemoveAvatar(ctx context.Context, id uuid.UUID, url string) error
 	Delete(ctx context.Context, id uuid.UUID) error
 	SearchUsers(ctx context.Context, query string, limit int) ([]models.User, error)
+	BulkUsers(ctx context.Context, ops []models.BulkUserOperation, atomic bool) ([]models.BulkUserResult, error)
+	ImportUsers(ctx context.Context, reqs []*models.CreateUserRequest, dryRun bool) []models.ImportResult
 }
 
+// ErrBulkAborted marks bulk operations that were rolled back because another operation failed
+var ErrBulkAborted = errors.New("rolled back because another operation failed")
+
+// ErrImportOtherOrganization rejects import rows whose email belongs to a user of
+// another organization than the row's
+var ErrImportOtherOrganization = errors.New("email belongs to a user of another organization")
+
+// ErrAdminRequired is returned when anyone but an admin who logged in with a second
+// factor tries to grant or change admin access
+var ErrAdminRequired = errors.New("only admins who logged in with a second factor can grant or change admin access")
+
+// ErrAuthenticationRequired is returned when an anonymous request tries to change or
+// delete a user
+var ErrAuthenticationRequired = errors.New("authentication required")
+
+// ErrUserAccessDenied is returned when anyone but the user themselves or an admin who
+// logged in with a second factor tries to change or delete a user
+var ErrUserAccessDenied = errors.New("only the user themselves or an admin can change or delete a user")
+
 // userService is the concrete implementation of UserService
 type userService struct {
-	userRepo repositories.UserRepository
-	orgRepo  repositories.OrganizationRepository
+	txManager      repositories.TxManager
+	userRepo       repositories.UserRepository
+	historyRepo    repositories.UserHistoryRepository
+	sessionRepo    repositories.SessionRepository
+	orgRepo        repositories.OrganizationRepository
+	auditService   AuditService
+	passwordPolicy *password.Policy
+	passwordHasher *password.Hasher
+	authService    AuthService
 }
 
 // NewUserService creates a new user service
-func NewUserService(userRepo repositories.UserRepository, orgRepo repositories.OrganizationRepository) UserService {
+func NewUserService(txManager repositories.TxManager, userRepo repositories.UserRepository, historyRepo repositories.UserHistoryRepository,
+	sessionRepo repositories.SessionRepository, orgRepo repositories.OrganizationRepository, auditService AuditService, passwordPolicy *password.Policy, passwordHasher *password.Hasher, authService AuthService) UserService {
 	return &userService{
-		userRepo: userRepo,
-		orgRepo:  orgRepo,
+		txManager:      txManager,
+		userRepo:       userRepo,
+		historyRepo:    historyRepo,
+		sessionRepo:    sessionRepo,
+		orgRepo:        orgRepo,
+		auditService:   auditService,
+		passwordPolicy: passwordPolicy,
+		passwordHasher: passwordHasher,
+		authService:    authService,
 	}
 }
 
 // CreateUser creates a new user
 func (s *userService) CreateUser(ctx context.Context, req *models.CreateUserRequest) (*models.User, error) {
-	// Verify organization exists
-	org, err := s.orgRepo.FindByID(ctx, req.OrganizationID)
+	user, err := s.userFromRequest(ctx, req)
 	if err != nil {
 		return nil, err
 	}
-	if org == nil {
-		return nil, errors.New("organization not found")
-	}
 
-	user, err := req.ToDomain()
+	err = s.txManager.WithinTransaction(ctx, func(ctx context.Context) error {
+		// Verify organization exists and keep it from being deleted until the user is created
+		if err := s.lockOrganization(ctx, req.OrganizationID); err != nil {
+			return err
+		}
+		if err := s.userRepo.Create(ctx, user); err != nil {
+			return err
+		}
+		return s.auditService.Record(ctx, AuditActionCreate, EntityUser, user.ID, nil, userSnapshot(user))
+	})
 	if err != nil {
 		return nil, err
 	}
 
-	if err := s.userRepo.Create(ctx, user); err != nil {
-		return nil, err
+	// The user can ask for a new link, so a failed email does not fail the creation
+	if err := s.authService.SendEmailVerification(ctx, user, user.Email); err != nil {
+		log.Printf("Failed to send verification email to user %s: %v", user.ID, err)
 	}
-
 	return user, nil
 }
 
+// lockOrganization verifies an organization exists and locks it FOR SHARE
+// for the rest of the surrounding transaction
+func (s *userService) lockOrganization(ctx context.Context, orgID uuid.UUID) error {
+	org, err := s.orgRepo.FindByIDForShare(ctx, orgID)
+	if err != nil {
+		return err
+	}
+	if org == nil {
+		return errors.New("organization not found")
+	}
+	return nil
+}
+
 // GetUser retrieves a user by ID
 func (s *userService) GetUser(ctx context.Context, id uuid.UUID) (*models.User, error) {
 	return s.userRepo.FindByID(ctx, id)
 }
 
+// GetUserAsOf reconstructs a user as it was at asOf. It returns nil if the user did
+// not exist at that time.
+func (s *userService) GetUserAsOf(ctx context.Context, id uuid.UUID, asOf time.Time) (*models.User, error) {
+	version, err := s.historyRepo.FindAsOf(ctx, id, asOf)
+	if err != nil {
+		return nil, err
+	}
+	if version != nil {
+		return version.ToDomain(), nil
+	}
+
+	user, err := s.userRepo.FindByIDUnscoped(ctx, id)
+	if err != nil || user == nil {
+		return nil, err
+	}
+	// The current version only applies from the end of the last past version, and
+	// deleted users have none
+	past, err := s.historyRepo.FindByUserID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if user.DeletedAt.Valid || currentValidFrom(user, past).After(asOf) {
+		return nil, nil
+	}
+	return user, nil
+}
+
+// GetUserHistory retrieves every known version of a user, oldest first, each with
+// the fields changed compared to the previous one
+func (s *userService) GetUserHistory(ctx context.Context, id uuid.UUID) ([]models.UserVersion, error) {
+	user, err := s.userRepo.FindByIDUnscoped(ctx, id)
+	if err != nil || user == nil {
+		return nil, err
+	}
+
+	past, err := s.historyRepo.FindByUserID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	versions := make([]models.UserVersion, 0, len(past)+1)
+	for i := range past {
+		validTo := past[i].ValidTo
+		versions = append(versions, models.UserVersion{
+			User:      *past[i].ToDomain(),
+			ValidFrom: past[i].ValidFrom,
+			ValidTo:   &validTo,
+			EndedBy:   past[i].Operation,
+			ChangedBy: past[i].ChangedBy,
+		})
+	}
+	if !user.DeletedAt.Valid {
+		versions = append(versions, models.UserVersion{User: *user, ValidFrom: currentValidFrom(user, past)})
+	}
+
+	var previous map[string]any
+	for i := range versions {
+		current := userHistorySnapshot(&versions[i].User)
+		versions[i].Changes = diffSnapshots(previous, current)
+		previous = current
+	}
+
+	return versions, nil
+}
+
+// currentValidFrom returns when the current version of user started, which is when
+// the last past version ended. updated_at cannot tell, as it also moves on changes the
+// history does not track, like new passwords.
+func currentValidFrom(user *models.User, past []models.UserHistory) time.Time {
+	validFrom := user.CreatedAt
+	for i := range past {
+		if past[i].ValidTo.After(validFrom) {
+			validFrom = past[i].ValidTo
+		}
+	}
+	return validFrom
+}
+
+// archiveUser stores user in its history as the version ended by operation, made by
+// the actor in ctx. An update to updated that changes none of the fields the history
+// keeps, like a new password, ends no version; nil updated always archives.
+func archiveUser(ctx context.Context, historyRepo repositories.UserHistoryRepository, user, updated *models.User, operation string) error {
+	if updated != nil && len(diffSnapshots(userHistorySnapshot(user), userHistorySnapshot(updated))) == 0 {
+		return nil
+	}
+	return historyRepo.Create(ctx, models.NewUserHistory(user, operation, auth.FromContext(ctx).UserID))
+}
+
+// userHistorySnapshot captures the fields of a user kept in its history
+func userHistorySnapshot(user *models.User) map[string]any {
+	snapshot := toMap(user.ToResponse())
+	for _, key := range []string{"email_verified_at", "pending_email", "two_factor_enabled"} {
+		delete(snapshot, key)
+	}
+	return snapshot
+}
+
+// GetByIDs retrieves users by ID in the requested order, along with the IDs that
+// matched no user
+func (s *userService) GetByIDs(ctx context.Context, ids []uuid.UUID) ([]models.User, []uuid.UUID, error) {
+	users, err := s.userRepo.FindByIDs(ctx, ids)
+	if err != nil {
+		return nil, nil, err
+	}
+	users, missing := inRequestOrder(ids, users, func(user *models.User) uuid.UUID { return user.ID })
+	return users, missing, nil
+}
+
 // ListUsers retrieves all users
 func (s *userService) ListUsers(ctx context.Context) ([]models.User, error) {
 	return s.userRepo.FindAll(ctx)
 }
 
+// FindUsers retrieves a page of the users matching filter
+func (s *userService) FindUsers(ctx context.Context, filter models.UserFilter) ([]models.User, error) {
+	return s.userRepo.Find(ctx, filter)
+}
+
+// ExportUsers streams the users matching filter to fn
+func (s *userService) ExportUsers(ctx context.Context, filter models.UserFilter, fn func(*models.User) error) error {
+	return s.userRepo.StreamAll(ctx, filter, fn)
+}
+
 // ListUsersByOrganization retrieves users by organization
 func (s *userService) ListUsersByOrganization(ctx context.Context, orgID uuid.UUID) ([]models.User, error) {
 	// Verify organization exists
@@ -81,25 +268,465 @@ func (s *userService) ListUsersByOrganization(ctx context.Context, orgID uuid.UU
 	return s.userRepo.FindByOrganizationID(ctx, orgID)
 }
 
-func (s *userService) Update(ctx context.Context, id uuid.UUID, req *models.UpdateUserRequest) (*models.User, error) {
-	user, err := s.userRepo.FindByID(ctx, id)
-	if err != nil || user == nil {
-		return nil, err
+// ListUsersByOrganizations retrieves a page of users of each of several organizations
+func (s *userService) ListUsersByOrganizations(ctx context.Context, orgIDs []uuid.UUID, limit, offset int) ([]models.User, error) {
+	return s.userRepo.FindByOrganizationIDs(ctx, orgIDs, limit, offset)
+}
+
+// LoadOrganizations sets the organization of each user, loading them all in one query
+func (s *userService) LoadOrganizations(ctx context.Context, users []models.User) error {
+	var orgIDs []uuid.UUID
+	seen := make(map[uuid.UUID]bool)
+	for _, user := range users {
+		if !seen[user.OrganizationID] {
+			seen[user.OrganizationID] = true
+			orgIDs = append(orgIDs, user.OrganizationID)
+		}
+	}
+	if len(orgIDs) == 0 {
+		return nil
 	}
 
-	updatedUser, err := req.ToDomain(user.ID)
+	orgs, err := s.orgRepo.FindByIDs(ctx, orgIDs)
 	if err != nil {
+		return err
+	}
+	byID := make(map[uuid.UUID]*models.Organization, len(orgs))
+	for i := range orgs {
+		byID[orgs[i].ID] = &orgs[i]
+	}
+	for i := range users {
+		if org, ok := byID[users[i].OrganizationID]; ok {
+			users[i].Organization = *org
+		}
+	}
+	return nil
+}
+
+// Update changes the fields of a user set in req. A new password ends every session
+// of the user, so that whoever changed it is the only one to know it is logged in.
+func (s *userService) Update(ctx context.Context, id uuid.UUID, req *models.UpdateUserRequest) (*models.User, error) {
+	updatedUser := req.ToDomain(id)
+
+	found := false
+	err := s.txManager.WithinTransaction(ctx, func(ctx context.Context) error {
+		user, err := s.userRepo.FindByID(ctx, id)
+		if err != nil || user == nil {
+			return err
+		}
+		found = true
+
+		if err := authorizeUserChange(ctx, user); err != nil {
+			return err
+		}
+		if err := authorizeAdminChange(ctx, req.IsAdmin, user.IsAdmin); err != nil {
+			return err
+		}
+
+		if req.Password != nil {
+			email, name := user.Email, user.Name
+			if req.Email != nil {
+				email = *req.Email
+			}
+			if req.Name != nil {
+				name = *req.Name
+			}
+			if err := s.passwordPolicy.Check(*req.Password, email, name); err != nil {
+				return err
+			}
+			if updatedUser.Password, err = s.passwordHasher.Hash(*req.Password); err != nil {
+				return err
+			}
+			if _, err := s.sessionRepo.RevokeAllForUser(ctx, id); err != nil {
+				return err
+			}
+		}
+
+		if req.OrganizationID != nil && *req.OrganizationID != user.OrganizationID {
+			if err := s.lockOrganization(ctx, *req.OrganizationID); err != nil {
+				return err
+			}
+		}
+
+		// A new email address only replaces the current one once it is verified
+		if req.Email != nil && !strings.EqualFold(*req.Email, user.Email) {
+			existing, err := s.userRepo.FindByEmail(ctx, *req.Email)
+			if err != nil {
+				return err
+			}
+			if existing != nil {
+				return ErrEmailTaken
+			}
+			updatedUser.Email = ""
+			updatedUser.PendingEmail = req.Email
+		}
+
+		if err := s.userRepo.Update(ctx, updatedUser); err != nil {
+			return err
+		}
+		if err := archiveUser(ctx, s.historyRepo, user, updatedUser, AuditActionUpdate); err != nil {
+			return err
+		}
+		return s.auditService.Record(ctx, AuditActionUpdate, EntityUser, id, userSnapshot(user), userSnapshot(updatedUser))
+	})
+	if err != nil || !found {
 		return nil, err
 	}
 
-	return updatedUser, s.userRepo.Update(ctx, updatedUser)
+	if req.Email != nil && updatedUser.PendingEmail != nil && strings.EqualFold(*updatedUser.PendingEmail, *req.Email) {
+		if err := s.authService.SendEmailVerification(ctx, updatedUser, *req.Email); err != nil {
+			log.Printf("Failed to send verification email to user %s: %v", id, err)
+		}
+	}
+	return updatedUser, nil
+}
+
+// RemoveAvatar clears the avatar of a user, unless it was changed from url in the meantime
+func (s *userService) RemoveAvatar(ctx context.Context, id uuid.UUID, url string) error {
+	return s.txManager.WithinTransaction(ctx, func(ctx context.Context) error {
+		user, err := s.userRepo.FindByID(ctx, id)
+		if err != nil || user == nil {
+			return err
+		}
+
+		removed, err := s.userRepo.ClearAvatarURL(ctx, id, url)
+		if err != nil || !removed {
+			return err
+		}
+		updatedUser := *user
+		updatedUser.AvatarURL = nil
+		if err := archiveUser(ctx, s.historyRepo, user, &updatedUser, AuditActionUpdate); err != nil {
+			return err
+		}
+		return s.auditService.Record(ctx, AuditActionUpdate, EntityUser, id, userSnapshot(user), userSnapshot(&updatedUser))
+	})
 }
 
 func (s *userService) Delete(ctx context.Context, id uuid.UUID) error {
-	return s.userRepo.Delete(ctx, id)
+	return s.txManager.WithinTransaction(ctx, func(ctx context.Context) error {
+		user, err := s.userRepo.FindByID(ctx, id)
+		if err != nil {
+			return err
+		}
+		if user == nil {
+			return errors.New("user not found")
+		}
+		if err := authorizeUserChange(ctx, user); err != nil {
+			return err
+		}
+
+		if err := archiveUser(ctx, s.historyRepo, user, nil, AuditActionDelete); err != nil {
+			return err
+		}
+		if err := s.userRepo.Delete(ctx, id); err != nil {
+			return err
+		}
+		return s.auditService.Record(ctx, AuditActionDelete, EntityUser, id, userSnapshot(user), nil)
+	})
 }
 
 // SearchUsers searches users by name or email
 func (s *userService) SearchUsers(ctx context.Context, query string, limit int) ([]models.User, error) {
 	return s.userRepo.Search(ctx, query, limit)
 }
+
+// BulkUsers runs a list of create, update and delete operations. Creates are inserted
+// first with a single batched insert, then updates and deletes run in request order.
+// In atomic mode everything runs in one transaction and the first failure rolls back
+// all operations; otherwise each operation succeeds or fails on its own.
+func (s *userService) BulkUsers(ctx context.Context, ops []models.BulkUserOperation, atomic bool) ([]models.BulkUserResult, error) {
+	results := make([]models.BulkUserResult, len(ops))
+	s.prepareBulkCreates(ctx, ops, results)
+
+	run := func(ctx context.Context) error {
+		if err := s.bulkCreate(ctx, ops, results, atomic); err != nil {
+			return err
+		}
+
+		for i, op := range ops {
+			if op.Op == "create" {
+				continue
+			}
+			results[i].ID = *op.ID
+
+			switch op.Op {
+			case "update":
+				user, err := s.Update(ctx, *op.ID, op.Update)
+				if err == nil && user == nil {
+					err = errors.New("user not found")
+				}
+				results[i].User, results[i].Err = user, err
+			case "delete":
+				results[i].Err = s.Delete(ctx, *op.ID)
+			}
+			if results[i].Err != nil && atomic {
+				return results[i].Err
+			}
+		}
+		return nil
+	}
+
+	if !atomic {
+		return results, run(ctx)
+	}
+
+	if err := s.txManager.WithinTransaction(ctx, run); err != nil {
+		for i := range results {
+			if results[i].Err == nil {
+				results[i].Err = ErrBulkAborted
+			}
+		}
+		return results, err
+	}
+	return results, nil
+}
+
+// prepareBulkCreates converts the create operations to domain users
+func (s *userService) prepareBulkCreates(ctx context.Context, ops []models.BulkUserOperation, results []models.BulkUserResult) {
+	var reqs []*models.CreateUserRequest
+	var indexes []int
+	for i, op := range ops {
+		if op.Op == "create" {
+			reqs = append(reqs, op.Create)
+			indexes = append(indexes, i)
+		}
+	}
+
+	users, errs := s.usersFromRequests(ctx, reqs)
+	for j, i := range indexes {
+		results[i].User, results[i].Err = users[j], errs[j]
+	}
+}
+
+// userFromRequest checks that the actor may create the user of a create request and its
+// password against the password policy, and converts the request to a domain user
+// with the hash of the password
+func (s *userService) userFromRequest(ctx context.Context, req *models.CreateUserRequest) (*models.User, error) {
+	if err := authorizeAdminChange(ctx, req.IsAdmin, false); err != nil {
+		return nil, err
+	}
+	if err := s.passwordPolicy.Check(req.Password, req.Email, req.Name); err != nil {
+		return nil, err
+	}
+	passwordHash, err := s.passwordHasher.Hash(req.Password)
+	if err != nil {
+		return nil, err
+	}
+	user := req.ToDomain()
+	user.Password = passwordHash
+	return user, nil
+}
+
+// usersFromRequests converts create requests to domain users like userFromRequest,
+// hashing passwords concurrently since hashing dominates the cost of a large batch
+func (s *userService) usersFromRequests(ctx context.Context, reqs []*models.CreateUserRequest) ([]*models.User, []error) {
+	users := make([]*models.User, len(reqs))
+	errs := make([]error, len(reqs))
+
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, runtime.NumCPU())
+	for i, req := range reqs {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func() {
+			defer wg.Done()
+			defer func() { <-sem }()
+			users[i], errs[i] = s.userFromRequest(ctx, req)
+		}()
+	}
+	wg.Wait()
+
+	return users, errs
+}
+
+// authorizeUserChange checks that the actor of ctx may change or delete user. Users may
+// change themselves, and admins who logged in with a second factor anyone. API keys,
+// which admins create, may change the users of their organization if they were
+// granted users:write.
+func authorizeUserChange(ctx context.Context, user *models.User) error {
+	actor := auth.FromContext(ctx)
+	switch {
+	case actor.UserID == nil && actor.APIKeyID == nil:
+		return ErrAuthenticationRequired
+	case actor.UserID != nil && *actor.UserID == user.ID, actor.IsVerifiedAdmin():
+		return nil
+	case actor.APIKeyID != nil && actor.HasPermission(auth.PermissionUsersWrite) && actor.InOrganizationScope(user.OrganizationID):
+		return nil
+	}
+	return ErrUserAccessDenied
+}
+
+// authorizeAdminChange checks that the actor of ctx may set the admin access of a user
+// who has current to isAdmin, nil leaving it unchanged. Only admins who logged in with
+// a second factor may grant or change admin access; anyone else, including anonymous
+// sign-ups and API keys, gets ErrAdminRequired.
+func authorizeAdminChange(ctx context.Context, isAdmin *bool, current bool) error {
+	if isAdmin == nil || *isAdmin == current || auth.FromContext(ctx).IsVerifiedAdmin() {
+		return nil
+	}
+	return ErrAdminRequired
+}
+
+// importedProfile returns the changes an import row makes to the existing user with
+// the given ID: its profile only. Imports never change the password, admin access,
+// email address or organization of a user.
+func importedProfile(id uuid.UUID, user *models.User) *models.User {
+	return &models.User{
+		ID:                 id,
+		Name:               user.Name,
+		PhoneNumber:        user.PhoneNumber,
+		SocialMedia:        user.SocialMedia,
+		Description:        user.Description,
+		AvatarURL:          user.AvatarURL,
+		ResearchCategories: user.ResearchCategories,
+	}
+}
+
+// bulkCreate verifies the organizations of the prepared users and inserts them in one batch.
+// The organizations stay locked by the transaction inserting their users. In best-effort
+// mode a failed batch is retried row by row so one bad row does not fail the rest.
+func (s *userService) bulkCreate(ctx context.Context, ops []models.BulkUserOperation, results []models.BulkUserResult, atomic bool) error {
+	var pending []int
+	for i, op := range ops {
+		if op.Op != "create" {
+			continue
+		}
+		if results[i].Err != nil {
+			if atomic {
+				return results[i].Err
+			}
+			continue
+		}
+		pending = append(pending, i)
+	}
+
+	var indexes []int
+	err := s.txManager.WithinTransaction(ctx, func(ctx context.Context) error {
+		orgErrs := make(map[uuid.UUID]error)
+		var users []*models.User
+		for _, i := range pending {
+			orgID := results[i].User.OrganizationID
+			if _, ok := orgErrs[orgID]; !ok {
+				orgErrs[orgID] = s.lockOrganization(ctx, orgID)
+			}
+			if results[i].Err = orgErrs[orgID]; results[i].Err != nil {
+				if atomic {
+					return results[i].Err
+				}
+				continue
+			}
+			users = append(users, results[i].User)
+			indexes = append(indexes, i)
+		}
+
+		if err := s.userRepo.CreateBatch(ctx, users); err != nil {
+			return err
+		}
+		for _, user := range users {
+			if err := s.auditService.Record(ctx, AuditActionCreate, EntityUser, user.ID, nil, userSnapshot(user)); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err == nil {
+		for _, i := range indexes {
+			results[i].ID = results[i].User.ID
+		}
+		return nil
+	}
+	if atomic {
+		for _, i := range indexes {
+			results[i].Err = err
+		}
+		return err
+	}
+
+	for _, i := range indexes {
+		user := results[i].User
+		user.ID = uuid.Nil
+		results[i].Err = s.txManager.WithinTransaction(ctx, func(ctx context.Context) error {
+			if err := s.lockOrganization(ctx, user.OrganizationID); err != nil {
+				return err
+			}
+			if err := s.userRepo.Create(ctx, user); err != nil {
+				return err
+			}
+			return s.auditService.Record(ctx, AuditActionCreate, EntityUser, user.ID, nil, userSnapshot(user))
+		})
+		if results[i].Err == nil {
+			results[i].ID = user.ID
+		}
+	}
+	return nil
+}
+
+// ImportUsers upserts users using the email as natural key. Rows matching an existing
+// user only update its profile, see importedProfile. Each row is applied in its own
+// transaction so a failing row does not affect the others. In dry-run mode only the
+// outcome is reported and nothing is written.
+func (s *userService) ImportUsers(ctx context.Context, reqs []*models.CreateUserRequest, dryRun bool) []models.ImportResult {
+	results := make([]models.ImportResult, len(reqs))
+
+	var users []*models.User
+	if dryRun {
+		for i, req := range reqs {
+			if results[i].Err = authorizeAdminChange(ctx, req.IsAdmin, false); results[i].Err == nil {
+				results[i].Err = s.passwordPolicy.Check(req.Password, req.Email, req.Name)
+			}
+		}
+	} else {
+		var errs []error
+		users, errs = s.usersFromRequests(ctx, reqs)
+		for i, err := range errs {
+			results[i].Err = err
+		}
+	}
+
+	for i, req := range reqs {
+		if results[i].Err != nil {
+			continue
+		}
+		results[i].Err = s.txManager.WithinTransaction(ctx, func(ctx context.Context) error {
+			if err := s.lockOrganization(ctx, req.OrganizationID); err != nil {
+				return err
+			}
+
+			existing, err := s.userRepo.FindByEmail(ctx, req.Email)
+			if err != nil {
+				return err
+			}
+
+			results[i].Created = existing == nil
+			if existing != nil {
+				results[i].ID = existing.ID
+				if existing.OrganizationID != req.OrganizationID {
+					return ErrImportOtherOrganization
+				}
+			}
+			if dryRun {
+				return nil
+			}
+
+			user := users[i]
+			if existing == nil {
+				if err := s.userRepo.Create(ctx, user); err != nil {
+					return err
+				}
+				results[i].ID = user.ID
+				return s.auditService.Record(ctx, AuditActionCreate, EntityUser, user.ID, nil, userSnapshot(user))
+			}
+
+			profile := importedProfile(existing.ID, user)
+			if err := s.userRepo.Update(ctx, profile); err != nil {
+				return err
+			}
+			if err := archiveUser(ctx, s.historyRepo, existing, profile, AuditActionUpdate); err != nil {
+				return err
+			}
+			return s.auditService.Record(ctx, AuditActionUpdate, EntityUser, profile.ID, userSnapshot(existing), userSnapshot(profile))
+		})
+	}
+
+	return results
+}
@@ -0,0 +1,233 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/hoshina-dev/custapi/internal/auth"
+	"github.com/hoshina-dev/custapi/internal/models"
+	"github.com/hoshina-dev/custapi/internal/password"
+	"github.com/hoshina-dev/custapi/internal/repositories"
+	"golang.org/x/crypto/bcrypt"
+)
+
+func TestAuthorizeAdminChange(t *testing.T) {
+	userID, keyID := uuid.New(), uuid.New()
+	verifiedAdmin := &auth.Actor{UserID: &userID, IsAdmin: true, TwoFactorEnabled: true, TwoFactorVerified: true}
+	unverifiedAdmin := &auth.Actor{UserID: &userID, IsAdmin: true, TwoFactorEnabled: true}
+	user := &auth.Actor{UserID: &userID, TwoFactorEnabled: true, TwoFactorVerified: true}
+	apiKey := &auth.Actor{APIKeyID: &keyID, IsAdmin: true, TwoFactorVerified: true}
+	yes, no := true, false
+
+	tests := []struct {
+		name    string
+		actor   *auth.Actor
+		isAdmin *bool
+		current bool
+		wantErr error
+	}{
+		{"anonymous without is_admin", nil, nil, false, nil},
+		{"anonymous keeping is_admin false", nil, &no, false, nil},
+		{"anonymous granting admin", nil, &yes, false, ErrAdminRequired},
+		{"user granting admin", user, &yes, false, ErrAdminRequired},
+		{"user revoking admin", user, &no, true, ErrAdminRequired},
+		{"user keeping admin unchanged", user, &yes, true, nil},
+		{"admin without second factor", unverifiedAdmin, &yes, false, ErrAdminRequired},
+		{"API key granting admin", apiKey, &yes, false, ErrAdminRequired},
+		{"verified admin granting admin", verifiedAdmin, &yes, false, nil},
+		{"verified admin revoking admin", verifiedAdmin, &no, true, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			if tt.actor != nil {
+				ctx = auth.NewContext(ctx, tt.actor)
+			}
+			if err := authorizeAdminChange(ctx, tt.isAdmin, tt.current); !errors.Is(err, tt.wantErr) {
+				t.Errorf("authorizeAdminChange() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestAuthorizeUserChange(t *testing.T) {
+	orgID, otherOrgID := uuid.New(), uuid.New()
+	target := &models.User{ID: uuid.New(), OrganizationID: orgID}
+	otherID, keyID := uuid.New(), uuid.New()
+
+	tests := []struct {
+		name    string
+		actor   *auth.Actor
+		wantErr error
+	}{
+		{"anonymous", nil, ErrAuthenticationRequired},
+		{"the user themselves", &auth.Actor{UserID: &target.ID}, nil},
+		{"another user", &auth.Actor{UserID: &otherID}, ErrUserAccessDenied},
+		{"admin without second factor", &auth.Actor{UserID: &otherID, IsAdmin: true, TwoFactorEnabled: true}, ErrUserAccessDenied},
+		{"verified admin", &auth.Actor{UserID: &otherID, IsAdmin: true, TwoFactorEnabled: true, TwoFactorVerified: true}, nil},
+		{"API key of the organization", &auth.Actor{APIKeyID: &keyID, OrganizationScope: &orgID, Permissions: []string{auth.PermissionUsersWrite}}, nil},
+		{"API key without users:write", &auth.Actor{APIKeyID: &keyID, OrganizationScope: &orgID, Permissions: []string{auth.PermissionUsersRead}}, ErrUserAccessDenied},
+		{"API key of another organization", &auth.Actor{APIKeyID: &keyID, OrganizationScope: &otherOrgID, Permissions: []string{auth.PermissionUsersWrite}}, ErrUserAccessDenied},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			if tt.actor != nil {
+				ctx = auth.NewContext(ctx, tt.actor)
+			}
+			if err := authorizeUserChange(ctx, target); !errors.Is(err, tt.wantErr) {
+				t.Errorf("authorizeUserChange() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// fakeTxManager runs fn directly
+type fakeTxManager struct{}
+
+func (fakeTxManager) WithinTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	return fn(ctx)
+}
+
+// fakeUserRepository serves one user and keeps the updates made to it
+type fakeUserRepository struct {
+	repositories.UserRepository
+	user    *models.User
+	updates []*models.User
+}
+
+func (r *fakeUserRepository) FindByID(ctx context.Context, id uuid.UUID) (*models.User, error) {
+	if id != r.user.ID {
+		return nil, nil
+	}
+	return r.user, nil
+}
+
+func (r *fakeUserRepository) Update(ctx context.Context, user *models.User) error {
+	r.updates = append(r.updates, user)
+	return nil
+}
+
+// fakeSessionRepository records whose sessions were revoked
+type fakeSessionRepository struct {
+	repositories.SessionRepository
+	revoked []uuid.UUID
+}
+
+func (r *fakeSessionRepository) RevokeAllForUser(ctx context.Context, userID uuid.UUID) (int64, error) {
+	r.revoked = append(r.revoked, userID)
+	return 1, nil
+}
+
+// fakeAuditService ignores the events it is asked to record
+type fakeAuditService struct {
+	AuditService
+}
+
+func (fakeAuditService) Record(ctx context.Context, action, entityType string, entityID uuid.UUID, before, after map[string]any) error {
+	return nil
+}
+
+func TestUpdate(t *testing.T) {
+	policy, err := password.NewPolicy(password.Config{MinLength: 8, MaxLength: 72})
+	if err != nil {
+		t.Fatalf("password.NewPolicy() error = %v", err)
+	}
+	hasher, err := password.NewHasher(password.HashConfig{Algorithm: "bcrypt", BcryptCost: bcrypt.MinCost})
+	if err != nil {
+		t.Fatalf("password.NewHasher() error = %v", err)
+	}
+	user := &models.User{ID: uuid.New(), Email: "ada@example.com", Name: "Ada"}
+	otherID := uuid.New()
+	newName, newPassword := "Ada Lovelace", "correct horse battery"
+
+	tests := []struct {
+		name        string
+		actor       uuid.UUID
+		req         *models.UpdateUserRequest
+		wantErr     error
+		wantRevoked bool
+	}{
+		{"profile change keeps sessions", user.ID, &models.UpdateUserRequest{Name: &newName}, nil, false},
+		{"password change ends sessions", user.ID, &models.UpdateUserRequest{Password: &newPassword}, nil, true},
+		{"another user", otherID, &models.UpdateUserRequest{Password: &newPassword}, ErrUserAccessDenied, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			userRepo := &fakeUserRepository{user: user}
+			sessionRepo := &fakeSessionRepository{}
+			service := NewUserService(fakeTxManager{}, userRepo, &fakeUserHistoryRepository{}, sessionRepo, nil,
+				fakeAuditService{}, policy, hasher, nil)
+
+			ctx := auth.NewContext(context.Background(), &auth.Actor{UserID: &tt.actor})
+			if _, err := service.Update(ctx, user.ID, tt.req); !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Update() error = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr != nil && len(userRepo.updates) > 0 {
+				t.Error("Update() changed the user")
+			}
+			if revoked := slices.Contains(sessionRepo.revoked, user.ID); revoked != tt.wantRevoked {
+				t.Errorf("revoked sessions = %v, want %v", revoked, tt.wantRevoked)
+			}
+		})
+	}
+}
+
+// fakeUserHistoryRepository keeps the versions it is asked to create
+type fakeUserHistoryRepository struct {
+	repositories.UserHistoryRepository
+	versions []*models.UserHistory
+}
+
+func (r *fakeUserHistoryRepository) Create(ctx context.Context, version *models.UserHistory) error {
+	r.versions = append(r.versions, version)
+	return nil
+}
+
+func TestArchiveUser(t *testing.T) {
+	actorID := uuid.New()
+	user := &models.User{ID: uuid.New(), Email: "ada@example.com", Name: "Ada", Password: "old hash"}
+	renamed, newPassword := *user, *user
+	renamed.Name = "Ada Lovelace"
+	newPassword.Password = "new hash"
+
+	tests := []struct {
+		name      string
+		updated   *models.User
+		operation string
+		archived  bool
+	}{
+		{"update of a kept field", &renamed, AuditActionUpdate, true},
+		{"update of the password only", &newPassword, AuditActionUpdate, false},
+		{"delete", nil, AuditActionDelete, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &fakeUserHistoryRepository{}
+			ctx := auth.NewContext(context.Background(), &auth.Actor{UserID: &actorID})
+			if err := archiveUser(ctx, repo, user, tt.updated, tt.operation); err != nil {
+				t.Fatalf("archiveUser() error = %v", err)
+			}
+
+			if !tt.archived {
+				if len(repo.versions) != 0 {
+					t.Errorf("archived %d versions, want none", len(repo.versions))
+				}
+				return
+			}
+			if len(repo.versions) != 1 {
+				t.Fatalf("archived %d versions, want 1", len(repo.versions))
+			}
+			version := repo.versions[0]
+			if version.UserID != user.ID || version.Name != user.Name || version.Operation != tt.operation {
+				t.Errorf("archived %+v, want the version of %s ended by %s", version, user.ID, tt.operation)
+			}
+			if version.ChangedBy == nil || *version.ChangedBy != actorID {
+				t.Errorf("changed by %v, want %s", version.ChangedBy, actorID)
+			}
+		})
+	}
+}
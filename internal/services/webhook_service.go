@@ -0,0 +1,111 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+
+	"github.com/google/uuid"
+	"github.com/hoshina-dev/custapi/internal/models"
+	"github.com/hoshina-dev/custapi/internal/repositories"
+)
+
+// WebhookService manages webhook subscriptions and queues deliveries of domain events
+type WebhookService interface {
+	EventPublisher
+	CreateWebhook(ctx context.Context, req *models.CreateWebhookRequest) (*models.WebhookSubscription, error)
+	ListWebhooks(ctx context.Context) ([]models.WebhookSubscription, error)
+	DeleteWebhook(ctx context.Context, id uuid.UUID) error
+	ListDeliveries(ctx context.Context, id uuid.UUID, status string, limit int) ([]models.WebhookDelivery, error)
+	RetryDelivery(ctx context.Context, id, deliveryID uuid.UUID) error
+}
+
+// webhookService is the concrete implementation of WebhookService
+type webhookService struct {
+	webhookRepo repositories.WebhookRepository
+}
+
+// NewWebhookService creates a new webhook service
+func NewWebhookService(webhookRepo repositories.WebhookRepository) WebhookService {
+	return &webhookService{
+		webhookRepo: webhookRepo,
+	}
+}
+
+// CreateWebhook creates a webhook subscription, generating a secret if none was given
+func (s *webhookService) CreateWebhook(ctx context.Context, req *models.CreateWebhookRequest) (*models.WebhookSubscription, error) {
+	if err := checkEventFilters(req.Events); err != nil {
+		return nil, err
+	}
+
+	var secret string
+	if req.Secret != nil {
+		secret = *req.Secret
+	} else {
+		b := make([]byte, 32)
+		if _, err := rand.Read(b); err != nil {
+			return nil, err
+		}
+		secret = hex.EncodeToString(b)
+	}
+
+	sub := req.ToDomain(secret)
+	if err := s.webhookRepo.CreateSubscription(ctx, sub); err != nil {
+		return nil, err
+	}
+	return sub, nil
+}
+
+// ListWebhooks retrieves all webhook subscriptions
+func (s *webhookService) ListWebhooks(ctx context.Context) ([]models.WebhookSubscription, error) {
+	return s.webhookRepo.FindAllSubscriptions(ctx)
+}
+
+// DeleteWebhook deletes a webhook subscription. Its pending deliveries are still attempted.
+func (s *webhookService) DeleteWebhook(ctx context.Context, id uuid.UUID) error {
+	return s.webhookRepo.DeleteSubscription(ctx, id)
+}
+
+// ListDeliveries retrieves the delivery log of a webhook subscription
+func (s *webhookService) ListDeliveries(ctx context.Context, id uuid.UUID, status string, limit int) ([]models.WebhookDelivery, error) {
+	sub, err := s.webhookRepo.FindSubscriptionByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if sub == nil {
+		return nil, errors.New("webhook not found")
+	}
+	return s.webhookRepo.FindDeliveries(ctx, id, status, limit)
+}
+
+// RetryDelivery queues a delivery again, typically one that ended up dead
+func (s *webhookService) RetryDelivery(ctx context.Context, id, deliveryID uuid.UUID) error {
+	return s.webhookRepo.RetryDelivery(ctx, id, deliveryID)
+}
+
+// Publish queues a delivery of each event to every active subscription that matches it.
+// It is the webhook sink of the outbox relay, so the same event may arrive more than once.
+func (s *webhookService) Publish(ctx context.Context, events ...models.DomainEvent) error {
+	subs, err := s.webhookRepo.FindActiveSubscriptions(ctx)
+	if err != nil || len(subs) == 0 {
+		return err
+	}
+
+	var deliveries []*models.WebhookDelivery
+	for i := range events {
+		for j := range subs {
+			if !subs[j].Matches(events[i].Type) {
+				continue
+			}
+			deliveries = append(deliveries, &models.WebhookDelivery{
+				SubscriptionID: subs[j].ID,
+				EventID:        events[i].ID,
+				EventType:      events[i].Type,
+				Payload:        events[i].Payload(),
+				Status:         models.DeliveryPending,
+			})
+		}
+	}
+	return s.webhookRepo.CreateDeliveries(ctx, deliveries)
+}
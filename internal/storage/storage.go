@@ -0,0 +1,21 @@
+// Package storage keeps uploaded blobs, such as avatars and organization images, in
+// a local directory or an S3-compatible bucket and hands out their public URLs.
+package storage
+
+import (
+	"context"
+	"strings"
+)
+
+// Storage stores blobs under slash separated keys
+type Storage interface {
+	// Put stores data under key, replacing any existing blob, and returns its public URL
+	Put(ctx context.Context, key string, data []byte, contentType string) (string, error)
+	// Delete removes the blob stored under key. Deleting a missing blob is not an error.
+	Delete(ctx context.Context, key string) error
+}
+
+// publicURL joins the public base URL of a storage and a key
+func publicURL(baseURL, key string) string {
+	return strings.TrimSuffix(baseURL, "/") + "/" + key
+}
@@ -0,0 +1,239 @@
+// Package stream broadcasts committed domain events to live subscribers
+package stream
+
+import (
+	"context"
+	"errors"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hoshina-dev/custapi/internal/models"
+	"github.com/hoshina-dev/custapi/internal/repositories"
+	"github.com/lib/pq"
+)
+
+// Channel is the Postgres notification channel carrying the IDs of new outbox events
+const Channel = "outbox_events"
+
+// subscriberBuffer is how many events a subscriber may lag behind before it is dropped
+const subscriberBuffer = 64
+
+// maxListenBackoff caps the delay between attempts to listen for notifications
+const maxListenBackoff = time.Minute
+
+// ErrUnavailable is returned to new subscribers while the hub is not listening for
+// notifications, as they would miss events
+var ErrUnavailable = errors.New("live events are unavailable")
+
+// Hub listens for outbox notifications and fans the events out to subscribers. Every
+// replica runs its own hub, so a change made through any replica reaches all streams.
+// The most recent events are kept so reconnecting clients can resume where they left off.
+type Hub struct {
+	repo       repositories.OutboxRepository
+	bufferSize int
+
+	mu          sync.Mutex
+	buffer      []models.OutboxEvent
+	subscribers map[*subscriber]struct{}
+	// listening is set once the channel is listened on, connected while the
+	// listener's connection is up
+	listening bool
+	connected bool
+}
+
+type subscriber struct {
+	events chan models.OutboxEvent
+}
+
+// NewHub creates a hub keeping the last bufferSize events for replay
+func NewHub(repo repositories.OutboxRepository, bufferSize int) *Hub {
+	return &Hub{
+		repo:        repo,
+		bufferSize:  bufferSize,
+		subscribers: make(map[*subscriber]struct{}),
+	}
+}
+
+// Run listens on the database at dsn until ctx is cancelled, then closes all
+// subscriptions. Failures to listen are retried with backoff. Subscriptions are refused
+// until the hub listens, and closed whenever the connection is lost so that clients
+// reconnect and resume from the replay buffer.
+func (h *Hub) Run(ctx context.Context, dsn string) {
+	defer h.closeAll()
+
+	listener := pq.NewListener(dsn, time.Second, time.Minute, func(ev pq.ListenerEventType, err error) {
+		if err != nil {
+			log.Printf("stream: listener: %v", err)
+		}
+		switch ev {
+		case pq.ListenerEventConnected, pq.ListenerEventReconnected:
+			h.setConnected(true)
+		case pq.ListenerEventDisconnected:
+			// Notifications sent until the listener reconnects are lost
+			h.setConnected(false)
+		}
+	})
+	defer listener.Close()
+	// Listen waits for a connection, which closing the listener gives up on
+	stop := context.AfterFunc(ctx, func() { _ = listener.Close() })
+	defer stop()
+
+	for delay := time.Second; ; delay = min(2*delay, maxListenBackoff) {
+		err := listener.Listen(Channel)
+		if err == nil {
+			break
+		}
+		if ctx.Err() != nil {
+			return
+		}
+		log.Printf("stream: listen: %v, retrying in %s", err, delay)
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(delay):
+		}
+	}
+	h.mu.Lock()
+	h.listening = true
+	h.mu.Unlock()
+
+	ping := time.NewTicker(time.Minute)
+	defer ping.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case n := <-listener.Notify:
+			// A nil notification signals a reconnect, after which events may have been missed
+			if n == nil {
+				continue
+			}
+			h.handle(ctx, n.Extra)
+		case <-ping.C:
+			go func() { _ = listener.Ping() }()
+		}
+	}
+}
+
+// Available reports whether the hub is listening for notifications and takes subscribers
+func (h *Hub) Available() bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.listening && h.connected
+}
+
+// setConnected records whether the listener's connection is up. Losing it ends the
+// subscriptions, which would silently miss the events notified in the meantime.
+func (h *Hub) setConnected(connected bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.connected = connected
+	if !connected {
+		h.closeSubscribers()
+	}
+}
+
+// handle loads the notified event and broadcasts it
+func (h *Hub) handle(ctx context.Context, payload string) {
+	id, err := uuid.Parse(payload)
+	if err != nil {
+		log.Printf("stream: invalid notification %q", payload)
+		return
+	}
+	event, err := h.repo.FindByID(ctx, id)
+	if err != nil || event == nil {
+		log.Printf("stream: loading event %s: %v", id, err)
+		return
+	}
+	h.Broadcast(*event)
+}
+
+// Broadcast stores event in the replay buffer and sends it to every subscriber.
+// Subscribers too slow to keep up are dropped; they can resume with their last event ID.
+func (h *Hub) Broadcast(event models.OutboxEvent) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.buffer = append(h.buffer, event)
+	if len(h.buffer) > h.bufferSize {
+		h.buffer = h.buffer[len(h.buffer)-h.bufferSize:]
+	}
+
+	for sub := range h.subscribers {
+		select {
+		case sub.events <- event:
+		default:
+			delete(h.subscribers, sub)
+			close(sub.events)
+		}
+	}
+}
+
+// Subscribe registers a new subscriber. If lastID is not zero, the buffered events
+// received after it are returned for replay. The channel is closed when the hub stops,
+// loses its connection or the subscriber falls behind; cancel must be called once the
+// subscriber is done. While the hub is not listening, it returns ErrUnavailable.
+func (h *Hub) Subscribe(lastID int64) (replay []models.OutboxEvent, events <-chan models.OutboxEvent, cancel func(), err error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if !h.listening || !h.connected {
+		return nil, nil, nil, ErrUnavailable
+	}
+
+	if lastID != 0 {
+		replay = h.replayAfter(lastID)
+	}
+
+	sub := &subscriber{events: make(chan models.OutboxEvent, subscriberBuffer)}
+	h.subscribers[sub] = struct{}{}
+
+	cancel = func() {
+		h.mu.Lock()
+		defer h.mu.Unlock()
+		if _, ok := h.subscribers[sub]; ok {
+			delete(h.subscribers, sub)
+			close(sub.events)
+		}
+	}
+	return replay, sub.events, cancel, nil
+}
+
+// replayAfter returns the buffered events received after the one with sequence lastID.
+// Sequences are assigned when events are written, not committed, so the buffer is in
+// arrival order and is searched for lastID rather than compared against it.
+func (h *Hub) replayAfter(lastID int64) []models.OutboxEvent {
+	for i := len(h.buffer) - 1; i >= 0; i-- {
+		if h.buffer[i].Sequence == lastID {
+			return append([]models.OutboxEvent(nil), h.buffer[i+1:]...)
+		}
+	}
+
+	// The last event is no longer buffered; send everything newer than it
+	var replay []models.OutboxEvent
+	for _, event := range h.buffer {
+		if event.Sequence > lastID {
+			replay = append(replay, event)
+		}
+	}
+	return replay
+}
+
+func (h *Hub) closeAll() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.listening = false
+	h.closeSubscribers()
+}
+
+func (h *Hub) closeSubscribers() {
+	for sub := range h.subscribers {
+		delete(h.subscribers, sub)
+		close(sub.events)
+	}
+}
@@ -0,0 +1,107 @@
+package stream
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/hoshina-dev/custapi/internal/models"
+)
+
+// listeningHub returns a hub in the state of one listening on a live connection
+func listeningHub(bufferSize int) *Hub {
+	h := NewHub(nil, bufferSize)
+	h.listening = true
+	h.connected = true
+	return h
+}
+
+func TestHubSubscribeUnavailable(t *testing.T) {
+	tests := []struct {
+		name      string
+		listening bool
+		connected bool
+		wantErr   error
+	}{
+		{"not listening yet", false, true, ErrUnavailable},
+		{"connection lost", true, false, ErrUnavailable},
+		{"listening", true, true, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := NewHub(nil, 10)
+			h.listening, h.connected = tt.listening, tt.connected
+
+			_, _, cancel, err := h.Subscribe(0)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Subscribe() error = %v, want %v", err, tt.wantErr)
+			}
+			if h.Available() != (tt.wantErr == nil) {
+				t.Errorf("Available() = %v", h.Available())
+			}
+			if cancel != nil {
+				cancel()
+			}
+		})
+	}
+}
+
+func TestHubDisconnectClosesSubscriptions(t *testing.T) {
+	h := listeningHub(10)
+	_, events, cancel, err := h.Subscribe(0)
+	if err != nil {
+		t.Fatalf("Subscribe() error = %v", err)
+	}
+	defer cancel()
+
+	h.setConnected(false)
+	if _, ok := <-events; ok {
+		t.Error("subscription still open after the connection was lost")
+	}
+	if _, _, _, err := h.Subscribe(0); !errors.Is(err, ErrUnavailable) {
+		t.Errorf("Subscribe() while disconnected error = %v, want ErrUnavailable", err)
+	}
+
+	h.setConnected(true)
+	if _, _, cancel, err := h.Subscribe(0); err != nil {
+		t.Errorf("Subscribe() after reconnecting error = %v", err)
+	} else {
+		cancel()
+	}
+}
+
+func TestHubReplay(t *testing.T) {
+	h := listeningHub(3)
+	for seq := int64(1); seq <= 5; seq++ {
+		h.Broadcast(models.OutboxEvent{ID: uuid.New(), Sequence: seq})
+	}
+
+	tests := []struct {
+		name   string
+		lastID int64
+		want   []int64
+	}{
+		{"no last event", 0, nil},
+		{"buffered last event", 3, []int64{4, 5}},
+		{"latest event", 5, []int64{}},
+		{"last event no longer buffered", 1, []int64{3, 4, 5}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			replay, _, cancel, err := h.Subscribe(tt.lastID)
+			if err != nil {
+				t.Fatalf("Subscribe() error = %v", err)
+			}
+			defer cancel()
+
+			if len(replay) != len(tt.want) {
+				t.Fatalf("replayed %d events, want %v", len(replay), tt.want)
+			}
+			for i, event := range replay {
+				if event.Sequence != tt.want[i] {
+					t.Errorf("replay[%d] = %d, want %d", i, event.Sequence, tt.want[i])
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,173 @@
+// Package webhooks delivers queued webhook events to subscribers
+package webhooks
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/hoshina-dev/custapi/internal/models"
+	"github.com/hoshina-dev/custapi/internal/repositories"
+	"github.com/hoshina-dev/custapi/internal/safehttp"
+)
+
+// Headers sent with every delivery
+const (
+	HeaderEvent     = "X-Custapi-Event"
+	HeaderDelivery  = "X-Custapi-Delivery"
+	HeaderTimestamp = "X-Custapi-Timestamp"
+	HeaderSignature = "X-Custapi-Signature"
+)
+
+// Config controls how the worker polls and retries deliveries
+type Config struct {
+	PollInterval time.Duration
+	BatchSize    int
+	Timeout      time.Duration
+	MaxAttempts  int
+	BaseBackoff  time.Duration
+	MaxBackoff   time.Duration
+}
+
+// Worker sends pending webhook deliveries and schedules retries for failed ones
+type Worker struct {
+	repo   repositories.WebhookRepository
+	client *http.Client
+	cfg    Config
+}
+
+// NewWorker creates a delivery worker. A nil client uses safehttp.NewClient with
+// cfg.Timeout, since subscribers choose the URLs deliveries are sent to.
+func NewWorker(repo repositories.WebhookRepository, client *http.Client, cfg Config) *Worker {
+	if client == nil {
+		client = safehttp.NewClient(cfg.Timeout)
+	}
+	return &Worker{repo: repo, client: client, cfg: cfg}
+}
+
+// Sign returns the signature of a delivery: "sha256=" followed by the hex encoded
+// HMAC-SHA256 of the timestamp, a dot and the body, keyed with the subscription secret
+func Sign(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Run delivers due webhooks until ctx is cancelled
+func (w *Worker) Run(ctx context.Context) {
+	ticker := time.NewTicker(w.cfg.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		// Keep draining while full batches come back
+		for {
+			n, err := w.RunOnce(ctx)
+			if err != nil {
+				log.Printf("webhooks: %v", err)
+			}
+			if err != nil || n < w.cfg.BatchSize || ctx.Err() != nil {
+				break
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// RunOnce claims one batch of due deliveries, attempts them and returns how many were claimed
+func (w *Worker) RunOnce(ctx context.Context) (int, error) {
+	// Claimed deliveries are not retried by other workers before the lease expires
+	lease := w.cfg.Timeout + w.cfg.PollInterval
+	deliveries, err := w.repo.ClaimDueDeliveries(ctx, w.cfg.BatchSize, lease)
+	if err != nil {
+		return 0, err
+	}
+
+	for i := range deliveries {
+		w.attempt(ctx, &deliveries[i])
+		if err := w.repo.UpdateDelivery(context.WithoutCancel(ctx), &deliveries[i]); err != nil {
+			log.Printf("webhooks: saving delivery %s: %v", deliveries[i].ID, err)
+		}
+	}
+	return len(deliveries), nil
+}
+
+// attempt sends a delivery and records the outcome on it
+func (w *Worker) attempt(ctx context.Context, d *models.WebhookDelivery) {
+	d.Attempts++
+
+	status, err := w.send(ctx, d)
+	if status != 0 {
+		d.ResponseStatus = &status
+	}
+	if err == nil {
+		now := time.Now()
+		d.Status = models.DeliverySucceeded
+		d.DeliveredAt = &now
+		d.LastError = nil
+		return
+	}
+
+	msg := err.Error()
+	d.LastError = &msg
+	if d.Attempts >= w.cfg.MaxAttempts || d.Subscription.DeletedAt.Valid {
+		d.Status = models.DeliveryDead
+		return
+	}
+	d.NextAttemptAt = time.Now().Add(w.backoff(d.Attempts))
+}
+
+// send POSTs the payload to the subscriber and returns the response status
+func (w *Worker) send(ctx context.Context, d *models.WebhookDelivery) (int, error) {
+	body, err := json.Marshal(d.Payload)
+	if err != nil {
+		return 0, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, d.Subscription.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(HeaderEvent, d.EventType)
+	req.Header.Set(HeaderDelivery, d.ID.String())
+	req.Header.Set(HeaderTimestamp, timestamp)
+	req.Header.Set(HeaderSignature, Sign(d.Subscription.Secret, timestamp, body))
+
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+	return resp.StatusCode, nil
+}
+
+// backoff returns the delay before the next attempt, doubling after every failure
+func (w *Worker) backoff(attempts int) time.Duration {
+	delay := w.cfg.BaseBackoff
+	for i := 1; i < attempts && delay < w.cfg.MaxBackoff; i++ {
+		delay *= 2
+	}
+	return min(delay, w.cfg.MaxBackoff)
+}
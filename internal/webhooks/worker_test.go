@@ -0,0 +1,238 @@
+package webhooks
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hoshina-dev/custapi/internal/models"
+	"github.com/hoshina-dev/custapi/internal/repositories"
+	"github.com/hoshina-dev/custapi/internal/safehttp"
+	"gorm.io/gorm"
+)
+
+// fakeRepository hands out the given deliveries once and keeps the saved ones
+type fakeRepository struct {
+	repositories.WebhookRepository
+	due   []models.WebhookDelivery
+	saved []models.WebhookDelivery
+}
+
+func (r *fakeRepository) ClaimDueDeliveries(ctx context.Context, limit int, lease time.Duration) ([]models.WebhookDelivery, error) {
+	due := r.due
+	r.due = nil
+	return due, nil
+}
+
+func (r *fakeRepository) UpdateDelivery(ctx context.Context, delivery *models.WebhookDelivery) error {
+	r.saved = append(r.saved, *delivery)
+	return nil
+}
+
+// loopbackClient only connects to the 127.0.0.1 address httptest servers listen on
+func loopbackClient() *http.Client {
+	return safehttp.NewClientAllowing(time.Second, func(addr netip.Addr) bool {
+		return addr == netip.MustParseAddr("127.0.0.1")
+	})
+}
+
+func testConfig() Config {
+	return Config{
+		PollInterval: time.Second,
+		BatchSize:    10,
+		Timeout:      5 * time.Second,
+		MaxAttempts:  3,
+		BaseBackoff:  30 * time.Second,
+		MaxBackoff:   time.Hour,
+	}
+}
+
+func TestSign(t *testing.T) {
+	body := []byte(`{"type":"user.created"}`)
+	tests := []struct {
+		name      string
+		secret    string
+		timestamp string
+		want      string
+	}{
+		{"known vector", "whsec_test", "1700000000", "sha256=2309b3241c934edd598182cd8af8663e23a4ed93bae9e076fbd3e8df8202253b"},
+		{"other secret", "other", "1700000000", "sha256=989c47dc249ac863660d51529b3299fcf404ce593d36d98245138805a76962f2"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Sign(tt.secret, tt.timestamp, body); got != tt.want {
+				t.Errorf("Sign() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	if Sign("whsec_test", "1700000001", body) == tests[0].want {
+		t.Error("Sign() does not depend on the timestamp")
+	}
+}
+
+func TestWorkerRunOnce(t *testing.T) {
+	tests := []struct {
+		name         string
+		status       int
+		attempts     int
+		deleted      bool
+		wantStatus   string
+		wantAttempts int
+		wantRetry    bool
+	}{
+		{"delivered", http.StatusNoContent, 0, false, models.DeliverySucceeded, 1, false},
+		{"failure is retried", http.StatusInternalServerError, 0, false, models.DeliveryPending, 1, true},
+		{"client error is retried", http.StatusGone, 1, false, models.DeliveryPending, 2, true},
+		{"last attempt gives up", http.StatusInternalServerError, 2, false, models.DeliveryDead, 3, false},
+		{"deleted subscription gives up", http.StatusInternalServerError, 0, true, models.DeliveryDead, 1, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			const secret = "whsec_test"
+			delivery := models.WebhookDelivery{
+				ID:        uuid.New(),
+				EventType: "user.created",
+				Payload:   models.JSONMap{"type": "user.created"},
+				Status:    models.DeliveryPending,
+				Attempts:  tt.attempts,
+				Subscription: models.WebhookSubscription{
+					Secret: secret,
+				},
+			}
+			if tt.deleted {
+				delivery.Subscription.DeletedAt = gorm.DeletedAt{Time: time.Now(), Valid: true}
+			}
+
+			var received *http.Request
+			var body []byte
+			receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				received = r
+				body, _ = io.ReadAll(r.Body)
+				w.WriteHeader(tt.status)
+			}))
+			defer receiver.Close()
+			delivery.Subscription.URL = receiver.URL
+
+			repo := &fakeRepository{due: []models.WebhookDelivery{delivery}}
+			start := time.Now()
+			n, err := NewWorker(repo, loopbackClient(), testConfig()).RunOnce(context.Background())
+			if err != nil || n != 1 {
+				t.Fatalf("RunOnce() = %d, %v, want 1, nil", n, err)
+			}
+
+			if received == nil {
+				t.Fatal("receiver got no request")
+			}
+			if received.Method != http.MethodPost || received.Header.Get("Content-Type") != "application/json" {
+				t.Errorf("request = %s %s, want a JSON POST", received.Method, received.Header.Get("Content-Type"))
+			}
+			if got := received.Header.Get(HeaderEvent); got != "user.created" {
+				t.Errorf("%s = %q, want user.created", HeaderEvent, got)
+			}
+			if got := received.Header.Get(HeaderDelivery); got != delivery.ID.String() {
+				t.Errorf("%s = %q, want %s", HeaderDelivery, got, delivery.ID)
+			}
+			timestamp := received.Header.Get(HeaderTimestamp)
+			if unix, err := strconv.ParseInt(timestamp, 10, 64); err != nil || time.Since(time.Unix(unix, 0)) > time.Minute {
+				t.Errorf("%s = %q, want the current Unix time", HeaderTimestamp, timestamp)
+			}
+			if got, want := received.Header.Get(HeaderSignature), Sign(secret, timestamp, body); got != want {
+				t.Errorf("%s = %q, want %q", HeaderSignature, got, want)
+			}
+
+			if len(repo.saved) != 1 {
+				t.Fatalf("saved %d deliveries, want 1", len(repo.saved))
+			}
+			saved := repo.saved[0]
+			if saved.Status != tt.wantStatus || saved.Attempts != tt.wantAttempts {
+				t.Errorf("delivery = %s after %d attempts, want %s after %d", saved.Status, saved.Attempts, tt.wantStatus, tt.wantAttempts)
+			}
+			if saved.ResponseStatus == nil || *saved.ResponseStatus != tt.status {
+				t.Errorf("ResponseStatus = %v, want %d", saved.ResponseStatus, tt.status)
+			}
+			if (saved.Status == models.DeliverySucceeded) != (saved.DeliveredAt != nil) {
+				t.Errorf("DeliveredAt = %v for a %s delivery", saved.DeliveredAt, saved.Status)
+			}
+			if (saved.Status == models.DeliverySucceeded) != (saved.LastError == nil) {
+				t.Errorf("LastError = %v for a %s delivery", saved.LastError, saved.Status)
+			}
+			if tt.wantRetry {
+				want := start.Add(NewWorker(nil, nil, testConfig()).backoff(saved.Attempts))
+				if saved.NextAttemptAt.Before(want) || saved.NextAttemptAt.After(want.Add(time.Minute)) {
+					t.Errorf("NextAttemptAt = %v, want about %v", saved.NextAttemptAt, want)
+				}
+			}
+		})
+	}
+}
+
+func TestWorkerRunOnceUnreachable(t *testing.T) {
+	receiver := httptest.NewServer(http.NotFoundHandler())
+	url := receiver.URL
+	receiver.Close()
+
+	repo := &fakeRepository{due: []models.WebhookDelivery{{
+		ID:           uuid.New(),
+		Payload:      models.JSONMap{},
+		Status:       models.DeliveryPending,
+		Subscription: models.WebhookSubscription{URL: url},
+	}}}
+	if _, err := NewWorker(repo, loopbackClient(), testConfig()).RunOnce(context.Background()); err != nil {
+		t.Fatalf("RunOnce() error = %v", err)
+	}
+
+	saved := repo.saved[0]
+	if saved.Status != models.DeliveryPending || saved.LastError == nil || saved.ResponseStatus != nil {
+		t.Errorf("delivery = %s, error %v, status %v, want a pending retry without a status", saved.Status, saved.LastError, saved.ResponseStatus)
+	}
+}
+
+func TestWorkerRunOnceRefusesInternalAddresses(t *testing.T) {
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Error("delivery reached the internal server")
+	}))
+	defer receiver.Close()
+
+	repo := &fakeRepository{due: []models.WebhookDelivery{{
+		ID:           uuid.New(),
+		Payload:      models.JSONMap{},
+		Status:       models.DeliveryPending,
+		Subscription: models.WebhookSubscription{URL: receiver.URL},
+	}}}
+	if _, err := NewWorker(repo, nil, testConfig()).RunOnce(context.Background()); err != nil {
+		t.Fatalf("RunOnce() error = %v", err)
+	}
+
+	saved := repo.saved[0]
+	if saved.LastError == nil || !strings.Contains(*saved.LastError, "is not allowed") {
+		t.Errorf("LastError = %v, want the address refused", saved.LastError)
+	}
+}
+
+func TestWorkerBackoff(t *testing.T) {
+	w := NewWorker(nil, nil, testConfig())
+	tests := []struct {
+		attempts int
+		want     time.Duration
+	}{
+		{1, 30 * time.Second},
+		{2, time.Minute},
+		{3, 2 * time.Minute},
+		{7, 32 * time.Minute},
+		{8, time.Hour},
+		{50, time.Hour},
+	}
+	for _, tt := range tests {
+		if got := w.backoff(tt.attempts); got != tt.want {
+			t.Errorf("backoff(%d) = %v, want %v", tt.attempts, got, tt.want)
+		}
+	}
+}
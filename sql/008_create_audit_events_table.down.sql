@@ -0,0 +1,7 @@
+-- Migration: 008_create_audit_events_table
+-- Description: Rollback audit_events table creation
+
+DROP INDEX IF EXISTS idx_audit_events_created_at;
+DROP INDEX IF EXISTS idx_audit_events_actor_id;
+DROP INDEX IF EXISTS idx_audit_events_entity;
+DROP TABLE IF EXISTS audit_events;
@@ -0,0 +1,7 @@
+-- Migration: 009_create_history_tables
+-- Description: Rollback organization_history and user_history table creation
+
+DROP INDEX IF EXISTS idx_user_history_validity;
+DROP TABLE IF EXISTS user_history;
+DROP INDEX IF EXISTS idx_organization_history_validity;
+DROP TABLE IF EXISTS organization_history;
@@ -0,0 +1,42 @@
+-- Migration: 009_create_history_tables
+-- Description: Create organization_history and user_history tables keeping every past version of an organization or user
+
+CREATE TABLE IF NOT EXISTS organization_history (
+    history_id BIGSERIAL PRIMARY KEY,
+    organization_id UUID NOT NULL,
+    operation VARCHAR(16) NOT NULL,
+    name VARCHAR(255) NOT NULL,
+    latitude NUMERIC(10, 8) NOT NULL,
+    longitude NUMERIC(11, 8) NOT NULL,
+    address TEXT,
+    description TEXT,
+    image_urls TEXT[] NOT NULL DEFAULT '{}',
+    created_at TIMESTAMP WITH TIME ZONE,
+    valid_from TIMESTAMP WITH TIME ZONE NOT NULL,
+    valid_to TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    changed_by UUID
+);
+
+CREATE INDEX IF NOT EXISTS idx_organization_history_validity ON organization_history(organization_id, valid_from, valid_to);
+
+-- Passwords and second factor secrets are never copied to the history
+CREATE TABLE IF NOT EXISTS user_history (
+    history_id BIGSERIAL PRIMARY KEY,
+    user_id UUID NOT NULL,
+    operation VARCHAR(16) NOT NULL,
+    email VARCHAR(255) NOT NULL,
+    name VARCHAR(255) NOT NULL,
+    organization_id UUID NOT NULL,
+    is_admin BOOLEAN NOT NULL,
+    phone_number VARCHAR(25),
+    social_media TEXT,
+    description TEXT,
+    avatar_url TEXT,
+    research_categories TEXT[] NOT NULL DEFAULT '{}',
+    created_at TIMESTAMP WITH TIME ZONE,
+    valid_from TIMESTAMP WITH TIME ZONE NOT NULL,
+    valid_to TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    changed_by UUID
+);
+
+CREATE INDEX IF NOT EXISTS idx_user_history_validity ON user_history(user_id, valid_from, valid_to);
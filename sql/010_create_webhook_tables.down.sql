@@ -0,0 +1,11 @@
+-- Migration: 010_create_webhook_tables
+-- Description: Rollback webhook subscription and delivery tables
+
+DROP TRIGGER IF EXISTS update_webhook_deliveries_updated_at ON webhook_deliveries;
+DROP INDEX IF EXISTS idx_webhook_deliveries_subscription_id;
+DROP INDEX IF EXISTS idx_webhook_deliveries_due;
+DROP TABLE IF EXISTS webhook_deliveries;
+
+DROP TRIGGER IF EXISTS update_webhook_subscriptions_updated_at ON webhook_subscriptions;
+DROP INDEX IF EXISTS idx_webhook_subscriptions_deleted_at;
+DROP TABLE IF EXISTS webhook_subscriptions;
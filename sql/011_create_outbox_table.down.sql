@@ -0,0 +1,7 @@
+-- Migration: 011_create_outbox_table
+-- Description: Rollback the transactional outbox
+
+DROP INDEX IF EXISTS idx_webhook_deliveries_subscription_event;
+
+DROP INDEX IF EXISTS idx_outbox_unpublished;
+DROP TABLE IF EXISTS outbox;
@@ -0,0 +1,27 @@
+-- Migration: 011_create_outbox_table
+-- Description: Create the transactional outbox for domain events and make webhook enqueueing idempotent
+
+CREATE TABLE IF NOT EXISTS outbox (
+    id UUID PRIMARY KEY,
+    sequence BIGSERIAL NOT NULL UNIQUE,
+    aggregate_type VARCHAR(32) NOT NULL,
+    aggregate_id UUID NOT NULL,
+    event_type VARCHAR(64) NOT NULL,
+    payload JSONB NOT NULL,
+    occurred_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    attempts INTEGER NOT NULL DEFAULT 0,
+    last_error TEXT,
+    published_at TIMESTAMP WITH TIME ZONE,
+    claimed_until TIMESTAMP WITH TIME ZONE,
+    failed_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+-- A relay claims a batch until claimed_until and publishes it outside of any
+-- transaction. No other batch is claimed while a claim is running, so events are
+-- still published by one relay at a time and in order. Events that failed too many
+-- times get failed_at and are no longer published.
+CREATE INDEX IF NOT EXISTS idx_outbox_unpublished ON outbox(sequence) WHERE published_at IS NULL AND failed_at IS NULL;
+
+-- The relay delivers at least once, so the same event may be queued for a webhook twice
+CREATE UNIQUE INDEX IF NOT EXISTS idx_webhook_deliveries_subscription_event ON webhook_deliveries(subscription_id, event_id);
@@ -0,0 +1,5 @@
+-- Migration: 012_create_outbox_notify_trigger
+-- Description: Rollback the outbox notification trigger
+
+DROP TRIGGER IF EXISTS notify_outbox_event ON outbox;
+DROP FUNCTION IF EXISTS notify_outbox_event();
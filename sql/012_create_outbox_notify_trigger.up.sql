@@ -0,0 +1,16 @@
+-- Migration: 012_create_outbox_notify_trigger
+-- Description: Notify listeners of every outbox event once its transaction commits
+
+CREATE OR REPLACE FUNCTION notify_outbox_event()
+RETURNS TRIGGER AS $$
+BEGIN
+    -- Only the ID is sent since notification payloads are limited to 8000 bytes
+    PERFORM pg_notify('outbox_events', NEW.id::text);
+    RETURN NEW;
+END;
+$$ language 'plpgsql';
+
+CREATE TRIGGER notify_outbox_event
+    AFTER INSERT ON outbox
+    FOR EACH ROW
+    EXECUTE FUNCTION notify_outbox_event();
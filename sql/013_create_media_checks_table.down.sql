@@ -0,0 +1,6 @@
+-- Migration: 013_create_media_checks_table
+-- Description: Rollback media checks table
+
+DROP TRIGGER IF EXISTS update_media_checks_updated_at ON media_checks;
+DROP INDEX IF EXISTS idx_media_checks_broken;
+DROP TABLE IF EXISTS media_checks;
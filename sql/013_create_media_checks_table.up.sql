@@ -0,0 +1,25 @@
+-- Migration: 013_create_media_checks_table
+-- Description: Create media checks table recording whether stored image URLs still resolve
+
+CREATE TABLE IF NOT EXISTS media_checks (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    entity_type VARCHAR(32) NOT NULL,
+    entity_id UUID NOT NULL,
+    field VARCHAR(32) NOT NULL,
+    url TEXT NOT NULL,
+    status VARCHAR(16) NOT NULL,
+    response_status INTEGER,
+    last_error TEXT,
+    failures INTEGER NOT NULL DEFAULT 0,
+    checked_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT uq_media_checks_reference UNIQUE (entity_type, entity_id, url)
+);
+
+CREATE INDEX IF NOT EXISTS idx_media_checks_broken ON media_checks(checked_at) WHERE status = 'broken';
+
+CREATE TRIGGER update_media_checks_updated_at
+    BEFORE UPDATE ON media_checks
+    FOR EACH ROW
+    EXECUTE FUNCTION update_updated_at_column();
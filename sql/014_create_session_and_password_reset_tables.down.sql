@@ -0,0 +1,9 @@
+-- Migration: 014_create_session_and_password_reset_tables
+-- Description: Rollback login session and password reset token tables
+
+DROP INDEX IF EXISTS idx_password_reset_tokens_user_id;
+DROP TABLE IF EXISTS password_reset_tokens;
+
+DROP TRIGGER IF EXISTS update_sessions_updated_at ON sessions;
+DROP INDEX IF EXISTS idx_sessions_user_id;
+DROP TABLE IF EXISTS sessions;
@@ -0,0 +1,8 @@
+-- Migration: 015_add_email_verification
+-- Description: Rollback email verification columns and tokens
+
+DROP INDEX IF EXISTS idx_email_verification_tokens_user_id;
+DROP TABLE IF EXISTS email_verification_tokens;
+
+ALTER TABLE users DROP COLUMN IF EXISTS pending_email;
+ALTER TABLE users DROP COLUMN IF EXISTS email_verified_at;
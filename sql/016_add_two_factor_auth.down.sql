@@ -0,0 +1,12 @@
+-- Migration: 016_add_two_factor_auth
+-- Description: Rollback two-factor authentication
+
+DROP INDEX IF EXISTS idx_login_challenges_expires_at;
+DROP TABLE IF EXISTS login_challenges;
+DROP TABLE IF EXISTS recovery_codes;
+
+ALTER TABLE sessions DROP COLUMN IF EXISTS two_factor_verified_at;
+
+ALTER TABLE users DROP COLUMN IF EXISTS totp_last_step;
+ALTER TABLE users DROP COLUMN IF EXISTS totp_enabled_at;
+ALTER TABLE users DROP COLUMN IF EXISTS totp_secret;
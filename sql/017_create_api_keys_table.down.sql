@@ -0,0 +1,7 @@
+-- Migration: 017_create_api_keys_table
+-- Description: Rollback API keys table
+
+DROP TRIGGER IF EXISTS update_api_keys_updated_at ON api_keys;
+DROP INDEX IF EXISTS idx_api_keys_deleted_at;
+DROP INDEX IF EXISTS idx_api_keys_organization_id;
+DROP TABLE IF EXISTS api_keys;
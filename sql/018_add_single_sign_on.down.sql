@@ -0,0 +1,13 @@
+-- Migration: 018_add_single_sign_on
+-- Description: Rollback single sign-on
+
+DROP INDEX IF EXISTS idx_sso_login_states_expires_at;
+DROP TABLE IF EXISTS sso_login_states;
+
+DROP INDEX IF EXISTS idx_external_identities_user_id;
+DROP TABLE IF EXISTS external_identities;
+
+DROP TRIGGER IF EXISTS update_sso_providers_updated_at ON sso_providers;
+DROP INDEX IF EXISTS idx_sso_providers_email_domains;
+DROP INDEX IF EXISTS uq_sso_providers_organization_id;
+DROP TABLE IF EXISTS sso_providers;
@@ -0,0 +1,66 @@
+-- Migration: 018_add_single_sign_on
+-- Description: Add OpenID Connect single sign-on per organization with linked external identities
+
+-- An organization has at most one provider. email_domains route logins by email
+-- address to it and limit which users it can create on first login.
+CREATE TABLE IF NOT EXISTS sso_providers (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    organization_id UUID NOT NULL,
+    issuer VARCHAR(2048) NOT NULL,
+    client_id VARCHAR(255) NOT NULL,
+    client_secret TEXT NOT NULL DEFAULT '',
+    email_domains TEXT[] NOT NULL DEFAULT '{}',
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    deleted_at TIMESTAMP WITH TIME ZONE,
+    CONSTRAINT fk_sso_provider_organization
+        FOREIGN KEY(organization_id)
+        REFERENCES organizations(id)
+        ON DELETE CASCADE
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS uq_sso_providers_organization_id ON sso_providers(organization_id) WHERE deleted_at IS NULL;
+CREATE INDEX IF NOT EXISTS idx_sso_providers_email_domains ON sso_providers USING GIN (email_domains) WHERE deleted_at IS NULL;
+
+CREATE TRIGGER update_sso_providers_updated_at
+    BEFORE UPDATE ON sso_providers
+    FOR EACH ROW
+    EXECUTE FUNCTION update_updated_at_column();
+
+-- An external identity is a user's account at an OpenID provider, identified by the
+-- provider's issuer and the subject it gives the user
+CREATE TABLE IF NOT EXISTS external_identities (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    user_id UUID NOT NULL,
+    issuer VARCHAR(2048) NOT NULL,
+    subject VARCHAR(255) NOT NULL,
+    email VARCHAR(255),
+    last_login_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT uq_external_identities_issuer_subject UNIQUE (issuer, subject),
+    CONSTRAINT fk_external_identity_user
+        FOREIGN KEY(user_id)
+        REFERENCES users(id)
+        ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_external_identities_user_id ON external_identities(user_id);
+
+-- A login state is kept from sending a user to their provider until they come back
+-- with an authorization code. The code verifier and nonce never leave the API.
+CREATE TABLE IF NOT EXISTS sso_login_states (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    provider_id UUID NOT NULL,
+    state_hash CHAR(64) NOT NULL,
+    nonce VARCHAR(64) NOT NULL,
+    code_verifier VARCHAR(128) NOT NULL,
+    expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT uq_sso_login_states_state_hash UNIQUE (state_hash),
+    CONSTRAINT fk_sso_login_state_provider
+        FOREIGN KEY(provider_id)
+        REFERENCES sso_providers(id)
+        ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_sso_login_states_expires_at ON sso_login_states(expires_at);
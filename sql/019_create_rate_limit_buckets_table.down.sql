@@ -0,0 +1,5 @@
+-- Migration: 019_create_rate_limit_buckets_table
+-- Description: Rollback rate limit buckets table
+
+DROP INDEX IF EXISTS idx_rate_limit_buckets_full_at;
+DROP TABLE IF EXISTS rate_limit_buckets;
@@ -0,0 +1,14 @@
+-- Migration: 019_create_rate_limit_buckets_table
+-- Description: Create token buckets of rate limited clients, shared by all replicas
+
+-- A bucket holds the tokens a client has left at refilled_at. full_at is when it has
+-- refilled completely, after which the row says nothing a missing one would not and
+-- is deleted. Losing buckets in a crash only resets limits, so the table is unlogged.
+CREATE UNLOGGED TABLE IF NOT EXISTS rate_limit_buckets (
+    key TEXT PRIMARY KEY,
+    tokens DOUBLE PRECISION NOT NULL,
+    refilled_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    full_at TIMESTAMP WITH TIME ZONE NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_rate_limit_buckets_full_at ON rate_limit_buckets(full_at);